@@ -1,12 +1,22 @@
 package main
 
 import (
+	"net/http"
+
 	"github.com/pers0na2dev/todo-api/internal/api"
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
 	"github.com/pers0na2dev/todo-api/internal/api/handlers"
+	"github.com/pers0na2dev/todo-api/internal/api/middleware"
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+	"github.com/pers0na2dev/todo-api/internal/caldav"
 	"github.com/pers0na2dev/todo-api/internal/config"
+	"github.com/pers0na2dev/todo-api/internal/graphqlapi"
+	"github.com/pers0na2dev/todo-api/internal/grpcapi"
+	"github.com/pers0na2dev/todo-api/internal/ical"
 	"github.com/pers0na2dev/todo-api/internal/repository"
 	"github.com/pers0na2dev/todo-api/internal/repository/postgres"
 	"github.com/pers0na2dev/todo-api/internal/service"
+	"github.com/pers0na2dev/todo-api/internal/todotxt"
 	"github.com/pers0na2dev/todo-api/pkg/cache"
 	"github.com/pers0na2dev/todo-api/pkg/logger"
 
@@ -30,22 +40,76 @@ func createApp() fx.Option {
 			logger.NewLogger,                 // создание логгера
 			repository.NewPostgresConnection, // подключение к базе данных
 			fx.Annotate(
-				cache.NewRedisCache,     // создание кеша
-				fx.As(new(cache.Cache)), // указываем что кеш реализует интерфейс Cache
+				repository.NewCache, // создание кеша в Redis, в памяти процесса или двухуровневого по CACHE_BACKEND со статистикой
+				fx.As(new(cache.Cache)),
+				fx.As(new(handlers.CacheAdmin)), // указываем что кеш реализует интерфейс CacheAdmin для маршрутов администратора
 			),
 			fx.Annotate(
 				postgres.NewTaskRepository,         // создание репозитория для задач
 				fx.As(new(service.TaskRepository)), // указываем что репозиторий для задач реализует интерфейс TaskRepository
+				fx.As(new(service.CacheWarmer)),    // указываем что репозиторий прогревает кеш недавно прочитанными задачами
+			),
+			fx.Annotate(
+				postgres.NewCalendarTokenRepository,         // создание репозитория для токенов лент календаря
+				fx.As(new(service.CalendarTokenRepository)), // указываем что репозиторий реализует интерфейс CalendarTokenRepository
+			),
+			service.NewEventBroker, // создание рассылки событий об изменении задач между репликами через каналы Redis
+			fx.Annotate(
+				service.NewTaskService, // создание сервиса для задач
+				// указываем что сервис для задач реализует интерфейсы TaskService для HTTP, gRPC, GraphQL API и CalDAV
+				fx.As(new(handlers.TaskService)),
+				fx.As(new(grpcapi.TaskService)),
+				fx.As(new(graphqlapi.TaskService)),
+				fx.As(new(caldav.TaskService)),
+				fx.As(new(service.TaskImporter)), // указываем что сервис для задач записывает задачи фонового импорта
+			),
+			fx.Annotate(
+				service.NewImportService,           // создание сервиса фонового импорта файлов Todoist, Trello и Microsoft To Do
+				fx.As(new(handlers.ImportService)), // указываем что сервис реализует интерфейс ImportService
+			),
+			fx.Annotate(
+				service.NewCalendarService,           // создание сервиса для токенов лент календаря
+				fx.As(new(handlers.CalendarService)), // указываем что сервис реализует интерфейс CalendarService
+			),
+			fx.Annotate(
+				service.NewWarmup,              // создание прогрева кеша после запуска
+				fx.As(new(handlers.Readiness)), // указываем что прогрев определяет готовность приложения
+			),
+			middleware.NewIdempotency, // создание middleware для Idempotency-Key
+			middleware.NewAdminAuth,   // создание проверки токена администратора
+			http.NewServeMux,          // создание маршрутизатора HTTP, в котором обработчики регистрируют маршруты
+			fx.Annotate(
+				openapi.NewRegistry, // создание реестра маршрутов с описанием OpenAPI
+				fx.As(fx.Self()),
+				fx.As(new(middleware.BodyLimits)), // указываем что реестр знает ограничения тела запроса для Idempotency-Key
+			),
+			fx.Annotate(
+				codec.NewRegistry, // создание реестра форматов тел запросов и ответов
+				// дополнительные форматы собираются из группы codecs
+				fx.ParamTags(`group:"codecs"`),
+			),
+			fx.Annotate(
+				ical.NewCodec,           // создание формата iCalendar для импорта и экспорта задач
+				fx.As(new(codec.Codec)), // указываем что формат реализует интерфейс Codec
+				fx.ResultTags(`group:"codecs"`),
 			),
 			fx.Annotate(
-				service.NewTaskService,           // создание сервиса для задач
-				fx.As(new(handlers.TaskService)), // указываем что сервис для задач реализует интерфейс TaskService
+				todotxt.NewCodec,        // создание формата todo.txt для импорта и экспорта задач
+				fx.As(new(codec.Codec)), // указываем что формат реализует интерфейс Codec
+				fx.ResultTags(`group:"codecs"`),
 			),
-			api.NewServer, // создание HTTP сервера
 		),
 		// fx.Invoke - вызывает функции которые будут выполняться при запуске приложения
 		fx.Invoke(
-			handlers.NewTaskHandler, // создание обработчика для задач
+			api.NewServer,               // создание HTTP сервера
+			handlers.NewTaskHandler,     // создание обработчика для задач
+			handlers.NewCalendarHandler, // создание обработчика лент календаря
+			handlers.NewImportHandler,   // создание обработчика импорта из других приложений
+			handlers.NewAdminHandler,    // создание обработчика маршрутов администратора
+			handlers.NewHealthHandler,   // создание обработчика проверки готовности
+			grpcapi.NewServer,           // создание gRPC сервера
+			graphqlapi.NewHandler,       // создание обработчика GraphQL
+			caldav.NewHandler,           // создание сервера CalDAV
 		),
 	)
 }
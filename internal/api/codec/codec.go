@@ -0,0 +1,164 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Codec интерфейс формата, в котором передаются тела запросов и ответов
+type Codec interface {
+	// MediaTypes возвращает типы содержимого формата, первый из них указывается в ответах
+	MediaTypes() []string
+	// Supports проверяет, может ли формат передавать значения типа t
+	Supports(t reflect.Type) bool
+	// Encode кодирует значение
+	Encode(w io.Writer, v any) error
+	// Decode декодирует значение в v, v должен быть указателем
+	Decode(r io.Reader, v any) error
+}
+
+var (
+	// ErrNotAcceptable ошибка, которая возвращается если ни один из форматов из Accept не подходит для ответа
+	ErrNotAcceptable = errors.New("not acceptable")
+	// ErrUnsupportedMediaType ошибка, которая возвращается если формат тела запроса не поддерживается
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+)
+
+// Registry структура, которая хранит поддерживаемые форматы и выбирает формат по заголовкам запроса
+// Порядок форматов задает предпочтение сервера, первый формат используется если клиент не указал Accept
+type Registry struct {
+	codecs   []Codec
+	protobuf *Protobuf
+}
+
+// NewRegistry функция, которая создает реестр с форматами JSON, MessagePack, Protobuf, CSV и NDJSON
+// Типы, передаваемые в Protobuf, регистрируются отдельно через RegisterProto
+// @param extra ...Codec - дополнительные форматы, они добавляются после встроенных
+// @return *Registry - новый экземпляр Registry
+func NewRegistry(extra ...Codec) *Registry {
+	protobuf := NewProtobuf()
+	return &Registry{
+		codecs:   append([]Codec{JSON{}, MessagePack{}, protobuf, CSV{}, NDJSON{}}, extra...),
+		protobuf: protobuf,
+	}
+}
+
+// Protobuf функция, которая возвращает формат Protobuf для регистрации типов
+// @return *Protobuf - формат Protobuf
+func (r *Registry) Protobuf() *Protobuf {
+	return r.protobuf
+}
+
+// Supported функция, которая возвращает форматы, поддерживающие тип t
+// @param t reflect.Type - тип значения
+// @return []Codec - форматы в порядке предпочтения сервера
+func (r *Registry) Supported(t reflect.Type) []Codec {
+	codecs := make([]Codec, 0, len(r.codecs))
+	for _, codec := range r.codecs {
+		if codec.Supports(t) {
+			codecs = append(codecs, codec)
+		}
+	}
+	return codecs
+}
+
+// ForContentType функция, которая возвращает формат по заголовку Content-Type
+// Пустой заголовок означает JSON
+// @param contentType string - значение заголовка Content-Type
+// @return Codec - формат
+// @return error - ошибка, ErrUnsupportedMediaType если формат не поддерживается
+func (r *Registry) ForContentType(contentType string) (Codec, error) {
+	if contentType == "" {
+		return r.codecs[0], nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, contentType)
+	}
+	for _, codec := range r.codecs {
+		for _, supported := range codec.MediaTypes() {
+			if mediaType == supported {
+				return codec, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mediaType)
+}
+
+// Negotiate функция, которая выбирает формат ответа по заголовку Accept
+// @param accept string - значение заголовка Accept
+// @param v any - значение, которое будет отправлено в ответе
+// @return Codec - формат ответа
+// @return error - ошибка, ErrNotAcceptable если подходящего формата нет
+func (r *Registry) Negotiate(accept string, v any) (Codec, error) {
+	codec := negotiate(parseAccept(accept), r.Supported(reflect.TypeOf(v)))
+	if codec == nil {
+		return nil, ErrNotAcceptable
+	}
+	return codec, nil
+}
+
+// Read функция, которая декодирует тело запроса в формате из заголовка Content-Type
+// @param req *http.Request - запрос
+// @param v any - указатель на значение, в которое декодируется тело
+// @return error - ошибка, ErrUnsupportedMediaType если формат не поддерживается
+func (r *Registry) Read(req *http.Request, v any) error {
+	codec, err := r.ForContentType(req.Header.Get("Content-Type"))
+	if err != nil {
+		return err
+	}
+	if !codec.Supports(reflect.TypeOf(v)) {
+		return fmt.Errorf("%w: %s", ErrUnsupportedMediaType, codec.MediaTypes()[0])
+	}
+
+	return codec.Decode(req.Body, v)
+}
+
+// Write функция, которая отправляет значение в формате, выбранном по заголовку Accept
+// Если подходящего формата нет, отправляет ответ 406 со списком доступных форматов
+// @param w http.ResponseWriter - ответ
+// @param req *http.Request - запрос
+// @param status int - HTTP код ответа
+// @param v any - значение
+func (r *Registry) Write(w http.ResponseWriter, req *http.Request, status int, v any) {
+	codec, err := r.Negotiate(req.Header.Get("Accept"), v)
+	if err != nil {
+		r.WriteNotAcceptable(w, v)
+		return
+	}
+
+	Encode(w, codec, status, v)
+}
+
+// WriteNotAcceptable функция, которая отправляет ответ 406 со списком форматов, в которых доступно значение
+// @param w http.ResponseWriter - ответ
+// @param v any - значение, которое не удалось отправить
+func (r *Registry) WriteNotAcceptable(w http.ResponseWriter, v any) {
+	var available []string
+	for _, codec := range r.Supported(reflect.TypeOf(v)) {
+		available = append(available, codec.MediaTypes()[0])
+	}
+
+	w.Header().Set("Vary", "Accept")
+	http.Error(w, "none of the acceptable media types is available, supported: "+strings.Join(available, ", "), http.StatusNotAcceptable)
+}
+
+// Encode функция, которая отправляет значение в заданном формате
+// Ошибка кодирования игнорируется, так как заголовки ответа к этому моменту уже отправлены
+// @param w http.ResponseWriter - ответ
+// @param codec Codec - формат
+// @param status int - HTTP код ответа
+// @param v any - значение
+func Encode(w http.ResponseWriter, codec Codec, status int, v any) {
+	w.Header().Set("Content-Type", codec.MediaTypes()[0])
+	w.Header().Set("Vary", "Accept")
+	w.WriteHeader(status)
+	codec.Encode(w, v)
+}
@@ -0,0 +1,138 @@
+package codec
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+	todov1 "github.com/pers0na2dev/todo-api/pkg/pb/todo/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupRegistry создает реестр с зарегистрированным типом задачи для Protobuf
+func setupRegistry() *Registry {
+	codecs := NewRegistry()
+	RegisterProto(codecs.Protobuf(),
+		func(task models.Task) *todov1.Task {
+			return &todov1.Task{Id: int64(task.ID), Title: task.Title, Completed: task.Completed, Version: int64(task.Version)}
+		},
+		func(task *todov1.Task) models.Task {
+			return models.Task{ID: int(task.GetId()), Title: task.GetTitle(), Completed: task.GetCompleted(), Version: int(task.GetVersion())}
+		},
+	)
+	return codecs
+}
+
+// TestNegotiate тестирует выбор формата ответа по заголовку Accept
+func TestNegotiate(t *testing.T) {
+	codecs := setupRegistry()
+
+	tests := []struct {
+		name     string
+		accept   string
+		value    any
+		expected string
+	}{
+		{name: "Без Accept отдается JSON", accept: "", value: &models.Task{}, expected: "application/json"},
+		{name: "Любой тип отдается в формате сервера по умолчанию", accept: "*/*", value: &models.Task{}, expected: "application/json"},
+		{name: "Точный тип", accept: "application/msgpack", value: &models.Task{}, expected: "application/msgpack"},
+		{name: "Альтернативное имя типа", accept: "application/x-msgpack", value: &models.Task{}, expected: "application/msgpack"},
+		{name: "Выигрывает больший вес", accept: "application/json;q=0.5, application/x-protobuf", value: &models.Task{}, expected: "application/x-protobuf"},
+		{name: "При равном весе выигрывает указанный раньше", accept: "application/x-protobuf, application/json", value: &models.Task{}, expected: "application/x-protobuf"},
+		{name: "Точный диапазон важнее общего", accept: "application/*;q=0.9, application/json;q=0", value: &models.Task{}, expected: "application/msgpack"},
+		{name: "Неподдерживаемый тип значения пропускается", accept: "application/x-protobuf, text/*;q=0.1", value: []*models.Task{}, expected: "text/csv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, err := codecs.Negotiate(tt.accept, tt.value)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, codec.MediaTypes()[0])
+		})
+	}
+
+	t.Run("Нет подходящего формата", func(t *testing.T) {
+		_, err := codecs.Negotiate("text/csv, application/json;q=0", &models.Task{})
+
+		assert.ErrorIs(t, err, ErrNotAcceptable)
+	})
+}
+
+// TestWriteNotAcceptable тестирует ответ 406 со списком доступных форматов
+func TestWriteNotAcceptable(t *testing.T) {
+	codecs := setupRegistry()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/html")
+
+	rec := httptest.NewRecorder()
+	codecs.Write(rec, req, 200, &models.Task{})
+
+	assert.Equal(t, 406, rec.Code)
+	assert.Contains(t, rec.Body.String(), "application/json, application/msgpack, application/x-protobuf")
+	assert.NotContains(t, rec.Body.String(), "text/csv")
+}
+
+// TestRoundTrip тестирует кодирование и декодирование значений во всех форматах
+func TestRoundTrip(t *testing.T) {
+	codecs := setupRegistry()
+	title := "Купить молоко, хлеб"
+	tasks := []*models.Task{{ID: 1, Title: title, Completed: true, Version: 3}, {ID: 2, Title: `"кавычки"`, Version: 1}}
+
+	for _, codec := range codecs.Supported(reflect.TypeOf(tasks)) {
+		t.Run(codec.MediaTypes()[0]+" список", func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, codec.Encode(&buf, tasks))
+
+			var decoded []*models.Task
+			require.NoError(t, codec.Decode(&buf, &decoded))
+			assert.Equal(t, tasks, decoded)
+		})
+	}
+
+	for _, codec := range codecs.Supported(reflect.TypeOf(tasks[0])) {
+		t.Run(codec.MediaTypes()[0]+" задача", func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, codec.Encode(&buf, tasks[0]))
+
+			var decoded models.Task
+			require.NoError(t, codec.Decode(&buf, &decoded))
+			assert.Equal(t, *tasks[0], decoded)
+		})
+	}
+}
+
+// TestCSV тестирует формат CSV
+func TestCSV(t *testing.T) {
+	t.Run("Поля встроенных структур становятся колонками", func(t *testing.T) {
+		results := []*models.TaskSearchResult{{Task: models.Task{ID: 1, Title: "Задача", Version: 2}, Rank: 0.5, Headline: "<mark>Задача</mark>"}}
+
+		var buf bytes.Buffer
+		require.NoError(t, CSV{}.Encode(&buf, results))
+
+		assert.Equal(t, "id,title,completed,version,due_at,rank,headline\n1,Задача,false,2,,0.5,<mark>Задача</mark>\n", buf.String())
+	})
+
+	t.Run("Неизвестная колонка отклоняется", func(t *testing.T) {
+		var tasks []models.Task
+		err := CSV{}.Decode(strings.NewReader("id,owner\n1,me\n"), &tasks)
+
+		assert.ErrorContains(t, err, `unknown csv column "owner"`)
+	})
+
+	t.Run("Некорректное значение указывает строку и колонку", func(t *testing.T) {
+		var tasks []models.Task
+		err := CSV{}.Decode(strings.NewReader("id,completed\n1,true\n2,maybe\n"), &tasks)
+
+		assert.ErrorContains(t, err, `line 3, column "completed"`)
+	})
+
+	t.Run("Вложенные структуры не поддерживаются", func(t *testing.T) {
+		assert.False(t, CSV{}.Supports(reflect.TypeOf([]models.TaskEvent{})))
+		assert.False(t, CSV{}.Supports(reflect.TypeOf(models.Task{})))
+	})
+}
@@ -0,0 +1,308 @@
+package codec
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSV формат text/csv для списков
+// Поддерживаются срезы структур с полями простых типов. Первая строка содержит имена колонок из тегов json,
+// поля встроенных структур становятся отдельными колонками
+type CSV struct{}
+
+// csvColumn колонка CSV
+type csvColumn struct {
+	name  string
+	index []int
+}
+
+// MediaTypes функция, которая возвращает типы содержимого CSV
+func (CSV) MediaTypes() []string {
+	return []string{"text/csv"}
+}
+
+// Supports функция, которая проверяет поддержку типа
+func (CSV) Supports(t reflect.Type) bool {
+	_, ok := csvElem(t)
+	return ok
+}
+
+// Encode функция, которая кодирует срез структур в CSV
+func (CSV) Encode(w io.Writer, v any) error {
+	value := reflect.ValueOf(v)
+	elem, ok := csvElem(value.Type())
+	if !ok {
+		return fmt.Errorf("type %T can not be encoded as csv", v)
+	}
+	for value.Kind() == reflect.Pointer {
+		value = value.Elem()
+	}
+	columns := csvColumns(elem)
+
+	writer := csv.NewWriter(w)
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.name
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(columns))
+	for i := 0; i < value.Len(); i++ {
+		item := value.Index(i)
+		for item.Kind() == reflect.Pointer && !item.IsNil() {
+			item = item.Elem()
+		}
+		if item.Kind() == reflect.Pointer {
+			continue
+		}
+
+		for j, column := range columns {
+			field, err := item.FieldByIndexErr(column.index)
+			if err != nil {
+				record[j] = ""
+				continue
+			}
+			record[j] = formatCSVValue(field)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// Decode функция, которая декодирует CSV в срез структур
+// Колонки сопоставляются по заголовку, неизвестные колонки отклоняются
+func (CSV) Decode(r io.Reader, v any) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer || target.IsNil() || target.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("csv decode target must be a pointer to a slice, got %T", v)
+	}
+	slice := target.Elem()
+	elem, ok := csvElem(slice.Type())
+	if !ok {
+		return fmt.Errorf("type %T can not be decoded from csv", v)
+	}
+
+	byName := make(map[string]csvColumn)
+	for _, column := range csvColumns(elem) {
+		byName[column.name] = column
+	}
+
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("csv header is missing")
+	}
+	if err != nil {
+		return err
+	}
+
+	columns := make([]csvColumn, len(header))
+	for i, name := range header {
+		column, ok := byName[strings.TrimSpace(name)]
+		if !ok {
+			return fmt.Errorf("unknown csv column %q", name)
+		}
+		columns[i] = column
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		item := reflect.New(elem).Elem()
+		for i, raw := range record {
+			field := item.FieldByIndex(columns[i].index)
+			if err := parseCSVValue(field, raw); err != nil {
+				return fmt.Errorf("line %d, column %q: %w", line, columns[i].name, err)
+			}
+		}
+
+		if slice.Type().Elem().Kind() == reflect.Pointer {
+			item = item.Addr()
+		}
+		slice.Set(reflect.Append(slice, item))
+	}
+}
+
+// csvElem функция, которая возвращает тип элемента среза, если срез можно передать в CSV
+// @param t reflect.Type - тип значения
+// @return reflect.Type - тип структуры элемента
+// @return bool - false, если тип не поддерживается
+func csvElem(t reflect.Type) (reflect.Type, bool) {
+	if t == nil {
+		return nil, false
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		return nil, false
+	}
+
+	elem := t.Elem()
+	for elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct || elem == reflect.TypeFor[time.Time]() {
+		return nil, false
+	}
+
+	for _, field := range reflect.VisibleFields(elem) {
+		if !field.IsExported() || field.Anonymous && field.Type.Kind() == reflect.Struct {
+			continue
+		}
+		if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name == "-" {
+			continue
+		}
+		if !csvScalar(field.Type) {
+			return nil, false
+		}
+	}
+	return elem, true
+}
+
+// csvColumns функция, которая возвращает колонки CSV для структуры
+// @param t reflect.Type - тип структуры
+// @return []csvColumn - колонки в порядке полей
+func csvColumns(t reflect.Type) []csvColumn {
+	var columns []csvColumn
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous && field.Type.Kind() == reflect.Struct {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		columns = append(columns, csvColumn{name: name, index: field.Index})
+	}
+	return columns
+}
+
+// csvScalar функция, которая проверяет, можно ли записать значение типа в одну ячейку CSV
+// @param t reflect.Type - тип поля
+// @return bool - true для строк, чисел, булевых значений, времени и указателей на них
+func csvScalar(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeFor[time.Time]() {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// formatCSVValue функция, которая преобразует значение поля в текст ячейки
+// @param v reflect.Value - значение поля
+// @return string - текст ячейки, пустой для nil
+func formatCSVValue(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32)
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// parseCSVValue функция, которая записывает текст ячейки в поле
+// Пустая ячейка оставляет поле нулевым, а указатель - nil
+// @param v reflect.Value - поле
+// @param raw string - текст ячейки
+// @return error - ошибка преобразования
+func parseCSVValue(v reflect.Value, raw string) error {
+	if raw == "" {
+		return nil
+	}
+	if v.Kind() == reflect.Pointer {
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+	if v.Type() == reflect.TypeFor[time.Time]() {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	}
+	return nil
+}
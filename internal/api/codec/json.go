@@ -0,0 +1,32 @@
+package codec
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+)
+
+// JSON формат application/json
+type JSON struct{}
+
+// MediaTypes функция, которая возвращает типы содержимого JSON
+func (JSON) MediaTypes() []string {
+	return []string{"application/json"}
+}
+
+// Supports функция, которая проверяет поддержку типа, JSON поддерживает любые типы
+func (JSON) Supports(reflect.Type) bool {
+	return true
+}
+
+// Encode функция, которая кодирует значение в JSON
+func (JSON) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// Decode функция, которая декодирует JSON и отклоняет неизвестные поля
+func (JSON) Decode(r io.Reader, v any) error {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
@@ -0,0 +1,38 @@
+package codec
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MessagePack формат application/msgpack
+// Имена полей берутся из тегов json, поэтому структура данных совпадает с JSON
+type MessagePack struct{}
+
+// MediaTypes функция, которая возвращает типы содержимого MessagePack
+func (MessagePack) MediaTypes() []string {
+	return []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}
+}
+
+// Supports функция, которая проверяет поддержку типа, MessagePack поддерживает любые типы
+func (MessagePack) Supports(reflect.Type) bool {
+	return true
+}
+
+// Encode функция, которая кодирует значение в MessagePack
+func (MessagePack) Encode(w io.Writer, v any) error {
+	encoder := msgpack.NewEncoder(w)
+	encoder.SetCustomStructTag("json")
+	encoder.UseCompactInts(true)
+	return encoder.Encode(v)
+}
+
+// Decode функция, которая декодирует MessagePack и отклоняет неизвестные поля
+func (MessagePack) Decode(r io.Reader, v any) error {
+	decoder := msgpack.NewDecoder(r)
+	decoder.SetCustomStructTag("json")
+	decoder.DisallowUnknownFields(true)
+	return decoder.Decode(v)
+}
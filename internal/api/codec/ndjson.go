@@ -0,0 +1,71 @@
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// NDJSON формат application/x-ndjson для списков
+// Каждый элемент списка передается отдельным JSON значением на своей строке
+type NDJSON struct{}
+
+// MediaTypes функция, которая возвращает типы содержимого NDJSON
+func (NDJSON) MediaTypes() []string {
+	return []string{"application/x-ndjson", "application/ndjson", "application/jsonl"}
+}
+
+// Supports функция, которая проверяет поддержку типа, NDJSON поддерживает срезы и массивы
+func (NDJSON) Supports(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Slice || t.Kind() == reflect.Array
+}
+
+// Encode функция, которая кодирует элементы списка по одному на строку
+func (NDJSON) Encode(w io.Writer, v any) error {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return fmt.Errorf("type %T can not be encoded as ndjson", v)
+	}
+
+	encoder := json.NewEncoder(w)
+	for i := 0; i < value.Len(); i++ {
+		if err := encoder.Encode(value.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decode функция, которая декодирует строки NDJSON в срез и отклоняет неизвестные поля
+func (NDJSON) Decode(r io.Reader, v any) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer || target.IsNil() || target.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("ndjson decode target must be a pointer to a slice, got %T", v)
+	}
+	slice := target.Elem()
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	for n := 1; ; n++ {
+		item := reflect.New(slice.Type().Elem())
+		err := decoder.Decode(item.Interface())
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("value %d: %w", n, err)
+		}
+		slice.Set(reflect.Append(slice, item.Elem()))
+	}
+}
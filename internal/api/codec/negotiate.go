@@ -0,0 +1,124 @@
+package codec
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// mediaRange диапазон типов содержимого из заголовка Accept
+type mediaRange struct {
+	typ     string
+	subtype string
+	quality float64
+	// index - позиция диапазона в заголовке, при равенстве качества выигрывает указанный раньше
+	index int
+}
+
+// specificity функция, которая возвращает точность диапазона: 2 для type/subtype, 1 для type/*, 0 для */*
+func (m mediaRange) specificity() int {
+	switch {
+	case m.typ == "*":
+		return 0
+	case m.subtype == "*":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// matches функция, которая проверяет, входит ли тип содержимого в диапазон
+// @param mediaType string - тип содержимого вида type/subtype
+// @return bool - true, если тип входит в диапазон
+func (m mediaRange) matches(mediaType string) bool {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	return (m.typ == "*" || m.typ == typ) && (m.subtype == "*" || m.subtype == subtype)
+}
+
+// parseAccept функция, которая разбирает заголовок Accept
+// Пустой заголовок равносилен */*, некорректные диапазоны пропускаются
+// @param accept string - значение заголовка Accept
+// @return []mediaRange - диапазоны типов содержимого
+func parseAccept(accept string) []mediaRange {
+	if strings.TrimSpace(accept) == "" {
+		return []mediaRange{{typ: "*", subtype: "*", quality: 1}}
+	}
+
+	var ranges []mediaRange
+	for i, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok || (typ == "*" && subtype != "*") {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			value, err := strconv.ParseFloat(q, 64)
+			if err != nil || value < 0 || value > 1 {
+				continue
+			}
+			quality = value
+		}
+
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, quality: quality, index: i})
+	}
+	return ranges
+}
+
+// negotiate функция, которая выбирает формат с наибольшим качеством по диапазонам из Accept
+// Качество формата определяется самым точным подходящим диапазоном, формат с качеством 0 не выбирается.
+// При равном качестве выигрывает более точный диапазон, затем указанный раньше в Accept, затем порядок сервера
+// @param ranges []mediaRange - диапазоны из Accept
+// @param codecs []Codec - форматы в порядке предпочтения сервера
+// @return Codec - выбранный формат, nil если подходящего нет
+func negotiate(ranges []mediaRange, codecs []Codec) Codec {
+	var (
+		best      Codec
+		bestRange mediaRange
+	)
+
+	for _, codec := range codecs {
+		matched, ok := bestMatch(ranges, codec.MediaTypes())
+		if !ok || matched.quality == 0 {
+			continue
+		}
+
+		if best == nil ||
+			matched.quality > bestRange.quality ||
+			matched.quality == bestRange.quality && matched.specificity() > bestRange.specificity() ||
+			matched.quality == bestRange.quality && matched.specificity() == bestRange.specificity() && matched.index < bestRange.index {
+			best, bestRange = codec, matched
+		}
+	}
+
+	return best
+}
+
+// bestMatch функция, которая находит самый точный диапазон, в который входит один из типов формата
+// @param ranges []mediaRange - диапазоны из Accept
+// @param mediaTypes []string - типы содержимого формата
+// @return mediaRange - найденный диапазон
+// @return bool - false, если ни один диапазон не подходит
+func bestMatch(ranges []mediaRange, mediaTypes []string) (mediaRange, bool) {
+	var (
+		best  mediaRange
+		found bool
+	)
+
+	for _, r := range ranges {
+		for _, mediaType := range mediaTypes {
+			if !r.matches(mediaType) {
+				continue
+			}
+			if !found || r.specificity() > best.specificity() {
+				best, found = r, true
+			}
+		}
+	}
+
+	return best, found
+}
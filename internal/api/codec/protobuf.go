@@ -0,0 +1,150 @@
+package codec
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// protoConverter преобразования типа Go в сообщение protobuf и обратно
+type protoConverter struct {
+	newMessage func() proto.Message
+	toProto    func(v reflect.Value) proto.Message
+	fromProto  func(m proto.Message) reflect.Value
+}
+
+// Protobuf формат application/x-protobuf
+// Значения передаются в виде сообщений из pkg/pb, преобразования для каждого типа регистрируются через RegisterProto.
+// Сообщения protobuf передаются как есть
+type Protobuf struct {
+	mu    sync.RWMutex
+	types map[reflect.Type]protoConverter
+}
+
+// NewProtobuf функция, которая создает формат Protobuf без зарегистрированных типов
+// @return *Protobuf - новый экземпляр Protobuf
+func NewProtobuf() *Protobuf {
+	return &Protobuf{types: make(map[reflect.Type]protoConverter)}
+}
+
+// RegisterProto функция, которая регистрирует преобразования типа T в сообщение M и обратно
+// @param p *Protobuf - формат Protobuf
+// @param toProto func(T) M - преобразование значения в сообщение
+// @param fromProto func(M) T - преобразование сообщения в значение
+func RegisterProto[T any, M proto.Message](p *Protobuf, toProto func(T) M, fromProto func(M) T) {
+	var message M
+	messageType := message.ProtoReflect().Type()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.types[reflect.TypeFor[T]()] = protoConverter{
+		newMessage: func() proto.Message { return messageType.New().Interface() },
+		toProto:    func(v reflect.Value) proto.Message { return toProto(v.Interface().(T)) },
+		fromProto:  func(m proto.Message) reflect.Value { return reflect.ValueOf(fromProto(m.(M))) },
+	}
+}
+
+// MediaTypes функция, которая возвращает типы содержимого Protobuf
+func (p *Protobuf) MediaTypes() []string {
+	return []string{"application/x-protobuf", "application/protobuf", "application/vnd.google.protobuf"}
+}
+
+// Supports функция, которая проверяет поддержку типа
+// Поддерживаются сообщения protobuf и зарегистрированные типы, в том числе указатели на них
+func (p *Protobuf) Supports(t reflect.Type) bool {
+	_, _, ok := p.lookup(t)
+	return ok
+}
+
+// Encode функция, которая кодирует значение в protobuf
+func (p *Protobuf) Encode(w io.Writer, v any) error {
+	value := reflect.ValueOf(v)
+	converter, depth, ok := p.lookup(value.Type())
+	if !ok {
+		return fmt.Errorf("type %T is not registered for protobuf", v)
+	}
+
+	message, ok := v.(proto.Message)
+	if !ok {
+		for ; depth > 0; depth-- {
+			value = value.Elem()
+		}
+		message = converter.toProto(value)
+	}
+
+	data, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Decode функция, которая декодирует сообщение protobuf в значение
+func (p *Protobuf) Decode(r io.Reader, v any) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return fmt.Errorf("decode target must be a non-nil pointer, got %T", v)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	if message, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(data, message)
+	}
+
+	converter, depth, ok := p.lookup(target.Type().Elem())
+	if !ok {
+		return fmt.Errorf("type %T is not registered for protobuf", v)
+	}
+
+	message := converter.newMessage()
+	if err := proto.Unmarshal(data, message); err != nil {
+		return err
+	}
+
+	// Создаем промежуточные указатели, если значение передано как **T
+	target = target.Elem()
+	for ; depth > 0; depth-- {
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		target = target.Elem()
+	}
+	target.Set(converter.fromProto(message))
+	return nil
+}
+
+// lookup функция, которая находит преобразования для типа, снимая указатели
+// @param t reflect.Type - тип значения
+// @return protoConverter - преобразования, пустые для сообщений protobuf
+// @return int - количество снятых указателей
+// @return bool - false, если тип не поддерживается
+func (p *Protobuf) lookup(t reflect.Type) (protoConverter, int, bool) {
+	if t == nil {
+		return protoConverter{}, 0, false
+	}
+	if t.Implements(reflect.TypeFor[proto.Message]()) {
+		return protoConverter{}, 0, true
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for depth := 0; ; depth++ {
+		if converter, ok := p.types[t]; ok {
+			return converter, depth, true
+		}
+		if t.Kind() != reflect.Pointer {
+			return protoConverter{}, 0, false
+		}
+		t = t.Elem()
+	}
+}
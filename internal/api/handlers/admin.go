@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+	"github.com/pers0na2dev/todo-api/internal/api/middleware"
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+	"github.com/pers0na2dev/todo-api/pkg/cache"
+)
+
+const (
+	// defaultCacheKeysLimit - количество записей в GET /v1/admin/cache/keys без параметра limit
+	defaultCacheKeysLimit = 100
+	// maxCacheKeysLimit - максимальное значение параметра limit в GET /v1/admin/cache/keys
+	maxCacheKeysLimit = 1000
+)
+
+// CacheAdmin интерфейс, который определяет методы для просмотра статистики и записей кеша
+type CacheAdmin interface {
+	Metrics() cache.Metrics
+	Inspect(ctx context.Context, key string) (cache.Entry, error)
+	Keys(ctx context.Context, prefix string, limit int) ([]cache.EntryInfo, error)
+	FlushPrefix(ctx context.Context, prefix string) (int, error)
+}
+
+// cacheMetricsResponse тело ответа GET /v1/admin/cache/metrics
+type cacheMetricsResponse struct {
+	Prefixes       []cachePrefixMetrics `json:"prefixes" doc:"Statistics per key prefix, ordered by prefix"`
+	InvalidateTags cacheOpMetrics       `json:"invalidate_tags" doc:"Tag invalidations, they are not bound to a single prefix"`
+}
+
+// cachePrefixMetrics статистика ключей с одним префиксом
+type cachePrefixMetrics struct {
+	Prefix      string           `json:"prefix"`
+	Hits        uint64           `json:"hits"`
+	Misses      uint64           `json:"misses"`
+	Absent      uint64           `json:"absent" doc:"Reads of cached \"known to be absent\" markers"`
+	Unavailable uint64           `json:"unavailable" doc:"Operations skipped while the cache backend is bypassed after errors"`
+	HitRatio    float64          `json:"hit_ratio" doc:"Share of reads answered by the cache, absent markers included"`
+	Get         cacheOpMetrics   `json:"get"`
+	Set         cacheOpMetrics   `json:"set"`
+	Delete      cacheOpMetrics   `json:"delete"`
+	ReadSize    cacheSizeMetrics `json:"read_size" doc:"Encoded size of values read"`
+	WriteSize   cacheSizeMetrics `json:"write_size" doc:"Encoded size of values written"`
+}
+
+// cacheOpMetrics статистика одной операции кеша
+type cacheOpMetrics struct {
+	Calls  uint64  `json:"calls"`
+	Errors uint64  `json:"errors" doc:"Failed calls; misses and absent markers are not errors"`
+	MeanMs float64 `json:"mean_ms"`
+	P50Ms  float64 `json:"p50_ms" doc:"Upper bound of the latency bucket with the median"`
+	P95Ms  float64 `json:"p95_ms"`
+	P99Ms  float64 `json:"p99_ms"`
+	MaxMs  float64 `json:"max_ms"`
+}
+
+// cacheSizeMetrics распределение размеров значений кеша
+type cacheSizeMetrics struct {
+	Count      uint64 `json:"count"`
+	TotalBytes int64  `json:"total_bytes"`
+	MeanBytes  int64  `json:"mean_bytes"`
+	P95Bytes   int64  `json:"p95_bytes" doc:"Upper bound of the size bucket with the 95th percentile"`
+	MaxBytes   int64  `json:"max_bytes"`
+}
+
+// cacheEntryInfo сведения о записи кеша
+type cacheEntryInfo struct {
+	Key        string  `json:"key"`
+	TTLSeconds float64 `json:"ttl_seconds" doc:"Remaining time to live, 0 if the entry does not expire"`
+	Size       int     `json:"size" doc:"Encoded size in bytes"`
+	Absent     bool    `json:"absent" doc:"The entry marks a value known to be absent"`
+}
+
+// cacheEntryResponse тело ответа GET /v1/admin/cache/entry
+type cacheEntryResponse struct {
+	cacheEntryInfo
+	Value any `json:"value,omitempty" doc:"Decoded value, omitted if it cannot be represented as JSON"`
+}
+
+// cacheFlushResponse тело ответа DELETE /v1/admin/cache/keys
+type cacheFlushResponse struct {
+	Flushed int `json:"flushed" doc:"Number of deleted entries"`
+}
+
+// AdminHandler структура, которая обрабатывает запросы администратора
+type AdminHandler struct {
+	cache  CacheAdmin
+	codecs *codec.Registry
+}
+
+// NewAdminHandler функция, которая создает обработчик запросов администратора и регистрирует его маршруты
+// Маршруты доступны только с токеном ADMIN_TOKEN
+// @param c CacheAdmin - кеш
+// @param auth *middleware.AdminAuth - проверка учетных данных администратора
+// @param reg *openapi.Registry - реестр маршрутов
+// @param codecs *codec.Registry - форматы тел ответов
+// @return *AdminHandler - обработчик запросов администратора
+func NewAdminHandler(c CacheAdmin, auth *middleware.AdminAuth, reg *openapi.Registry, codecs *codec.Registry) *AdminHandler {
+	handler := &AdminHandler{cache: c, codecs: codecs}
+
+	reg.Handle("GET /v1/admin/cache/metrics", auth.Wrap(handler.CacheMetrics), &openapi.Op{
+		ID:           "getCacheMetrics",
+		Summary:      "Cache hits, misses, errors, latency and value sizes per key prefix",
+		Description:  "Statistics are collected by this replica since its start.",
+		Tags:         adminTag,
+		HeaderParams: []openapi.Param{adminAuthParam},
+		Responses: map[int]openapi.Resp{
+			http.StatusOK:           {Description: "Cache statistics", Body: cacheMetricsResponse{}},
+			http.StatusUnauthorized: errorResp("Missing or invalid admin token"),
+			http.StatusForbidden:    errorResp("Admin endpoints are disabled"),
+		},
+	})
+	reg.Handle("GET /v1/admin/cache/keys", auth.Wrap(handler.CacheKeys), &openapi.Op{
+		ID:           "listCacheKeys",
+		Summary:      "List cache entries by key prefix with their TTLs",
+		Tags:         adminTag,
+		HeaderParams: []openapi.Param{adminAuthParam},
+		QueryParams: []openapi.Param{
+			{Name: "prefix", Description: "Key prefix, e.g. task:", Schema: openapi.String()},
+			{Name: "limit", Description: "Maximum number of entries", Schema: openapi.Integer().WithMinimum(1).WithMaximum(maxCacheKeysLimit)},
+		},
+		Responses: map[int]openapi.Resp{
+			http.StatusOK:                  {Description: "Entries ordered by key", Body: []cacheEntryInfo{}},
+			http.StatusUnauthorized:        errorResp("Missing or invalid admin token"),
+			http.StatusForbidden:           errorResp("Admin endpoints are disabled"),
+			http.StatusInternalServerError: errorResp("Internal error"),
+			http.StatusNotImplemented:      errorResp("The cache backend cannot list entries"),
+		},
+	})
+	reg.Handle("GET /v1/admin/cache/entry", auth.Wrap(handler.CacheEntry), &openapi.Op{
+		ID:           "getCacheEntry",
+		Summary:      "Inspect a cache entry",
+		Tags:         adminTag,
+		HeaderParams: []openapi.Param{adminAuthParam},
+		QueryParams:  []openapi.Param{{Name: "key", Description: "Cache key", Required: true, Schema: openapi.String()}},
+		Responses: map[int]openapi.Resp{
+			http.StatusOK:                  {Description: "The entry with its decoded value", Body: cacheEntryResponse{}},
+			http.StatusUnauthorized:        errorResp("Missing or invalid admin token"),
+			http.StatusForbidden:           errorResp("Admin endpoints are disabled"),
+			http.StatusNotFound:            errorResp("Key not found"),
+			http.StatusInternalServerError: errorResp("Internal error"),
+			http.StatusNotImplemented:      errorResp("The cache backend cannot inspect entries"),
+		},
+	})
+	reg.Handle("DELETE /v1/admin/cache/keys", auth.Wrap(handler.FlushCachePrefix), &openapi.Op{
+		ID:           "flushCachePrefix",
+		Summary:      "Delete all cache entries with a key prefix",
+		Description:  "With the tiered backend the local copies are dropped on every replica.",
+		Tags:         adminTag,
+		HeaderParams: []openapi.Param{adminAuthParam},
+		QueryParams: []openapi.Param{
+			{Name: "prefix", Description: "Key prefix, e.g. tasks:suggest:", Required: true, Schema: openapi.String()},
+		},
+		Responses: map[int]openapi.Resp{
+			http.StatusOK:                  {Description: "Number of deleted entries", Body: cacheFlushResponse{}},
+			http.StatusBadRequest:          errorResp("Empty prefix"),
+			http.StatusUnauthorized:        errorResp("Missing or invalid admin token"),
+			http.StatusForbidden:           errorResp("Admin endpoints are disabled"),
+			http.StatusInternalServerError: errorResp("Internal error"),
+			http.StatusNotImplemented:      errorResp("The cache backend cannot delete entries by prefix"),
+		},
+	})
+
+	return handler
+}
+
+// CacheMetrics функция, которая возвращает статистику кеша
+func (h *AdminHandler) CacheMetrics(w http.ResponseWriter, r *http.Request) {
+	metrics := h.cache.Metrics()
+
+	resp := cacheMetricsResponse{
+		Prefixes:       make([]cachePrefixMetrics, 0, len(metrics.Prefixes)),
+		InvalidateTags: newCacheOpMetrics(metrics.InvalidateTags),
+	}
+	for prefix, m := range metrics.Prefixes {
+		resp.Prefixes = append(resp.Prefixes, newCachePrefixMetrics(prefix, m))
+	}
+	sort.Slice(resp.Prefixes, func(i, j int) bool { return resp.Prefixes[i].Prefix < resp.Prefixes[j].Prefix })
+
+	h.codecs.Write(w, r, http.StatusOK, resp)
+}
+
+// CacheKeys функция, которая возвращает записи кеша с префиксом ключа
+func (h *AdminHandler) CacheKeys(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if limit == 0 {
+		limit = defaultCacheKeysLimit
+	}
+
+	infos, err := h.cache.Keys(r.Context(), r.URL.Query().Get("prefix"), limit)
+	if err != nil {
+		writeCacheAdminError(w, err)
+		return
+	}
+
+	resp := make([]cacheEntryInfo, len(infos))
+	for i, info := range infos {
+		resp[i] = newCacheEntryInfo(info)
+	}
+	h.codecs.Write(w, r, http.StatusOK, resp)
+}
+
+// CacheEntry функция, которая возвращает запись кеша со значением
+func (h *AdminHandler) CacheEntry(w http.ResponseWriter, r *http.Request) {
+	entry, err := h.cache.Inspect(r.Context(), r.URL.Query().Get("key"))
+	if err != nil {
+		writeCacheAdminError(w, err)
+		return
+	}
+
+	resp := cacheEntryResponse{cacheEntryInfo: newCacheEntryInfo(entry.EntryInfo)}
+	if entry.Value != nil {
+		resp.Value = entry.Value
+	}
+	h.codecs.Write(w, r, http.StatusOK, resp)
+}
+
+// FlushCachePrefix функция, которая удаляет записи кеша с префиксом ключа
+// Пустой префикс не принимается, чтобы кеш нельзя было очистить целиком по ошибке
+func (h *AdminHandler) FlushCachePrefix(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	if prefix == "" {
+		http.Error(w, "prefix must not be empty", http.StatusBadRequest)
+		return
+	}
+
+	flushed, err := h.cache.FlushPrefix(r.Context(), prefix)
+	if err != nil {
+		writeCacheAdminError(w, err)
+		return
+	}
+
+	h.codecs.Write(w, r, http.StatusOK, cacheFlushResponse{Flushed: flushed})
+}
+
+// writeCacheAdminError функция, которая преобразует ошибку кеша в HTTP ответ
+// @param w http.ResponseWriter - ответ
+// @param err error - ошибка кеша
+func writeCacheAdminError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, cache.ErrMiss):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errors.ErrUnsupported):
+		http.Error(w, "the cache backend does not support this operation", http.StatusNotImplemented)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// newCachePrefixMetrics функция, которая преобразует статистику префикса в тело ответа
+// @param prefix string - префикс ключей
+// @param m cache.PrefixMetrics - статистика
+// @return cachePrefixMetrics - статистика для ответа
+func newCachePrefixMetrics(prefix string, m cache.PrefixMetrics) cachePrefixMetrics {
+	out := cachePrefixMetrics{
+		Prefix:      prefix,
+		Hits:        m.Hits,
+		Misses:      m.Misses,
+		Absent:      m.Absent,
+		Unavailable: m.Unavailable,
+		Get:         newCacheOpMetrics(m.Get),
+		Set:         newCacheOpMetrics(m.Set),
+		Delete:      newCacheOpMetrics(m.Delete),
+		ReadSize:    newCacheSizeMetrics(m.ReadSize),
+		WriteSize:   newCacheSizeMetrics(m.WriteSize),
+	}
+	if reads := m.Hits + m.Misses + m.Absent; reads > 0 {
+		out.HitRatio = float64(m.Hits+m.Absent) / float64(reads)
+	}
+	return out
+}
+
+// newCacheOpMetrics функция, которая преобразует статистику операции в тело ответа
+// @param m cache.OpMetrics - статистика операции
+// @return cacheOpMetrics - статистика для ответа
+func newCacheOpMetrics(m cache.OpMetrics) cacheOpMetrics {
+	ms := func(ns float64) float64 { return ns / float64(time.Millisecond) }
+	return cacheOpMetrics{
+		Calls:  m.Calls,
+		Errors: m.Errors,
+		MeanMs: ms(m.Latency.Mean()),
+		P50Ms:  ms(float64(m.Latency.Quantile(0.5))),
+		P95Ms:  ms(float64(m.Latency.Quantile(0.95))),
+		P99Ms:  ms(float64(m.Latency.Quantile(0.99))),
+		MaxMs:  ms(float64(m.Latency.Max)),
+	}
+}
+
+// newCacheSizeMetrics функция, которая преобразует распределение размеров в тело ответа
+// @param h cache.Histogram - распределение размеров
+// @return cacheSizeMetrics - распределение для ответа
+func newCacheSizeMetrics(h cache.Histogram) cacheSizeMetrics {
+	return cacheSizeMetrics{
+		Count:      h.Count,
+		TotalBytes: h.Sum,
+		MeanBytes:  int64(h.Mean()),
+		P95Bytes:   h.Quantile(0.95),
+		MaxBytes:   h.Max,
+	}
+}
+
+// newCacheEntryInfo функция, которая преобразует сведения о записи в тело ответа
+// @param info cache.EntryInfo - сведения о записи
+// @return cacheEntryInfo - сведения для ответа
+func newCacheEntryInfo(info cache.EntryInfo) cacheEntryInfo {
+	return cacheEntryInfo{Key: info.Key, TTLSeconds: info.TTL.Seconds(), Size: info.Size, Absent: info.Absent}
+}
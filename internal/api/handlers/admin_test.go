@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+	"github.com/pers0na2dev/todo-api/internal/api/handlers/mocks"
+	"github.com/pers0na2dev/todo-api/internal/api/middleware"
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+	"github.com/pers0na2dev/todo-api/internal/config"
+	"github.com/pers0na2dev/todo-api/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupAdminTest подготавливает маршрутизатор с обработчиком администратора и мок кеша
+func setupAdminTest(t *testing.T) (*http.ServeMux, *mocks.CacheAdmin) {
+	t.Helper()
+
+	mockCache := new(mocks.CacheAdmin)
+	mux := http.NewServeMux()
+	codecs := codec.NewRegistry()
+	auth := middleware.NewAdminAuth(&config.Config{AdminToken: "secret"})
+	NewAdminHandler(mockCache, auth, openapi.NewRegistry(mux, codecs), codecs)
+
+	return mux, mockCache
+}
+
+// serveAdmin функция, которая выполняет запрос администратора с токеном
+func serveAdmin(mux *http.ServeMux, method, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestAdminHandler тестирует маршруты администратора кеша
+func TestAdminHandler(t *testing.T) {
+	mux, mockCache := setupAdminTest(t)
+
+	t.Run("Запрос без токена отклоняется", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/v1/admin/cache/keys?prefix=task:", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		mockCache.AssertNotCalled(t, "FlushPrefix", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Статистика по префиксам", func(t *testing.T) {
+		mockCache.On("Metrics").Return(cache.Metrics{Prefixes: map[string]cache.PrefixMetrics{
+			"tasks:": {Hits: 1},
+			"task:": {
+				Hits:   3,
+				Misses: 1,
+				Get: cache.OpMetrics{Calls: 4, Latency: cache.Histogram{
+					Bounds: []int64{int64(time.Millisecond)}, Counts: []uint64{4, 0}, Count: 4, Sum: int64(2 * time.Millisecond), Max: int64(time.Millisecond),
+				}},
+			},
+		}}).Once()
+
+		rec := serveAdmin(mux, http.MethodGet, "/v1/admin/cache/metrics")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp cacheMetricsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Prefixes, 2)
+		assert.Equal(t, "task:", resp.Prefixes[0].Prefix)
+		assert.Equal(t, 0.75, resp.Prefixes[0].HitRatio)
+		assert.Equal(t, 0.5, resp.Prefixes[0].Get.MeanMs)
+		assert.Equal(t, 1.0, resp.Prefixes[0].Get.P95Ms)
+	})
+
+	t.Run("Записи по префиксу", func(t *testing.T) {
+		mockCache.On("Keys", mock.Anything, "task:", defaultCacheKeysLimit).Return([]cache.EntryInfo{
+			{Key: "task:1", TTL: 90 * time.Second, Size: 42},
+		}, nil).Once()
+
+		rec := serveAdmin(mux, http.MethodGet, "/v1/admin/cache/keys?prefix=task:")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `[{"key":"task:1","ttl_seconds":90,"size":42,"absent":false}]`, rec.Body.String())
+	})
+
+	t.Run("Запись со значением", func(t *testing.T) {
+		mockCache.On("Inspect", mock.Anything, "task:1").Return(cache.Entry{
+			EntryInfo: cache.EntryInfo{Key: "task:1", Size: 12},
+			Value:     json.RawMessage(`{"id":1}`),
+		}, nil).Once()
+		mockCache.On("Inspect", mock.Anything, "task:2").Return(cache.Entry{}, fmt.Errorf("%w for key task:2", cache.ErrMiss)).Once()
+
+		rec := serveAdmin(mux, http.MethodGet, "/v1/admin/cache/entry?key=task:1")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"key":"task:1","ttl_seconds":0,"size":12,"absent":false,"value":{"id":1}}`, rec.Body.String())
+
+		rec = serveAdmin(mux, http.MethodGet, "/v1/admin/cache/entry?key=task:2")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Удаление по префиксу", func(t *testing.T) {
+		mockCache.On("FlushPrefix", mock.Anything, "tasks:suggest:").Return(3, nil).Once()
+
+		rec := serveAdmin(mux, http.MethodDelete, "/v1/admin/cache/keys?prefix=tasks:suggest:")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"flushed":3}`, rec.Body.String())
+
+		rec = serveAdmin(mux, http.MethodDelete, "/v1/admin/cache/keys?prefix=")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Хранилище без просмотра записей", func(t *testing.T) {
+		mockCache.On("Keys", mock.Anything, "", 10).Return(nil, errors.ErrUnsupported).Once()
+
+		rec := serveAdmin(mux, http.MethodGet, "/v1/admin/cache/keys?limit=10")
+		assert.Equal(t, http.StatusNotImplemented, rec.Code)
+	})
+
+	mockCache.AssertExpectations(t)
+}
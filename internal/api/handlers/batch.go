@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+	"github.com/pers0na2dev/todo-api/internal/models"
+)
+
+// batchRequest тело запроса POST /v1/tasks:batch
+type batchRequest struct {
+	// Mode - режим выполнения, по умолчанию all_or_nothing
+	Mode       models.BatchMode        `json:"mode" schema:"optional,enum=all_or_nothing|best_effort"`
+	Operations []models.BatchOperation `json:"operations" schema:"maxItems=1000"`
+}
+
+// batchResponse тело ответа POST /v1/tasks:batch
+type batchResponse struct {
+	// Committed - true, если изменения зафиксированы в базе данных
+	Committed bool                `json:"committed"`
+	Results   []batchResultOutput `json:"results"`
+}
+
+// batchResultOutput результат одной операции в ответе
+type batchResultOutput struct {
+	Index  int                       `json:"index"`
+	Op     models.BatchOperationType `json:"op"`
+	Status int                       `json:"status"`
+	Task   *models.Task              `json:"task,omitempty"`
+	Error  string                    `json:"error,omitempty"`
+}
+
+// BatchTasks функция, которая выполняет пакет операций над задачами
+func (h *TaskHandler) BatchTasks(w http.ResponseWriter, r *http.Request) {
+	// Формат ответа выбирается до выполнения операций, чтобы не изменять задачи, если результат нельзя вернуть клиенту
+	responseCodec, err := h.codecs.Negotiate(r.Header.Get("Accept"), batchResponse{})
+	if err != nil {
+		h.codecs.WriteNotAcceptable(w, batchResponse{})
+		return
+	}
+
+	var req batchRequest
+	// Декодирование тела запроса в формате из заголовка Content-Type в структуру req
+	if err := h.codecs.Read(r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = models.BatchAllOrNothing
+	}
+
+	// Выполнение операций
+	results, err := h.taskService.ApplyBatch(r.Context(), req.Operations, req.Mode)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	resp := batchResponse{
+		Committed: true,
+		Results:   make([]batchResultOutput, len(results)),
+	}
+	for i, result := range results {
+		op := req.Operations[i].Op
+		out := batchResultOutput{Index: i, Op: op, Task: result.Task}
+
+		if result.Err != nil {
+			out.Status = errorStatus(result.Err)
+			out.Error = result.Err.Error()
+			// В режиме all_or_nothing любая ошибка означает откат транзакции
+			if req.Mode == models.BatchAllOrNothing {
+				resp.Committed = false
+			}
+		} else {
+			out.Status = batchSuccessStatus(op)
+		}
+
+		resp.Results[i] = out
+	}
+
+	// Кодирование результатов в выбранном формате и отправка ответа
+	codec.Encode(w, responseCodec, http.StatusOK, resp)
+}
+
+// batchSuccessStatus функция, которая возвращает HTTP код успешно выполненной операции
+// Код совпадает с тем, который вернул бы соответствующий одиночный запрос
+// @param op models.BatchOperationType - тип операции
+// @return int - HTTP код
+func batchSuccessStatus(op models.BatchOperationType) int {
+	switch op {
+	case models.BatchCreate:
+		return http.StatusCreated
+	case models.BatchDelete:
+		return http.StatusNoContent
+	default:
+		return http.StatusOK
+	}
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+	"github.com/pers0na2dev/todo-api/internal/config"
+	"github.com/pers0na2dev/todo-api/internal/ical"
+	"github.com/pers0na2dev/todo-api/internal/models"
+)
+
+// CalendarService интерфейс, который определяет методы для работы с токенами лент календаря
+type CalendarService interface {
+	CreateToken(ctx context.Context, name string) (*models.CalendarToken, string, error)
+	ListTokens(ctx context.Context) ([]*models.CalendarToken, error)
+	RevokeToken(ctx context.Context, id int) error
+	VerifyToken(ctx context.Context, secret string) (*models.CalendarToken, error)
+}
+
+// calendarTokenRequest тело запроса POST /v1/calendar/tokens
+type calendarTokenRequest struct {
+	Name string `json:"name" schema:"optional,maxLength=255" doc:"Label to recognize the token, e.g. the device or calendar app"`
+}
+
+// calendarTokenResponse тело ответа POST /v1/calendar/tokens
+type calendarTokenResponse struct {
+	models.CalendarToken
+	Token string `json:"token" doc:"Secret part of the feed URL, returned only once"`
+	URL   string `json:"url" doc:"Path of the feed to subscribe to"`
+}
+
+// feedSuffix - расширение, с которым запрашивается лента календаря
+const feedSuffix = ".ics"
+
+// CalendarHandler структура, которая обрабатывает запросы лент календаря
+type CalendarHandler struct {
+	taskService TaskService
+	calendar    CalendarService
+	codecs      *codec.Registry
+	// loc - часовой пояс, в котором лента показывает сроки задач
+	loc *time.Location
+}
+
+// NewCalendarHandler функция, которая создает обработчик лент календаря и регистрирует его маршруты
+// Загрузка .ics выполняется через POST /v1/tasks/import с Content-Type text/calendar
+// @param taskService TaskService - сервис задач
+// @param calendar CalendarService - сервис токенов лент календаря
+// @param reg *openapi.Registry - реестр маршрутов
+// @param codecs *codec.Registry - форматы тел запросов и ответов
+// @param cfg *config.Config - конфигурация
+// @return *CalendarHandler - обработчик лент календаря
+// @return error - ошибка, если часовой пояс календаря неизвестен
+func NewCalendarHandler(taskService TaskService, calendar CalendarService, reg *openapi.Registry, codecs *codec.Registry, cfg *config.Config) (*CalendarHandler, error) {
+	loc, err := cfg.CalendarLocation()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CALENDAR_TIMEZONE: %w", err)
+	}
+
+	handler := &CalendarHandler{taskService: taskService, calendar: calendar, codecs: codecs, loc: loc}
+
+	reg.Handle("POST /v1/calendar/tokens", handler.CreateToken, &openapi.Op{
+		ID:          "createCalendarToken",
+		Summary:     "Issue a token for the read-only calendar feed",
+		Description: "The token is returned only once. Subscribe calendar apps to the returned url.",
+		Tags:        calendarTag,
+		Request:     calendarTokenRequest{},
+		Responses: map[int]openapi.Resp{
+			http.StatusCreated:             {Description: "The issued token", Body: calendarTokenResponse{}},
+			http.StatusInternalServerError: errorResp("Internal error"),
+		},
+	})
+	reg.Handle("GET /v1/calendar/tokens", handler.ListTokens, &openapi.Op{
+		ID:      "listCalendarTokens",
+		Summary: "List issued calendar feed tokens",
+		Tags:    calendarTag,
+		Responses: map[int]openapi.Resp{
+			http.StatusOK:                  {Description: "Issued tokens without their secrets", Body: []*models.CalendarToken{}},
+			http.StatusInternalServerError: errorResp("Internal error"),
+		},
+	})
+	reg.Handle("DELETE /v1/calendar/tokens/{id}", handler.RevokeToken, &openapi.Op{
+		ID:         "revokeCalendarToken",
+		Summary:    "Revoke a calendar feed token",
+		Tags:       calendarTag,
+		PathParams: []openapi.Param{{Name: "id", Description: "Token id", Schema: openapi.Integer().WithMinimum(1)}},
+		Responses: map[int]openapi.Resp{
+			http.StatusNoContent:           {Description: "The token was revoked"},
+			http.StatusNotFound:            errorResp("Token not found"),
+			http.StatusInternalServerError: errorResp("Internal error"),
+		},
+	})
+	reg.Handle("GET /v1/calendar/{file}", handler.Feed, &openapi.Op{
+		ID:      "getCalendarFeed",
+		Summary: "iCalendar feed of all tasks",
+		Description: "Every task is a VTODO; tasks with a due date are also published as VEVENT at the due time, " +
+			"because many calendar apps ignore VTODO. Times are written in CALENDAR_TIMEZONE with a VTIMEZONE block.",
+		Tags:       calendarTag,
+		PathParams: []openapi.Param{{Name: "file", Description: "Feed token followed by .ics", Schema: openapi.String()}},
+		QueryParams: []openapi.Param{
+			{Name: "component", Description: "Publish only VTODO or only VEVENT components", Schema: openapi.String().WithEnum("vtodo", "vevent")},
+		},
+		Responses: map[int]openapi.Resp{
+			http.StatusOK:                  {Description: "iCalendar feed", Body: "", ContentType: ical.MediaType},
+			http.StatusNotFound:            errorResp("Unknown or revoked token"),
+			http.StatusInternalServerError: errorResp("Internal error"),
+		},
+	})
+
+	return handler, nil
+}
+
+// CreateToken функция, которая выдает новый токен ленты календаря
+func (h *CalendarHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	responseCodec, err := h.codecs.Negotiate(r.Header.Get("Accept"), calendarTokenResponse{})
+	if err != nil {
+		h.codecs.WriteNotAcceptable(w, calendarTokenResponse{})
+		return
+	}
+
+	var req calendarTokenRequest
+	if err := h.codecs.Read(r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	token, secret, err := h.calendar.CreateToken(r.Context(), strings.TrimSpace(req.Name))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := calendarTokenResponse{CalendarToken: *token, Token: secret, URL: "/v1/calendar/" + secret + feedSuffix}
+	codec.Encode(w, responseCodec, http.StatusCreated, resp)
+}
+
+// ListTokens функция, которая возвращает выданные токены
+func (h *CalendarHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.calendar.ListTokens(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.codecs.Write(w, r, http.StatusOK, tokens)
+}
+
+// RevokeToken функция, которая отзывает токен
+func (h *CalendarHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.calendar.RevokeToken(r.Context(), id)
+	if errors.Is(err, models.ErrCalendarTokenNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Feed функция, которая отдает ленту календаря по токену из имени файла
+// Задачи передаются клиенту по мере чтения из базы данных, как при экспорте
+func (h *CalendarHandler) Feed(w http.ResponseWriter, r *http.Request) {
+	// Неизвестный токен и имя без .ics не различаются, чтобы не подсказывать формат ссылки
+	secret, ok := strings.CutSuffix(r.PathValue("file"), feedSuffix)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	_, err := h.calendar.VerifyToken(r.Context(), secret)
+	if errors.Is(err, models.ErrCalendarTokenNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	component := r.URL.Query().Get("component")
+	now := time.Now()
+	enc := ical.NewEncoder(w)
+	started := false
+	start := func() {
+		w.Header().Set("Content-Type", ical.MediaType+"; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		ical.BeginCalendar(enc, "Tasks", h.loc, now)
+		started = true
+	}
+
+	err = h.taskService.ExportTasks(r.Context(), func(record *models.TaskRecord) error {
+		if !started {
+			start()
+		}
+		if component != "vevent" {
+			enc.Component(ical.Todo(record, h.loc, now))
+		}
+		if event := ical.Event(record, h.loc, now); event != nil && component != "vtodo" {
+			enc.Component(event)
+		}
+		return enc.Err()
+	})
+	if err != nil {
+		if !started {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Код 200 уже отправлен, поэтому обрываем соединение, чтобы календарь не принял неполную ленту за полную
+		panic(http.ErrAbortHandler)
+	}
+
+	if !started {
+		start()
+	}
+	enc.End("VCALENDAR")
+	if err := enc.Flush(); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+}
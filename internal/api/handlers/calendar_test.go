@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+	"github.com/pers0na2dev/todo-api/internal/api/handlers/mocks"
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+	"github.com/pers0na2dev/todo-api/internal/config"
+	"github.com/pers0na2dev/todo-api/internal/ical"
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupCalendarTest подготавливает маршрутизатор с обработчиками задач и календаря и их моки
+func setupCalendarTest(t *testing.T) (*http.ServeMux, *mocks.TaskService, *mocks.CalendarService) {
+	t.Helper()
+
+	cfg := &config.Config{CalendarTimezone: "Europe/Berlin"}
+	icalCodec, err := ical.NewCodec(cfg)
+	require.NoError(t, err)
+
+	mockService := new(mocks.TaskService)
+	mockCalendar := new(mocks.CalendarService)
+	mux := http.NewServeMux()
+	codecs := codec.NewRegistry(icalCodec)
+	reg := openapi.NewRegistry(mux, codecs)
+	_, err = NewTaskHandler(mockService, reg, codecs, cfg)
+	require.NoError(t, err)
+	_, err = NewCalendarHandler(mockService, mockCalendar, reg, codecs, cfg)
+	require.NoError(t, err)
+
+	return mux, mockService, mockCalendar
+}
+
+// TestCalendarFeed тестирует ленту календаря
+func TestCalendarFeed(t *testing.T) {
+	mux, mockService, mockCalendar := setupCalendarTest(t)
+	dueAt := time.Date(2024, 12, 20, 17, 0, 0, 0, time.UTC)
+	records := []*models.TaskRecord{
+		{ID: 1, Title: "Сдать отчет", Version: 1, DueAt: &dueAt},
+		{ID: 2, Title: "Позвонить", Completed: true, Version: 3},
+	}
+	export := func(args mock.Arguments) {
+		fn := args.Get(1).(func(*models.TaskRecord) error)
+		for _, record := range records {
+			fn(record)
+		}
+	}
+
+	t.Run("Лента содержит VTIMEZONE, VTODO и VEVENT", func(t *testing.T) {
+		mockCalendar.On("VerifyToken", mock.Anything, "secret").Return(&models.CalendarToken{ID: 1}, nil).Once()
+		mockService.On("ExportTasks", mock.Anything, mock.Anything).Run(export).Return(nil).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/calendar/secret.ics", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/calendar; charset=utf-8", rec.Header().Get("Content-Type"))
+
+		calendar, err := ical.Decode(rec.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "VCALENDAR", calendar.Name)
+		assert.Len(t, calendar.Children("VTIMEZONE"), 1)
+		assert.Len(t, calendar.Children("VTODO"), 2)
+		events := calendar.Children("VEVENT")
+		require.Len(t, events, 1)
+		assert.Equal(t, "20241220T180000", events[0].Prop("DTSTART").Value)
+		mockService.AssertExpectations(t)
+		mockCalendar.AssertExpectations(t)
+	})
+
+	t.Run("Параметр component оставляет только VTODO", func(t *testing.T) {
+		mockCalendar.On("VerifyToken", mock.Anything, "secret").Return(&models.CalendarToken{ID: 1}, nil).Once()
+		mockService.On("ExportTasks", mock.Anything, mock.Anything).Run(export).Return(nil).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/calendar/secret.ics?component=vtodo", nil))
+
+		calendar, err := ical.Decode(rec.Body)
+		require.NoError(t, err)
+		assert.Len(t, calendar.Children("VTODO"), 2)
+		assert.Empty(t, calendar.Children("VEVENT"))
+	})
+
+	t.Run("Пустая лента остается корректным календарем", func(t *testing.T) {
+		mockCalendar.On("VerifyToken", mock.Anything, "secret").Return(&models.CalendarToken{ID: 1}, nil).Once()
+		mockService.On("ExportTasks", mock.Anything, mock.Anything).Return(nil).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/calendar/secret.ics", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		_, err := ical.Decode(rec.Body)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Неизвестный токен возвращает 404", func(t *testing.T) {
+		mockCalendar.On("VerifyToken", mock.Anything, "unknown").Return(nil, models.ErrCalendarTokenNotFound).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/calendar/unknown.ics", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		mockCalendar.AssertExpectations(t)
+	})
+
+	t.Run("Ссылка без .ics возвращает 404 без проверки токена", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/calendar/plain", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		mockCalendar.AssertNotCalled(t, "VerifyToken", mock.Anything, "plain")
+	})
+}
+
+// TestCalendarTokens тестирует выдачу и отзыв токенов лент календаря
+func TestCalendarTokens(t *testing.T) {
+	mux, _, mockCalendar := setupCalendarTest(t)
+
+	t.Run("Выданный токен возвращается вместе со ссылкой на ленту", func(t *testing.T) {
+		token := &models.CalendarToken{ID: 1, Name: "Телефон", CreatedAt: time.Date(2024, 12, 18, 9, 0, 0, 0, time.UTC)}
+		mockCalendar.On("CreateToken", mock.Anything, "Телефон").Return(token, "secret", nil).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/calendar/tokens", strings.NewReader(`{"name":" Телефон "}`)))
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		var resp calendarTokenResponse
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, "secret", resp.Token)
+		assert.Equal(t, "/v1/calendar/secret.ics", resp.URL)
+		assert.Equal(t, 1, resp.ID)
+		mockCalendar.AssertExpectations(t)
+	})
+
+	t.Run("Отзыв несуществующего токена возвращает 404", func(t *testing.T) {
+		mockCalendar.On("RevokeToken", mock.Anything, 5).Return(models.ErrCalendarTokenNotFound).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/v1/calendar/tokens/5", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		mockCalendar.AssertExpectations(t)
+	})
+
+	t.Run("Отзыв токена возвращает 204", func(t *testing.T) {
+		mockCalendar.On("RevokeToken", mock.Anything, 1).Return(nil).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/v1/calendar/tokens/1", nil))
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		mockCalendar.AssertExpectations(t)
+	})
+}
+
+// TestImportCalendar тестирует импорт задач из загруженного .ics
+func TestImportCalendar(t *testing.T) {
+	mux, mockService, _ := setupCalendarTest(t)
+
+	t.Run("VTODO импортируются с UID в качестве внешнего id", func(t *testing.T) {
+		dueAt := time.Date(2024, 12, 20, 17, 0, 0, 0, time.UTC)
+		mockService.On("ImportTasks", mock.Anything, mock.MatchedBy(func(records []models.TaskRecord) bool {
+			return len(records) == 1 && records[0].ExternalID == "abc@example.com" && records[0].Title == "Сдать отчет" &&
+				records[0].Completed && records[0].DueAt.Equal(dueAt)
+		}), false).Return([]models.ImportResult{
+			{Task: &models.Task{ID: 1, Title: "Сдать отчет", Completed: true, Version: 1, DueAt: &dueAt}, Created: true},
+		}, nil).Once()
+
+		body := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nUID:abc@example.com\r\nSUMMARY:Сдать отчет\r\n" +
+			"DUE;TZID=Europe/Berlin:20241220T180000\r\nSTATUS:COMPLETED\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+		req := httptest.NewRequest(http.MethodPost, "/v1/tasks/import", strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/calendar")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"created":1`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Некорректный календарь возвращает 400", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/tasks/import", strings.NewReader("BEGIN:VCALENDAR\r\n"))
+		req.Header.Set("Content-Type", "text/calendar")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+)
+
+// taskETag функция, которая формирует ETag задачи из её версии
+// @param task *models.Task - задача
+// @return string - значение заголовка ETag
+func taskETag(task *models.Task) string {
+	return `"` + strconv.Itoa(task.Version) + `"`
+}
+
+// parseIfMatch функция, которая извлекает ожидаемую версию задачи из заголовка If-Match
+// @param r *http.Request - запрос
+// @return int - ожидаемая версия задачи
+// @return bool - true, если заголовок присутствует
+// @return bool - true, если заголовок содержит корректный строгий ETag
+func parseIfMatch(r *http.Request) (int, bool, bool) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return 0, false, false
+	}
+
+	// If-Match использует строгое сравнение, поэтому слабые ETag не подходят
+	if !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) || len(value) < 2 {
+		return 0, true, false
+	}
+
+	version, err := strconv.Atoi(value[1 : len(value)-1])
+	if err != nil {
+		return 0, true, false
+	}
+
+	return version, true, true
+}
+
+// matchesIfNoneMatch функция, которая проверяет совпадает ли ETag с заголовком If-None-Match
+// Используется слабое сравнение, как того требует RFC 9110
+// @param r *http.Request - запрос
+// @param etag string - текущий ETag ресурса
+// @return bool - true, если клиент уже имеет актуальную версию
+func matchesIfNoneMatch(r *http.Request, etag string) bool {
+	value := r.Header.Get("If-None-Match")
+	if value == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(value, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+)
+
+// Readiness интерфейс, который сообщает, готово ли приложение принимать трафик
+type Readiness interface {
+	Ready() bool
+}
+
+// readinessResponse тело ответа GET /readyz
+type readinessResponse struct {
+	Status string `json:"status" doc:"ready, or warming_up while the cache is being warmed up after start"`
+}
+
+// HealthHandler структура, которая обрабатывает проверки состояния приложения
+type HealthHandler struct {
+	readiness Readiness
+	codecs    *codec.Registry
+}
+
+// NewHealthHandler функция, которая создает обработчик проверок состояния и регистрирует его маршруты
+// @param readiness Readiness - готовность приложения
+// @param reg *openapi.Registry - реестр маршрутов
+// @param codecs *codec.Registry - форматы тел ответов
+// @return *HealthHandler - обработчик проверок состояния
+func NewHealthHandler(readiness Readiness, reg *openapi.Registry, codecs *codec.Registry) *HealthHandler {
+	handler := &HealthHandler{readiness: readiness, codecs: codecs}
+
+	reg.Handle("GET /readyz", handler.Ready, &openapi.Op{
+		ID:          "getReadiness",
+		Summary:     "Readiness probe",
+		Description: "Reports not ready until the cache warmup after start has finished or timed out.",
+		Tags:        healthTag,
+		Responses: map[int]openapi.Resp{
+			http.StatusOK:                 {Description: "The replica is ready to serve traffic", Body: readinessResponse{}},
+			http.StatusServiceUnavailable: {Description: "The cache is still warming up", Body: readinessResponse{}},
+		},
+	})
+
+	return handler
+}
+
+// Ready функция, которая возвращает готовность приложения
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	if !h.readiness.Ready() {
+		h.codecs.Write(w, r, http.StatusServiceUnavailable, readinessResponse{Status: "warming_up"})
+		return
+	}
+	h.codecs.Write(w, r, http.StatusOK, readinessResponse{Status: "ready"})
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+	"github.com/pers0na2dev/todo-api/internal/api/handlers/mocks"
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHealthHandler тестирует проверку готовности приложения
+func TestHealthHandler(t *testing.T) {
+	mockReadiness := new(mocks.Readiness)
+	mux := http.NewServeMux()
+	codecs := codec.NewRegistry()
+	NewHealthHandler(mockReadiness, openapi.NewRegistry(mux, codecs), codecs)
+
+	t.Run("Пока кеш прогревается, приложение не готово", func(t *testing.T) {
+		mockReadiness.On("Ready").Return(false).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.JSONEq(t, `{"status":"warming_up"}`, rec.Body.String())
+	})
+
+	t.Run("После прогрева приложение готово", func(t *testing.T) {
+		mockReadiness.On("Ready").Return(true).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"status":"ready"}`, rec.Body.String())
+	})
+
+	mockReadiness.AssertExpectations(t)
+}
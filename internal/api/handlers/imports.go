@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+	"github.com/pers0na2dev/todo-api/internal/config"
+	"github.com/pers0na2dev/todo-api/internal/importer"
+	"github.com/pers0na2dev/todo-api/internal/models"
+)
+
+// ImportService интерфейс, который определяет методы для фонового импорта файлов других приложений
+type ImportService interface {
+	StartImport(ctx context.Context, source string, records []models.TaskRecord, warnings []string) (*models.ImportJob, error)
+	GetImport(ctx context.Context, id string) (*models.ImportJob, error)
+}
+
+// ImportHandler структура, которая обрабатывает запросы импорта из Todoist, Trello и Microsoft To Do
+type ImportHandler struct {
+	imports ImportService
+	codecs  *codec.Registry
+	// loc - часовой пояс для сроков без часового пояса в файлах
+	loc *time.Location
+}
+
+// NewImportHandler функция, которая создает обработчик импорта и регистрирует его маршруты
+// @param imports ImportService - сервис импорта
+// @param reg *openapi.Registry - реестр маршрутов
+// @param codecs *codec.Registry - форматы тел ответов
+// @param cfg *config.Config - конфигурация
+// @return *ImportHandler - обработчик импорта
+// @return error - ошибка, если часовой пояс календаря неизвестен
+func NewImportHandler(imports ImportService, reg *openapi.Registry, codecs *codec.Registry, cfg *config.Config) (*ImportHandler, error) {
+	loc, err := cfg.CalendarLocation()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CALENDAR_TIMEZONE: %w", err)
+	}
+
+	handler := &ImportHandler{imports: imports, codecs: codecs, loc: loc}
+
+	sources := make([]any, len(importer.Sources))
+	for i, source := range importer.Sources {
+		sources[i] = string(source)
+	}
+
+	reg.Handle("POST /v1/imports", handler.StartImport, &openapi.Op{
+		ID:      "startImport",
+		Summary: "Import an export file of Todoist, Trello or Microsoft To Do",
+		Description: "The body is the exported file as is: a Todoist project CSV (todoist-csv), a Todoist backup or Sync API " +
+			"JSON (todoist-json), a Trello board JSON (trello) or Microsoft To Do lists from Graph API with expanded tasks " +
+			"and checklistItems (mstodo). Lists, projects and sections become +project tags in the title, checklist items " +
+			"and subtasks become tasks titled \"parent: item\". Tasks are imported in the background; poll the returned " +
+			"Location for progress. Importing the same file again updates the tasks instead of duplicating them.",
+		Tags: importsTag,
+		QueryParams: []openapi.Param{
+			{Name: "source", Description: "Format of the file", Required: true, Schema: openapi.String().WithEnum(sources...)},
+			{Name: "list", Description: "Project name for todoist-csv, which does not contain it", Schema: openapi.String().WithMaxLength(255)},
+		},
+		HeaderParams: []openapi.Param{idempotencyKeyParam},
+		// Тело читает сам обработчик, ограничение указано для middleware, которые читают его раньше
+		MaxBodyBytes: maxImportBodyBytes,
+		Responses: map[int]openapi.Resp{
+			http.StatusAccepted:              {Description: "The import job was started", Body: models.ImportJob{}, Headers: map[string]string{"Location": "URL of the import job"}},
+			http.StatusBadRequest:            errorResp("The file can not be read or contains no tasks"),
+			http.StatusRequestEntityTooLarge: errorResp("The file is too large"),
+			http.StatusInternalServerError:   errorResp("Internal error"),
+		},
+	})
+	reg.Handle("GET /v1/imports/{id}", handler.GetImport, &openapi.Op{
+		ID:          "getImport",
+		Summary:     "Get progress of an import job",
+		Description: "Jobs are shared by all replicas and kept for 24 hours after their last update.",
+		Tags:        importsTag,
+		PathParams:  []openapi.Param{{Name: "id", Description: "Import job id", Schema: openapi.String()}},
+		Responses: map[int]openapi.Resp{
+			http.StatusOK:                  {Description: "The import job", Body: models.ImportJob{}},
+			http.StatusNotFound:            errorResp("Import job not found"),
+			http.StatusInternalServerError: errorResp("Internal error"),
+		},
+	})
+
+	return handler, nil
+}
+
+// StartImport функция, которая разбирает загруженный файл и запускает его импорт в фоне
+// Файл разбирается до ответа, чтобы поврежденный файл возвращал 400, а не задание с ошибкой
+func (h *ImportHandler) StartImport(w http.ResponseWriter, r *http.Request) {
+	responseCodec, err := h.codecs.Negotiate(r.Header.Get("Accept"), models.ImportJob{})
+	if err != nil {
+		h.codecs.WriteNotAcceptable(w, models.ImportJob{})
+		return
+	}
+
+	source := r.URL.Query().Get("source")
+	opts := importer.Options{List: strings.TrimSpace(r.URL.Query().Get("list")), Loc: h.loc}
+	result, err := importer.Parse(importer.Source(source), http.MaxBytesReader(w, r.Body, maxImportBodyBytes), opts)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("request body must not exceed %d bytes", maxImportBodyBytes), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(result.Records) == 0 {
+		http.Error(w, "the file contains no tasks", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.imports.StartImport(r.Context(), source, result.Records, result.Warnings)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", "/v1/imports/"+job.ID)
+	codec.Encode(w, responseCodec, http.StatusAccepted, job)
+}
+
+// GetImport функция, которая возвращает состояние задания импорта
+func (h *ImportHandler) GetImport(w http.ResponseWriter, r *http.Request) {
+	job, err := h.imports.GetImport(r.Context(), r.PathValue("id"))
+	if errors.Is(err, models.ErrImportJobNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.codecs.Write(w, r, http.StatusOK, job)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+	"github.com/pers0na2dev/todo-api/internal/api/handlers/mocks"
+	"github.com/pers0na2dev/todo-api/internal/api/middleware"
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+	"github.com/pers0na2dev/todo-api/internal/config"
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupImportTest подготавливает маршрутизатор с обработчиком импорта и мок сервиса
+func setupImportTest(t *testing.T) (*http.ServeMux, *mocks.ImportService) {
+	t.Helper()
+
+	mockImports := new(mocks.ImportService)
+	mux := http.NewServeMux()
+	codecs := codec.NewRegistry()
+	_, err := NewImportHandler(mockImports, openapi.NewRegistry(mux, codecs), codecs, &config.Config{CalendarTimezone: "Europe/Berlin"})
+	require.NoError(t, err)
+
+	return mux, mockImports
+}
+
+// TestStartImport тестирует запуск импорта файла
+func TestStartImport(t *testing.T) {
+	mux, mockImports := setupImportTest(t)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	t.Run("Файл разбирается и импортируется в фоне", func(t *testing.T) {
+		file := "TYPE,CONTENT,PRIORITY,INDENT,DATE\ntask,Купить молоко,1,1,2024-03-10\ntask,Обезжиренное,4,2,\n"
+		due := time.Date(2024, 3, 10, 0, 0, 0, 0, berlin)
+		job := &models.ImportJob{ID: "abc", Source: "todoist-csv", Status: models.ImportPending, Total: 2}
+
+		mockImports.On("StartImport", mock.Anything, "todoist-csv", mock.MatchedBy(func(records []models.TaskRecord) bool {
+			return len(records) == 2 &&
+				records[0].Title == "Купить молоко +Покупки pri:A" && records[0].DueAt.Equal(due) &&
+				records[1].Title == "Купить молоко: Обезжиренное +Покупки"
+		}), []string(nil)).Return(job, nil).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/imports?source=todoist-csv&list=Покупки", strings.NewReader(file)))
+
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Equal(t, "/v1/imports/abc", rec.Header().Get("Location"))
+		var body models.ImportJob
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		assert.Equal(t, *job, body)
+		mockImports.AssertExpectations(t)
+	})
+
+	t.Run("Неизвестный формат отклоняется проверкой параметров", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/imports?source=asana", strings.NewReader("{}")))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Поврежденный файл и файл без задач возвращают 400", func(t *testing.T) {
+		for _, file := range []string{`{"cards": [`, `{"lists": [], "cards": []}`} {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/imports?source=trello", strings.NewReader(file)))
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+		mockImports.AssertNumberOfCalls(t, "StartImport", 1)
+	})
+
+	t.Run("Файл больше 1 МиБ с Idempotency-Key импортируется один раз", func(t *testing.T) {
+		mux := http.NewServeMux()
+		codecs := codec.NewRegistry()
+		reg := openapi.NewRegistry(mux, codecs)
+		_, err := NewImportHandler(mockImports, reg, codecs, &config.Config{CalendarTimezone: "Europe/Berlin"})
+		require.NoError(t, err)
+		handler := withIdempotency(t, mux, reg)
+
+		file := "TYPE,CONTENT\n" + strings.Repeat("task,"+strings.Repeat("Задача", 40)+"\n", 3000)
+		require.Greater(t, len(file), openapi.DefaultMaxBodyBytes)
+		job := &models.ImportJob{ID: "big", Source: "todoist-csv", Status: models.ImportPending, Total: 3000}
+		mockImports.On("StartImport", mock.Anything, "todoist-csv", mock.MatchedBy(func(records []models.TaskRecord) bool {
+			return len(records) == 3000
+		}), []string(nil)).Return(job, nil).Once()
+
+		send := func() *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/v1/imports?source=todoist-csv", strings.NewReader(file))
+			req.Header.Set(middleware.IdempotencyKeyHeader, "import-big")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec
+		}
+		first := send()
+		second := send()
+
+		assert.Equal(t, http.StatusAccepted, first.Code)
+		assert.Equal(t, http.StatusAccepted, second.Code)
+		assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+		mockImports.AssertExpectations(t)
+	})
+
+	t.Run("Слишком большой файл возвращает 413", func(t *testing.T) {
+		file := `{"value": [{"displayName": "` + strings.Repeat("a", maxImportBodyBytes) + `"}]}`
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/imports?source=mstodo", strings.NewReader(file)))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+}
+
+// TestGetImport тестирует получение состояния импорта
+func TestGetImport(t *testing.T) {
+	mux, mockImports := setupImportTest(t)
+
+	t.Run("Возвращается прогресс задания", func(t *testing.T) {
+		job := &models.ImportJob{ID: "abc", Source: "trello", Status: models.ImportRunning, Total: 10, Processed: 5, Created: 5, Warnings: []string{}}
+		mockImports.On("GetImport", mock.Anything, "abc").Return(job, nil).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/imports/abc", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var body models.ImportJob
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		assert.Equal(t, *job, body)
+		mockImports.AssertExpectations(t)
+	})
+
+	t.Run("Неизвестное задание возвращает 404", func(t *testing.T) {
+		mockImports.On("GetImport", mock.Anything, "missing").Return(nil, models.ErrImportJobNotFound).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/imports/missing", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		mockImports.AssertExpectations(t)
+	})
+}
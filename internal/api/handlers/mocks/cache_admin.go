@@ -0,0 +1,40 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/pers0na2dev/todo-api/pkg/cache"
+	"github.com/stretchr/testify/mock"
+)
+
+// CacheAdmin это автоматически сгенерированный мок для интерфейса CacheAdmin
+type CacheAdmin struct {
+	mock.Mock
+}
+
+// Metrics мок для метода Metrics
+func (m *CacheAdmin) Metrics() cache.Metrics {
+	args := m.Called()
+	return args.Get(0).(cache.Metrics)
+}
+
+// Inspect мок для метода Inspect
+func (m *CacheAdmin) Inspect(ctx context.Context, key string) (cache.Entry, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).(cache.Entry), args.Error(1)
+}
+
+// Keys мок для метода Keys
+func (m *CacheAdmin) Keys(ctx context.Context, prefix string, limit int) ([]cache.EntryInfo, error) {
+	args := m.Called(ctx, prefix, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]cache.EntryInfo), args.Error(1)
+}
+
+// FlushPrefix мок для метода FlushPrefix
+func (m *CacheAdmin) FlushPrefix(ctx context.Context, prefix string) (int, error) {
+	args := m.Called(ctx, prefix)
+	return args.Int(0), args.Error(1)
+}
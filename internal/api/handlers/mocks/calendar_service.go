@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+// CalendarService это автоматически сгенерированный мок для интерфейса CalendarService
+type CalendarService struct {
+	mock.Mock
+}
+
+// CreateToken мок для метода CreateToken
+func (m *CalendarService) CreateToken(ctx context.Context, name string) (*models.CalendarToken, string, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, "", args.Error(2)
+	}
+	return args.Get(0).(*models.CalendarToken), args.String(1), args.Error(2)
+}
+
+// ListTokens мок для метода ListTokens
+func (m *CalendarService) ListTokens(ctx context.Context) ([]*models.CalendarToken, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.CalendarToken), args.Error(1)
+}
+
+// RevokeToken мок для метода RevokeToken
+func (m *CalendarService) RevokeToken(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// VerifyToken мок для метода VerifyToken
+func (m *CalendarService) VerifyToken(ctx context.Context, secret string) (*models.CalendarToken, error) {
+	args := m.Called(ctx, secret)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CalendarToken), args.Error(1)
+}
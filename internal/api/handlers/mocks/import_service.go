@@ -0,0 +1,31 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+// ImportService это автоматически сгенерированный мок для интерфейса ImportService
+type ImportService struct {
+	mock.Mock
+}
+
+// StartImport мок для метода StartImport
+func (m *ImportService) StartImport(ctx context.Context, source string, records []models.TaskRecord, warnings []string) (*models.ImportJob, error) {
+	args := m.Called(ctx, source, records, warnings)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ImportJob), args.Error(1)
+}
+
+// GetImport мок для метода GetImport
+func (m *ImportService) GetImport(ctx context.Context, id string) (*models.ImportJob, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ImportJob), args.Error(1)
+}
@@ -0,0 +1,14 @@
+package mocks
+
+import "github.com/stretchr/testify/mock"
+
+// Readiness это автоматически сгенерированный мок для интерфейса Readiness
+type Readiness struct {
+	mock.Mock
+}
+
+// Ready мок для метода Ready
+func (m *Readiness) Ready() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
@@ -0,0 +1,103 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+// TaskService это автоматически сгенерированный мок для интерфейса TaskService
+type TaskService struct {
+	mock.Mock
+}
+
+// CreateTask мок для метода CreateTask
+func (m *TaskService) CreateTask(ctx context.Context, title string) (*models.Task, error) {
+	args := m.Called(ctx, title)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
+// GetTasks мок для метода GetTasks
+func (m *TaskService) GetTasks(ctx context.Context) ([]*models.Task, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*models.Task), args.Error(1)
+}
+
+// GetTaskByID мок для метода GetTaskByID
+func (m *TaskService) GetTaskByID(ctx context.Context, id int) (*models.Task, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
+// OpenCloseTask мок для метода OpenCloseTask
+func (m *TaskService) OpenCloseTask(ctx context.Context, id int, version int) (*models.Task, error) {
+	args := m.Called(ctx, id, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
+// RemoveTask мок для метода RemoveTask
+func (m *TaskService) RemoveTask(ctx context.Context, id int, version int) error {
+	args := m.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+// ApplyBatch мок для метода ApplyBatch
+func (m *TaskService) ApplyBatch(ctx context.Context, ops []models.BatchOperation, mode models.BatchMode) ([]models.BatchResult, error) {
+	args := m.Called(ctx, ops, mode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.BatchResult), args.Error(1)
+}
+
+// SearchTasks мок для метода SearchTasks
+func (m *TaskService) SearchTasks(ctx context.Context, query string, lang string, limit int) ([]*models.TaskSearchResult, error) {
+	args := m.Called(ctx, query, lang, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.TaskSearchResult), args.Error(1)
+}
+
+// SuggestTasks мок для метода SuggestTasks
+func (m *TaskService) SuggestTasks(ctx context.Context, query string, limit int) ([]*models.TaskSuggestion, error) {
+	args := m.Called(ctx, query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.TaskSuggestion), args.Error(1)
+}
+
+// ExportTasks мок для метода ExportTasks
+func (m *TaskService) ExportTasks(ctx context.Context, fn func(*models.TaskRecord) error) error {
+	args := m.Called(ctx, fn)
+	return args.Error(0)
+}
+
+// ImportTasks мок для метода ImportTasks
+func (m *TaskService) ImportTasks(ctx context.Context, records []models.TaskRecord, dryRun bool) ([]models.ImportResult, error) {
+	args := m.Called(ctx, records, dryRun)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ImportResult), args.Error(1)
+}
+
+// SyncTasks мок для метода SyncTasks
+func (m *TaskService) SyncTasks(ctx context.Context, records []models.TaskRecord) ([]models.ImportResult, error) {
+	args := m.Called(ctx, records)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ImportResult), args.Error(1)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+	"github.com/pers0na2dev/todo-api/internal/models"
+	todov1 "github.com/pers0na2dev/todo-api/pkg/pb/todo/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// registerProtoTypes функция, которая регистрирует типы, передаваемые в формате Protobuf
+// Используются сообщения gRPC API, поэтому клиенты могут использовать одни и те же сгенерированные типы
+// @param p *codec.Protobuf - формат Protobuf
+func registerProtoTypes(p *codec.Protobuf) {
+	codec.RegisterProto(p, taskToProto, taskFromProto)
+	codec.RegisterProto(p,
+		func(tasks []*models.Task) *todov1.ListTasksResponse {
+			resp := &todov1.ListTasksResponse{Tasks: make([]*todov1.Task, 0, len(tasks))}
+			for _, task := range tasks {
+				resp.Tasks = append(resp.Tasks, taskToProto(*task))
+			}
+			return resp
+		},
+		func(resp *todov1.ListTasksResponse) []*models.Task {
+			tasks := make([]*models.Task, 0, len(resp.GetTasks()))
+			for _, task := range resp.GetTasks() {
+				converted := taskFromProto(task)
+				tasks = append(tasks, &converted)
+			}
+			return tasks
+		},
+	)
+	codec.RegisterProto(p,
+		func(req createTaskRequest) *todov1.CreateTaskRequest {
+			return &todov1.CreateTaskRequest{Title: req.Title}
+		},
+		func(req *todov1.CreateTaskRequest) createTaskRequest {
+			return createTaskRequest{Title: req.GetTitle()}
+		},
+	)
+}
+
+// taskToProto функция, которая преобразует задачу в сообщение protobuf
+// @param task models.Task - задача
+// @return *todov1.Task - сообщение protobuf
+func taskToProto(task models.Task) *todov1.Task {
+	message := &todov1.Task{
+		Id:        int64(task.ID),
+		Title:     task.Title,
+		Completed: task.Completed,
+		Version:   int64(task.Version),
+	}
+	if task.DueAt != nil {
+		message.DueAt = timestamppb.New(*task.DueAt)
+	}
+	return message
+}
+
+// taskFromProto функция, которая преобразует сообщение protobuf в задачу
+// @param task *todov1.Task - сообщение protobuf
+// @return models.Task - задача
+func taskFromProto(task *todov1.Task) models.Task {
+	result := models.Task{
+		ID:        int(task.GetId()),
+		Title:     task.GetTitle(),
+		Completed: task.GetCompleted(),
+		Version:   int(task.GetVersion()),
+	}
+	if task.GetDueAt() != nil {
+		dueAt := task.GetDueAt().AsTime()
+		result.DueAt = &dueAt
+	}
+	return result
+}
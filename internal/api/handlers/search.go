@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// SearchTasks функция, которая выполняет полнотекстовый поиск задач
+// Параметры запроса: q - поисковый запрос, lang - конфигурация поиска, limit - количество результатов
+func (h *TaskHandler) SearchTasks(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	// Получение количества результатов из параметров запроса
+	limit, err := parseLimit(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Поиск задач
+	results, err := h.taskService.SearchTasks(r.Context(), params.Get("q"), params.Get("lang"), limit)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	// Кодирование результатов в формате из заголовка Accept и отправка ответа
+	h.codecs.Write(w, r, http.StatusOK, results)
+}
+
+// SuggestTasks функция, которая возвращает подсказки для быстрого перехода к задаче
+// Параметры запроса: q - начало или часть названия задачи, limit - количество подсказок
+func (h *TaskHandler) SuggestTasks(w http.ResponseWriter, r *http.Request) {
+	// Получение количества подсказок из параметров запроса
+	limit, err := parseLimit(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Поиск подсказок
+	suggestions, err := h.taskService.SuggestTasks(r.Context(), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	// Кодирование подсказок в формате из заголовка Accept и отправка ответа
+	h.codecs.Write(w, r, http.StatusOK, suggestions)
+}
+
+// parseLimit функция, которая извлекает параметр limit из запроса
+// @param r *http.Request - запрос
+// @return int - значение limit или 0, если параметр не передан
+// @return error - ошибка
+func parseLimit(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"github.com/pers0na2dev/todo-api/internal/api/middleware"
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+)
+
+// Общие элементы описания маршрутов для документа OpenAPI
+var (
+	tasksTag    = []string{"tasks"}
+	calendarTag = []string{"calendar"}
+	importsTag  = []string{"imports"}
+	adminTag    = []string{"admin"}
+	healthTag   = []string{"health"}
+
+	taskIDParam = openapi.Param{
+		Name:        "id",
+		Description: "Task id",
+		Schema:      openapi.Integer().WithMinimum(1),
+	}
+	ifMatchParam = openapi.Param{
+		Name:        "If-Match",
+		Description: "Strong ETag of the task version the change is based on",
+		Required:    true,
+		Schema:      openapi.String(),
+	}
+	idempotencyKeyParam = openapi.Param{
+		Name:        middleware.IdempotencyKeyHeader,
+		Description: "Client-generated key; retries with the same key replay the stored response for 24h",
+		Schema:      openapi.String().WithMaxLength(255),
+	}
+	adminAuthParam = openapi.Param{
+		Name:        "Authorization",
+		Description: "Bearer followed by ADMIN_TOKEN",
+		Schema:      openapi.String(),
+	}
+
+	etagHeader = map[string]string{"ETag": "Task version"}
+)
+
+// errorResp функция, которая описывает ответ с текстом ошибки
+// @param description string - описание ответа
+// @return openapi.Resp - описание ответа
+func errorResp(description string) openapi.Resp {
+	return openapi.Resp{Description: description, Body: "", ContentType: "text/plain"}
+}
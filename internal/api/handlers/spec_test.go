@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+	"github.com/pers0na2dev/todo-api/internal/api/handlers/mocks"
+	"github.com/pers0na2dev/todo-api/internal/api/middleware"
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+	"github.com/pers0na2dev/todo-api/internal/caldav"
+	caldavmocks "github.com/pers0na2dev/todo-api/internal/caldav/mocks"
+	"github.com/pers0na2dev/todo-api/internal/config"
+	"github.com/pers0na2dev/todo-api/internal/graphqlapi"
+	"github.com/pers0na2dev/todo-api/internal/service"
+	servicemocks "github.com/pers0na2dev/todo-api/internal/service/mocks"
+	"github.com/pers0na2dev/todo-api/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+// undocumentedRoutes маршруты, которые намеренно не описаны в документе OpenAPI, и причины
+var undocumentedRoutes = map[string]string{
+	"/dav/":               "CalDAV использует методы WebDAV PROPFIND и REPORT, которых нет в OpenAPI",
+	"/.well-known/caldav": "перенаправление на /dav/ для любого метода (RFC 6764)",
+}
+
+// TestOpenAPICoversRoutes проверяет, что у каждого маршрута HTTP сервера есть описание в документе OpenAPI
+// Маршруты регистрируют все обработчики, которые приложение монтирует на маршрутизатор, включая GraphQL и CalDAV
+func TestOpenAPICoversRoutes(t *testing.T) {
+	logger := zap.NewNop()
+	cfg := &config.Config{}
+	codecs := codec.NewRegistry()
+	reg := openapi.NewRegistry(http.NewServeMux(), codecs)
+	_, err := NewTaskHandler(new(mocks.TaskService), reg, codecs, cfg)
+	assert.NoError(t, err)
+	_, err = NewCalendarHandler(new(mocks.TaskService), new(mocks.CalendarService), reg, codecs, cfg)
+	assert.NoError(t, err)
+	_, err = NewImportHandler(new(mocks.ImportService), reg, codecs, cfg)
+	assert.NoError(t, err)
+	NewAdminHandler(new(mocks.CacheAdmin), middleware.NewAdminAuth(cfg), reg, codecs)
+	NewHealthHandler(new(mocks.Readiness), reg, codecs)
+	// События рассылаются только внутри процесса: кеш в памяти не поддерживает каналы
+	memoryCache, err := cache.NewMemoryCache(cache.MemoryOptions{})
+	require.NoError(t, err)
+	events, err := service.NewEventBroker(fxtest.NewLifecycle(t), memoryCache, logger)
+	require.NoError(t, err)
+	taskService := service.NewTaskService(new(servicemocks.TaskRepository), events, cfg, logger)
+	_, err = graphqlapi.NewHandler(taskService, reg, codecs, logger)
+	assert.NoError(t, err)
+	_, err = caldav.NewHandler(new(caldavmocks.TaskService), reg, cfg, logger)
+	assert.NoError(t, err)
+	doc := reg.Document()
+
+	undocumented := make(map[string]bool)
+	for _, route := range reg.Routes() {
+		if route.Operation == nil {
+			pattern := strings.TrimSpace(route.Method + " " + route.Path)
+			assert.Contains(t, undocumentedRoutes, pattern, "route %s has no OpenAPI operation", pattern)
+			undocumented[pattern] = true
+			continue
+		}
+
+		operation := doc.Paths[route.Path][strings.ToLower(route.Method)]
+		if !assert.NotNil(t, operation, "route %s %s is missing from the OpenAPI document", route.Method, route.Path) {
+			continue
+		}
+
+		assert.NotEmpty(t, operation.OperationID, "route %s %s has no operationId", route.Method, route.Path)
+		assert.NotEmpty(t, operation.Responses, "route %s %s has no responses", route.Method, route.Path)
+
+		// Каждый параметр пути из шаблона маршрута должен быть описан
+		for _, segment := range strings.Split(route.Path, "/") {
+			if !strings.HasPrefix(segment, "{") {
+				continue
+			}
+			name := strings.Trim(segment, "{}")
+			found := false
+			for _, param := range operation.Parameters {
+				found = found || (param.In == "path" && param.Name == name)
+			}
+			assert.True(t, found, "route %s %s does not describe path parameter %s", route.Method, route.Path, name)
+		}
+	}
+
+	// Исключения для маршрутов, которых больше нет, нужно удалять
+	for pattern := range undocumentedRoutes {
+		assert.True(t, undocumented[pattern], "undocumented route %s is not registered", pattern)
+	}
+}
+
+// TestOpenAPIDocument проверяет, что документ отдается и ссылки на схемы разрешаются
+func TestOpenAPIDocument(t *testing.T) {
+	mux := http.NewServeMux()
+	codecs := codec.NewRegistry()
+	_, err := NewTaskHandler(new(mocks.TaskService), openapi.NewRegistry(mux, codecs), codecs, &config.Config{})
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var doc map[string]any
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, openapi.Version, doc["openapi"])
+
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	for _, ref := range collectRefs(doc) {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		assert.Contains(t, schemas, name, "unresolved reference %s", ref)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "/openapi.json")
+}
+
+// collectRefs собирает все значения $ref в документе
+func collectRefs(node any) []string {
+	var refs []string
+	switch v := node.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if ref, ok := child.(string); ok && key == "$ref" {
+				refs = append(refs, ref)
+				continue
+			}
+			refs = append(refs, collectRefs(child)...)
+		}
+	case []any:
+		for _, child := range v {
+			refs = append(refs, collectRefs(child)...)
+		}
+	}
+	return refs
+}
@@ -2,37 +2,171 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+	"github.com/pers0na2dev/todo-api/internal/config"
 	"github.com/pers0na2dev/todo-api/internal/models"
 )
 
 // TaskService интерфейс, который определяет методы для работы с задачами
 // интерфейс для сервиса задач позволяет использовать разные реализации сервиса задач с одинаковым интерфейсом
 type TaskService interface {
-	CreateTask(ctx context.Context, title string) error
+	CreateTask(ctx context.Context, title string) (*models.Task, error)
 	GetTasks(ctx context.Context) ([]*models.Task, error)
 	GetTaskByID(ctx context.Context, id int) (*models.Task, error)
-	OpenCloseTask(ctx context.Context, id int) error
-	RemoveTask(ctx context.Context, id int) error
+	OpenCloseTask(ctx context.Context, id int, version int) (*models.Task, error)
+	RemoveTask(ctx context.Context, id int, version int) error
+	ApplyBatch(ctx context.Context, ops []models.BatchOperation, mode models.BatchMode) ([]models.BatchResult, error)
+	SearchTasks(ctx context.Context, query string, lang string, limit int) ([]*models.TaskSearchResult, error)
+	SuggestTasks(ctx context.Context, query string, limit int) ([]*models.TaskSuggestion, error)
+	ExportTasks(ctx context.Context, fn func(*models.TaskRecord) error) error
+	ImportTasks(ctx context.Context, records []models.TaskRecord, dryRun bool) ([]models.ImportResult, error)
+	SyncTasks(ctx context.Context, records []models.TaskRecord) ([]models.ImportResult, error)
+}
+
+// createTaskRequest тело запроса POST /v1/tasks
+type createTaskRequest struct {
+	Title string `json:"title" schema:"minLength=1,maxLength=255"`
 }
 
 type TaskHandler struct {
 	taskService TaskService
+	codecs      *codec.Registry
+	// loc - часовой пояс, в котором форматы с датами без времени записывают сроки задач
+	loc *time.Location
 }
 
-func NewTaskHandler(taskService TaskService, mux *http.ServeMux) *TaskHandler {
-	handler := &TaskHandler{taskService: taskService}
+// NewTaskHandler функция, которая создает обработчик задач и регистрирует его маршруты
+// Маршруты регистрируются вместе с описанием, из которого строится документ OpenAPI
+// @param taskService TaskService - сервис задач
+// @param reg *openapi.Registry - реестр маршрутов
+// @param codecs *codec.Registry - форматы тел запросов и ответов
+// @param cfg *config.Config - конфигурация
+// @return *TaskHandler - обработчик задач
+// @return error - ошибка, если часовой пояс календаря неизвестен
+func NewTaskHandler(taskService TaskService, reg *openapi.Registry, codecs *codec.Registry, cfg *config.Config) (*TaskHandler, error) {
+	loc, err := cfg.CalendarLocation()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CALENDAR_TIMEZONE: %w", err)
+	}
+
+	handler := &TaskHandler{taskService: taskService, codecs: codecs, loc: loc}
+
+	// Типы должны быть зарегистрированы до маршрутов, чтобы формат Protobuf попал в документ OpenAPI
+	registerProtoTypes(codecs.Protobuf())
 
-	mux.HandleFunc("GET /v1/tasks", handler.GetTasks)
-	mux.HandleFunc("GET /v1/tasks/{id}", handler.GetTaskByID)
-	mux.HandleFunc("POST /v1/tasks", handler.CreateTask)
-	mux.HandleFunc("PUT /v1/tasks/{id}", handler.ChangeTaskStatus)
-	mux.HandleFunc("DELETE /v1/tasks/{id}", handler.RemoveTask)
+	reg.Handle("GET /v1/tasks", handler.GetTasks, &openapi.Op{
+		ID:      "listTasks",
+		Summary: "List all tasks",
+		Tags:    tasksTag,
+		Responses: map[int]openapi.Resp{
+			http.StatusOK:                  {Description: "All tasks", Body: []*models.Task{}},
+			http.StatusInternalServerError: errorResp("Internal error"),
+		},
+	})
+	reg.Handle("GET /v1/tasks/{id}", handler.GetTaskByID, &openapi.Op{
+		ID:           "getTask",
+		Summary:      "Get a task by id",
+		Description:  "The task version is returned in the ETag header. A matching If-None-Match returns 304.",
+		Tags:         tasksTag,
+		PathParams:   []openapi.Param{taskIDParam},
+		HeaderParams: []openapi.Param{{Name: "If-None-Match", Description: "ETag of the cached copy", Schema: openapi.String()}},
+		Responses: map[int]openapi.Resp{
+			http.StatusOK:                  {Description: "The task", Body: models.Task{}, Headers: etagHeader},
+			http.StatusNotModified:         {Description: "The cached copy is up to date", Headers: etagHeader},
+			http.StatusNotFound:            errorResp("Task not found"),
+			http.StatusInternalServerError: errorResp("Internal error"),
+		},
+	})
+	reg.Handle("GET /v1/tasks/search", handler.SearchTasks, &openapi.Op{
+		ID:          "searchTasks",
+		Summary:     "Full-text search over task titles",
+		Description: "Results are ordered by ts_rank and contain a headline: the HTML-escaped title with matches wrapped in <mark>.",
+		Tags:        tasksTag,
+		QueryParams: []openapi.Param{
+			{Name: "q", Description: "Search query in websearch_to_tsquery syntax", Required: true, Schema: openapi.String().WithMaxLength(models.MaxTitleLength)},
+			{Name: "lang", Description: "Text search configuration", Schema: openapi.String().WithEnum("simple", "english", "russian")},
+			{Name: "limit", Description: "Maximum number of results", Schema: openapi.Integer().WithMinimum(1).WithMaximum(models.MaxSearchLimit)},
+		},
+		Responses: map[int]openapi.Resp{
+			http.StatusOK:                  {Description: "Matching tasks", Body: []*models.TaskSearchResult{}},
+			http.StatusInternalServerError: errorResp("Internal error"),
+		},
+	})
+	reg.Handle("GET /v1/tasks/suggest", handler.SuggestTasks, &openapi.Op{
+		ID:      "suggestTasks",
+		Summary: "Typo-tolerant quick-find by task title",
+		Tags:    tasksTag,
+		QueryParams: []openapi.Param{
+			{Name: "q", Description: "Beginning or part of a task title", Required: true, Schema: openapi.String().WithMaxLength(models.MaxTitleLength)},
+			{Name: "limit", Description: "Maximum number of suggestions", Schema: openapi.Integer().WithMinimum(1).WithMaximum(models.MaxSuggestLimit)},
+		},
+		Responses: map[int]openapi.Resp{
+			http.StatusOK:                  {Description: "Most similar tasks", Body: []*models.TaskSuggestion{}},
+			http.StatusInternalServerError: errorResp("Internal error"),
+		},
+	})
+	reg.Handle("POST /v1/tasks", handler.CreateTask, &openapi.Op{
+		ID:           "createTask",
+		Summary:      "Create a task",
+		Description:  "Reusing an Idempotency-Key with a different body returns 422.",
+		Tags:         tasksTag,
+		HeaderParams: []openapi.Param{idempotencyKeyParam},
+		Request:      createTaskRequest{},
+		Responses: map[int]openapi.Resp{
+			http.StatusCreated:             {Description: "The created task", Body: models.Task{}, Headers: map[string]string{"ETag": etagHeader["ETag"], "Location": "URL of the created task"}},
+			http.StatusInternalServerError: errorResp("Internal error"),
+		},
+	})
+	reg.Handle("PUT /v1/tasks/{id}", handler.ChangeTaskStatus, &openapi.Op{
+		ID:           "toggleTask",
+		Summary:      "Toggle the completion status of a task",
+		Tags:         tasksTag,
+		PathParams:   []openapi.Param{taskIDParam},
+		HeaderParams: []openapi.Param{ifMatchParam},
+		Responses: map[int]openapi.Resp{
+			http.StatusNoContent:            {Description: "The task was updated", Headers: etagHeader},
+			http.StatusNotFound:             errorResp("Task not found"),
+			http.StatusPreconditionFailed:   errorResp("The task version does not match If-Match"),
+			http.StatusPreconditionRequired: errorResp("If-Match header is missing"),
+			http.StatusInternalServerError:  errorResp("Internal error"),
+		},
+	})
+	reg.Handle("DELETE /v1/tasks/{id}", handler.RemoveTask, &openapi.Op{
+		ID:           "deleteTask",
+		Summary:      "Delete a task",
+		Tags:         tasksTag,
+		PathParams:   []openapi.Param{taskIDParam},
+		HeaderParams: []openapi.Param{ifMatchParam},
+		Responses: map[int]openapi.Resp{
+			http.StatusNoContent:            {Description: "The task was deleted"},
+			http.StatusNotFound:             errorResp("Task not found"),
+			http.StatusPreconditionFailed:   errorResp("The task version does not match If-Match"),
+			http.StatusPreconditionRequired: errorResp("If-Match header is missing"),
+			http.StatusInternalServerError:  errorResp("Internal error"),
+		},
+	})
+	reg.Handle("POST /v1/tasks:batch", handler.BatchTasks, &openapi.Op{
+		ID:           "batchTasks",
+		Summary:      "Apply create, update, complete and delete operations in one transaction",
+		Tags:         tasksTag,
+		HeaderParams: []openapi.Param{idempotencyKeyParam},
+		Request:      batchRequest{},
+		Responses: map[int]openapi.Resp{
+			http.StatusOK:                  {Description: "Per-operation results", Body: batchResponse{}},
+			http.StatusInternalServerError: errorResp("Internal error"),
+		},
+	})
+	handler.registerTransferRoutes(reg)
+	handler.registerTodoTxtRoutes(reg)
 
-	return &TaskHandler{taskService: taskService}
+	return handler, nil
 }
 
 // GetTasks функция, которая возвращает все задачи
@@ -43,10 +177,8 @@ func (h *TaskHandler) GetTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Установка заголовка Content-Type для ответа
-	w.Header().Set("Content-Type", "application/json")
-	// Кодирование задач в JSON и отправка ответа
-	json.NewEncoder(w).Encode(tasks)
+	// Кодирование задач в формате из заголовка Accept и отправка ответа
+	h.codecs.Write(w, r, http.StatusOK, tasks)
 }
 
 // GetTaskByID функция, которая возвращает задачу по id
@@ -61,35 +193,60 @@ func (h *TaskHandler) GetTaskByID(w http.ResponseWriter, r *http.Request) {
 	// Получение задачи по id
 	task, err := h.taskService.GetTaskByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, err)
+		return
+	}
+
+	// Версия задачи передается клиенту в виде ETag
+	etag := taskETag(task)
+	w.Header().Set("ETag", etag)
+
+	// Если у клиента уже есть актуальная версия, тело ответа не отправляем
+	if matchesIfNoneMatch(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
-	// Установка заголовка Content-Type для ответа
-	w.Header().Set("Content-Type", "application/json")
-	// Кодирование задачи в JSON и отправка ответа
-	json.NewEncoder(w).Encode(task)
+	// Кодирование задачи в формате из заголовка Accept и отправка ответа
+	h.codecs.Write(w, r, http.StatusOK, task)
 }
 
 // CreateTask функция, которая создает новую задачу
 func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
-	var task models.Task
-	// Декодирование тела запроса в структуру task
-	err := json.NewDecoder(r.Body).Decode(&task)
+	// Формат ответа выбирается до создания задачи, чтобы не создавать задачу, которую нельзя вернуть клиенту
+	responseCodec, err := h.codecs.Negotiate(r.Header.Get("Accept"), &models.Task{})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		h.codecs.WriteNotAcceptable(w, &models.Task{})
+		return
+	}
+
+	var req createTaskRequest
+	// Декодирование тела запроса в формате из заголовка Content-Type в структуру req
+	if err := h.codecs.Read(r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Проверка задачи на валидность
+	task := models.Task{Title: req.Title}
+	if err := task.Validate(); err != nil {
+		openapi.WriteProblem(w, http.StatusUnprocessableEntity, "invalid task", []openapi.Violation{
+			{Location: "body", Field: "/title", Message: err.Error()},
+		})
 		return
 	}
 
 	// Создание задачи в базе данных
-	err = h.taskService.CreateTask(r.Context(), task.Title)
+	created, err := h.taskService.CreateTask(r.Context(), task.Title)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Отправка ответа с кодом 201 Created
-	w.WriteHeader(http.StatusCreated)
+	// Отправка созданной задачи с кодом 201 Created
+	w.Header().Set("Location", "/v1/tasks/"+strconv.Itoa(created.ID))
+	w.Header().Set("ETag", taskETag(created))
+	codec.Encode(w, responseCodec, http.StatusCreated, created)
 }
 
 // ChangeTaskStatus функция, которая изменяет статус задачи
@@ -101,14 +258,21 @@ func (h *TaskHandler) ChangeTaskStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Получение ожидаемой версии задачи из заголовка If-Match
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
 	// Изменение статуса задачи
-	err = h.taskService.OpenCloseTask(r.Context(), id)
+	task, err := h.taskService.OpenCloseTask(r.Context(), id, version)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
-	// Отправка ответа с кодом 204 No Content
+	// Отправка ответа с новой версией задачи и кодом 204 No Content
+	w.Header().Set("ETag", taskETag(task))
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -121,13 +285,75 @@ func (h *TaskHandler) RemoveTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Получение ожидаемой версии задачи из заголовка If-Match
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
 	// Удаление задачи из базы данных
-	err = h.taskService.RemoveTask(r.Context(), id)
+	err = h.taskService.RemoveTask(r.Context(), id, version)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
 	// Отправка ответа с кодом 204 No Content
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// requireIfMatch функция, которая извлекает версию задачи из If-Match и отвечает ошибкой если это невозможно
+// @param w http.ResponseWriter - ответ
+// @param r *http.Request - запрос
+// @return int - ожидаемая версия задачи
+// @return bool - false, если ответ с ошибкой уже отправлен
+func requireIfMatch(w http.ResponseWriter, r *http.Request) (int, bool) {
+	version, present, valid := parseIfMatch(r)
+	if !present {
+		http.Error(w, "If-Match header is required", http.StatusPreconditionRequired)
+		return 0, false
+	}
+	if !valid {
+		// Некорректный ETag не может совпасть ни с одной версией задачи
+		http.Error(w, models.ErrVersionConflict.Error(), http.StatusPreconditionFailed)
+		return 0, false
+	}
+
+	return version, true
+}
+
+// writeServiceError функция, которая преобразует ошибку сервиса в HTTP ответ
+// @param w http.ResponseWriter - ответ
+// @param err error - ошибка сервиса
+func writeServiceError(w http.ResponseWriter, err error) {
+	http.Error(w, err.Error(), errorStatus(err))
+}
+
+// errorStatus функция, которая возвращает HTTP код для ошибки сервиса
+// @param err error - ошибка сервиса
+// @return int - HTTP код
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrTaskNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, models.ErrInvalidBatch), errors.Is(err, models.ErrInvalidSearch), errors.Is(err, models.ErrInvalidImport):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrBatchAborted):
+		return http.StatusFailedDependency
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeDecodeError функция, которая преобразует ошибку декодирования тела запроса в HTTP ответ
+// @param w http.ResponseWriter - ответ
+// @param err error - ошибка декодирования
+func writeDecodeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, codec.ErrUnsupportedMediaType) {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
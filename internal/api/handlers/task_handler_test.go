@@ -0,0 +1,586 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+	"github.com/pers0na2dev/todo-api/internal/api/handlers/mocks"
+	"github.com/pers0na2dev/todo-api/internal/api/middleware"
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+	"github.com/pers0na2dev/todo-api/internal/config"
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/pers0na2dev/todo-api/pkg/cache"
+	todov1 "github.com/pers0na2dev/todo-api/pkg/pb/todo/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// setupHandlerTest подготавливает маршрутизатор с обработчиком задач и мок сервиса
+func setupHandlerTest(t *testing.T) (*http.ServeMux, *mocks.TaskService) {
+	t.Helper()
+
+	mockService := new(mocks.TaskService)
+	mux := http.NewServeMux()
+	codecs := codec.NewRegistry()
+	_, err := NewTaskHandler(mockService, openapi.NewRegistry(mux, codecs), codecs, &config.Config{})
+	assert.NoError(t, err)
+
+	return mux, mockService
+}
+
+// withIdempotency оборачивает маршрутизатор в middleware идемпотентности так же, как HTTP сервер
+func withIdempotency(t *testing.T, mux *http.ServeMux, reg *openapi.Registry) http.Handler {
+	t.Helper()
+
+	c, err := cache.NewMemoryCache(cache.MemoryOptions{})
+	require.NoError(t, err)
+
+	return middleware.NewIdempotency(c, reg, zap.NewNop()).Wrap(mux)
+}
+
+// TestGetTaskByIDConditional тестирует ETag и условные GET запросы
+func TestGetTaskByIDConditional(t *testing.T) {
+	mux, mockService := setupHandlerTest(t)
+	task := &models.Task{ID: 1, Title: "Тестовая задача", Version: 5}
+
+	t.Run("Ответ содержит ETag с версией задачи", func(t *testing.T) {
+		mockService.On("GetTaskByID", mock.Anything, 1).Return(task, nil).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/tasks/1", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `"5"`, rec.Header().Get("ETag"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Совпадающий If-None-Match возвращает 304", func(t *testing.T) {
+		mockService.On("GetTaskByID", mock.Anything, 1).Return(task, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/tasks/1", nil)
+		req.Header.Set("If-None-Match", `W/"5"`)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Empty(t, rec.Body.String())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Несуществующая задача возвращает 404", func(t *testing.T) {
+		mockService.On("GetTaskByID", mock.Anything, 2).Return(nil, models.ErrTaskNotFound).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/tasks/2", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestChangeTaskStatusIfMatch тестирует обязательный заголовок If-Match
+func TestChangeTaskStatusIfMatch(t *testing.T) {
+	mux, mockService := setupHandlerTest(t)
+
+	t.Run("Без If-Match возвращается 428", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/v1/tasks/1", nil))
+
+		assert.Equal(t, http.StatusPreconditionRequired, rec.Code)
+		mockService.AssertNotCalled(t, "OpenCloseTask")
+	})
+
+	t.Run("Успешное изменение возвращает новый ETag", func(t *testing.T) {
+		updated := &models.Task{ID: 1, Title: "Тестовая задача", Completed: true, Version: 4}
+		mockService.On("OpenCloseTask", mock.Anything, 1, 3).Return(updated, nil).Once()
+
+		req := httptest.NewRequest(http.MethodPut, "/v1/tasks/1", nil)
+		req.Header.Set("If-Match", `"3"`)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, `"4"`, rec.Header().Get("ETag"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Несовпадающая версия возвращает 412", func(t *testing.T) {
+		mockService.On("OpenCloseTask", mock.Anything, 1, 2).Return(nil, models.ErrVersionConflict).Once()
+
+		req := httptest.NewRequest(http.MethodPut, "/v1/tasks/1", nil)
+		req.Header.Set("If-Match", `"2"`)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Слабый ETag в If-Match возвращает 412", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/v1/tasks/1", nil)
+		req.Header.Set("If-Match", `W/"3"`)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		mockService.AssertNotCalled(t, "RemoveTask")
+	})
+}
+
+// TestBatchTasks тестирует формирование ответа на пакетный запрос
+func TestBatchTasks(t *testing.T) {
+	mux, mockService := setupHandlerTest(t)
+
+	t.Run("Ошибка в режиме all_or_nothing откатывает пакет", func(t *testing.T) {
+		results := []models.BatchResult{
+			{Err: models.ErrBatchAborted},
+			{Err: models.ErrVersionConflict},
+		}
+		mockService.On("ApplyBatch", mock.Anything, mock.Anything, models.BatchAllOrNothing).Return(results, nil).Once()
+
+		body := `{"operations":[{"op":"complete","id":1,"version":1},{"op":"delete","id":2,"version":1}]}`
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/tasks:batch", strings.NewReader(body)))
+
+		var resp batchResponse
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.False(t, resp.Committed)
+		assert.Equal(t, http.StatusFailedDependency, resp.Results[0].Status)
+		assert.Equal(t, http.StatusPreconditionFailed, resp.Results[1].Status)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Некорректный пакет возвращает 400", func(t *testing.T) {
+		mockService.On("ApplyBatch", mock.Anything, mock.Anything, models.BatchBestEffort).Return(nil, models.ErrInvalidBatch).Once()
+
+		body := `{"mode":"best_effort","operations":[{"op":"delete","id":1}]}`
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/tasks:batch", strings.NewReader(body)))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestSearchTasks тестирует маршрут полнотекстового поиска
+func TestSearchTasks(t *testing.T) {
+	mux, mockService := setupHandlerTest(t)
+
+	t.Run("Параметры запроса передаются в сервис", func(t *testing.T) {
+		results := []*models.TaskSearchResult{{Task: models.Task{ID: 1, Title: "Купить молоко"}, Headline: "Купить <mark>молоко</mark>"}}
+		mockService.On("SearchTasks", mock.Anything, "молоко", "russian", 5).Return(results, nil).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/tasks/search?q=%D0%BC%D0%BE%D0%BB%D0%BE%D0%BA%D0%BE&lang=russian&limit=5", nil))
+
+		var got []*models.TaskSearchResult
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		assert.Equal(t, results, got)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Запрос без обязательного параметра отклоняется до вызова сервиса", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/tasks/search?limit=1000", nil))
+
+		var problem openapi.ValidationProblem
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+		assert.Len(t, problem.Violations, 2)
+		mockService.AssertNotCalled(t, "SearchTasks")
+	})
+
+	t.Run("Запрос длиннее названия задачи отклоняется до вызова сервиса", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/tasks/search?q="+strings.Repeat("я", models.MaxTitleLength+1), nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockService.AssertNotCalled(t, "SearchTasks")
+	})
+
+	t.Run("Ошибка сервиса возвращает 400", func(t *testing.T) {
+		mockService.On("SearchTasks", mock.Anything, " ", "", 0).Return(nil, models.ErrInvalidSearch).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/tasks/search?q=+", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestSuggestTasks тестирует маршрут поиска задач с опечатками
+func TestSuggestTasks(t *testing.T) {
+	mux, mockService := setupHandlerTest(t)
+
+	t.Run("Параметры запроса передаются в сервис", func(t *testing.T) {
+		suggestions := []*models.TaskSuggestion{{Task: models.Task{ID: 1, Title: "Купить молоко"}}}
+		mockService.On("SuggestTasks", mock.Anything, "молок", 3).Return(suggestions, nil).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/tasks/suggest?q=%D0%BC%D0%BE%D0%BB%D0%BE%D0%BA&limit=3", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Запрос длиннее названия задачи отклоняется до вызова сервиса", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/tasks/suggest?q="+strings.Repeat("я", models.MaxTitleLength+1), nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockService.AssertNotCalled(t, "SuggestTasks")
+	})
+}
+
+// TestCreateTaskValidation тестирует проверку тела запроса на создание задачи
+func TestCreateTaskValidation(t *testing.T) {
+	mux, mockService := setupHandlerTest(t)
+
+	t.Run("Неизвестные поля и неверные типы отклоняются с кодом 422", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/tasks", strings.NewReader(`{"title":1,"done":true}`)))
+
+		var problem openapi.ValidationProblem
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+		assert.Equal(t, []openapi.Violation{
+			{Location: "body", Field: "/done", Message: "unknown field"},
+			{Location: "body", Field: "/title", Message: "must be a string"},
+		}, problem.Violations)
+		mockService.AssertNotCalled(t, "CreateTask")
+	})
+
+	t.Run("Название из пробелов не проходит Task.Validate", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/tasks", strings.NewReader(`{"title":"   "}`)))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		mockService.AssertNotCalled(t, "CreateTask")
+	})
+
+	t.Run("Корректная задача создается", func(t *testing.T) {
+		created := &models.Task{ID: 7, Title: "Купить молоко", Version: 1}
+		mockService.On("CreateTask", mock.Anything, "Купить молоко").Return(created, nil).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/tasks", strings.NewReader(`{"title":"Купить молоко"}`)))
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "/v1/tasks/7", rec.Header().Get("Location"))
+		mockService.AssertExpectations(t)
+	})
+}
+
+// TestContentNegotiation тестирует выбор формата ответа по Accept и декодирование тела по Content-Type
+func TestContentNegotiation(t *testing.T) {
+	mux, mockService := setupHandlerTest(t)
+	tasks := []*models.Task{{ID: 1, Title: "Первая", Version: 1}, {ID: 2, Title: "Вторая", Completed: true, Version: 4}}
+
+	t.Run("Список задач в CSV", func(t *testing.T) {
+		mockService.On("GetTasks", mock.Anything).Return(tasks, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/tasks", nil)
+		req.Header.Set("Accept", "text/csv")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "Accept", rec.Header().Get("Vary"))
+		assert.Equal(t, "id,title,completed,version,due_at\n1,Первая,false,1,\n2,Вторая,true,4,\n", rec.Body.String())
+	})
+
+	t.Run("Список задач в Protobuf", func(t *testing.T) {
+		mockService.On("GetTasks", mock.Anything).Return(tasks, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/tasks", nil)
+		req.Header.Set("Accept", "application/x-protobuf")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		var resp todov1.ListTasksResponse
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, proto.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Len(t, resp.GetTasks(), 2)
+		assert.Equal(t, int64(4), resp.GetTasks()[1].GetVersion())
+	})
+
+	t.Run("Неподдерживаемый формат возвращает 406", func(t *testing.T) {
+		mockService.On("SearchTasks", mock.Anything, "молоко", "", 0).Return([]*models.TaskSearchResult{}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/tasks/search?q=молоко", nil)
+		req.Header.Set("Accept", "application/x-protobuf")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+		assert.Contains(t, rec.Body.String(), "text/csv")
+	})
+
+	t.Run("Задача создается из MessagePack и возвращается в MessagePack", func(t *testing.T) {
+		created := &models.Task{ID: 8, Title: "Из msgpack", Version: 1}
+		mockService.On("CreateTask", mock.Anything, "Из msgpack").Return(created, nil).Once()
+
+		body, err := msgpack.Marshal(map[string]any{"title": "Из msgpack"})
+		assert.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/v1/tasks", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/msgpack")
+		req.Header.Set("Accept", "application/msgpack")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		var task map[string]any
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "application/msgpack", rec.Header().Get("Content-Type"))
+		assert.NoError(t, msgpack.Unmarshal(rec.Body.Bytes(), &task))
+		assert.Equal(t, "Из msgpack", task["title"])
+	})
+
+	t.Run("Задача не создается, если ответ нельзя отдать в запрошенном формате", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/tasks", strings.NewReader(`{"title":"Задача"}`))
+		req.Header.Set("Accept", "text/csv")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+		mockService.AssertNotCalled(t, "CreateTask", mock.Anything, "Задача")
+	})
+
+	t.Run("Тело в Protobuf проверяется по схеме", func(t *testing.T) {
+		body, err := proto.Marshal(&todov1.CreateTaskRequest{Title: strings.Repeat("я", models.MaxTitleLength+1)})
+		assert.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/v1/tasks", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("Тело в CSV для создания задачи не поддерживается", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/tasks", strings.NewReader("title\nЗадача\n"))
+		req.Header.Set("Content-Type", "text/csv")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	})
+}
+
+// TestExportTasks тестирует потоковую выгрузку задач
+func TestExportTasks(t *testing.T) {
+	mux, mockService := setupHandlerTest(t)
+	dueAt := time.Date(2024, 12, 20, 18, 0, 0, 0, time.UTC)
+	records := []*models.TaskRecord{
+		{ID: 1, ExternalID: "trello-1", Title: "Купить молоко, хлеб", Version: 2, DueAt: &dueAt},
+		{ID: 2, Title: "Позвонить", Completed: true, Version: 1},
+	}
+	export := func(args mock.Arguments) {
+		fn := args.Get(1).(func(*models.TaskRecord) error)
+		for _, record := range records {
+			fn(record)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		format      string
+		contentType string
+		expected    string
+	}{
+		{
+			name:        "CSV с заголовком",
+			format:      "csv",
+			contentType: "text/csv",
+			expected:    "id,external_id,title,completed,version,due_at\n1,trello-1,\"Купить молоко, хлеб\",false,2,2024-12-20T18:00:00Z\n2,,Позвонить,true,1,\n",
+		},
+		{
+			name:        "JSON массив по умолчанию",
+			contentType: "application/json",
+			expected:    "[\n" + `{"id":1,"external_id":"trello-1","title":"Купить молоко, хлеб","completed":false,"version":2,"due_at":"2024-12-20T18:00:00Z"},` + "\n" + `{"id":2,"title":"Позвонить","completed":true,"version":1}` + "\n]\n",
+		},
+		{
+			name:        "NDJSON по задаче на строку",
+			format:      "ndjson",
+			contentType: "application/x-ndjson",
+			expected:    `{"id":1,"external_id":"trello-1","title":"Купить молоко, хлеб","completed":false,"version":2,"due_at":"2024-12-20T18:00:00Z"}` + "\n" + `{"id":2,"title":"Позвонить","completed":true,"version":1}` + "\n",
+		},
+		{
+			name:        "todo.txt с тегами due, id и rev",
+			format:      "todotxt",
+			contentType: "text/plain; charset=utf-8",
+			expected:    "Купить молоко, хлеб due:2024-12-20 id:1 rev:2\nx Позвонить id:2 rev:1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService.On("ExportTasks", mock.Anything, mock.Anything).Run(export).Return(nil).Once()
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/tasks/export?format="+tt.format, nil))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.contentType, rec.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expected, rec.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Пустой список экспортируется как пустой массив", func(t *testing.T) {
+		mockService.On("ExportTasks", mock.Anything, mock.Anything).Return(nil).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/tasks/export", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "[]\n", rec.Body.String())
+	})
+
+	t.Run("Ошибка до начала выгрузки возвращает 500", func(t *testing.T) {
+		mockService.On("ExportTasks", mock.Anything, mock.Anything).Return(assert.AnError).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/tasks/export?format=csv", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+
+	t.Run("Ошибка во время выгрузки обрывает ответ", func(t *testing.T) {
+		mockService.On("ExportTasks", mock.Anything, mock.Anything).Run(export).Return(assert.AnError).Once()
+
+		rec := httptest.NewRecorder()
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/tasks/export?format=ndjson", nil))
+		})
+	})
+
+	t.Run("Неизвестный формат отклоняется", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/tasks/export?format=xml", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+// TestImportTasks тестирует импорт задач
+func TestImportTasks(t *testing.T) {
+	mux, mockService := setupHandlerTest(t)
+
+	t.Run("CSV импортируется с созданием и обновлением задач", func(t *testing.T) {
+		records := []models.TaskRecord{{ExternalID: "a", Title: "Первая"}, {Title: "Вторая", Completed: true}}
+		mockService.On("ImportTasks", mock.Anything, records, false).Return([]models.ImportResult{
+			{Task: &models.Task{ID: 7, Title: "Первая", Version: 3}},
+			{Task: &models.Task{ID: 8, Title: "Вторая", Completed: true, Version: 1}, Created: true},
+		}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/tasks/import", strings.NewReader("external_id,title,completed\na,Первая,false\n,Вторая,true\n"))
+		req.Header.Set("Content-Type", "text/csv")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var resp importResponse
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, 1, resp.Created)
+		assert.Equal(t, 1, resp.Updated)
+		assert.Equal(t, importResultOutput{Row: 1, ExternalID: "a", Status: "updated", Task: &models.Task{ID: 7, Title: "Первая", Version: 3}}, resp.Results[0])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("NDJSON в режиме dry_run", func(t *testing.T) {
+		records := []models.TaskRecord{{Title: "Задача"}}
+		mockService.On("ImportTasks", mock.Anything, records, true).Return([]models.ImportResult{
+			{Task: &models.Task{ID: 9, Title: "Задача", Version: 1}, Created: true},
+		}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/tasks/import?dry_run=true", strings.NewReader(`{"title":"Задача"}`+"\n"))
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"dry_run":true`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Некорректные строки возвращаются с номерами", func(t *testing.T) {
+		records := []models.TaskRecord{{Title: "Задача"}, {Title: " "}}
+		mockService.On("ImportTasks", mock.Anything, records, false).Return([]models.ImportResult{
+			{Err: models.ErrImportAborted},
+			{Err: errors.New("title is required")},
+		}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/tasks/import", strings.NewReader(`[{"title":"Задача"},{"title":" "}]`))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		var problem openapi.ValidationProblem
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+		assert.Equal(t, []openapi.Violation{{Location: "body", Field: "/1", Message: "row 2: title is required"}}, problem.Violations)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Импорт больше 1 МиБ с Idempotency-Key выполняется один раз", func(t *testing.T) {
+		mux := http.NewServeMux()
+		codecs := codec.NewRegistry()
+		reg := openapi.NewRegistry(mux, codecs)
+		_, err := NewTaskHandler(mockService, reg, codecs, &config.Config{})
+		require.NoError(t, err)
+		handler := withIdempotency(t, mux, reg)
+
+		records := make([]models.TaskRecord, 5000)
+		results := make([]models.ImportResult, len(records))
+		for i := range records {
+			records[i] = models.TaskRecord{Title: fmt.Sprintf("%s %05d", strings.Repeat("Задача", 40), i)}
+			results[i] = models.ImportResult{Task: &models.Task{ID: i + 1, Title: records[i].Title, Version: 1}, Created: true}
+		}
+		body, err := json.Marshal(records)
+		require.NoError(t, err)
+		require.Greater(t, len(body), openapi.DefaultMaxBodyBytes)
+		mockService.On("ImportTasks", mock.Anything, mock.MatchedBy(func(got []models.TaskRecord) bool {
+			return len(got) == len(records) && got[len(got)-1] == records[len(records)-1]
+		}), false).Return(results, nil).Once()
+
+		send := func() *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/v1/tasks/import", bytes.NewReader(body))
+			req.Header.Set(middleware.IdempotencyKeyHeader, "import-1")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec
+		}
+		first := send()
+		second := send()
+
+		assert.Equal(t, http.StatusOK, first.Code)
+		assert.Equal(t, http.StatusOK, second.Code)
+		assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Пустой импорт возвращает 400", func(t *testing.T) {
+		mockService.On("ImportTasks", mock.Anything, []models.TaskRecord{}, false).Return(nil, models.ErrInvalidImport).Once()
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/tasks/import", strings.NewReader(`[]`)))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		mockService.AssertExpectations(t)
+	})
+}
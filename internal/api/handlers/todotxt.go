@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/pers0na2dev/todo-api/internal/todotxt"
+)
+
+// todoTxtSyncResponse тело ответа PUT /v1/tasks/todotxt
+type todoTxtSyncResponse struct {
+	Created   int                 `json:"created"`
+	Updated   int                 `json:"updated"`
+	Completed int                 `json:"completed"`
+	Unchanged int                 `json:"unchanged"`
+	Results   []todoTxtLineOutput `json:"results"`
+}
+
+// todoTxtLineOutput результат одной строки файла в ответе
+type todoTxtLineOutput struct {
+	// Line - номер строки в файле, начиная с 1
+	Line   int          `json:"line"`
+	Status string       `json:"status" schema:"enum=created|updated|completed|unchanged"`
+	Task   *models.Task `json:"task"`
+}
+
+// registerTodoTxtRoutes функция, которая регистрирует маршрут синхронизации задач с файлом todo.txt
+// Выгрузка файла выполняется через GET /v1/tasks/export?format=todotxt
+// @param reg *openapi.Registry - реестр маршрутов
+func (h *TaskHandler) registerTodoTxtRoutes(reg *openapi.Registry) {
+	reg.Handle("PUT /v1/tasks/todotxt", h.SyncTodoTxt, &openapi.Op{
+		ID:      "syncTodoTxt",
+		Summary: "Apply an edited todo.txt file",
+		Description: "The body is a todo.txt file sent as text/plain or " + todotxt.MediaType + ". Lines are matched with tasks " +
+			"by the id:N tag written by the todotxt export; lines without it create tasks. Changed titles and due dates " +
+			"update tasks and the x marker completes them. Tasks missing from the file are left as is. Priority is kept " +
+			"in the title as a pri:X tag. Invalid lines are listed with 422 and nothing is changed. The export also writes " +
+			"the task version as a rev:N tag; if a changed line refers to a task that was changed after the file was " +
+			"exported, 412 is returned and nothing is changed. Lines without rev:N overwrite the current version.",
+		Tags: tasksTag,
+		Responses: map[int]openapi.Resp{
+			http.StatusOK:                    {Description: "Per-line results", Body: todoTxtSyncResponse{}},
+			http.StatusBadRequest:            errorResp("The file can not be read"),
+			http.StatusPreconditionFailed:    errorResp("A task was changed after the file was exported"),
+			http.StatusRequestEntityTooLarge: errorResp("The file is too large"),
+			http.StatusUnsupportedMediaType:  errorResp("The body is not text/plain"),
+			http.StatusUnprocessableEntity:   {Description: "Invalid lines, nothing was changed", Body: openapi.ValidationProblem{}, ContentType: "application/json"},
+			http.StatusInternalServerError:   errorResp("Internal error"),
+		},
+	})
+}
+
+// SyncTodoTxt функция, которая сравнивает загруженный файл todo.txt с задачами и применяет изменения
+func (h *TaskHandler) SyncTodoTxt(w http.ResponseWriter, r *http.Request) {
+	// Формат ответа выбирается до изменения задач, чтобы не изменять задачи, если результат нельзя вернуть клиенту
+	responseCodec, err := h.codecs.Negotiate(r.Header.Get("Accept"), todoTxtSyncResponse{})
+	if err != nil {
+		h.codecs.WriteNotAcceptable(w, todoTxtSyncResponse{})
+		return
+	}
+
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != "text/plain" && mediaType != todotxt.MediaType) {
+			http.Error(w, "request body must be text/plain or "+todotxt.MediaType, http.StatusUnsupportedMediaType)
+			return
+		}
+	}
+
+	tasks, err := todotxt.Read(http.MaxBytesReader(w, r.Body, maxImportBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("request body must not exceed %d bytes", maxImportBodyBytes), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Текущие задачи, с которыми сравниваются строки файла
+	current := make(map[int]*models.TaskRecord)
+	err = h.taskService.ExportTasks(r.Context(), func(record *models.TaskRecord) error {
+		current[record.ID] = record
+		return nil
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	changes := todotxt.Diff(current, tasks, h.loc)
+
+	var violations []openapi.Violation
+	for _, change := range changes {
+		if change.Err != nil {
+			violations = append(violations, lineViolation(change.Line, change.Err))
+		}
+	}
+	if len(violations) > 0 {
+		openapi.WriteProblem(w, http.StatusUnprocessableEntity, "invalid lines, nothing was changed", violations)
+		return
+	}
+
+	// changed - индексы изменений, которые нужно сохранить, в порядке записей для сервиса
+	var records []models.TaskRecord
+	var changed []int
+	for i, change := range changes {
+		if change.Status != todotxt.Unchanged {
+			records = append(records, change.Record)
+			changed = append(changed, i)
+		}
+	}
+
+	results, err := h.taskService.SyncTasks(r.Context(), records)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	// Ошибки проверки отдельных задач, например пустое название, возвращаются с номерами строк
+	for i, result := range results {
+		if result.Err != nil && !errors.Is(result.Err, models.ErrImportAborted) {
+			violations = append(violations, lineViolation(changes[changed[i]].Line, result.Err))
+		}
+	}
+	if len(violations) > 0 {
+		openapi.WriteProblem(w, http.StatusUnprocessableEntity, "invalid lines, nothing was changed", violations)
+		return
+	}
+
+	resp := todoTxtSyncResponse{Results: make([]todoTxtLineOutput, len(changes))}
+	for i, change := range changes {
+		task := change.Record.Task()
+		resp.Results[i] = todoTxtLineOutput{Line: change.Line, Status: string(change.Status), Task: &task}
+	}
+	for i, result := range results {
+		resp.Results[changed[i]].Task = result.Task
+	}
+	for _, change := range changes {
+		switch change.Status {
+		case todotxt.Created:
+			resp.Created++
+		case todotxt.Updated:
+			resp.Updated++
+		case todotxt.Completed:
+			resp.Completed++
+		default:
+			resp.Unchanged++
+		}
+	}
+
+	// Кодирование результатов в выбранном формате и отправка ответа
+	codec.Encode(w, responseCodec, http.StatusOK, resp)
+}
+
+// lineViolation функция, которая описывает ошибку в строке файла todo.txt
+// @param line int - номер строки
+// @param err error - ошибка
+// @return openapi.Violation - нарушение для ответа 422
+func lineViolation(line int, err error) openapi.Violation {
+	return openapi.Violation{Location: "body", Message: fmt.Sprintf("line %d: %v", line, err)}
+}
+
+// todoTxtRecordWriter запись задач строками todo.txt
+type todoTxtRecordWriter struct {
+	writer *todotxt.Writer
+	loc    *time.Location
+}
+
+// newTodoTxtRecordWriter функция, которая создает запись задач строками todo.txt
+// @param w io.Writer - тело ответа
+// @param loc *time.Location - часовой пояс, в котором записываются даты сроков
+// @return recordWriter - запись задач
+func newTodoTxtRecordWriter(w io.Writer, loc *time.Location) recordWriter {
+	return &todoTxtRecordWriter{writer: todotxt.NewWriter(w), loc: loc}
+}
+
+// Write функция, которая записывает задачу строкой с тегом id
+func (t *todoTxtRecordWriter) Write(record *models.TaskRecord) error {
+	return t.writer.Write(todotxt.FromRecord(record, t.loc))
+}
+
+// Close функция, которая дописывает буферизованные строки
+func (t *todoTxtRecordWriter) Close() error {
+	return t.writer.Flush()
+}
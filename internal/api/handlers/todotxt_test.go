@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/pers0na2dev/todo-api/internal/todotxt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestSyncTodoTxt тестирует загрузку измененного файла todo.txt
+func TestSyncTodoTxt(t *testing.T) {
+	mux, mockService := setupHandlerTest(t)
+	dueAt := time.Date(2024, 12, 20, 18, 0, 0, 0, time.UTC)
+	records := []*models.TaskRecord{
+		{ID: 1, Title: "Купить молоко", Version: 2, DueAt: &dueAt},
+		{ID: 2, Title: "Позвонить", Version: 1},
+		{ID: 3, Title: "Сдать отчет", Version: 4},
+	}
+	export := func(args mock.Arguments) {
+		fn := args.Get(1).(func(*models.TaskRecord) error)
+		for _, record := range records {
+			fn(record)
+		}
+	}
+	sync := func(file, contentType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/v1/tasks/todotxt", strings.NewReader(file))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Изменения файла применяются одним вызовом сервиса", func(t *testing.T) {
+		changed := []models.TaskRecord{
+			{ID: 1, Title: "Купить молоко", Completed: true, Version: 2, DueAt: &dueAt},
+			{ID: 2, Title: "Позвонить маме +семья pri:A", Version: 1},
+			{Title: "Новая задача @дом"},
+		}
+		mockService.On("ExportTasks", mock.Anything, mock.Anything).Run(export).Return(nil).Once()
+		mockService.On("SyncTasks", mock.Anything, changed).Return([]models.ImportResult{
+			{Task: &models.Task{ID: 1, Title: "Купить молоко", Completed: true, Version: 3, DueAt: &dueAt}},
+			{Task: &models.Task{ID: 2, Title: "Позвонить маме +семья pri:A", Version: 2}},
+			{Task: &models.Task{ID: 4, Title: "Новая задача @дом", Version: 1}, Created: true},
+		}, nil).Once()
+
+		file := "x 2024-12-19 Купить молоко due:2024-12-20 id:1\n(A) Позвонить маме +семья id:2\nСдать отчет id:3\nНовая задача @дом\n"
+		rec := sync(file, "text/plain; charset=utf-8")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var resp todoTxtSyncResponse
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, 1, resp.Created)
+		assert.Equal(t, 1, resp.Updated)
+		assert.Equal(t, 1, resp.Completed)
+		assert.Equal(t, 1, resp.Unchanged)
+		assert.Equal(t, todoTxtLineOutput{Line: 3, Status: "unchanged", Task: &models.Task{ID: 3, Title: "Сдать отчет", Version: 4}}, resp.Results[2])
+		assert.Equal(t, todoTxtLineOutput{Line: 4, Status: "created", Task: &models.Task{ID: 4, Title: "Новая задача @дом", Version: 1}}, resp.Results[3])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Некорректные строки возвращаются с номерами без изменения задач", func(t *testing.T) {
+		mockService.On("ExportTasks", mock.Anything, mock.Anything).Run(export).Return(nil).Once()
+
+		rec := sync("Купить молоко id:1\nЗадача due:завтра\n", "")
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		var problem openapi.ValidationProblem
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+		assert.Equal(t, []openapi.Violation{{Location: "body", Message: "line 2: invalid due:завтра, expected YYYY-MM-DD"}}, problem.Violations)
+		mockService.AssertNumberOfCalls(t, "SyncTasks", 1)
+	})
+
+	t.Run("Ошибки проверки задач сервисом возвращаются с номерами строк", func(t *testing.T) {
+		mockService.On("ExportTasks", mock.Anything, mock.Anything).Run(export).Return(nil).Once()
+		mockService.On("SyncTasks", mock.Anything, []models.TaskRecord{{ID: 3, Title: "", Version: 4}}).Return([]models.ImportResult{
+			{Err: assert.AnError},
+		}, nil).Once()
+
+		rec := sync("Купить молоко due:2024-12-20 id:1\nid:3\n", "")
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), "line 2: "+assert.AnError.Error())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Задача изменена после выгрузки файла", func(t *testing.T) {
+		mockService.On("ExportTasks", mock.Anything, mock.Anything).Run(export).Return(nil).Once()
+		mockService.On("SyncTasks", mock.Anything, []models.TaskRecord{{ID: 2, Title: "Перезвонить", Version: 1}}).Return(nil, models.ErrVersionConflict).Once()
+
+		rec := sync("Перезвонить id:2\n", todotxt.MediaType)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Задача изменена между выгрузкой и загрузкой файла", func(t *testing.T) {
+		// Файл выгружен с версией 1, после этого задача изменена и сейчас имеет версию 2
+		exported := todotxt.FromRecord(records[1], time.UTC).String()
+		changedAfterExport := func(args mock.Arguments) {
+			fn := args.Get(1).(func(*models.TaskRecord) error)
+			fn(&models.TaskRecord{ID: 2, Title: "Позвонить завтра", Version: 2})
+		}
+		mockService.On("ExportTasks", mock.Anything, mock.Anything).Run(changedAfterExport).Return(nil).Once()
+		// Запись сохраняется с версией из файла, поэтому сервис отклоняет ее, а не перезаписывает изменение
+		mockService.On("SyncTasks", mock.Anything, []models.TaskRecord{{ID: 2, Title: "Перезвонить", Version: 1}}).Return(nil, models.ErrVersionConflict).Once()
+
+		rec := sync(strings.Replace(exported, "Позвонить", "Перезвонить", 1)+"\n", todotxt.MediaType)
+
+		assert.Equal(t, "Позвонить id:2 rev:1", exported)
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Тело не в текстовом формате отклоняется", func(t *testing.T) {
+		rec := sync(`[{"title":"Задача"}]`, "application/json")
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	})
+}
@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+	"github.com/pers0na2dev/todo-api/internal/models"
+)
+
+// maxImportBodyBytes - максимальный размер тела запроса импорта
+const maxImportBodyBytes = 32 << 20
+
+// importResponse тело ответа POST /v1/tasks/import
+type importResponse struct {
+	// DryRun - true, если изменения не сохранены
+	DryRun  bool                 `json:"dry_run"`
+	Created int                  `json:"created"`
+	Updated int                  `json:"updated"`
+	Results []importResultOutput `json:"results"`
+}
+
+// importResultOutput результат импорта одной задачи в ответе
+type importResultOutput struct {
+	// Row - номер задачи в импорте, начиная с 1
+	Row        int          `json:"row"`
+	ExternalID string       `json:"external_id,omitempty"`
+	Status     string       `json:"status" schema:"enum=created|updated"`
+	Task       *models.Task `json:"task"`
+}
+
+// exportFormat формат экспорта задач
+type exportFormat struct {
+	contentType string
+	// fileName - имя файла, которое предлагается клиенту
+	fileName string
+	// newWriter создает запись задач, loc - часовой пояс для форматов, в которых сроки записываются датой
+	newWriter func(w io.Writer, loc *time.Location) recordWriter
+}
+
+// exportFormats - поддерживаемые форматы экспорта по значению параметра format
+var exportFormats = map[string]exportFormat{
+	"csv":     {contentType: "text/csv", fileName: "tasks.csv", newWriter: newCSVRecordWriter},
+	"json":    {contentType: "application/json", fileName: "tasks.json", newWriter: newJSONRecordWriter},
+	"ndjson":  {contentType: "application/x-ndjson", fileName: "tasks.ndjson", newWriter: newNDJSONRecordWriter},
+	"todotxt": {contentType: "text/plain; charset=utf-8", fileName: "todo.txt", newWriter: newTodoTxtRecordWriter},
+}
+
+// recordWriter интерфейс потоковой записи задач в формате экспорта
+// Ничего не пишется до первой задачи или до Close, чтобы ошибку до начала выгрузки можно было вернуть обычным ответом
+type recordWriter interface {
+	Write(record *models.TaskRecord) error
+	Close() error
+}
+
+// registerTransferRoutes функция, которая регистрирует маршруты импорта и экспорта задач
+// @param reg *openapi.Registry - реестр маршрутов
+func (h *TaskHandler) registerTransferRoutes(reg *openapi.Registry) {
+	reg.Handle("GET /v1/tasks/export", h.ExportTasks, &openapi.Op{
+		ID:      "exportTasks",
+		Summary: "Export all tasks",
+		Description: "Tasks are streamed in id order as a JSON array, CSV with a header row, NDJSON with one task per line " +
+			"or todo.txt with due dates in CALENDAR_TIMEZONE and an id:N tag that PUT /v1/tasks/todotxt matches lines by.",
+		Tags: tasksTag,
+		QueryParams: []openapi.Param{
+			{Name: "format", Description: "Export format, json by default", Schema: openapi.String().WithEnum("csv", "json", "ndjson", "todotxt")},
+		},
+		Responses: map[int]openapi.Resp{
+			// Формат ответа задается параметром format, а не заголовком Accept
+			http.StatusOK:                  {Description: "All tasks; text/csv, application/x-ndjson or text/plain for the csv, ndjson and todotxt formats", Body: []models.TaskRecord{}, ContentType: "application/json", Headers: map[string]string{"Content-Disposition": "Suggested file name"}},
+			http.StatusInternalServerError: errorResp("Internal error"),
+		},
+	})
+	reg.Handle("POST /v1/tasks/import", h.ImportTasks, &openapi.Op{
+		ID:      "importTasks",
+		Summary: "Import tasks from JSON, CSV or NDJSON",
+		Description: "The format is taken from Content-Type. Tasks with an external_id that is already imported are updated, " +
+			"other tasks are created. Every task is validated separately; if any is invalid, nothing is imported and " +
+			"422 lists the invalid rows. With dry_run=true the import is checked against the database and rolled back.",
+		Tags:         tasksTag,
+		QueryParams:  []openapi.Param{{Name: "dry_run", Description: "Report the result without saving changes", Schema: openapi.Boolean()}},
+		HeaderParams: []openapi.Param{idempotencyKeyParam},
+		Request:      []models.TaskRecord{},
+		MaxBodyBytes: maxImportBodyBytes,
+		Responses: map[int]openapi.Resp{
+			http.StatusOK:                  {Description: "Per-task results", Body: importResponse{}},
+			http.StatusBadRequest:          errorResp("The import is empty or too large"),
+			http.StatusInternalServerError: errorResp("Internal error"),
+		},
+	})
+}
+
+// ExportTasks функция, которая выгружает все задачи в формате из параметра format
+// Задачи передаются клиенту по мере чтения из базы данных, без загрузки всего списка в память
+func (h *TaskHandler) ExportTasks(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		name = "json"
+	}
+	format, ok := exportFormats[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown export format %q", name), http.StatusBadRequest)
+		return
+	}
+
+	writer := format.newWriter(w, h.loc)
+	started := false
+	start := func() {
+		w.Header().Set("Content-Type", format.contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, format.fileName))
+		w.WriteHeader(http.StatusOK)
+		started = true
+	}
+
+	err := h.taskService.ExportTasks(r.Context(), func(record *models.TaskRecord) error {
+		if !started {
+			start()
+		}
+		return writer.Write(record)
+	})
+	if err != nil {
+		if !started {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Код 200 уже отправлен, поэтому обрываем соединение, чтобы клиент не принял неполную выгрузку за полную
+		panic(http.ErrAbortHandler)
+	}
+
+	if !started {
+		start()
+	}
+	if err := writer.Close(); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// ImportTasks функция, которая импортирует задачи из тела запроса в формате из заголовка Content-Type
+func (h *TaskHandler) ImportTasks(w http.ResponseWriter, r *http.Request) {
+	// Формат ответа выбирается до импорта, чтобы не изменять задачи, если результат нельзя вернуть клиенту
+	responseCodec, err := h.codecs.Negotiate(r.Header.Get("Accept"), importResponse{})
+	if err != nil {
+		h.codecs.WriteNotAcceptable(w, importResponse{})
+		return
+	}
+
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var records []models.TaskRecord
+	// Декодирование тела запроса в формате из заголовка Content-Type
+	if err := h.codecs.Read(r, &records); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Импорт задач
+	results, err := h.taskService.ImportTasks(r.Context(), records, dryRun)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	// Ошибки проверки отдельных задач возвращаются так же, как ошибки проверки тела запроса
+	var violations []openapi.Violation
+	for i, result := range results {
+		if result.Err != nil && !errors.Is(result.Err, models.ErrImportAborted) {
+			violations = append(violations, openapi.Violation{
+				Location: "body",
+				Field:    "/" + strconv.Itoa(i),
+				Message:  fmt.Sprintf("row %d: %v", i+1, result.Err),
+			})
+		}
+	}
+	if len(violations) > 0 {
+		openapi.WriteProblem(w, http.StatusUnprocessableEntity, "invalid tasks, nothing was imported", violations)
+		return
+	}
+
+	resp := importResponse{DryRun: dryRun, Results: make([]importResultOutput, len(results))}
+	for i, result := range results {
+		out := importResultOutput{Row: i + 1, ExternalID: records[i].ExternalID, Status: "updated", Task: result.Task}
+		if result.Created {
+			out.Status = "created"
+			resp.Created++
+		} else {
+			resp.Updated++
+		}
+		resp.Results[i] = out
+	}
+
+	// Кодирование результатов в выбранном формате и отправка ответа
+	codec.Encode(w, responseCodec, http.StatusOK, resp)
+}
+
+// jsonRecordWriter запись задач в виде JSON массива
+type jsonRecordWriter struct {
+	w       io.Writer
+	written bool
+}
+
+// newJSONRecordWriter функция, которая создает запись задач в виде JSON массива
+// @param w io.Writer - тело ответа
+// @return recordWriter - запись задач
+func newJSONRecordWriter(w io.Writer, _ *time.Location) recordWriter {
+	return &jsonRecordWriter{w: w}
+}
+
+// Write функция, которая дописывает задачу в массив
+func (j *jsonRecordWriter) Write(record *models.TaskRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n"
+	if !j.written {
+		separator = "[\n"
+		j.written = true
+	}
+	if _, err := io.WriteString(j.w, separator); err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+	return err
+}
+
+// Close функция, которая закрывает массив
+func (j *jsonRecordWriter) Close() error {
+	if !j.written {
+		_, err := io.WriteString(j.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(j.w, "\n]\n")
+	return err
+}
+
+// ndjsonRecordWriter запись задач по одной на строку
+type ndjsonRecordWriter struct {
+	encoder *json.Encoder
+}
+
+// newNDJSONRecordWriter функция, которая создает запись задач по одной на строку
+// @param w io.Writer - тело ответа
+// @return recordWriter - запись задач
+func newNDJSONRecordWriter(w io.Writer, _ *time.Location) recordWriter {
+	return &ndjsonRecordWriter{encoder: json.NewEncoder(w)}
+}
+
+// Write функция, которая записывает задачу отдельной строкой
+func (n *ndjsonRecordWriter) Write(record *models.TaskRecord) error {
+	return n.encoder.Encode(record)
+}
+
+// Close функция, которая завершает запись, NDJSON не требует завершающих данных
+func (n *ndjsonRecordWriter) Close() error {
+	return nil
+}
+
+// csvRecordWriter запись задач в CSV с колонками, совпадающими с форматом text/csv
+type csvRecordWriter struct {
+	writer *csv.Writer
+	header bool
+}
+
+// newCSVRecordWriter функция, которая создает запись задач в CSV
+// @param w io.Writer - тело ответа
+// @return recordWriter - запись задач
+func newCSVRecordWriter(w io.Writer, _ *time.Location) recordWriter {
+	return &csvRecordWriter{writer: csv.NewWriter(w)}
+}
+
+// Write функция, которая записывает задачу строкой CSV, перед первой задачей записывается заголовок
+func (c *csvRecordWriter) Write(record *models.TaskRecord) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	return c.writer.Write([]string{
+		strconv.Itoa(record.ID),
+		record.ExternalID,
+		record.Title,
+		strconv.FormatBool(record.Completed),
+		strconv.Itoa(record.Version),
+		formatDueAt(record.DueAt),
+	})
+}
+
+// Close функция, которая дописывает буферизованные строки
+func (c *csvRecordWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// writeHeader функция, которая один раз записывает строку заголовка
+// @return error - ошибка записи
+func (c *csvRecordWriter) writeHeader() error {
+	if c.header {
+		return nil
+	}
+	c.header = true
+	return c.writer.Write([]string{"id", "external_id", "title", "completed", "version", "due_at"})
+}
+
+// formatDueAt функция, которая преобразует срок выполнения в текст ячейки CSV
+// @param dueAt *time.Time - срок выполнения
+// @return string - время в RFC 3339 или пустая строка, если срок не задан
+func formatDueAt(dueAt *time.Time) string {
+	if dueAt == nil {
+		return ""
+	}
+	return dueAt.Format(time.RFC3339)
+}
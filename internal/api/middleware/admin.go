@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/pers0na2dev/todo-api/internal/config"
+)
+
+// AdminAuth структура, которая реализует middleware проверки учетных данных администратора
+// Администратор передает ADMIN_TOKEN в заголовке Authorization: Bearer <token>
+type AdminAuth struct {
+	// tokenHash - хеш ADMIN_TOKEN, nil если маршруты администратора отключены
+	tokenHash []byte
+}
+
+// NewAdminAuth функция, которая создает новый экземпляр AdminAuth
+// Если ADMIN_TOKEN не задан, маршруты администратора отвечают 403 на любой запрос
+// @param cfg *config.Config - конфигурация
+// @return *AdminAuth - новый экземпляр AdminAuth
+func NewAdminAuth(cfg *config.Config) *AdminAuth {
+	a := &AdminAuth{}
+	if cfg.AdminToken != "" {
+		hash := sha256.Sum256([]byte(cfg.AdminToken))
+		a.tokenHash = hash[:]
+	}
+	return a
+}
+
+// Wrap функция, которая пропускает к обработчику только запросы администратора
+// @param next http.HandlerFunc - обработчик
+// @return http.HandlerFunc - обработчик с проверкой учетных данных
+func (a *AdminAuth) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.tokenHash == nil {
+			http.Error(w, "admin endpoints are disabled, set ADMIN_TOKEN to enable them", http.StatusForbidden)
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		// Сравниваются хеши, чтобы время сравнения не зависело ни от совпадающей части, ни от длины токена
+		hash := sha256.Sum256([]byte(token))
+		if !ok || subtle.ConstantTimeCompare(hash[:], a.tokenHash) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "invalid admin credentials", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pers0na2dev/todo-api/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAdminAuth тестирует проверку учетных данных администратора
+func TestAdminAuth(t *testing.T) {
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+	serve := func(token, authorization string) *httptest.ResponseRecorder {
+		handler := NewAdminAuth(&config.Config{AdminToken: token}).Wrap(next)
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/cache/metrics", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	t.Run("Запрос с верным токеном проходит", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, serve("secret", "Bearer secret").Code)
+	})
+
+	t.Run("Запрос без токена или с неверным токеном отклоняется", func(t *testing.T) {
+		for _, authorization := range []string{"", "Bearer wrong", "Basic secret", "secret"} {
+			rec := serve("secret", authorization)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code, authorization)
+			assert.Equal(t, `Bearer realm="admin"`, rec.Header().Get("WWW-Authenticate"))
+		}
+	})
+
+	t.Run("Без ADMIN_TOKEN маршруты отключены", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve("", "").Code)
+		assert.Equal(t, http.StatusForbidden, serve("", "Bearer ").Code)
+	})
+}
@@ -0,0 +1,329 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/pkg/cache"
+	"go.uber.org/zap"
+)
+
+const (
+	// IdempotencyKeyHeader - заголовок, в котором клиент передает ключ идемпотентности
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// idempotencyReplayedHeader - заголовок, которым помечается повторно отправленный ответ
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+
+	idempotencyCacheKeyPrefix = "idempotency:"
+	// idempotencyLockKeyPrefix - префикс блокировок, которыми ключ захватывается на время выполнения запроса
+	idempotencyLockKeyPrefix = "lock:idempotency:"
+	idempotencyTTL           = 24 * time.Hour
+	idempotencyLockTTL       = time.Minute
+	maxIdempotencyKeyLength  = 255
+	// maxIdempotentMemoryBody - часть тела запроса, которая хранится в памяти, остальное пишется во временный файл
+	maxIdempotentMemoryBody = 1 << 20
+)
+
+// BodyLimits интерфейс, который возвращает максимальный размер тела запроса для маршрута
+type BodyLimits interface {
+	MaxBodyBytes(r *http.Request) int64
+}
+
+// idempotencyRecord структура, которая хранится в кеше для каждого ключа идемпотентности
+type idempotencyRecord struct {
+	// Fingerprint - отпечаток запроса (метод, путь и тело)
+	Fingerprint string `json:"fingerprint"`
+	// StatusCode - код ответа
+	StatusCode int `json:"status_code"`
+	// Header - заголовки ответа
+	Header http.Header `json:"header"`
+	// Body - тело ответа
+	Body []byte `json:"body"`
+}
+
+// Idempotency структура, которая реализует middleware для заголовка Idempotency-Key
+// Ответ на первый POST запрос сохраняется в кеше и отправляется повторно при ретраях с тем же ключом
+type Idempotency struct {
+	cache cache.Cache
+	// locker - блокировки, общие для всех реплик, nil если кеш их не поддерживает
+	locker cache.Locker
+	limits BodyLimits
+	logger *zap.Logger
+
+	// mu и inFlight защищают от одновременного выполнения запросов с одним ключом внутри одной реплики
+	mu       sync.Mutex
+	inFlight map[string]struct{}
+}
+
+// NewIdempotency функция, которая создает новый экземпляр Idempotency
+// Если кеш реализует cache.Locker, ключ захватывается для всех реплик, иначе только внутри процесса
+// @param c cache.Cache - кеш для хранения ответов
+// @param limits BodyLimits - ограничения размера тела запроса по маршрутам
+// @param logger *zap.Logger - логгер
+// @return *Idempotency - новый экземпляр Idempotency
+func NewIdempotency(c cache.Cache, limits BodyLimits, logger *zap.Logger) *Idempotency {
+	m := &Idempotency{
+		cache:    c,
+		limits:   limits,
+		logger:   logger,
+		inFlight: make(map[string]struct{}),
+	}
+	m.locker, _ = c.(cache.Locker)
+
+	return m
+}
+
+// Wrap функция, которая оборачивает обработчик в middleware идемпотентности
+// @param next http.Handler - обработчик
+// @return http.Handler - обработчик с поддержкой Idempotency-Key
+func (m *Idempotency) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if r.Method != http.MethodPost || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+
+		// Тело нужно прочитать целиком, чтобы посчитать отпечаток запроса. Ограничение размера берется у маршрута,
+		// чтобы ключ можно было передать и с большим импортом, а тело больше maxIdempotentMemoryBody не держится в памяти
+		limit := m.limits.MaxBodyBytes(r)
+		fingerprint := newRequestHash(r)
+		var body spillBuffer
+		defer body.Close()
+
+		n, err := io.Copy(io.MultiWriter(fingerprint, &body), io.LimitReader(r.Body, limit+1))
+		switch {
+		case body.err != nil:
+			m.logger.Error("failed to buffer idempotent request body", zap.Error(body.err))
+			http.Error(w, "failed to buffer request body", http.StatusInternalServerError)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case n > limit:
+			http.Error(w, "request body is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		reader, err := body.Reader()
+		if err != nil {
+			m.logger.Error("failed to read buffered request body", zap.Error(err))
+			http.Error(w, "failed to buffer request body", http.StatusInternalServerError)
+			return
+		}
+		r.Body = io.NopCloser(reader)
+
+		m.serve(w, r, next, key, hex.EncodeToString(fingerprint.Sum(nil)))
+	})
+}
+
+// serve функция, которая отвечает сохраненным ответом или выполняет запрос и сохраняет ответ
+// @param w http.ResponseWriter - ответ
+// @param r *http.Request - запрос с уже прочитанным телом
+// @param next http.Handler - обработчик
+// @param key string - ключ идемпотентности
+// @param fingerprint string - отпечаток запроса
+func (m *Idempotency) serve(w http.ResponseWriter, r *http.Request, next http.Handler, key, fingerprint string) {
+	cacheKey := idempotencyCacheKeyPrefix + key
+
+	if !m.acquire(cacheKey) {
+		http.Error(w, "a request with this Idempotency-Key is already in progress", http.StatusConflict)
+		return
+	}
+	defer m.release(cacheKey)
+
+	ctx := r.Context()
+
+	// Проверяем, выполнялся ли уже запрос с этим ключом
+	if m.replayStored(w, r, cacheKey, fingerprint) {
+		return
+	}
+
+	// Захватываем ключ атомарно для всех реплик, иначе две реплики могли бы одновременно не найти ответ
+	// и обе выполнить запрос. Захват снимается после сохранения ответа
+	if m.locker != nil {
+		unlock, acquired, err := m.locker.Lock(ctx, idempotencyLockKeyPrefix+key, idempotencyLockTTL)
+		switch {
+		case err != nil:
+			m.logger.Warn("failed to lock idempotency key, guarding it within the replica only", zap.String("key", key), zap.Error(err))
+		case !acquired:
+			http.Error(w, "a request with this Idempotency-Key is already in progress", http.StatusConflict)
+			return
+		default:
+			defer unlock()
+			// Другая реплика могла сохранить ответ и снять захват между проверкой и захватом ключа
+			if m.replayStored(w, r, cacheKey, fingerprint) {
+				return
+			}
+		}
+	}
+
+	rec := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+	next.ServeHTTP(rec, r)
+
+	// Ответы с ошибкой сервера не сохраняем, чтобы клиент мог повторить запрос
+	if rec.statusCode >= http.StatusInternalServerError {
+		return
+	}
+
+	record := idempotencyRecord{
+		Fingerprint: fingerprint,
+		StatusCode:  rec.statusCode,
+		Header:      w.Header().Clone(),
+		Body:        rec.body.Bytes(),
+	}
+	if err := m.cache.Set(ctx, cacheKey, &record, idempotencyTTL); err != nil {
+		m.logger.Warn("failed to store idempotent response", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// replayStored функция, которая отвечает на запрос, если ответ с этим ключом уже сохранен
+// @param w http.ResponseWriter - ответ
+// @param r *http.Request - запрос
+// @param cacheKey string - ключ кеша
+// @param fingerprint string - отпечаток запроса
+// @return bool - true, если ответ отправлен и запрос выполнять не нужно
+func (m *Idempotency) replayStored(w http.ResponseWriter, r *http.Request, cacheKey, fingerprint string) bool {
+	var record idempotencyRecord
+	if err := m.cache.Get(r.Context(), cacheKey, &record); err != nil {
+		return false
+	}
+
+	if record.Fingerprint != fingerprint {
+		http.Error(w, "Idempotency-Key has already been used with a different request", http.StatusUnprocessableEntity)
+		return true
+	}
+	m.replay(w, &record)
+	return true
+}
+
+// acquire функция, которая захватывает ключ внутри текущей реплики
+// @param key string - ключ идемпотентности
+// @return bool - false, если ключ уже захвачен другим запросом
+func (m *Idempotency) acquire(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.inFlight[key]; ok {
+		return false
+	}
+	m.inFlight[key] = struct{}{}
+	return true
+}
+
+// release функция, которая освобождает ключ внутри текущей реплики
+// @param key string - ключ идемпотентности
+func (m *Idempotency) release(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.inFlight, key)
+}
+
+// replay функция, которая отправляет сохраненный ответ
+// @param w http.ResponseWriter - ответ
+// @param record *idempotencyRecord - сохраненный ответ
+func (m *Idempotency) replay(w http.ResponseWriter, record *idempotencyRecord) {
+	for name, values := range record.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(idempotencyReplayedHeader, "true")
+	w.WriteHeader(record.StatusCode)
+	w.Write(record.Body)
+}
+
+// newRequestHash функция, которая начинает вычисление отпечатка запроса по методу и пути
+// Тело запроса дописывается в хеш по мере чтения
+// @param r *http.Request - запрос
+// @return hash.Hash - хеш отпечатка запроса
+func newRequestHash(r *http.Request) hash.Hash {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.RequestURI()))
+	h.Write([]byte{0})
+	return h
+}
+
+// spillBuffer структура, которая хранит тело запроса: первые maxIdempotentMemoryBody байт в памяти,
+// остальное во временном файле. После использования буфер нужно закрыть, чтобы удалить файл
+type spillBuffer struct {
+	mem  bytes.Buffer
+	file *os.File
+	// err - ошибка записи во временный файл, чтобы отличить ее от ошибки чтения тела запроса
+	err error
+}
+
+// Write функция, которая дописывает данные в память, а после заполнения памяти - во временный файл
+func (b *spillBuffer) Write(p []byte) (int, error) {
+	if b.file == nil && b.mem.Len()+len(p) <= maxIdempotentMemoryBody {
+		return b.mem.Write(p)
+	}
+	if b.file == nil {
+		b.file, b.err = os.CreateTemp("", "idempotency-body-*")
+		if b.err != nil {
+			return 0, b.err
+		}
+	}
+	n, err := b.file.Write(p)
+	b.err = err
+	return n, err
+}
+
+// Reader функция, которая возвращает записанные данные с начала
+// @return io.Reader - данные
+// @return error - ошибка перемотки временного файла
+func (b *spillBuffer) Reader() (io.Reader, error) {
+	if b.file == nil {
+		return bytes.NewReader(b.mem.Bytes()), nil
+	}
+	if _, err := b.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.MultiReader(bytes.NewReader(b.mem.Bytes()), b.file), nil
+}
+
+// Close функция, которая удаляет временный файл
+// @return error - ошибка удаления файла
+func (b *spillBuffer) Close() error {
+	if b.file == nil {
+		return nil
+	}
+	b.file.Close()
+	return os.Remove(b.file.Name())
+}
+
+// responseRecorder структура, которая передает ответ клиенту и одновременно сохраняет его копию
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+// WriteHeader функция, которая запоминает код ответа
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.statusCode = statusCode
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write функция, которая запоминает тело ответа
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
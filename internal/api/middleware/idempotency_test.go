@@ -0,0 +1,257 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// memoryCache простая реализация cache.Cache в памяти для тестов
+type memoryCache struct {
+	mu    sync.Mutex
+	items map[string][]byte
+}
+
+func (c *memoryCache) Set(_ context.Context, key string, value interface{}, _ time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = data
+	return nil
+}
+
+func (c *memoryCache) SetWithTags(ctx context.Context, key string, value interface{}, expiration time.Duration, _ ...string) error {
+	return c.Set(ctx, key, value, expiration)
+}
+
+func (c *memoryCache) SetAbsent(_ context.Context, key string, _ time.Duration, _ ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+	return nil
+}
+
+func (c *memoryCache) Get(_ context.Context, key string, dest interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.items[key]
+	if !ok {
+		return errors.New("cache miss")
+	}
+	return json.Unmarshal(data, dest)
+}
+
+func (c *memoryCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+	return nil
+}
+
+func (c *memoryCache) InvalidateTags(_ context.Context, _ ...string) error {
+	return nil
+}
+
+// lockingCache кеш для тестов с блокировками, общими для всех экземпляров middleware, как блокировки в Redis
+type lockingCache struct {
+	*memoryCache
+	locks map[string]bool
+}
+
+func (c *lockingCache) Lock(_ context.Context, key string, _ time.Duration) (func(), bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.locks[key] {
+		return nil, false, nil
+	}
+	c.locks[key] = true
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.locks, key)
+	}, true, nil
+}
+
+// barrierCache кеш для тестов, первые чтения которого возвращаются только после того, как кеш прочитали оба запроса
+type barrierCache struct {
+	*lockingCache
+	mu      sync.Mutex
+	waiting int
+	ready   chan struct{}
+}
+
+func (c *barrierCache) Get(ctx context.Context, key string, dest interface{}) error {
+	err := c.lockingCache.Get(ctx, key, dest)
+
+	c.mu.Lock()
+	if c.waiting == 0 {
+		c.mu.Unlock()
+		return err
+	}
+	c.waiting--
+	if c.waiting == 0 {
+		close(c.ready)
+	}
+	c.mu.Unlock()
+	<-c.ready
+	return err
+}
+
+// bodyLimit ограничение размера тела запроса, одинаковое для всех маршрутов
+type bodyLimit int64
+
+// MaxBodyBytes возвращает ограничение
+func (l bodyLimit) MaxBodyBytes(*http.Request) int64 { return int64(l) }
+
+// testBodyLimit - ограничение размера тела в тестах middleware
+const testBodyLimit = bodyLimit(4 << 20)
+
+// setupIdempotencyTest подготавливает middleware и счетчик вызовов обработчика
+func setupIdempotencyTest(t *testing.T, status int) (http.Handler, *int) {
+	t.Helper()
+
+	calls := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"id":1}`))
+	})
+
+	m := NewIdempotency(&memoryCache{items: make(map[string][]byte)}, testBodyLimit, zap.NewNop())
+	return m.Wrap(next), &calls
+}
+
+// newIdempotentRequest создает POST запрос с заголовком Idempotency-Key
+func newIdempotentRequest(key, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/v1/tasks", strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, key)
+	return req
+}
+
+// TestIdempotency тестирует повторную отправку сохраненного ответа
+func TestIdempotency(t *testing.T) {
+	t.Run("Повторный запрос получает сохраненный ответ", func(t *testing.T) {
+		handler, calls := setupIdempotencyTest(t, http.StatusCreated)
+
+		first := httptest.NewRecorder()
+		handler.ServeHTTP(first, newIdempotentRequest("key-1", `{"title":"a"}`))
+		second := httptest.NewRecorder()
+		handler.ServeHTTP(second, newIdempotentRequest("key-1", `{"title":"a"}`))
+
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+		assert.Equal(t, "true", second.Header().Get(idempotencyReplayedHeader))
+	})
+
+	t.Run("Тот же ключ с другим телом возвращает 422", func(t *testing.T) {
+		handler, calls := setupIdempotencyTest(t, http.StatusCreated)
+
+		handler.ServeHTTP(httptest.NewRecorder(), newIdempotentRequest("key-2", `{"title":"a"}`))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, newIdempotentRequest("key-2", `{"title":"b"}`))
+
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("Ответ с ошибкой сервера не сохраняется", func(t *testing.T) {
+		handler, calls := setupIdempotencyTest(t, http.StatusInternalServerError)
+
+		handler.ServeHTTP(httptest.NewRecorder(), newIdempotentRequest("key-3", `{"title":"a"}`))
+		handler.ServeHTTP(httptest.NewRecorder(), newIdempotentRequest("key-3", `{"title":"a"}`))
+
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("Тело больше буфера в памяти передается обработчику целиком", func(t *testing.T) {
+		body := strings.Repeat("a", maxIdempotentMemoryBody+1)
+		var received []string
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			received = append(received, string(data))
+			w.WriteHeader(http.StatusCreated)
+		})
+		handler := NewIdempotency(&memoryCache{items: make(map[string][]byte)}, testBodyLimit, zap.NewNop()).Wrap(next)
+
+		first := httptest.NewRecorder()
+		handler.ServeHTTP(first, newIdempotentRequest("key-5", body))
+		second := httptest.NewRecorder()
+		handler.ServeHTTP(second, newIdempotentRequest("key-5", body))
+
+		assert.Equal(t, http.StatusCreated, first.Code)
+		assert.Equal(t, []string{body}, received)
+		assert.Equal(t, "true", second.Header().Get(idempotencyReplayedHeader))
+	})
+
+	t.Run("Тело больше ограничения маршрута возвращает 413", func(t *testing.T) {
+		handler, calls := setupIdempotencyTest(t, http.StatusCreated)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, newIdempotentRequest("key-6", strings.Repeat("a", int(testBodyLimit)+1)))
+
+		assert.Equal(t, 0, *calls)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+
+	t.Run("Запросы без ключа не кешируются", func(t *testing.T) {
+		handler, calls := setupIdempotencyTest(t, http.StatusCreated)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/tasks", strings.NewReader(`{}`)))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/tasks", strings.NewReader(`{}`)))
+
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("Реплики с общим кешем не выполняют запрос с одним ключом дважды", func(t *testing.T) {
+		shared := &lockingCache{memoryCache: &memoryCache{items: make(map[string][]byte)}, locks: make(map[string]bool)}
+		// Обе реплики проверяют кеш до того, как любая из них начнет выполнять запрос
+		barrier := &barrierCache{lockingCache: shared, waiting: 2, ready: make(chan struct{})}
+		var calls atomic.Int32
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":1}`))
+		})
+		replicas := []http.Handler{
+			NewIdempotency(barrier, testBodyLimit, zap.NewNop()).Wrap(next),
+			NewIdempotency(barrier, testBodyLimit, zap.NewNop()).Wrap(next),
+		}
+
+		codes := make([]int, len(replicas))
+		var wg sync.WaitGroup
+		for i, replica := range replicas {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				rec := httptest.NewRecorder()
+				replica.ServeHTTP(rec, newIdempotentRequest("key-4", `{"title":"a"}`))
+				codes[i] = rec.Code
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), calls.Load())
+		for _, code := range codes {
+			assert.Contains(t, []int{http.StatusCreated, http.StatusConflict}, code)
+		}
+		assert.Empty(t, shared.locks)
+	})
+}
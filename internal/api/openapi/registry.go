@@ -0,0 +1,309 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// Param параметр маршрута
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+	Schema      *Schema
+}
+
+// Resp описание ответа маршрута
+type Resp struct {
+	Description string
+	// Body - пример значения тела ответа, схема строится по его типу. nil означает ответ без тела
+	Body any
+	// ContentType - тип содержимого тела, по умолчанию application/json
+	ContentType string
+	// Headers - заголовки ответа и их описания
+	Headers map[string]string
+}
+
+// Op описание маршрута, из которого строится операция OpenAPI
+type Op struct {
+	ID          string
+	Summary     string
+	Description string
+	Tags        []string
+
+	PathParams   []Param
+	QueryParams  []Param
+	HeaderParams []Param
+
+	// Request - пример значения тела запроса, схема строится по его типу. nil означает запрос без тела
+	Request any
+	// Responses - ответы по HTTP кодам
+	Responses map[int]Resp
+	// MaxBodyBytes - максимальный размер тела запроса, по умолчанию DefaultMaxBodyBytes
+	MaxBodyBytes int64
+}
+
+// Route маршрут, зарегистрированный через Registry
+type Route struct {
+	// Method - метод HTTP, пусто если маршрут принимает любой метод
+	Method string
+	Path   string
+	// Operation - операция OpenAPI, nil если маршрут не описан
+	Operation *Operation
+}
+
+// Registry структура, которая регистрирует маршруты в http.ServeMux и одновременно собирает их описание
+// Все маршруты API должны регистрироваться через Registry, чтобы попасть в документ OpenAPI
+type Registry struct {
+	mux    *http.ServeMux
+	info   Info
+	codecs *codec.Registry
+
+	mu     sync.RWMutex
+	gen    *generator
+	routes []Route
+	// bodyLimits - MaxBodyBytes маршрутов по шаблону, с которым они зарегистрированы
+	bodyLimits map[string]int64
+}
+
+// NewRegistry функция, которая создает новый экземпляр Registry и регистрирует маршруты документации
+// GET /openapi.json - документ OpenAPI, GET /docs - интерактивная документация
+// @param mux *http.ServeMux - маршрутизатор
+// @param codecs *codec.Registry - форматы тел запросов и ответов
+// @return *Registry - новый экземпляр Registry
+func NewRegistry(mux *http.ServeMux, codecs *codec.Registry) *Registry {
+	r := &Registry{
+		mux:        mux,
+		info:       Info{Title: "Todo API", Version: "1.0.0"},
+		codecs:     codecs,
+		gen:        newGenerator(),
+		bodyLimits: make(map[string]int64),
+	}
+
+	r.Handle("GET /openapi.json", r.serveDocument, &Op{
+		ID:      "getOpenAPIDocument",
+		Summary: "OpenAPI document describing this API",
+		Tags:    []string{"docs"},
+		Responses: map[int]Resp{
+			http.StatusOK: {Description: "OpenAPI 3.1 document", Body: map[string]any{}, ContentType: "application/json"},
+		},
+	})
+	r.Handle("GET /docs", serveDocsPage, &Op{
+		ID:      "getDocsPage",
+		Summary: "Interactive API documentation",
+		Tags:    []string{"docs"},
+		Responses: map[int]Resp{
+			http.StatusOK: {Description: "HTML page", Body: "", ContentType: "text/html"},
+		},
+	})
+
+	return r
+}
+
+// Handle функция, которая регистрирует обработчик и его описание
+// Описанные маршруты оборачиваются в проверку параметров и тела запроса по схеме операции
+// @param pattern string - шаблон маршрута http.ServeMux вида "METHOD /path/{param}" или "/path/" для любого метода
+// @param handler http.HandlerFunc - обработчик
+// @param op *Op - описание маршрута, nil если маршрут не описан
+func (r *Registry) Handle(pattern string, handler http.HandlerFunc, op *Op) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		method, path = "", pattern
+	}
+	route := Route{Method: method, Path: path}
+
+	r.mu.Lock()
+	if op != nil {
+		route.Operation = r.gen.operation(op, r.codecs)
+		handler = newValidator(route.Operation, r.gen.components, r.codecs, op.Request, op.MaxBodyBytes).wrap(handler)
+		if op.MaxBodyBytes > 0 {
+			r.bodyLimits[pattern] = op.MaxBodyBytes
+		}
+	}
+	r.routes = append(r.routes, route)
+	r.mu.Unlock()
+
+	r.mux.HandleFunc(pattern, handler)
+}
+
+// MaxBodyBytes функция, которая возвращает максимальный размер тела запроса для маршрута, которому соответствует запрос
+// Нужна middleware, которые читают тело до маршрутизации, например идемпотентности
+// @param req *http.Request - запрос
+// @return int64 - MaxBodyBytes из описания маршрута или DefaultMaxBodyBytes
+func (r *Registry) MaxBodyBytes(req *http.Request) int64 {
+	_, pattern := r.mux.Handler(req)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if limit, ok := r.bodyLimits[pattern]; ok {
+		return limit
+	}
+	return DefaultMaxBodyBytes
+}
+
+// Routes функция, которая возвращает все зарегистрированные маршруты
+// @return []Route - маршруты
+func (r *Registry) Routes() []Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]Route(nil), r.routes...)
+}
+
+// Document функция, которая строит документ OpenAPI по зарегистрированным маршрутам
+// Маршруты без описания в документ не попадают
+// @return *Document - документ OpenAPI
+func (r *Registry) Document() *Document {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    r.info,
+		Paths:   make(map[string]map[string]*Operation),
+	}
+
+	for _, route := range r.routes {
+		if route.Operation == nil {
+			continue
+		}
+		if doc.Paths[route.Path] == nil {
+			doc.Paths[route.Path] = make(map[string]*Operation)
+		}
+		doc.Paths[route.Path][strings.ToLower(route.Method)] = route.Operation
+	}
+
+	doc.Components.Schemas = r.gen.components
+	return doc
+}
+
+// operation функция, которая преобразует описание маршрута в операцию OpenAPI
+// Тела без явного типа содержимого описываются во всех форматах, которые их поддерживают
+// @param op *Op - описание маршрута
+// @param codecs *codec.Registry - форматы тел запросов и ответов
+// @return *Operation - операция
+func (g *generator) operation(op *Op, codecs *codec.Registry) *Operation {
+	out := &Operation{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Responses:   make(map[string]*Response),
+	}
+
+	for _, group := range []struct {
+		in     string
+		params []Param
+	}{{"path", op.PathParams}, {"query", op.QueryParams}, {"header", op.HeaderParams}} {
+		for _, p := range group.params {
+			out.Parameters = append(out.Parameters, &Parameter{
+				Name:        p.Name,
+				In:          group.in,
+				Description: p.Description,
+				// Параметры пути в OpenAPI всегда обязательны
+				Required: p.Required || group.in == "path",
+				Schema:   p.Schema,
+			})
+		}
+	}
+
+	if op.Request != nil {
+		out.RequestBody = &RequestBody{
+			Required: true,
+			Content:  g.content(reflect.TypeOf(op.Request), codecs.Supported(reflect.TypeOf(op.Request))),
+		}
+	}
+
+	// Ответы с ошибками проверки формирует валидатор, поэтому они описываются автоматически
+	responses := make(map[int]Resp, len(op.Responses)+5)
+	negotiated := false
+	for code, resp := range op.Responses {
+		responses[code] = resp
+		negotiated = negotiated || resp.Body != nil && resp.ContentType == ""
+	}
+	if len(out.Parameters) > 0 || op.Request != nil {
+		responses[http.StatusBadRequest] = problemResp("The request does not match the schema")
+	}
+	if op.Request != nil {
+		responses[http.StatusRequestEntityTooLarge] = problemResp("The request body is too large")
+		responses[http.StatusUnsupportedMediaType] = problemResp("The request body media type is not supported")
+		responses[http.StatusUnprocessableEntity] = problemResp("The request body does not match the schema")
+	}
+	if negotiated {
+		responses[http.StatusNotAcceptable] = Resp{Description: "None of the media types in Accept is available", Body: "", ContentType: "text/plain"}
+	}
+
+	codes := make([]int, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	for _, code := range codes {
+		resp := responses[code]
+		response := &Response{Description: resp.Description}
+
+		if resp.Body != nil {
+			bodyType := reflect.TypeOf(resp.Body)
+			if resp.ContentType == "" {
+				response.Content = g.content(bodyType, codecs.Supported(bodyType))
+			} else {
+				response.Content = map[string]*MediaType{resp.ContentType: {Schema: g.schemaFor(bodyType)}}
+			}
+		}
+
+		for name, description := range resp.Headers {
+			if response.Headers == nil {
+				response.Headers = make(map[string]*Header)
+			}
+			response.Headers[name] = &Header{Description: description, Schema: String()}
+		}
+
+		out.Responses[strconv.Itoa(code)] = response
+	}
+
+	return out
+}
+
+// content функция, которая описывает тело во всех переданных форматах
+// @param t reflect.Type - тип тела
+// @param codecs []codec.Codec - форматы
+// @return map[string]*MediaType - описание тела по типам содержимого
+func (g *generator) content(t reflect.Type, codecs []codec.Codec) map[string]*MediaType {
+	schema := g.schemaFor(t)
+	content := make(map[string]*MediaType, len(codecs))
+	for _, c := range codecs {
+		content[c.MediaTypes()[0]] = &MediaType{Schema: schema}
+	}
+	return content
+}
+
+// problemResp функция, которая описывает ответ валидатора с ошибками проверки запроса
+// @param description string - описание ответа
+// @return Resp - описание ответа
+func problemResp(description string) Resp {
+	return Resp{Description: description, Body: ValidationProblem{}, ContentType: "application/json"}
+}
+
+// serveDocument функция, которая отдает документ OpenAPI
+func (r *Registry) serveDocument(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(r.Document())
+}
+
+// serveDocsPage функция, которая отдает страницу интерактивной документации
+func serveDocsPage(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docsPage)
+}
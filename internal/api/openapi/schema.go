@@ -0,0 +1,276 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Schema схема JSON Schema 2020-12, которую использует OpenAPI 3.1
+// Поддерживается только подмножество ключевых слов, достаточное для описания моделей API
+type Schema struct {
+	Ref         string `json:"$ref,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Format      string `json:"format,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	// AdditionalProperties - схема значений словаря, для структур всегда false
+	AdditionalProperties *Schema `json:"-"`
+	// Closed - true, если объект не допускает свойств кроме описанных в Properties
+	Closed bool `json:"-"`
+
+	Items    *Schema `json:"items,omitempty"`
+	MinItems *int    `json:"minItems,omitempty"`
+	MaxItems *int    `json:"maxItems,omitempty"`
+
+	Enum      []any    `json:"enum,omitempty"`
+	MinLength *int     `json:"minLength,omitempty"`
+	MaxLength *int     `json:"maxLength,omitempty"`
+	Minimum   *float64 `json:"minimum,omitempty"`
+	Maximum   *float64 `json:"maximum,omitempty"`
+}
+
+// MarshalJSON функция, которая сериализует схему, включая additionalProperties
+// В JSON Schema additionalProperties может быть как схемой, так и значением false
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	out := struct {
+		*plain
+		AdditionalProperties any `json:"additionalProperties,omitempty"`
+	}{plain: (*plain)(s)}
+
+	switch {
+	case s.AdditionalProperties != nil:
+		out.AdditionalProperties = s.AdditionalProperties
+	case s.Closed:
+		out.AdditionalProperties = false
+	}
+
+	return json.Marshal(out)
+}
+
+// Integer функция, которая создает схему целого числа
+// @return *Schema - схема
+func Integer() *Schema {
+	return &Schema{Type: "integer"}
+}
+
+// String функция, которая создает схему строки
+// @return *Schema - схема
+func String() *Schema {
+	return &Schema{Type: "string"}
+}
+
+// Boolean функция, которая создает схему логического значения
+// @return *Schema - схема
+func Boolean() *Schema {
+	return &Schema{Type: "boolean"}
+}
+
+// WithMinimum функция, которая задает минимальное значение числа
+// @param v float64 - минимальное значение
+// @return *Schema - та же схема
+func (s *Schema) WithMinimum(v float64) *Schema {
+	s.Minimum = &v
+	return s
+}
+
+// WithMaximum функция, которая задает максимальное значение числа
+// @param v float64 - максимальное значение
+// @return *Schema - та же схема
+func (s *Schema) WithMaximum(v float64) *Schema {
+	s.Maximum = &v
+	return s
+}
+
+// WithEnum функция, которая задает допустимые значения
+// @param values ...any - допустимые значения
+// @return *Schema - та же схема
+func (s *Schema) WithEnum(values ...any) *Schema {
+	s.Enum = values
+	return s
+}
+
+// WithMaxLength функция, которая задает максимальную длину строки
+// @param n int - максимальная длина
+// @return *Schema - та же схема
+func (s *Schema) WithMaxLength(n int) *Schema {
+	s.MaxLength = &n
+	return s
+}
+
+// generator структура, которая строит схемы по типам Go и собирает именованные схемы в components
+type generator struct {
+	components map[string]*Schema
+	// names - имена, под которыми типы уже зарегистрированы в components
+	names map[reflect.Type]string
+}
+
+// newGenerator функция, которая создает новый генератор схем
+// @return *generator - новый генератор
+func newGenerator() *generator {
+	return &generator{
+		components: make(map[string]*Schema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor функция, которая возвращает схему для типа Go
+// Именованные структуры выносятся в components и возвращаются в виде ссылки
+// @param t reflect.Type - тип
+// @return *Schema - схема
+func (g *generator) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Boolean()
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return String()
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.ref(t)
+	default:
+		// interface{} и прочие типы допускают любое значение
+		return &Schema{}
+	}
+}
+
+// ref функция, которая регистрирует именованную структуру в components и возвращает ссылку на неё
+// @param t reflect.Type - тип структуры
+// @return *Schema - ссылка на схему
+func (g *generator) ref(t reflect.Type) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = componentName(t)
+		g.names[t] = name
+		// Регистрируем имя до построения схемы, чтобы рекурсивные типы не зацикливались
+		g.components[name] = &Schema{}
+		*g.components[name] = *g.structSchema(t)
+	}
+
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// structSchema функция, которая строит схему объекта по полям структуры
+// Поле обязательно, если в теге json нет omitempty. Тег schema позволяет задать ограничения:
+// schema:"optional,minLength=1,maxLength=255,minimum=1,maximum=100,enum=a|b"
+// Тег doc задает описание поля
+// @param t reflect.Type - тип структуры
+// @return *Schema - схема объекта
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema), Closed: true}
+	g.collectFields(t, schema)
+	return schema
+}
+
+// collectFields функция, которая добавляет поля структуры в схему объекта, раскрывая встроенные структуры
+// @param t reflect.Type - тип структуры
+// @param schema *Schema - схема объекта
+func (g *generator) collectFields(t reflect.Type, schema *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.collectFields(embedded, schema)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := g.schemaFor(field.Type)
+		if doc := field.Tag.Get("doc"); doc != "" {
+			// В OpenAPI 3.1 описание допустимо и рядом с $ref
+			prop.Description = doc
+		}
+
+		required := !strings.Contains(opts, "omitempty")
+		for _, rule := range strings.Split(field.Tag.Get("schema"), ",") {
+			key, value, _ := strings.Cut(rule, "=")
+			switch key {
+			case "optional":
+				required = false
+			case "required":
+				required = true
+			case "minLength":
+				n, _ := strconv.Atoi(value)
+				prop.MinLength = &n
+			case "maxLength":
+				n, _ := strconv.Atoi(value)
+				prop.MaxLength = &n
+			case "minimum":
+				v, _ := strconv.ParseFloat(value, 64)
+				prop.Minimum = &v
+			case "maximum":
+				v, _ := strconv.ParseFloat(value, 64)
+				prop.Maximum = &v
+			case "maxItems":
+				n, _ := strconv.Atoi(value)
+				prop.MaxItems = &n
+			case "enum":
+				for _, v := range strings.Split(value, "|") {
+					prop.Enum = append(prop.Enum, v)
+				}
+			}
+		}
+
+		schema.Properties[name] = prop
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// componentName функция, которая возвращает имя схемы в components для типа
+// @param t reflect.Type - тип
+// @return string - имя схемы
+func componentName(t reflect.Type) string {
+	name := []rune(t.Name())
+	name[0] = unicode.ToUpper(name[0])
+	return string(name)
+}
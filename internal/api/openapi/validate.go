@@ -0,0 +1,376 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+)
+
+// DefaultMaxBodyBytes - максимальный размер тела запроса по умолчанию
+const DefaultMaxBodyBytes = 1 << 20
+
+// Violation нарушение схемы в запросе
+type Violation struct {
+	// Location - часть запроса: path, query или body
+	Location string `json:"location"`
+	// Field - имя параметра или JSON Pointer на поле тела запроса
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationProblem тело ответа с ошибками проверки запроса
+type ValidationProblem struct {
+	Error      string      `json:"error"`
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// validator структура, которая проверяет запрос по схеме операции до вызова обработчика
+type validator struct {
+	operation    *Operation
+	components   map[string]*Schema
+	codecs       *codec.Registry
+	requestType  reflect.Type
+	maxBodyBytes int64
+}
+
+// newValidator функция, которая создает валидатор для операции
+// @param operation *Operation - операция OpenAPI
+// @param components map[string]*Schema - схемы, на которые могут ссылаться схемы операции
+// @param codecs *codec.Registry - форматы тела запроса
+// @param request any - пример значения тела запроса, nil если у операции нет тела
+// @param maxBodyBytes int64 - максимальный размер тела запроса, 0 означает DefaultMaxBodyBytes
+// @return *validator - валидатор
+func newValidator(operation *Operation, components map[string]*Schema, codecs *codec.Registry, request any, maxBodyBytes int64) *validator {
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = DefaultMaxBodyBytes
+	}
+
+	return &validator{
+		operation:    operation,
+		components:   components,
+		codecs:       codecs,
+		requestType:  reflect.TypeOf(request),
+		maxBodyBytes: maxBodyBytes,
+	}
+}
+
+// wrap функция, которая оборачивает обработчик в проверку запроса
+// Нарушения в параметрах возвращаются с кодом 400, нарушения в теле запроса - с кодом 422
+// @param next http.HandlerFunc - обработчик
+// @return http.HandlerFunc - обработчик с проверкой запроса
+func (v *validator) wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if violations := v.validateParams(r); len(violations) > 0 {
+			WriteProblem(w, http.StatusBadRequest, "invalid request parameters", violations)
+			return
+		}
+
+		if v.operation.RequestBody != nil {
+			status, problem, violations := v.validateBody(w, r)
+			if status != 0 {
+				WriteProblem(w, status, problem, violations)
+				return
+			}
+		}
+
+		next(w, r)
+	}
+}
+
+// validateParams функция, которая проверяет параметры пути и строки запроса
+// Заголовки не проверяются: обработчики сами отвечают на их отсутствие специальными кодами, например 428
+// @param r *http.Request - запрос
+// @return []Violation - нарушения
+func (v *validator) validateParams(r *http.Request) []Violation {
+	var violations []Violation
+	query := r.URL.Query()
+
+	for _, param := range v.operation.Parameters {
+		var values []string
+		switch param.In {
+		case "path":
+			values = []string{r.PathValue(param.Name)}
+		case "query":
+			values = query[param.Name]
+		default:
+			continue
+		}
+
+		if len(values) == 0 || (len(values) == 1 && values[0] == "" && param.In == "query") {
+			if param.Required {
+				violations = append(violations, Violation{Location: param.In, Field: param.Name, Message: "is required"})
+			}
+			continue
+		}
+		if len(values) > 1 {
+			violations = append(violations, Violation{Location: param.In, Field: param.Name, Message: "must not be repeated"})
+			continue
+		}
+
+		value, err := parseParam(values[0], param.Schema)
+		if err != nil {
+			violations = append(violations, Violation{Location: param.In, Field: param.Name, Message: err.Error()})
+			continue
+		}
+
+		var paramViolations []Violation
+		v.validateValue(param.Schema, value, "", &paramViolations)
+		for _, violation := range paramViolations {
+			violation.Location, violation.Field = param.In, param.Name
+			violations = append(violations, violation)
+		}
+	}
+
+	return violations
+}
+
+// validateBody функция, которая читает и проверяет тело запроса, после чего возвращает его в r.Body
+// Тело в формате, отличном от JSON, декодируется в тип тела операции и проверяется в виде JSON
+// @param w http.ResponseWriter - ответ
+// @param r *http.Request - запрос
+// @return int - HTTP код ошибки или 0, если тело корректно
+// @return string - описание ошибки
+// @return []Violation - нарушения схемы
+func (v *validator) validateBody(w http.ResponseWriter, r *http.Request) (int, string, []Violation) {
+	bodyCodec, err := v.codecs.ForContentType(r.Header.Get("Content-Type"))
+	if err != nil || !bodyCodec.Supports(v.requestType) {
+		var supported []string
+		for _, c := range v.codecs.Supported(v.requestType) {
+			supported = append(supported, c.MediaTypes()[0])
+		}
+		return http.StatusUnsupportedMediaType, "request body must be one of " + strings.Join(supported, ", "), nil
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, v.maxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return http.StatusRequestEntityTooLarge, fmt.Sprintf("request body must not exceed %d bytes", v.maxBodyBytes), nil
+		}
+		return http.StatusBadRequest, "failed to read request body", nil
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if v.operation.RequestBody.Required {
+			return http.StatusBadRequest, "request body is required", nil
+		}
+		return 0, "", nil
+	}
+
+	if _, isJSON := bodyCodec.(codec.JSON); !isJSON {
+		mediaType := bodyCodec.MediaTypes()[0]
+		if body, err = v.transcode(bodyCodec, body); err != nil {
+			return http.StatusBadRequest, "request body is not valid " + mediaType, []Violation{{Location: "body", Message: err.Error()}}
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return http.StatusBadRequest, "request body is not valid JSON", []Violation{{Location: "body", Message: err.Error()}}
+	}
+	if decoder.More() {
+		return http.StatusBadRequest, "request body must contain a single JSON value", nil
+	}
+
+	var violations []Violation
+	v.validateValue(v.operation.RequestBody.Content["application/json"].Schema, value, "", &violations)
+	for i := range violations {
+		violations[i].Location = "body"
+	}
+	if len(violations) > 0 {
+		return http.StatusUnprocessableEntity, "request body does not match the schema", violations
+	}
+
+	return 0, "", nil
+}
+
+// transcode функция, которая преобразует тело запроса из другого формата в JSON
+// @param bodyCodec codec.Codec - формат тела запроса
+// @param body []byte - тело запроса
+// @return []byte - тело запроса в JSON
+// @return error - ошибка декодирования
+func (v *validator) transcode(bodyCodec codec.Codec, body []byte) ([]byte, error) {
+	value := reflect.New(v.requestType)
+	if err := bodyCodec.Decode(bytes.NewReader(body), value.Interface()); err != nil {
+		return nil, err
+	}
+	return json.Marshal(value.Interface())
+}
+
+// validateValue функция, которая проверяет значение по схеме и собирает все нарушения
+// @param schema *Schema - схема
+// @param value any - значение, полученное из encoding/json с UseNumber
+// @param pointer string - JSON Pointer на значение
+// @param violations *[]Violation - собранные нарушения
+func (v *validator) validateValue(schema *Schema, value any, pointer string, violations *[]Violation) {
+	if schema == nil {
+		return
+	}
+	if schema.Ref != "" {
+		v.validateValue(v.components[strings.TrimPrefix(schema.Ref, "#/components/schemas/")], value, pointer, violations)
+		return
+	}
+
+	fail := func(format string, args ...any) {
+		*violations = append(*violations, Violation{Field: pointer, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				*violations = append(*violations, Violation{Field: pointer + "/" + name, Message: "is required"})
+			}
+		}
+		// Сортируем поля, чтобы порядок нарушений в ответе был стабильным
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			child := pointer + "/" + name
+			switch prop, ok := schema.Properties[name]; {
+			case ok:
+				v.validateValue(prop, object[name], child, violations)
+			case schema.AdditionalProperties != nil:
+				v.validateValue(schema.AdditionalProperties, object[name], child, violations)
+			case schema.Closed:
+				*violations = append(*violations, Violation{Field: child, Message: "unknown field"})
+			}
+		}
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			fail("must be an array")
+			return
+		}
+		if schema.MinItems != nil && len(array) < *schema.MinItems {
+			fail("must contain at least %d items", *schema.MinItems)
+		}
+		if schema.MaxItems != nil && len(array) > *schema.MaxItems {
+			fail("must contain at most %d items", *schema.MaxItems)
+		}
+		for i, item := range array {
+			v.validateValue(schema.Items, item, pointer+"/"+strconv.Itoa(i), violations)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		length := utf8.RuneCountInString(str)
+		if schema.MinLength != nil && length < *schema.MinLength {
+			fail("must be at least %d characters long", *schema.MinLength)
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			fail("must be at most %d characters long", *schema.MaxLength)
+		}
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			fail("must be a %s", schema.Type)
+			return
+		}
+		f, err := number.Float64()
+		if err != nil {
+			fail("must be a %s", schema.Type)
+			return
+		}
+		if _, err := number.Int64(); schema.Type == "integer" && err != nil {
+			fail("must be an integer")
+			return
+		}
+		if schema.Minimum != nil && f < *schema.Minimum {
+			fail("must be greater than or equal to %v", *schema.Minimum)
+		}
+		if schema.Maximum != nil && f > *schema.Maximum {
+			fail("must be less than or equal to %v", *schema.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("must be a boolean")
+			return
+		}
+	}
+
+	if len(schema.Enum) > 0 && !containsValue(schema.Enum, value) {
+		fail("must be one of %v", schema.Enum)
+	}
+}
+
+// parseParam функция, которая преобразует строковое значение параметра к типу из схемы
+// @param raw string - значение параметра
+// @param schema *Schema - схема параметра
+// @return any - значение в том же виде, что возвращает encoding/json с UseNumber
+// @return error - ошибка преобразования
+func parseParam(raw string, schema *Schema) (any, error) {
+	if schema == nil {
+		return raw, nil
+	}
+
+	switch schema.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(raw, 10, 64); err != nil {
+			return nil, errors.New("must be an integer")
+		}
+		return json.Number(raw), nil
+	case "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, errors.New("must be a number")
+		}
+		return json.Number(raw), nil
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, errors.New("must be a boolean")
+		}
+		return b, nil
+	default:
+		return raw, nil
+	}
+}
+
+// containsValue функция, которая проверяет входит ли значение в список допустимых
+// @param enum []any - допустимые значения
+// @param value any - значение
+// @return bool - true, если значение допустимо
+func containsValue(enum []any, value any) bool {
+	for _, allowed := range enum {
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteProblem функция, которая отправляет ответ с ошибками проверки запроса
+// @param w http.ResponseWriter - ответ
+// @param status int - HTTP код
+// @param message string - описание ошибки
+// @param violations []Violation - нарушения
+func WriteProblem(w http.ResponseWriter, status int, message string, violations []Violation) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ValidationProblem{Error: message, Violations: violations})
+}
@@ -0,0 +1,146 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// testItem тестовая модель тела запроса
+type testItem struct {
+	Name  string   `json:"name" schema:"minLength=1,maxLength=5"`
+	Count int      `json:"count,omitempty" schema:"minimum=1"`
+	Kind  string   `json:"kind,omitempty" schema:"enum=a|b"`
+	Tags  []string `json:"tags,omitempty" schema:"maxItems=2"`
+}
+
+// setupValidatorTest регистрирует тестовый маршрут и возвращает маршрутизатор
+func setupValidatorTest(t *testing.T) (*http.ServeMux, *bool) {
+	t.Helper()
+
+	called := false
+	mux := http.NewServeMux()
+	reg := NewRegistry(mux, codec.NewRegistry())
+	reg.Handle("POST /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusNoContent)
+	}, &Op{
+		ID:           "createItem",
+		PathParams:   []Param{{Name: "id", Schema: Integer().WithMinimum(1)}},
+		QueryParams:  []Param{{Name: "mode", Schema: String().WithEnum("fast", "slow")}},
+		Request:      testItem{},
+		MaxBodyBytes: 64,
+		Responses:    map[int]Resp{http.StatusNoContent: {Description: "ok"}},
+	})
+
+	return mux, &called
+}
+
+// TestValidator тестирует проверку запроса по схеме операции
+func TestValidator(t *testing.T) {
+	cases := []struct {
+		name       string
+		target     string
+		body       string
+		status     int
+		violations []Violation
+	}{
+		{
+			name:   "Корректный запрос передается обработчику",
+			target: "/items/1?mode=fast",
+			body:   `{"name":"abc","kind":"a"}`,
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "Все нарушения параметров перечисляются",
+			target: "/items/0?mode=medium",
+			body:   `{"name":"abc"}`,
+			status: http.StatusBadRequest,
+			violations: []Violation{
+				{Location: "path", Field: "id", Message: "must be greater than or equal to 1"},
+				{Location: "query", Field: "mode", Message: "must be one of [fast slow]"},
+			},
+		},
+		{
+			name:   "Все нарушения тела запроса перечисляются",
+			target: "/items/1",
+			body:   `{"count":0,"kind":"c","tags":["x","y","z"],"extra":1}`,
+			status: http.StatusUnprocessableEntity,
+			violations: []Violation{
+				{Location: "body", Field: "/name", Message: "is required"},
+				{Location: "body", Field: "/count", Message: "must be greater than or equal to 1"},
+				{Location: "body", Field: "/extra", Message: "unknown field"},
+				{Location: "body", Field: "/kind", Message: "must be one of [a b]"},
+				{Location: "body", Field: "/tags", Message: "must contain at most 2 items"},
+			},
+		},
+		{
+			name:   "Некорректный JSON возвращает 400",
+			target: "/items/1",
+			body:   `{"name":`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Слишком большое тело возвращает 413",
+			target: "/items/1",
+			body:   `{"name":"` + strings.Repeat("a", 100) + `"}`,
+			status: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mux, called := setupValidatorTest(t)
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tc.target, strings.NewReader(tc.body)))
+
+			assert.Equal(t, tc.status, rec.Code)
+			assert.Equal(t, tc.status == http.StatusNoContent, *called)
+			if tc.violations != nil {
+				var problem ValidationProblem
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+				assert.Equal(t, tc.violations, problem.Violations)
+			}
+		})
+	}
+}
+
+// TestValidatorContentType тестирует проверку тела запроса в разных форматах
+func TestValidatorContentType(t *testing.T) {
+	t.Run("Неподдерживаемый формат возвращает 415", func(t *testing.T) {
+		mux, called := setupValidatorTest(t)
+
+		req := httptest.NewRequest(http.MethodPost, "/items/1", strings.NewReader(`name=abc`))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+		assert.False(t, *called)
+	})
+
+	t.Run("Тело в MessagePack проверяется по той же схеме", func(t *testing.T) {
+		mux, called := setupValidatorTest(t)
+
+		body, err := msgpack.Marshal(map[string]any{"name": "abcdef"})
+		assert.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/items/1", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/msgpack")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		var problem ValidationProblem
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+		assert.Equal(t, []Violation{{Location: "body", Field: "/name", Message: "must be at most 5 characters long"}}, problem.Violations)
+		assert.False(t, *called)
+	})
+}
@@ -4,20 +4,19 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/pers0na2dev/todo-api/internal/api/middleware"
 	"github.com/pers0na2dev/todo-api/internal/config"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
 
-// NewServer создает новый HTTP сервер
-func NewServer(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) *http.ServeMux {
-	// Создание нового маршрутизатора HTTP, который будет использоваться для обработки запросов
-	mux := http.NewServeMux()
-
+// NewServer создает новый HTTP сервер поверх маршрутизатора, в котором обработчики регистрируют свои маршруты
+func NewServer(lc fx.Lifecycle, cfg *config.Config, mux *http.ServeMux, idempotency *middleware.Idempotency, logger *zap.Logger) {
 	// Создание нового HTTP сервера с заданным адресом и маршрутизатором
+	// POST запросы с заголовком Idempotency-Key проходят через middleware идемпотентности
 	server := &http.Server{
 		Addr:    cfg.HTTPPort,
-		Handler: mux,
+		Handler: idempotency.Wrap(mux),
 	}
 
 	// Добавление хука жизненного цикла fx для запуска и завершения сервера
@@ -32,6 +31,4 @@ func NewServer(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) *http.Se
 			return server.Shutdown(ctx) // Завершение работы HTTP сервера
 		},
 	})
-
-	return mux
 }
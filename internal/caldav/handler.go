@@ -0,0 +1,485 @@
+// Package caldav реализует сервер CalDAV (RFC 4791) для синхронизации задач с приложениями,
+// которые работают с VTODO, например Apple Reminders, Thunderbird и Tasks.org
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+	"github.com/pers0na2dev/todo-api/internal/config"
+	"github.com/pers0na2dev/todo-api/internal/ical"
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"go.uber.org/zap"
+)
+
+// Пути ресурсов CalDAV. Корень является принципалом, в домашней коллекции один календарь со всеми задачами
+const (
+	rootPath     = "/dav/"
+	homePath     = "/dav/calendars/"
+	calendarPath = "/dav/calendars/tasks/"
+	// objectSuffix - расширение ресурсов задач
+	objectSuffix = ".ics"
+)
+
+// allowedMethods - методы, которые поддерживает сервер
+const allowedMethods = "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, PROPPATCH, REPORT"
+
+// TaskService интерфейс, который определяет методы сервиса задач, нужные CalDAV
+type TaskService interface {
+	ExportTasks(ctx context.Context, fn func(*models.TaskRecord) error) error
+	GetTaskRecord(ctx context.Context, externalID string, id int) (*models.TaskRecord, error)
+	PutTaskRecord(ctx context.Context, record models.TaskRecord, version int) (*models.Task, bool, error)
+	RemoveTask(ctx context.Context, id int, version int) error
+}
+
+// resourceKind тип ресурса CalDAV
+type resourceKind int
+
+const (
+	kindPrincipal resourceKind = iota
+	kindHome
+	kindCalendar
+	kindObject
+)
+
+// resource ресурс CalDAV
+type resource struct {
+	kind resourceKind
+	href string
+	// record - задача ресурса kindObject
+	record *models.TaskRecord
+	// ctag - отпечаток всех задач календаря, заполняется для kindCalendar
+	ctag string
+}
+
+// Handler структура, которая обрабатывает запросы CalDAV
+type Handler struct {
+	taskService TaskService
+	// loc - часовой пояс, в котором записываются сроки задач
+	loc    *time.Location
+	logger *zap.Logger
+}
+
+// NewHandler функция, которая создает обработчик CalDAV и монтирует его на /dav/
+// /.well-known/caldav перенаправляет на /dav/, чтобы клиенты находили сервер по адресу хоста (RFC 6764)
+// Маршруты не описываются в OpenAPI: WebDAV использует методы PROPFIND и REPORT, которых нет в OpenAPI
+// @param taskService TaskService - сервис задач
+// @param reg *openapi.Registry - реестр маршрутов
+// @param cfg *config.Config - конфигурация, из CALENDAR_TIMEZONE берется пояс сроков задач
+// @param logger *zap.Logger - логгер
+// @return *Handler - обработчик CalDAV
+// @return error - ошибка, если часовой пояс неизвестен
+func NewHandler(taskService TaskService, reg *openapi.Registry, cfg *config.Config, logger *zap.Logger) (*Handler, error) {
+	loc, err := cfg.CalendarLocation()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CALENDAR_TIMEZONE: %w", err)
+	}
+
+	handler := &Handler{taskService: taskService, loc: loc, logger: logger}
+	reg.Handle(rootPath, handler.ServeHTTP, nil)
+	reg.Handle("/.well-known/caldav", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, rootPath, http.StatusMovedPermanently)
+	}, nil)
+
+	return handler, nil
+}
+
+// ServeHTTP функция, которая выбирает обработку запроса по методу
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("DAV", "1, 3, calendar-access")
+
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Allow", allowedMethods)
+		w.WriteHeader(http.StatusOK)
+	case "PROPFIND":
+		h.propfind(w, r)
+	case "PROPPATCH":
+		h.proppatch(w, r)
+	case "REPORT":
+		h.report(w, r)
+	case http.MethodGet, http.MethodHead:
+		h.get(w, r)
+	case http.MethodPut:
+		h.put(w, r)
+	case http.MethodDelete:
+		h.delete(w, r)
+	default:
+		w.Header().Set("Allow", allowedMethods)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// propfind функция, которая возвращает свойства ресурса и, при Depth: 1, его дочерних ресурсов
+func (h *Handler) propfind(w http.ResponseWriter, r *http.Request) {
+	depth := r.Header.Get("Depth")
+	if depth != "0" && depth != "1" {
+		// Без Depth PROPFIND обходит все дерево, а для календаря это выгрузка всех задач
+		writeError(w, http.StatusForbidden, xml.Name{Space: nsDAV, Local: "propfind-finite-depth"})
+		return
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if body != nil && !body.is(nsDAV, "propfind") {
+		http.Error(w, "propfind element is expected", http.StatusBadRequest)
+		return
+	}
+	pf := parsePropfind(body)
+
+	res, err := h.resolve(r.Context(), r.URL.EscapedPath())
+	if errors.Is(err, models.ErrTaskNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		h.internalError(w, err)
+		return
+	}
+
+	ms := newMultistatus()
+	switch {
+	case res.kind == kindPrincipal && depth == "1":
+		h.writeProps(ms, &resource{kind: kindHome, href: homePath}, pf)
+	case res.kind == kindHome && depth == "1":
+		calendar := &resource{kind: kindCalendar, href: calendarPath}
+		if calendar.ctag, err = h.listCalendar(r.Context(), nil); err != nil {
+			h.internalError(w, err)
+			return
+		}
+		h.writeProps(ms, calendar, pf)
+	case res.kind == kindCalendar:
+		// ctag вычисляется при том же чтении задач, которым перечисляются ресурсы календаря
+		var list func(*models.TaskRecord)
+		if depth == "1" {
+			list = func(record *models.TaskRecord) { h.writeProps(ms, objectResource(record), pf) }
+		}
+		if res.ctag, err = h.listCalendar(r.Context(), list); err != nil {
+			h.internalError(w, err)
+			return
+		}
+	}
+	h.writeProps(ms, res, pf)
+	ms.writeTo(w)
+}
+
+// proppatch функция, которая отклоняет изменение свойств
+// Название и цвет календаря не хранятся, поэтому каждое свойство получает 403, а клиент оставляет свои значения
+func (h *Handler) proppatch(w http.ResponseWriter, r *http.Request) {
+	body, err := readBody(r)
+	if err != nil || body == nil || !body.is(nsDAV, "propertyupdate") {
+		http.Error(w, "propertyupdate element is expected", http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.resolve(r.Context(), r.URL.EscapedPath())
+	if errors.Is(err, models.ErrTaskNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		h.internalError(w, err)
+		return
+	}
+
+	var props []xml.Name
+	for _, update := range body.Children {
+		if prop := update.child(nsDAV, "prop"); prop != nil {
+			for _, p := range prop.Children {
+				props = append(props, p.XMLName)
+			}
+		}
+	}
+
+	ms := newMultistatus()
+	ms.forbidden(res.href, props)
+	ms.writeTo(w)
+}
+
+// get функция, которая возвращает задачу в формате iCalendar
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	uid, ok := objectUID(r.URL.EscapedPath())
+	if !ok {
+		w.Header().Set("Allow", allowedMethods)
+		http.Error(w, "only task resources can be downloaded", http.StatusMethodNotAllowed)
+		return
+	}
+
+	record, err := h.findRecord(r.Context(), uid)
+	if errors.Is(err, models.ErrTaskNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		h.internalError(w, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := ical.WriteTodo(&buf, record, h.loc, time.Now()); err != nil {
+		h.internalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", objectContentType)
+	w.Header().Set("ETag", objectETag(record))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(buf.Bytes())
+	}
+}
+
+// put функция, которая создает или заменяет задачу из VTODO
+// Имя ресурса должно совпадать с UID задачи, так как ссылки на ресурсы строятся из UID
+func (h *Handler) put(w http.ResponseWriter, r *http.Request) {
+	uid, ok := objectUID(r.URL.EscapedPath())
+	if !ok {
+		w.Header().Set("Allow", allowedMethods)
+		http.Error(w, "tasks can only be stored inside "+calendarPath, http.StatusMethodNotAllowed)
+		return
+	}
+	if mediaType, _, _ := strings.Cut(r.Header.Get("Content-Type"), ";"); !strings.EqualFold(strings.TrimSpace(mediaType), ical.MediaType) {
+		writeError(w, http.StatusUnsupportedMediaType, xml.Name{Space: nsCalDAV, Local: "supported-calendar-data"})
+		return
+	}
+
+	calendar, err := ical.Decode(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		writeError(w, http.StatusForbidden, xml.Name{Space: nsCalDAV, Local: "valid-calendar-data"})
+		return
+	}
+	if calendar.Name != "VCALENDAR" || len(calendar.Children("VTODO")) != 1 {
+		// В календаре задач хранятся только VTODO, по одной задаче на ресурс
+		writeError(w, http.StatusForbidden, xml.Name{Space: nsCalDAV, Local: "supported-calendar-component"})
+		return
+	}
+	records, err := ical.RecordsFromCalendar(calendar, h.loc)
+	if err != nil {
+		writeError(w, http.StatusForbidden, xml.Name{Space: nsCalDAV, Local: "valid-calendar-data"})
+		return
+	}
+	record := records[0]
+	if record.ExternalID != uid {
+		http.Error(w, fmt.Sprintf("resource name must be the task UID followed by %s", objectSuffix), http.StatusBadRequest)
+		return
+	}
+
+	existing, err := h.findRecord(r.Context(), uid)
+	if err != nil && !errors.Is(err, models.ErrTaskNotFound) {
+		h.internalError(w, err)
+		return
+	}
+	version, ok := checkPreconditions(r, existing)
+	if !ok {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	// Задачи, созданные через API, не имеют внешнего id и изменяются по id из их UID
+	if existing != nil && existing.ExternalID == "" {
+		record.ID, record.ExternalID = existing.ID, ""
+	}
+
+	_, created, err := h.taskService.PutTaskRecord(r.Context(), record, version)
+	switch {
+	case errors.Is(err, models.ErrInvalidTask):
+		writeError(w, http.StatusForbidden, xml.Name{Space: nsCalDAV, Local: "valid-calendar-object-resource"})
+	case errors.Is(err, models.ErrVersionConflict), errors.Is(err, models.ErrTaskNotFound):
+		w.WriteHeader(http.StatusPreconditionFailed)
+	case err != nil:
+		h.internalError(w, err)
+	case created:
+		// ETag не возвращается, так как сохраняются не все свойства VTODO и клиент должен перечитать задачу
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// delete функция, которая удаляет задачу
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request) {
+	uid, ok := objectUID(r.URL.EscapedPath())
+	if !ok {
+		http.Error(w, "only task resources can be deleted", http.StatusForbidden)
+		return
+	}
+
+	existing, err := h.findRecord(r.Context(), uid)
+	if errors.Is(err, models.ErrTaskNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		h.internalError(w, err)
+		return
+	}
+	version, ok := checkPreconditions(r, existing)
+	if !ok {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	if version == 0 {
+		version = existing.Version
+	}
+
+	err = h.taskService.RemoveTask(r.Context(), existing.ID, version)
+	switch {
+	case errors.Is(err, models.ErrVersionConflict):
+		w.WriteHeader(http.StatusPreconditionFailed)
+	case errors.Is(err, models.ErrTaskNotFound):
+		http.NotFound(w, r)
+	case err != nil:
+		h.internalError(w, err)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// checkPreconditions функция, которая проверяет заголовки If-Match и If-None-Match
+// @param r *http.Request - запрос
+// @param existing *models.TaskRecord - текущая задача или nil, если ресурса нет
+// @return int - версия из If-Match, которую нужно проверить при изменении, или 0
+// @return bool - false, если условие не выполнено и нужно вернуть 412
+func checkPreconditions(r *http.Request, existing *models.TaskRecord) (int, bool) {
+	if noneMatch := r.Header.Get("If-None-Match"); noneMatch != "" {
+		if existing != nil && (noneMatch == "*" || noneMatch == objectETag(existing)) {
+			return 0, false
+		}
+	}
+
+	match := r.Header.Get("If-Match")
+	if match == "" {
+		return 0, true
+	}
+	if existing == nil {
+		return 0, false
+	}
+	if match == "*" {
+		return 0, true
+	}
+	version, err := strconv.Atoi(strings.Trim(match, `"`))
+	if err != nil || match != objectETag(&models.TaskRecord{Version: version}) {
+		return 0, false
+	}
+	return version, true
+}
+
+// resolve функция, которая находит ресурс по пути
+// @param ctx context.Context - контекст выполнения
+// @param path string - экранированный путь запроса
+// @return *resource - ресурс
+// @return error - ошибка, models.ErrTaskNotFound если ресурса нет
+func (h *Handler) resolve(ctx context.Context, path string) (*resource, error) {
+	switch strings.TrimSuffix(path, "/") + "/" {
+	case rootPath:
+		return &resource{kind: kindPrincipal, href: rootPath}, nil
+	case homePath:
+		return &resource{kind: kindHome, href: homePath}, nil
+	case calendarPath:
+		return &resource{kind: kindCalendar, href: calendarPath}, nil
+	}
+
+	uid, ok := objectUID(path)
+	if !ok {
+		return nil, models.ErrTaskNotFound
+	}
+	record, err := h.findRecord(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return objectResource(record), nil
+}
+
+// listCalendar функция, которая перечисляет задачи календаря и вычисляет его ctag
+// ctag - хеш UID и версий всех задач, он меняется при создании, изменении и удалении любой задачи
+// @param ctx context.Context - контекст выполнения
+// @param fn func(*models.TaskRecord) - обработчик задачи, может быть nil
+// @return string - ctag
+// @return error - ошибка
+func (h *Handler) listCalendar(ctx context.Context, fn func(*models.TaskRecord)) (string, error) {
+	hash := sha256.New()
+	err := h.taskService.ExportTasks(ctx, func(record *models.TaskRecord) error {
+		fmt.Fprintf(hash, "%s\x00%d\x00", ical.TaskUID(record), record.Version)
+		if fn != nil {
+			fn(record)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)[:16]), nil
+}
+
+// findRecord функция, которая находит задачу по UID
+// @param ctx context.Context - контекст выполнения
+// @param uid string - UID задачи
+// @return *models.TaskRecord - задача
+// @return error - ошибка, models.ErrTaskNotFound если задачи нет
+func (h *Handler) findRecord(ctx context.Context, uid string) (*models.TaskRecord, error) {
+	return h.taskService.GetTaskRecord(ctx, uid, ical.ParseTaskUID(uid))
+}
+
+// internalError функция, которая логирует ошибку и возвращает 500
+// @param w http.ResponseWriter - ответ
+// @param err error - ошибка
+func (h *Handler) internalError(w http.ResponseWriter, err error) {
+	h.logger.Error("caldav request failed", zap.Error(err))
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// objectResource функция, которая создает ресурс задачи
+// @param record *models.TaskRecord - задача
+// @return *resource - ресурс
+func objectResource(record *models.TaskRecord) *resource {
+	return &resource{kind: kindObject, href: objectHref(ical.TaskUID(record)), record: record}
+}
+
+// objectHref функция, которая возвращает ссылку на ресурс задачи
+// @param uid string - UID задачи
+// @return string - экранированный путь
+func objectHref(uid string) string {
+	return calendarPath + url.PathEscape(uid) + objectSuffix
+}
+
+// objectUID функция, которая извлекает UID задачи из пути ресурса
+// @param path string - экранированный путь
+// @return string - UID
+// @return bool - false, если путь не указывает на ресурс задачи
+func objectUID(path string) (string, bool) {
+	name, ok := strings.CutPrefix(path, calendarPath)
+	if !ok || strings.Contains(name, "/") {
+		return "", false
+	}
+	name, ok = strings.CutSuffix(name, objectSuffix)
+	if !ok || name == "" {
+		return "", false
+	}
+	uid, err := url.PathUnescape(name)
+	if err != nil {
+		return "", false
+	}
+	return uid, true
+}
+
+// objectETag функция, которая формирует ETag ресурса задачи из её версии
+// @param record *models.TaskRecord - задача
+// @return string - значение заголовка ETag
+func objectETag(record *models.TaskRecord) string {
+	return `"` + strconv.Itoa(record.Version) + `"`
+}
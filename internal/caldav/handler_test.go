@@ -0,0 +1,359 @@
+package caldav
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+	"github.com/pers0na2dev/todo-api/internal/caldav/mocks"
+	"github.com/pers0na2dev/todo-api/internal/config"
+	"github.com/pers0na2dev/todo-api/internal/ical"
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// testResponse ответ ресурса в Multi-Status
+type testResponse struct {
+	Href      string `xml:"DAV: href"`
+	Status    string `xml:"DAV: status"`
+	Propstats []struct {
+		Prop   node   `xml:"DAV: prop"`
+		Status string `xml:"DAV: status"`
+	} `xml:"DAV: propstat"`
+}
+
+// prop функция, которая возвращает свойство ответа и статус его propstat
+func (r *testResponse) prop(space, local string) (*node, string) {
+	for _, ps := range r.Propstats {
+		if p := ps.Prop.child(space, local); p != nil {
+			return p, ps.Status
+		}
+	}
+	return nil, ""
+}
+
+// setupCalDAVTest подготавливает маршрутизатор с сервером CalDAV и мок сервиса задач
+func setupCalDAVTest(t *testing.T) (*http.ServeMux, *mocks.TaskService) {
+	t.Helper()
+
+	mockService := new(mocks.TaskService)
+	mux := http.NewServeMux()
+	_, err := NewHandler(mockService, openapi.NewRegistry(mux, codec.NewRegistry()), &config.Config{CalendarTimezone: "Europe/Berlin"}, zap.NewNop())
+	require.NoError(t, err)
+
+	return mux, mockService
+}
+
+// serve выполняет запрос CalDAV
+func serve(mux *http.ServeMux, method, target, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeMultistatus разбирает ответ Multi-Status в ответы ресурсов по ссылкам
+func decodeMultistatus(t *testing.T, rec *httptest.ResponseRecorder) map[string]*testResponse {
+	t.Helper()
+
+	require.Equal(t, http.StatusMultiStatus, rec.Code, rec.Body.String())
+	var ms struct {
+		Responses []testResponse `xml:"DAV: response"`
+	}
+	require.NoError(t, xml.Unmarshal(rec.Body.Bytes(), &ms))
+
+	responses := make(map[string]*testResponse, len(ms.Responses))
+	for i := range ms.Responses {
+		responses[ms.Responses[i].Href] = &ms.Responses[i]
+	}
+	return responses
+}
+
+// TestDiscovery тестирует поиск календаря клиентом
+func TestDiscovery(t *testing.T) {
+	mux, mockService := setupCalDAVTest(t)
+
+	t.Run("well-known перенаправляет на корень", func(t *testing.T) {
+		rec := serve(mux, "PROPFIND", "/.well-known/caldav", "", nil)
+
+		assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+		assert.Equal(t, rootPath, rec.Header().Get("Location"))
+	})
+
+	t.Run("OPTIONS сообщает о поддержке calendar-access", func(t *testing.T) {
+		rec := serve(mux, http.MethodOptions, calendarPath, "", nil)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("DAV"), "calendar-access")
+		assert.Contains(t, rec.Header().Get("Allow"), "REPORT")
+	})
+
+	t.Run("Принципал указывает на домашнюю коллекцию", func(t *testing.T) {
+		body := `<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+			<d:prop><d:current-user-principal/><c:calendar-home-set/><d:unknown/></d:prop></d:propfind>`
+		responses := decodeMultistatus(t, serve(mux, "PROPFIND", rootPath, body, map[string]string{"Depth": "0"}))
+
+		principal := responses[rootPath]
+		require.NotNil(t, principal)
+		home, status := principal.prop(nsCalDAV, "calendar-home-set")
+		require.NotNil(t, home)
+		assert.Equal(t, homePath, home.child(nsDAV, "href").Text)
+		assert.Contains(t, status, "200")
+		_, status = principal.prop(nsDAV, "unknown")
+		assert.Contains(t, status, "404")
+	})
+
+	t.Run("Домашняя коллекция содержит календарь задач", func(t *testing.T) {
+		mockService.On("ExportTasks", mock.Anything, mock.Anything).Return(nil).Once()
+
+		body := `<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+			<d:prop><d:resourcetype/><c:supported-calendar-component-set/></d:prop></d:propfind>`
+		responses := decodeMultistatus(t, serve(mux, "PROPFIND", homePath, body, map[string]string{"Depth": "1"}))
+
+		calendar := responses[calendarPath]
+		require.NotNil(t, calendar)
+		resourceType, _ := calendar.prop(nsDAV, "resourcetype")
+		assert.NotNil(t, resourceType.child(nsCalDAV, "calendar"))
+		components, _ := calendar.prop(nsCalDAV, "supported-calendar-component-set")
+		assert.Equal(t, "VTODO", components.child(nsCalDAV, "comp").attr("name"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("PROPFIND без Depth отклоняется", func(t *testing.T) {
+		rec := serve(mux, "PROPFIND", calendarPath, "", nil)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), "propfind-finite-depth")
+	})
+}
+
+// TestCalendarListing тестирует перечисление задач календаря
+func TestCalendarListing(t *testing.T) {
+	mux, mockService := setupCalDAVTest(t)
+	dueAt := time.Date(2024, 12, 20, 17, 0, 0, 0, time.UTC)
+	records := []*models.TaskRecord{
+		{ID: 1, Title: "Сдать отчет", Version: 2, DueAt: &dueAt},
+		{ID: 2, ExternalID: "apple/uid 1", Title: "Позвонить", Completed: true, Version: 1},
+	}
+	export := func(args mock.Arguments) {
+		fn := args.Get(1).(func(*models.TaskRecord) error)
+		for _, record := range records {
+			fn(record)
+		}
+	}
+	propfind := `<d:propfind xmlns:d="DAV:" xmlns:cs="http://calendarserver.org/ns/"><d:prop><d:getetag/><cs:getctag/></d:prop></d:propfind>`
+
+	t.Run("Depth 1 возвращает ctag календаря и ETag задач", func(t *testing.T) {
+		mockService.On("ExportTasks", mock.Anything, mock.Anything).Run(export).Return(nil).Once()
+
+		responses := decodeMultistatus(t, serve(mux, "PROPFIND", calendarPath, propfind, map[string]string{"Depth": "1"}))
+
+		assert.Len(t, responses, 3)
+		ctag, _ := responses[calendarPath].prop(nsCS, "getctag")
+		require.NotNil(t, ctag)
+		assert.NotEmpty(t, ctag.Text)
+
+		etag, _ := responses[calendarPath+"task-1@todo-api.ics"].prop(nsDAV, "getetag")
+		assert.Equal(t, `"2"`, etag.Text)
+		// UID с "/" и пробелом экранируется в ссылке
+		assert.NotNil(t, responses[calendarPath+"apple%2Fuid%201.ics"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("ctag меняется при изменении задачи", func(t *testing.T) {
+		mockService.On("ExportTasks", mock.Anything, mock.Anything).Run(export).Return(nil).Once()
+		before, _ := decodeMultistatus(t, serve(mux, "PROPFIND", calendarPath, propfind, map[string]string{"Depth": "0"}))[calendarPath].prop(nsCS, "getctag")
+
+		records[1].Version++
+		mockService.On("ExportTasks", mock.Anything, mock.Anything).Run(export).Return(nil).Once()
+		after, _ := decodeMultistatus(t, serve(mux, "PROPFIND", calendarPath, propfind, map[string]string{"Depth": "0"}))[calendarPath].prop(nsCS, "getctag")
+
+		assert.NotEqual(t, before.Text, after.Text)
+	})
+
+	t.Run("calendar-query отбирает невыполненные задачи", func(t *testing.T) {
+		mockService.On("ExportTasks", mock.Anything, mock.Anything).Run(export).Return(nil).Once()
+
+		body := `<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+			<d:prop><d:getetag/><c:calendar-data/></d:prop>
+			<c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VTODO">
+				<c:prop-filter name="STATUS"><c:text-match negate-condition="yes">COMPLETED</c:text-match></c:prop-filter>
+			</c:comp-filter></c:comp-filter></c:filter>
+		</c:calendar-query>`
+		responses := decodeMultistatus(t, serve(mux, "REPORT", calendarPath, body, map[string]string{"Depth": "1"}))
+
+		require.Len(t, responses, 1)
+		data, _ := responses[calendarPath+"task-1@todo-api.ics"].prop(nsCalDAV, "calendar-data")
+		require.NotNil(t, data)
+		calendar, err := ical.Decode(strings.NewReader(data.Text))
+		require.NoError(t, err)
+		assert.Equal(t, "Сдать отчет", calendar.Children("VTODO")[0].Text("SUMMARY"))
+		assert.Len(t, calendar.Children("VTIMEZONE"), 1)
+	})
+
+	t.Run("calendar-query отбирает задачи по сроку", func(t *testing.T) {
+		mockService.On("ExportTasks", mock.Anything, mock.Anything).Run(export).Return(nil).Once()
+
+		body := `<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+			<d:prop><d:getetag/></d:prop>
+			<c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VTODO">
+				<c:time-range start="20241221T000000Z"/>
+			</c:comp-filter></c:comp-filter></c:filter>
+		</c:calendar-query>`
+		responses := decodeMultistatus(t, serve(mux, "REPORT", calendarPath, body, nil))
+
+		// Задача со сроком 20 декабря не попадает в интервал, задача без срока попадает в любой
+		require.Len(t, responses, 1)
+		assert.NotNil(t, responses[calendarPath+"apple%2Fuid%201.ics"])
+	})
+
+	t.Run("calendar-multiget возвращает задачи по ссылкам", func(t *testing.T) {
+		mockService.On("GetTaskRecord", mock.Anything, "task-1@todo-api", 1).Return(records[0], nil).Once()
+		mockService.On("GetTaskRecord", mock.Anything, "gone", 0).Return(nil, models.ErrTaskNotFound).Once()
+
+		body := `<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+			<d:prop><d:getetag/></d:prop>
+			<d:href>/dav/calendars/tasks/task-1@todo-api.ics</d:href>
+			<d:href>/dav/calendars/tasks/gone.ics</d:href>
+		</c:calendar-multiget>`
+		responses := decodeMultistatus(t, serve(mux, "REPORT", calendarPath, body, nil))
+
+		etag, _ := responses[calendarPath+"task-1@todo-api.ics"].prop(nsDAV, "getetag")
+		assert.Equal(t, `"2"`, etag.Text)
+		assert.Contains(t, responses[calendarPath+"gone.ics"].Status, "404")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Неподдерживаемый отчет отклоняется", func(t *testing.T) {
+		rec := serve(mux, "REPORT", calendarPath, `<d:sync-collection xmlns:d="DAV:"/>`, nil)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), "supported-report")
+	})
+}
+
+// TestObjects тестирует чтение, запись и удаление задач
+func TestObjects(t *testing.T) {
+	mux, mockService := setupCalDAVTest(t)
+	todo := func(uid, summary string) string {
+		return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nUID:" + uid + "\r\nSUMMARY:" + summary +
+			"\r\nSTATUS:COMPLETED\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+	}
+	calendarType := map[string]string{"Content-Type": "text/calendar; charset=utf-8"}
+
+	t.Run("GET возвращает задачу с ETag", func(t *testing.T) {
+		mockService.On("GetTaskRecord", mock.Anything, "task-3@todo-api", 3).
+			Return(&models.TaskRecord{ID: 3, Title: "Задача", Version: 4}, nil).Once()
+
+		rec := serve(mux, http.MethodGet, calendarPath+"task-3@todo-api.ics", "", nil)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `"4"`, rec.Header().Get("ETag"))
+		assert.Contains(t, rec.Body.String(), "UID:task-3@todo-api\r\n")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("PUT создает задачу с UID в качестве внешнего id", func(t *testing.T) {
+		mockService.On("GetTaskRecord", mock.Anything, "new-uid", 0).Return(nil, models.ErrTaskNotFound).Once()
+		mockService.On("PutTaskRecord", mock.Anything, models.TaskRecord{ExternalID: "new-uid", Title: "Купить молоко", Completed: true}, 0).
+			Return(&models.Task{ID: 10, Title: "Купить молоко", Completed: true, Version: 1}, true, nil).Once()
+
+		headers := map[string]string{"Content-Type": "text/calendar", "If-None-Match": "*"}
+		rec := serve(mux, http.MethodPut, calendarPath+"new-uid.ics", todo("new-uid", "Купить молоко"), headers)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Empty(t, rec.Header().Get("ETag"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("PUT задачи, созданной через API, изменяет её по id с проверкой версии", func(t *testing.T) {
+		mockService.On("GetTaskRecord", mock.Anything, "task-3@todo-api", 3).
+			Return(&models.TaskRecord{ID: 3, Title: "Задача", Version: 4}, nil).Once()
+		mockService.On("PutTaskRecord", mock.Anything, models.TaskRecord{ID: 3, Title: "Задача", Completed: true}, 4).
+			Return(&models.Task{ID: 3, Title: "Задача", Completed: true, Version: 5}, false, nil).Once()
+
+		headers := map[string]string{"Content-Type": "text/calendar", "If-Match": `"4"`}
+		rec := serve(mux, http.MethodPut, calendarPath+"task-3@todo-api.ics", todo("task-3@todo-api", "Задача"), headers)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("PUT с устаревшим ETag возвращает 412", func(t *testing.T) {
+		mockService.On("GetTaskRecord", mock.Anything, "task-3@todo-api", 3).
+			Return(&models.TaskRecord{ID: 3, Title: "Задача", Version: 5}, nil).Once()
+		mockService.On("PutTaskRecord", mock.Anything, mock.Anything, 4).Return(nil, false, models.ErrVersionConflict).Once()
+
+		headers := map[string]string{"Content-Type": "text/calendar", "If-Match": `"4"`}
+		rec := serve(mux, http.MethodPut, calendarPath+"task-3@todo-api.ics", todo("task-3@todo-api", "Задача"), headers)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("PUT с If-None-Match для существующей задачи возвращает 412", func(t *testing.T) {
+		mockService.On("GetTaskRecord", mock.Anything, "uid", 0).
+			Return(&models.TaskRecord{ID: 7, ExternalID: "uid", Title: "Задача", Version: 1}, nil).Once()
+
+		headers := map[string]string{"Content-Type": "text/calendar", "If-None-Match": "*"}
+		rec := serve(mux, http.MethodPut, calendarPath+"uid.ics", todo("uid", "Задача"), headers)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Имя ресурса должно совпадать с UID", func(t *testing.T) {
+		rec := serve(mux, http.MethodPut, calendarPath+"other.ics", todo("uid", "Задача"), calendarType)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("VEVENT не принимается", func(t *testing.T) {
+		body := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:e\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+		rec := serve(mux, http.MethodPut, calendarPath+"e.ics", body, calendarType)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), "supported-calendar-component")
+	})
+
+	t.Run("Задача без названия отклоняется", func(t *testing.T) {
+		mockService.On("GetTaskRecord", mock.Anything, "empty", 0).Return(nil, models.ErrTaskNotFound).Once()
+		mockService.On("PutTaskRecord", mock.Anything, mock.Anything, 0).Return(nil, false, models.ErrInvalidTask).Once()
+
+		rec := serve(mux, http.MethodPut, calendarPath+"empty.ics", todo("empty", ""), calendarType)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), "valid-calendar-object-resource")
+	})
+
+	t.Run("DELETE удаляет задачу текущей версии", func(t *testing.T) {
+		mockService.On("GetTaskRecord", mock.Anything, "uid", 0).
+			Return(&models.TaskRecord{ID: 7, ExternalID: "uid", Title: "Задача", Version: 2}, nil).Once()
+		mockService.On("RemoveTask", mock.Anything, 7, 2).Return(nil).Once()
+
+		rec := serve(mux, http.MethodDelete, calendarPath+"uid.ics", "", nil)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("DELETE несуществующей задачи возвращает 404", func(t *testing.T) {
+		mockService.On("GetTaskRecord", mock.Anything, "gone", 0).Return(nil, models.ErrTaskNotFound).Once()
+
+		rec := serve(mux, http.MethodDelete, calendarPath+"gone.ics", "", nil)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		mockService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,43 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+// TaskService это автоматически сгенерированный мок для интерфейса TaskService
+type TaskService struct {
+	mock.Mock
+}
+
+// ExportTasks мок для метода ExportTasks
+func (m *TaskService) ExportTasks(ctx context.Context, fn func(*models.TaskRecord) error) error {
+	args := m.Called(ctx, fn)
+	return args.Error(0)
+}
+
+// GetTaskRecord мок для метода GetTaskRecord
+func (m *TaskService) GetTaskRecord(ctx context.Context, externalID string, id int) (*models.TaskRecord, error) {
+	args := m.Called(ctx, externalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TaskRecord), args.Error(1)
+}
+
+// PutTaskRecord мок для метода PutTaskRecord
+func (m *TaskService) PutTaskRecord(ctx context.Context, record models.TaskRecord, version int) (*models.Task, bool, error) {
+	args := m.Called(ctx, record, version)
+	if args.Get(0) == nil {
+		return nil, false, args.Error(2)
+	}
+	return args.Get(0).(*models.Task), args.Bool(1), args.Error(2)
+}
+
+// RemoveTask мок для метода RemoveTask
+func (m *TaskService) RemoveTask(ctx context.Context, id int, version int) error {
+	args := m.Called(ctx, id, version)
+	return args.Error(0)
+}
@@ -0,0 +1,141 @@
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/ical"
+)
+
+// objectContentType - тип содержимого ресурсов задач
+const objectContentType = ical.MediaType + "; charset=utf-8; component=VTODO"
+
+// Свойства, которые возвращаются сервером
+var (
+	propResourceType          = xml.Name{Space: nsDAV, Local: "resourcetype"}
+	propDisplayName           = xml.Name{Space: nsDAV, Local: "displayname"}
+	propGetETag               = xml.Name{Space: nsDAV, Local: "getetag"}
+	propGetContentType        = xml.Name{Space: nsDAV, Local: "getcontenttype"}
+	propCurrentUserPrincipal  = xml.Name{Space: nsDAV, Local: "current-user-principal"}
+	propPrincipalURL          = xml.Name{Space: nsDAV, Local: "principal-URL"}
+	propOwner                 = xml.Name{Space: nsDAV, Local: "owner"}
+	propSupportedReportSet    = xml.Name{Space: nsDAV, Local: "supported-report-set"}
+	propCurrentUserPrivileges = xml.Name{Space: nsDAV, Local: "current-user-privilege-set"}
+	propCalendarHomeSet       = xml.Name{Space: nsCalDAV, Local: "calendar-home-set"}
+	propSupportedComponents   = xml.Name{Space: nsCalDAV, Local: "supported-calendar-component-set"}
+	propCalendarData          = xml.Name{Space: nsCalDAV, Local: "calendar-data"}
+	propGetCTag               = xml.Name{Space: nsCS, Local: "getctag"}
+)
+
+// allProps - свойства, которые возвращаются на allprop
+// calendar-data в allprop не входит (RFC 4791, раздел 9.6)
+var allProps = []xml.Name{
+	propResourceType, propDisplayName, propGetETag, propGetContentType, propCurrentUserPrincipal, propPrincipalURL,
+	propOwner, propSupportedReportSet, propCurrentUserPrivileges, propCalendarHomeSet, propSupportedComponents, propGetCTag,
+}
+
+// writeProps функция, которая добавляет в ответ запрошенные свойства ресурса
+// @param ms *multistatus - ответ
+// @param res *resource - ресурс
+// @param pf propfind - запрошенные свойства
+func (h *Handler) writeProps(ms *multistatus, res *resource, pf propfind) {
+	names := pf.props
+	if pf.all || pf.names {
+		names = allProps
+	}
+
+	found := make(map[xml.Name]string, len(names))
+	var missing []xml.Name
+	for _, name := range names {
+		value, ok := h.propValue(res, name)
+		switch {
+		case ok && pf.names:
+			found[name] = ""
+		case ok:
+			found[name] = value
+		case !pf.all && !pf.names:
+			// На allprop и propname отсутствующие у ресурса свойства не перечисляются
+			missing = append(missing, name)
+		}
+	}
+
+	ms.propstat(res.href, found, missing)
+}
+
+// propValue функция, которая возвращает значение свойства ресурса в виде XML
+// @param res *resource - ресурс
+// @param name xml.Name - свойство
+// @return string - значение
+// @return bool - false, если у ресурса нет такого свойства
+func (h *Handler) propValue(res *resource, name xml.Name) (string, bool) {
+	switch name {
+	case propResourceType:
+		switch res.kind {
+		case kindPrincipal:
+			return "<d:collection/><d:principal/>", true
+		case kindHome:
+			return "<d:collection/>", true
+		case kindCalendar:
+			return "<d:collection/><c:calendar/>", true
+		default:
+			return "", true
+		}
+	case propDisplayName:
+		switch res.kind {
+		case kindPrincipal:
+			return "todo-api", true
+		case kindCalendar:
+			return "Tasks", true
+		case kindObject:
+			return escape(res.record.Title), true
+		}
+	case propCurrentUserPrincipal:
+		return href(rootPath), true
+	case propPrincipalURL, propOwner:
+		if res.kind == kindPrincipal || name == propOwner {
+			return href(rootPath), true
+		}
+	case propCalendarHomeSet:
+		if res.kind == kindPrincipal {
+			return href(homePath), true
+		}
+	case propCurrentUserPrivileges:
+		return "<d:privilege><d:read/></d:privilege><d:privilege><d:write/></d:privilege>" +
+			"<d:privilege><d:write-content/></d:privilege><d:privilege><d:bind/></d:privilege>" +
+			"<d:privilege><d:unbind/></d:privilege>", true
+	case propSupportedReportSet:
+		if res.kind == kindCalendar || res.kind == kindObject {
+			return "<d:supported-report><d:report><c:calendar-query/></d:report></d:supported-report>" +
+				"<d:supported-report><d:report><c:calendar-multiget/></d:report></d:supported-report>", true
+		}
+	case propSupportedComponents:
+		if res.kind == kindCalendar {
+			return `<c:comp name="VTODO"/>`, true
+		}
+	case propGetCTag:
+		if res.kind == kindCalendar {
+			return escape(res.ctag), true
+		}
+	case propGetETag:
+		switch res.kind {
+		case kindCalendar:
+			return escape(`"` + res.ctag + `"`), true
+		case kindObject:
+			return escape(objectETag(res.record)), true
+		}
+	case propGetContentType:
+		if res.kind == kindObject {
+			return escape(objectContentType), true
+		}
+	case propCalendarData:
+		if res.kind == kindObject {
+			var buf bytes.Buffer
+			if err := ical.WriteTodo(&buf, res.record, h.loc, time.Now()); err != nil {
+				return "", false
+			}
+			return escape(buf.String()), true
+		}
+	}
+	return "", false
+}
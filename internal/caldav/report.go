@@ -0,0 +1,301 @@
+package caldav
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/ical"
+	"github.com/pers0na2dev/todo-api/internal/models"
+)
+
+// errUnsupportedFilter ошибка, которая возвращается если фильтр calendar-query содержит неподдерживаемые условия
+var errUnsupportedFilter = errors.New("unsupported filter")
+
+// timeRangeLayout - формат атрибутов start и end элемента time-range
+const timeRangeLayout = "20060102T150405Z"
+
+// report функция, которая выполняет отчеты calendar-query и calendar-multiget над календарем
+func (h *Handler) report(w http.ResponseWriter, r *http.Request) {
+	if strings.TrimSuffix(r.URL.EscapedPath(), "/")+"/" != calendarPath {
+		writeError(w, http.StatusForbidden, xml.Name{Space: nsDAV, Local: "supported-report"})
+		return
+	}
+
+	body, err := readBody(r)
+	if err != nil || body == nil {
+		http.Error(w, "report body is expected", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case body.is(nsCalDAV, "calendar-multiget"):
+		h.multiget(w, r, body)
+	case body.is(nsCalDAV, "calendar-query"):
+		h.query(w, r, body)
+	default:
+		writeError(w, http.StatusForbidden, xml.Name{Space: nsDAV, Local: "supported-report"})
+	}
+}
+
+// multiget функция, которая возвращает свойства задач по списку ссылок
+// @param w http.ResponseWriter - ответ
+// @param r *http.Request - запрос
+// @param body *node - элемент calendar-multiget
+func (h *Handler) multiget(w http.ResponseWriter, r *http.Request, body *node) {
+	pf := parsePropfind(body)
+	ms := newMultistatus()
+
+	for _, child := range body.Children {
+		if !child.is(nsDAV, "href") {
+			continue
+		}
+		ref := strings.TrimSpace(child.Text)
+
+		// Клиенты передают как пути, так и полные ссылки
+		path := ref
+		if u, err := url.Parse(ref); err == nil {
+			path = u.EscapedPath()
+		}
+		uid, ok := objectUID(path)
+		if !ok {
+			ms.status(ref, http.StatusNotFound)
+			continue
+		}
+
+		record, err := h.findRecord(r.Context(), uid)
+		if errors.Is(err, models.ErrTaskNotFound) {
+			ms.status(ref, http.StatusNotFound)
+			continue
+		}
+		if err != nil {
+			h.internalError(w, err)
+			return
+		}
+		h.writeProps(ms, objectResource(record), pf)
+	}
+
+	ms.writeTo(w)
+}
+
+// query функция, которая возвращает свойства задач, подходящих под фильтр
+// @param w http.ResponseWriter - ответ
+// @param r *http.Request - запрос
+// @param body *node - элемент calendar-query
+func (h *Handler) query(w http.ResponseWriter, r *http.Request, body *node) {
+	filter := body.child(nsCalDAV, "filter")
+	if filter == nil {
+		writeError(w, http.StatusForbidden, xml.Name{Space: nsCalDAV, Local: "valid-filter"})
+		return
+	}
+
+	pf := parsePropfind(body)
+	ms := newMultistatus()
+	now := time.Now()
+	err := h.taskService.ExportTasks(r.Context(), func(record *models.TaskRecord) error {
+		// Фильтр проверяется на том же VTODO, который получит клиент
+		calendar := ical.NewComponent("VCALENDAR")
+		calendar.Components = append(calendar.Components, ical.Todo(record, h.loc, now))
+
+		matched, err := h.matchFilter(filter, calendar)
+		if err != nil {
+			return err
+		}
+		if matched {
+			h.writeProps(ms, objectResource(record), pf)
+		}
+		return nil
+	})
+	if errors.Is(err, errUnsupportedFilter) {
+		writeError(w, http.StatusForbidden, xml.Name{Space: nsCalDAV, Local: "supported-filter"})
+		return
+	}
+	if err != nil {
+		h.internalError(w, err)
+		return
+	}
+
+	ms.writeTo(w)
+}
+
+// matchFilter функция, которая проверяет календарь по элементу filter
+// @param filter *node - элемент filter с одним comp-filter для VCALENDAR
+// @param calendar *ical.Component - календарь
+// @return bool - true, если календарь подходит
+// @return error - errUnsupportedFilter если фильтр содержит неподдерживаемые условия
+func (h *Handler) matchFilter(filter *node, calendar *ical.Component) (bool, error) {
+	for i := range filter.Children {
+		child := &filter.Children[i]
+		if !child.is(nsCalDAV, "comp-filter") {
+			return false, fmt.Errorf("%w: %s", errUnsupportedFilter, child.XMLName.Local)
+		}
+		matched, err := h.matchCompFilter(child, []*ical.Component{calendar})
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchCompFilter функция, которая проверяет, что хотя бы один компонент с именем из фильтра подходит под его условия
+// @param filter *node - элемент comp-filter
+// @param candidates []*ical.Component - компоненты того же уровня
+// @return bool - true, если условие выполнено
+// @return error - errUnsupportedFilter если фильтр содержит неподдерживаемые условия
+func (h *Handler) matchCompFilter(filter *node, candidates []*ical.Component) (bool, error) {
+	name := strings.ToUpper(filter.attr("name"))
+	var components []*ical.Component
+	for _, c := range candidates {
+		if c.Name == name {
+			components = append(components, c)
+		}
+	}
+
+	if filter.child(nsCalDAV, "is-not-defined") != nil {
+		return len(components) == 0, nil
+	}
+
+	for _, c := range components {
+		matched, err := h.matchComponent(filter, c)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+	return false, nil
+}
+
+// matchComponent функция, которая проверяет компонент по всем вложенным условиям comp-filter
+// @param filter *node - элемент comp-filter
+// @param c *ical.Component - компонент
+// @return bool - true, если все условия выполнены
+// @return error - errUnsupportedFilter если фильтр содержит неподдерживаемые условия
+func (h *Handler) matchComponent(filter *node, c *ical.Component) (bool, error) {
+	for i := range filter.Children {
+		child := &filter.Children[i]
+
+		var matched bool
+		var err error
+		switch {
+		case child.is(nsCalDAV, "comp-filter"):
+			matched, err = h.matchCompFilter(child, c.Components)
+		case child.is(nsCalDAV, "prop-filter"):
+			matched, err = h.matchPropFilter(child, c)
+		case child.is(nsCalDAV, "time-range"):
+			matched, err = h.matchTodoTimeRange(child, c)
+		default:
+			err = fmt.Errorf("%w: %s", errUnsupportedFilter, child.XMLName.Local)
+		}
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchPropFilter функция, которая проверяет свойство компонента по prop-filter
+// @param filter *node - элемент prop-filter
+// @param c *ical.Component - компонент
+// @return bool - true, если условие выполнено
+// @return error - errUnsupportedFilter если фильтр содержит неподдерживаемые условия
+func (h *Handler) matchPropFilter(filter *node, c *ical.Component) (bool, error) {
+	prop := c.Prop(strings.ToUpper(filter.attr("name")))
+	if filter.child(nsCalDAV, "is-not-defined") != nil {
+		return prop == nil, nil
+	}
+	if prop == nil {
+		return false, nil
+	}
+
+	for i := range filter.Children {
+		child := &filter.Children[i]
+		switch {
+		case child.is(nsCalDAV, "text-match"):
+			if !textMatch(child, ical.UnescapeText(prop.Value)) {
+				return false, nil
+			}
+		case child.is(nsCalDAV, "time-range"):
+			start, end, err := parseTimeRange(child)
+			if err != nil {
+				return false, err
+			}
+			t, err := ical.ParseDateTime(prop, h.loc)
+			if err != nil || !inRange(t, start, end) {
+				return false, nil
+			}
+		default:
+			return false, fmt.Errorf("%w: %s", errUnsupportedFilter, child.XMLName.Local)
+		}
+	}
+	return true, nil
+}
+
+// matchTodoTimeRange функция, которая проверяет, что VTODO попадает в интервал
+// У задач хранится только срок, поэтому из таблицы RFC 4791 (раздел 9.9) нужны две строки:
+// задача со сроком подходит при start < DUE <= end, задача без сроков подходит под любой интервал
+// @param filter *node - элемент time-range
+// @param c *ical.Component - компонент
+// @return bool - true, если задача попадает в интервал
+// @return error - errUnsupportedFilter если интервал задан некорректно
+func (h *Handler) matchTodoTimeRange(filter *node, c *ical.Component) (bool, error) {
+	start, end, err := parseTimeRange(filter)
+	if err != nil {
+		return false, err
+	}
+
+	due := c.Prop("DUE")
+	if due == nil {
+		return true, nil
+	}
+	t, err := ical.ParseDateTime(due, h.loc)
+	if err != nil {
+		return false, nil
+	}
+	return (start.IsZero() || start.Before(t)) && (end.IsZero() || !end.Before(t)), nil
+}
+
+// parseTimeRange функция, которая разбирает атрибуты start и end, один из них может отсутствовать
+// @param filter *node - элемент time-range
+// @return time.Time - начало интервала или нулевое время
+// @return time.Time - конец интервала или нулевое время
+// @return error - errUnsupportedFilter если время записано не в UTC
+func parseTimeRange(filter *node) (time.Time, time.Time, error) {
+	var bounds [2]time.Time
+	for i, name := range []string{"start", "end"} {
+		value := filter.attr(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(timeRangeLayout, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid time-range %s %q", errUnsupportedFilter, name, value)
+		}
+		bounds[i] = t
+	}
+	return bounds[0], bounds[1], nil
+}
+
+// inRange функция, которая проверяет start <= t < end для интервала с необязательными границами
+// @param t time.Time - время
+// @param start time.Time - начало интервала или нулевое время
+// @param end time.Time - конец интервала или нулевое время
+// @return bool - true, если время попадает в интервал
+func inRange(t, start, end time.Time) bool {
+	return (start.IsZero() || !t.Before(start)) && (end.IsZero() || t.Before(end))
+}
+
+// textMatch функция, которая проверяет вхождение текста по text-match
+// Сравнение без учета регистра, кроме сопоставления i;octet
+// @param filter *node - элемент text-match
+// @param value string - значение свойства
+// @return bool - true, если условие выполнено
+func textMatch(filter *node, value string) bool {
+	text := filter.Text
+	if filter.attr("collation") != "i;octet" {
+		text, value = strings.ToLower(text), strings.ToLower(value)
+	}
+	return strings.Contains(value, text) != (filter.attr("negate-condition") == "yes")
+}
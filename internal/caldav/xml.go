@@ -0,0 +1,259 @@
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+)
+
+// Пространства имен XML, которые используют клиенты CalDAV
+const (
+	nsDAV    = "DAV:"
+	nsCalDAV = "urn:ietf:params:xml:ns:caldav"
+	// nsCS - расширения Apple CalendarServer, из них используется getctag
+	nsCS = "http://calendarserver.org/ns/"
+)
+
+// prefixes - префиксы, которые объявляются в корне ответа Multi-Status
+var prefixes = map[string]string{nsDAV: "d", nsCalDAV: "c", nsCS: "cs"}
+
+// maxBodyBytes - максимальный размер тела запроса PROPFIND, REPORT, PROPPATCH и PUT
+const maxBodyBytes = 1 << 20
+
+// node элемент XML тела запроса с вложенными элементами
+type node struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Text     string     `xml:",chardata"`
+	Children []node     `xml:",any"`
+}
+
+// child функция, которая возвращает первый вложенный элемент с заданным именем
+// @param space string - пространство имен
+// @param local string - имя элемента
+// @return *node - элемент или nil, если его нет
+func (n *node) child(space, local string) *node {
+	for i := range n.Children {
+		if n.Children[i].XMLName.Space == space && n.Children[i].XMLName.Local == local {
+			return &n.Children[i]
+		}
+	}
+	return nil
+}
+
+// attr функция, которая возвращает значение атрибута без пространства имен
+// @param name string - имя атрибута
+// @return string - значение или пустая строка, если атрибута нет
+func (n *node) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// is функция, которая проверяет имя элемента
+// @param space string - пространство имен
+// @param local string - имя элемента
+// @return bool - true, если имя совпадает
+func (n *node) is(space, local string) bool {
+	return n.XMLName.Space == space && n.XMLName.Local == local
+}
+
+// readBody функция, которая разбирает XML тело запроса
+// @param r *http.Request - запрос
+// @return *node - корневой элемент или nil, если тело пустое
+// @return error - ошибка разбора
+func readBody(r *http.Request) (*node, error) {
+	root := &node{}
+	err := xml.NewDecoder(io.LimitReader(r.Body, maxBodyBytes)).Decode(root)
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid xml body: %w", err)
+	}
+	return root, nil
+}
+
+// propfind запрошенные свойства из элемента propfind, calendar-query или calendar-multiget
+type propfind struct {
+	// all - запрошены все свойства (allprop или пустое тело)
+	all bool
+	// names - запрошены только имена свойств (propname)
+	names bool
+	props []xml.Name
+}
+
+// parsePropfind функция, которая извлекает запрошенные свойства из элемента
+// @param n *node - элемент, содержащий prop, allprop или propname, может быть nil
+// @return propfind - запрошенные свойства
+func parsePropfind(n *node) propfind {
+	if n == nil || n.child(nsDAV, "allprop") != nil {
+		return propfind{all: true}
+	}
+	if n.child(nsDAV, "propname") != nil {
+		return propfind{names: true}
+	}
+
+	var pf propfind
+	if prop := n.child(nsDAV, "prop"); prop != nil {
+		for _, p := range prop.Children {
+			pf.props = append(pf.props, p.XMLName)
+		}
+	}
+	if len(pf.props) == 0 {
+		pf.all = true
+	}
+	return pf
+}
+
+// multistatus ответ 207 Multi-Status, который собирается в памяти перед отправкой
+// Так ошибка базы данных в середине листинга возвращается как 500, а не как оборванный ответ
+type multistatus struct {
+	buf bytes.Buffer
+}
+
+// newMultistatus функция, которая начинает ответ Multi-Status
+// @return *multistatus - новый ответ
+func newMultistatus() *multistatus {
+	m := &multistatus{}
+	m.buf.WriteString(xml.Header)
+	m.buf.WriteString(`<d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav" xmlns:cs="http://calendarserver.org/ns/">`)
+	return m
+}
+
+// propstat функция, которая добавляет ответ ресурса с найденными и ненайденными свойствами
+// @param href string - ссылка на ресурс
+// @param found map[xml.Name]string - значения найденных свойств в виде XML
+// @param missing []xml.Name - свойства, которых у ресурса нет
+func (m *multistatus) propstat(href string, found map[xml.Name]string, missing []xml.Name) {
+	m.buf.WriteString("<d:response><d:href>")
+	xml.EscapeText(&m.buf, []byte(href))
+	m.buf.WriteString("</d:href>")
+
+	if len(found) > 0 {
+		// Свойства сортируются, чтобы одинаковые данные всегда давали одинаковый ответ
+		names := make([]xml.Name, 0, len(found))
+		for name := range found {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			return names[i].Space+names[i].Local < names[j].Space+names[j].Local
+		})
+
+		m.buf.WriteString("<d:propstat><d:prop>")
+		for _, name := range names {
+			m.element(name, found[name])
+		}
+		m.buf.WriteString("</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>")
+	}
+
+	m.statusProps(missing, http.StatusNotFound)
+	m.buf.WriteString("</d:response>")
+}
+
+// forbidden функция, которая добавляет ответ ресурса, свойства которого нельзя изменить
+// @param href string - ссылка на ресурс
+// @param props []xml.Name - свойства
+func (m *multistatus) forbidden(href string, props []xml.Name) {
+	m.buf.WriteString("<d:response><d:href>")
+	xml.EscapeText(&m.buf, []byte(href))
+	m.buf.WriteString("</d:href>")
+	m.statusProps(props, http.StatusForbidden)
+	m.buf.WriteString("</d:response>")
+}
+
+// status функция, которая добавляет ответ ресурса с одним статусом, например 404 для неизвестной ссылки
+// @param href string - ссылка на ресурс
+// @param code int - код статуса
+func (m *multistatus) status(href string, code int) {
+	m.buf.WriteString("<d:response><d:href>")
+	xml.EscapeText(&m.buf, []byte(href))
+	m.buf.WriteString("</d:href>")
+	fmt.Fprintf(&m.buf, "<d:status>HTTP/1.1 %d %s</d:status></d:response>", code, http.StatusText(code))
+}
+
+// statusProps функция, которая записывает propstat с пустыми свойствами и кодом статуса
+// @param props []xml.Name - свойства
+// @param code int - код статуса
+func (m *multistatus) statusProps(props []xml.Name, code int) {
+	if len(props) == 0 {
+		return
+	}
+	m.buf.WriteString("<d:propstat><d:prop>")
+	for _, name := range props {
+		m.element(name, "")
+	}
+	fmt.Fprintf(&m.buf, "</d:prop><d:status>HTTP/1.1 %d %s</d:status></d:propstat>", code, http.StatusText(code))
+}
+
+// element функция, которая записывает элемент свойства
+// Для пространств имен без объявленного префикса пространство объявляется на самом элементе
+// @param name xml.Name - имя элемента
+// @param inner string - содержимое в виде XML
+func (m *multistatus) element(name xml.Name, inner string) {
+	tag, decl := qualify(name)
+	if inner == "" {
+		m.buf.WriteString("<" + tag + decl + "/>")
+		return
+	}
+	m.buf.WriteString("<" + tag + decl + ">" + inner + "</" + tag + ">")
+}
+
+// writeTo функция, которая завершает и отправляет ответ
+// @param w http.ResponseWriter - ответ
+func (m *multistatus) writeTo(w http.ResponseWriter) {
+	m.buf.WriteString("</d:multistatus>")
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	w.Write(m.buf.Bytes())
+}
+
+// qualify функция, которая возвращает имя элемента с префиксом и объявление пространства имен, если оно нужно
+// @param name xml.Name - имя элемента
+// @return string - имя с префиксом
+// @return string - объявление пространства имен или пустая строка
+func qualify(name xml.Name) (string, string) {
+	if prefix, ok := prefixes[name.Space]; ok {
+		return prefix + ":" + name.Local, ""
+	}
+	if name.Space == "" {
+		return name.Local, ""
+	}
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(name.Space))
+	return "x:" + name.Local, ` xmlns:x="` + b.String() + `"`
+}
+
+// writeError функция, которая отправляет ошибку с нарушенным условием WebDAV или CalDAV
+// @param w http.ResponseWriter - ответ
+// @param code int - код статуса
+// @param condition xml.Name - условие, например CALDAV:valid-calendar-data
+func writeError(w http.ResponseWriter, code int, condition xml.Name) {
+	tag, decl := qualify(condition)
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(code)
+	fmt.Fprintf(w, `%s<d:error xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><%s%s/></d:error>`, xml.Header, tag, decl)
+}
+
+// escape функция, которая экранирует текст для содержимого элемента
+// @param text string - текст
+// @return string - экранированный текст
+func escape(text string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+// href функция, которая записывает ссылку для значения свойства
+// @param path string - путь
+// @return string - элемент href
+func href(path string) string {
+	return "<d:href>" + escape(path) + "</d:href>"
+}
@@ -1,16 +1,91 @@
 package config
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
 type Config struct {
 	// HTTP_PORT - порт для запуска HTTP сервера
 	HTTPPort string `mapstructure:"HTTP_PORT"`
+	// GRPC_PORT - порт для запуска gRPC сервера
+	GRPCPort string `mapstructure:"GRPC_PORT"`
 	// POSTGRES_DSN - строка подключения к базе данных
 	PostgresDSN string `mapstructure:"POSTGRES_DSN"`
 	// REDIS_DSN - строка подключения к Redis
 	RedisDSN string `mapstructure:"REDIS_DSN"`
+	// REDIS_SENTINEL_MASTER - имя master в Redis Sentinel, если задано, подключение идет через REDIS_SENTINEL_ADDRS
+	RedisSentinelMaster string `mapstructure:"REDIS_SENTINEL_MASTER"`
+	// REDIS_SENTINEL_ADDRS - адреса узлов Sentinel через запятую
+	RedisSentinelAddrs []string `mapstructure:"REDIS_SENTINEL_ADDRS"`
+	// REDIS_SENTINEL_PASSWORD - пароль узлов Sentinel
+	RedisSentinelPassword string `mapstructure:"REDIS_SENTINEL_PASSWORD"`
+	// REDIS_CLUSTER_ADDRS - начальные узлы Redis Cluster через запятую, если заданы, REDIS_DSN не используется
+	RedisClusterAddrs []string `mapstructure:"REDIS_CLUSTER_ADDRS"`
+	// REDIS_USERNAME - имя пользователя Redis, заменяет указанное в REDIS_DSN
+	RedisUsername string `mapstructure:"REDIS_USERNAME"`
+	// REDIS_PASSWORD - пароль Redis, заменяет указанный в REDIS_DSN
+	RedisPassword string `mapstructure:"REDIS_PASSWORD"`
+	// REDIS_DB - номер базы данных Redis, заменяет указанный в REDIS_DSN
+	RedisDB int `mapstructure:"REDIS_DB"`
+	// REDIS_TLS - подключаться к Redis по TLS
+	RedisTLS bool `mapstructure:"REDIS_TLS"`
+	// REDIS_TLS_CA_FILE - файл с сертификатами CA в формате PEM для проверки сертификата Redis, пусто - системные
+	RedisTLSCAFile string `mapstructure:"REDIS_TLS_CA_FILE"`
+	// REDIS_TLS_SERVER_NAME - имя сервера для проверки сертификата Redis, пусто - из адреса
+	RedisTLSServerName string `mapstructure:"REDIS_TLS_SERVER_NAME"`
+	// REDIS_POOL_SIZE - размер пула соединений на узел Redis, 0 - 10 на каждый CPU
+	RedisPoolSize int `mapstructure:"REDIS_POOL_SIZE"`
+	// REDIS_MIN_IDLE_CONNS - минимальное число простаивающих соединений на узел Redis
+	RedisMinIdleConns int `mapstructure:"REDIS_MIN_IDLE_CONNS"`
+	// REDIS_DIAL_TIMEOUT - таймаут подключения к Redis, 0 - 5s
+	RedisDialTimeout time.Duration `mapstructure:"REDIS_DIAL_TIMEOUT"`
+	// REDIS_READ_TIMEOUT - таймаут чтения ответа Redis, 0 - 3s
+	RedisReadTimeout time.Duration `mapstructure:"REDIS_READ_TIMEOUT"`
+	// REDIS_WRITE_TIMEOUT - таймаут записи команды в Redis, 0 - равен REDIS_READ_TIMEOUT
+	RedisWriteTimeout time.Duration `mapstructure:"REDIS_WRITE_TIMEOUT"`
+	// REDIS_POOL_TIMEOUT - время ожидания свободного соединения из пула, 0 - REDIS_READ_TIMEOUT + 1s
+	RedisPoolTimeout time.Duration `mapstructure:"REDIS_POOL_TIMEOUT"`
+	// CACHE_BACKEND - хранилище кеша: redis, memory (память процесса, не требует Redis)
+	// или tiered (копия в памяти процесса перед Redis)
+	CacheBackend string `mapstructure:"CACHE_BACKEND"`
+	// CACHE_MAX_ENTRIES - максимальное количество записей кеша memory и копий tiered, 0 - без ограничения
+	CacheMaxEntries int `mapstructure:"CACHE_MAX_ENTRIES"`
+	// CACHE_MAX_BYTES - максимальный размер кеша memory в байтах, 0 - без ограничения
+	CacheMaxBytes int64 `mapstructure:"CACHE_MAX_BYTES"`
+	// CACHE_EVICTION - политика вытеснения кеша memory: lru или tinylfu
+	CacheEviction string `mapstructure:"CACHE_EVICTION"`
+	// CACHE_LOCAL_TTL - срок жизни копии в памяти процесса для кеша tiered
+	CacheLocalTTL time.Duration `mapstructure:"CACHE_LOCAL_TTL"`
+	// CACHE_INVALIDATION_CHANNEL - канал Redis, через который реплики с кешем tiered удаляют копии измененных ключей
+	CacheInvalidationChannel string `mapstructure:"CACHE_INVALIDATION_CHANNEL"`
+	// CACHE_BREAKER_THRESHOLD - число ошибок Redis подряд, после которого кеш временно не используется
+	CacheBreakerThreshold int `mapstructure:"CACHE_BREAKER_THRESHOLD"`
+	// CACHE_BREAKER_COOLDOWN - время, в течение которого запросы идут в базу данных мимо кеша после ошибок Redis
+	CacheBreakerCooldown time.Duration `mapstructure:"CACHE_BREAKER_COOLDOWN"`
+	// CACHE_CODEC - кодек значений в Redis: json, msgpack или gob. Значения в старом формате читаются после смены
+	CacheCodec string `mapstructure:"CACHE_CODEC"`
+	// CACHE_COMPRESSION - сжатие значений в Redis: none, zstd или snappy
+	CacheCompression string `mapstructure:"CACHE_COMPRESSION"`
+	// CACHE_COMPRESS_THRESHOLD - минимальный размер значения в байтах, которое сжимается
+	CacheCompressThreshold int `mapstructure:"CACHE_COMPRESS_THRESHOLD"`
+	// CACHE_ACCESS_LOG_KEY - отсортированное множество Redis с недавно прочитанными ключами для прогрева кеша,
+	// пусто - обращения не учитываются. Для кеша memory журнал не ведется
+	CacheAccessLogKey string `mapstructure:"CACHE_ACCESS_LOG_KEY"`
+	// CACHE_ACCESS_LOG_SIZE - максимальное число ключей в журнале обращений
+	CacheAccessLogSize int `mapstructure:"CACHE_ACCESS_LOG_SIZE"`
+	// CACHE_WARMUP_TIMEOUT - время прогрева кеша после запуска, до его завершения /readyz отвечает 503.
+	// 0 - прогрев отключен
+	CacheWarmupTimeout time.Duration `mapstructure:"CACHE_WARMUP_TIMEOUT"`
+	// CACHE_WARMUP_KEYS - максимальное число ключей из журнала обращений, которые загружаются при прогреве
+	CacheWarmupKeys int `mapstructure:"CACHE_WARMUP_KEYS"`
+	// ADMIN_TOKEN - токен администратора для маршрутов /v1/admin, пусто - маршруты отключены
+	AdminToken string `mapstructure:"ADMIN_TOKEN"`
+	// SEARCH_CONFIG - конфигурация полнотекстового поиска Postgres по умолчанию (simple, english, russian)
+	SearchConfig string `mapstructure:"SEARCH_CONFIG"`
+	// CALENDAR_TIMEZONE - часовой пояс IANA, в котором ленты календаря показывают сроки задач и читается время без пояса
+	CalendarTimezone string `mapstructure:"CALENDAR_TIMEZONE"`
 }
 
 func LoadConfig() (*Config, error) {
@@ -20,6 +95,41 @@ func LoadConfig() (*Config, error) {
 	// Автоматически использовать переменные окружения, если они доступны
 	viper.AutomaticEnv()
 
+	// Значения по умолчанию для необязательных параметров
+	viper.SetDefault("SEARCH_CONFIG", "english")
+	viper.SetDefault("GRPC_PORT", ":9090")
+	viper.SetDefault("CALENDAR_TIMEZONE", "UTC")
+	viper.SetDefault("CACHE_BACKEND", "redis")
+	viper.SetDefault("CACHE_MAX_ENTRIES", 10000)
+	viper.SetDefault("CACHE_MAX_BYTES", 64<<20)
+	viper.SetDefault("CACHE_EVICTION", "lru")
+	viper.SetDefault("CACHE_LOCAL_TTL", "5s")
+	viper.SetDefault("CACHE_INVALIDATION_CHANNEL", "cache:invalidate")
+	viper.SetDefault("CACHE_BREAKER_THRESHOLD", 5)
+	viper.SetDefault("CACHE_BREAKER_COOLDOWN", "30s")
+	viper.SetDefault("CACHE_CODEC", "json")
+	viper.SetDefault("CACHE_COMPRESSION", "none")
+	viper.SetDefault("CACHE_COMPRESS_THRESHOLD", 1024)
+	viper.SetDefault("CACHE_ACCESS_LOG_KEY", "cache:access")
+	viper.SetDefault("CACHE_ACCESS_LOG_SIZE", 10000)
+	viper.SetDefault("CACHE_WARMUP_TIMEOUT", "10s")
+	viper.SetDefault("CACHE_WARMUP_KEYS", 1000)
+
+	// Необязательные параметры без значений по умолчанию. AutomaticEnv читает только известные viper ключи,
+	// поэтому без привязки их можно было бы задать только в .env
+	for _, key := range []string{
+		"REDIS_SENTINEL_MASTER", "REDIS_SENTINEL_ADDRS", "REDIS_SENTINEL_PASSWORD", "REDIS_CLUSTER_ADDRS",
+		"REDIS_USERNAME", "REDIS_PASSWORD", "REDIS_DB",
+		"REDIS_TLS", "REDIS_TLS_CA_FILE", "REDIS_TLS_SERVER_NAME",
+		"REDIS_POOL_SIZE", "REDIS_MIN_IDLE_CONNS",
+		"REDIS_DIAL_TIMEOUT", "REDIS_READ_TIMEOUT", "REDIS_WRITE_TIMEOUT", "REDIS_POOL_TIMEOUT",
+		"ADMIN_TOKEN",
+	} {
+		if err := viper.BindEnv(key); err != nil {
+			return nil, err
+		}
+	}
+
 	// Чтение конфигурационного файла
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, err
@@ -34,3 +144,10 @@ func LoadConfig() (*Config, error) {
 	// Возврат загруженной конфигурации
 	return &cfg, nil
 }
+
+// CalendarLocation функция, которая возвращает часовой пояс календаря
+// @return *time.Location - часовой пояс из CALENDAR_TIMEZONE
+// @return error - ошибка, если часовой пояс неизвестен
+func (c *Config) CalendarLocation() (*time.Location, error) {
+	return time.LoadLocation(c.CalendarTimezone)
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadTestConfig функция, которая загружает конфигурацию из временного каталога с файлом .env
+func loadTestConfig(t *testing.T, env string) *Config {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(dir+"/.env", []byte(env), 0o600))
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		viper.Reset()
+	})
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	return cfg
+}
+
+// TestLoadConfig тестирует загрузку конфигурации из .env и переменных окружения
+func TestLoadConfig(t *testing.T) {
+	t.Run("Параметры Redis задаются переменными окружения", func(t *testing.T) {
+		t.Setenv("REDIS_SENTINEL_MASTER", "mymaster")
+		t.Setenv("REDIS_SENTINEL_ADDRS", "s1:26379,s2:26379")
+		t.Setenv("REDIS_SENTINEL_PASSWORD", "sentinel-secret")
+		t.Setenv("REDIS_CLUSTER_ADDRS", "a:1,b:2")
+		t.Setenv("REDIS_USERNAME", "app")
+		t.Setenv("REDIS_PASSWORD", "secret")
+		t.Setenv("REDIS_DB", "2")
+		t.Setenv("REDIS_TLS", "true")
+		t.Setenv("REDIS_TLS_CA_FILE", "/etc/redis/ca.pem")
+		t.Setenv("REDIS_TLS_SERVER_NAME", "redis.internal")
+		t.Setenv("REDIS_POOL_SIZE", "50")
+		t.Setenv("REDIS_MIN_IDLE_CONNS", "5")
+		t.Setenv("REDIS_DIAL_TIMEOUT", "2s")
+		t.Setenv("REDIS_READ_TIMEOUT", "500ms")
+		t.Setenv("REDIS_WRITE_TIMEOUT", "1s")
+		t.Setenv("REDIS_POOL_TIMEOUT", "3s")
+
+		cfg := loadTestConfig(t, "HTTP_PORT=:8080\n")
+
+		assert.Equal(t, "mymaster", cfg.RedisSentinelMaster)
+		assert.Equal(t, []string{"s1:26379", "s2:26379"}, cfg.RedisSentinelAddrs)
+		assert.Equal(t, "sentinel-secret", cfg.RedisSentinelPassword)
+		assert.Equal(t, []string{"a:1", "b:2"}, cfg.RedisClusterAddrs)
+		assert.Equal(t, "app", cfg.RedisUsername)
+		assert.Equal(t, "secret", cfg.RedisPassword)
+		assert.Equal(t, 2, cfg.RedisDB)
+		assert.True(t, cfg.RedisTLS)
+		assert.Equal(t, "/etc/redis/ca.pem", cfg.RedisTLSCAFile)
+		assert.Equal(t, "redis.internal", cfg.RedisTLSServerName)
+		assert.Equal(t, 50, cfg.RedisPoolSize)
+		assert.Equal(t, 5, cfg.RedisMinIdleConns)
+		assert.Equal(t, 2*time.Second, cfg.RedisDialTimeout)
+		assert.Equal(t, 500*time.Millisecond, cfg.RedisReadTimeout)
+		assert.Equal(t, time.Second, cfg.RedisWriteTimeout)
+		assert.Equal(t, 3*time.Second, cfg.RedisPoolTimeout)
+	})
+
+	t.Run("Токен администратора задается переменной окружения", func(t *testing.T) {
+		t.Setenv("ADMIN_TOKEN", "admin-secret")
+
+		cfg := loadTestConfig(t, "HTTP_PORT=:8080\n")
+
+		assert.Equal(t, "admin-secret", cfg.AdminToken)
+	})
+
+	t.Run("Без переменных окружения параметры Redis не заданы", func(t *testing.T) {
+		cfg := loadTestConfig(t, "HTTP_PORT=:8080\nREDIS_USERNAME=from-file\n")
+
+		assert.Equal(t, ":8080", cfg.HTTPPort)
+		assert.Equal(t, "from-file", cfg.RedisUsername)
+		assert.Empty(t, cfg.RedisClusterAddrs)
+		assert.Zero(t, cfg.RedisPoolTimeout)
+		assert.Empty(t, cfg.AdminToken)
+	})
+}
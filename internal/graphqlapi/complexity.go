@@ -0,0 +1,149 @@
+package graphqlapi
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+const (
+	// MaxQueryDepth - максимальная вложенность полей в запросе
+	MaxQueryDepth = 8
+	// MaxQueryComplexity - максимальная стоимость запроса
+	MaxQueryComplexity = 1000
+	// defaultListSize - предполагаемый размер списка, если его нельзя определить по аргументам
+	defaultListSize = 100
+)
+
+// ErrQueryTooComplex ошибка, которая возвращается если стоимость запроса превышает MaxQueryComplexity
+var ErrQueryTooComplex = errors.New("query is too complex")
+
+// queryComplexity функция, которая считает стоимость операции запроса
+// Каждое поле стоит 1, стоимость вложенных полей списков умножается на ожидаемый размер списка.
+// Если операцию нельзя выбрать однозначно, считается самая дорогая операция документа
+// @param query string - текст запроса
+// @param operationName string - имя выполняемой операции
+// @param variables map[string]any - переменные запроса
+// @return int - стоимость операции
+// @return error - ошибка разбора запроса
+func queryComplexity(query string, operationName string, variables map[string]any) (int, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return 0, err
+	}
+
+	c := &complexityCounter{doc: doc, variables: variables, visiting: make(map[string]bool)}
+	if op := doc.Operations.ForName(operationName); op != nil {
+		return c.selectionSet(op.SelectionSet), nil
+	}
+
+	complexity := 0
+	for _, op := range doc.Operations {
+		complexity = max(complexity, c.selectionSet(op.SelectionSet))
+	}
+	return complexity, nil
+}
+
+// checkComplexity функция, которая проверяет, что стоимость запроса не превышает MaxQueryComplexity
+// @param query string - текст запроса
+// @param operationName string - имя выполняемой операции
+// @param variables map[string]any - переменные запроса
+// @return error - ошибка, ErrQueryTooComplex если запрос слишком дорогой
+func checkComplexity(query string, operationName string, variables map[string]any) error {
+	complexity, err := queryComplexity(query, operationName, variables)
+	if err != nil {
+		return err
+	}
+	if complexity > MaxQueryComplexity {
+		return fmt.Errorf("%w: complexity exceeds limit %d", ErrQueryTooComplex, MaxQueryComplexity)
+	}
+	return nil
+}
+
+// complexityCounter структура, которая обходит документ запроса и считает его стоимость
+type complexityCounter struct {
+	doc       *ast.QueryDocument
+	variables map[string]any
+	// visiting - фрагменты на текущем пути обхода, защищает от циклических фрагментов
+	visiting map[string]bool
+}
+
+// selectionSet функция, которая считает стоимость набора полей
+// Подсчет прекращается, как только стоимость превышает лимит, чтобы огромные запросы не обходились целиком
+// и произведения размеров вложенных списков не переполнялись
+// @param set ast.SelectionSet - набор полей
+// @return int - стоимость, не больше MaxQueryComplexity+1
+func (c *complexityCounter) selectionSet(set ast.SelectionSet) int {
+	total := 0
+	for _, selection := range set {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			total += 1 + c.listSize(selection)*c.selectionSet(selection.SelectionSet)
+		case *ast.InlineFragment:
+			total += c.selectionSet(selection.SelectionSet)
+		case *ast.FragmentSpread:
+			fragment := c.doc.Fragments.ForName(selection.Name)
+			if fragment == nil || c.visiting[selection.Name] {
+				continue
+			}
+			c.visiting[selection.Name] = true
+			total += c.selectionSet(fragment.SelectionSet)
+			delete(c.visiting, selection.Name)
+		}
+
+		if total > MaxQueryComplexity {
+			return MaxQueryComplexity + 1
+		}
+	}
+	return total
+}
+
+// listSize функция, которая возвращает ожидаемое количество элементов, возвращаемых полем
+// @param field *ast.Field - поле запроса
+// @return int - ожидаемый размер списка, 1 для полей, которые не возвращают список
+func (c *complexityCounter) listSize(field *ast.Field) int {
+	switch field.Name {
+	case "tasks":
+		if ids, ok := c.argument(field, "ids").([]any); ok {
+			return max(len(ids), 1)
+		}
+		return defaultListSize
+	case "search":
+		switch limit := c.argument(field, "limit").(type) {
+		case int64:
+			return clampListSize(float64(limit))
+		case float64:
+			return clampListSize(limit)
+		}
+		return models.DefaultSearchLimit
+	default:
+		return 1
+	}
+}
+
+// clampListSize функция, которая ограничивает размер списка, заданный клиентом, разумными пределами
+// Стоимость запроса с размером больше MaxQueryComplexity все равно превысит лимит
+// @param size float64 - размер списка из аргументов
+// @return int - размер списка от 1 до MaxQueryComplexity+1
+func clampListSize(size float64) int {
+	return int(min(max(size, 1), MaxQueryComplexity+1))
+}
+
+// argument функция, которая возвращает значение аргумента поля с подставленными переменными
+// @param field *ast.Field - поле запроса
+// @param name string - имя аргумента
+// @return any - значение аргумента, nil если аргумент не задан
+func (c *complexityCounter) argument(field *ast.Field, name string) any {
+	arg := field.Arguments.ForName(name)
+	if arg == nil {
+		return nil
+	}
+	value, err := arg.Value.Value(c.variables)
+	if err != nil {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,78 @@
+package graphqlapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestQueryComplexity тестирует подсчет стоимости запросов
+func TestQueryComplexity(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		operation string
+		variables map[string]any
+		expected  int
+	}{
+		{
+			name:     "Поля без списков",
+			query:    `{ task(id: "1") { id title } }`,
+			expected: 3,
+		},
+		{
+			name:     "Размер списка по количеству id",
+			query:    `{ tasks(ids: ["1", "2", "3"]) { id title } }`,
+			expected: 1 + 3*2,
+		},
+		{
+			name:     "Список без id считается списком размера по умолчанию",
+			query:    `{ tasks { id } }`,
+			expected: 1 + defaultListSize,
+		},
+		{
+			name:      "Лимит поиска из переменной",
+			query:     `query($n: Int) { search(q: "x", limit: $n) { task { id } } }`,
+			variables: map[string]any{"n": float64(10)},
+			expected:  1 + 10*2,
+		},
+		{
+			name:     "Фрагменты раскрываются",
+			query:    `{ task(id: "1") { ...fields } } fragment fields on Task { id ... on Task { title } }`,
+			expected: 3,
+		},
+		{
+			name:      "Считается только выбранная операция",
+			query:     `query A { tasks { id } } query B { task(id: "1") { id } }`,
+			operation: "B",
+			expected:  2,
+		},
+		{
+			name:     "Циклические фрагменты не зацикливают подсчет",
+			query:    `{ task(id: "1") { ...a } } fragment a on Task { id ...b } fragment b on Task { title ...a }`,
+			expected: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			complexity, err := queryComplexity(tt.query, tt.operation, tt.variables)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, complexity)
+		})
+	}
+
+	t.Run("Вложенные списки не переполняют счетчик", func(t *testing.T) {
+		err := checkComplexity(`{ search(q: "x", limit: 1000000000000) { task { id } } }`, "", nil)
+
+		assert.ErrorIs(t, err, ErrQueryTooComplex)
+	})
+
+	t.Run("Синтаксическая ошибка", func(t *testing.T) {
+		_, err := queryComplexity(`{ task(`, "", nil)
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,54 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+)
+
+// Коды ошибок, которые передаются клиенту в extensions.code
+const (
+	codeBadUserInput    = "BAD_USER_INPUT"
+	codeNotFound        = "NOT_FOUND"
+	codeVersionConflict = "VERSION_CONFLICT"
+	codeQueryTooComplex = "QUERY_TOO_COMPLEX"
+	codeCanceled        = "CANCELED"
+	codeInternal        = "INTERNAL_SERVER_ERROR"
+)
+
+// gqlError ошибка резолвера с кодом, по которому клиент может отличить причину ошибки
+type gqlError struct {
+	code    string
+	message string
+}
+
+// Error функция, которая возвращает текст ошибки
+func (e *gqlError) Error() string {
+	return e.message
+}
+
+// Extensions функция, которая возвращает дополнительные поля ошибки GraphQL
+func (e *gqlError) Extensions() map[string]any {
+	return map[string]any{"code": e.code}
+}
+
+// toGraphQLError функция, которая преобразует ошибку сервиса в ошибку GraphQL
+// @param err error - ошибка сервиса
+// @return error - ошибка с кодом
+func toGraphQLError(err error) error {
+	code := codeInternal
+	switch {
+	case errors.Is(err, models.ErrTaskNotFound):
+		code = codeNotFound
+	case errors.Is(err, models.ErrVersionConflict):
+		// Клиенту нужно перечитать задачу и повторить мутацию с актуальной версией
+		code = codeVersionConflict
+	case errors.Is(err, models.ErrInvalidSearch):
+		code = codeBadUserInput
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		code = codeCanceled
+	}
+
+	return &gqlError{code: code, message: err.Error()}
+}
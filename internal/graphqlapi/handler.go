@@ -0,0 +1,146 @@
+package graphqlapi
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+	"github.com/graph-gophers/graphql-go"
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+	"go.uber.org/zap"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// maxRequestBytes - максимальный размер запроса GraphQL в теле POST запроса и в сообщении WebSocket
+const maxRequestBytes = openapi.DefaultMaxBodyBytes
+
+// request тело запроса GraphQL
+type request struct {
+	Query         string         `json:"query" schema:"minLength=1" doc:"GraphQL document"`
+	OperationName string         `json:"operationName,omitempty" doc:"Operation to execute if the document contains several"`
+	Variables     map[string]any `json:"variables,omitempty" doc:"Values of the operation variables"`
+}
+
+// responseError ошибка в ответе GraphQL, используется только для документа OpenAPI
+type responseError struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// response ответ GraphQL, используется только для документа OpenAPI
+type response struct {
+	Data   any             `json:"data,omitempty"`
+	Errors []responseError `json:"errors,omitempty"`
+}
+
+// Handler структура, которая обрабатывает запросы GraphQL по HTTP и WebSocket
+type Handler struct {
+	schema      *graphql.Schema
+	taskService TaskService
+	codecs      *codec.Registry
+	logger      *zap.Logger
+	upgrader    websocket.Upgrader
+}
+
+// NewHandler функция, которая создает обработчик GraphQL и регистрирует его маршруты
+// POST /graphql выполняет запросы и мутации, GET /graphql принимает подписки по протоколу graphql-transport-ws
+// @param taskService TaskService - сервис задач
+// @param reg *openapi.Registry - реестр маршрутов
+// @param codecs *codec.Registry - форматы тел запросов
+// @param logger *zap.Logger - логгер
+// @return *Handler - обработчик GraphQL
+// @return error - ошибка разбора схемы
+func NewHandler(taskService TaskService, reg *openapi.Registry, codecs *codec.Registry, logger *zap.Logger) (*Handler, error) {
+	schema, err := graphql.ParseSchema(schemaSDL, &resolver{taskService: taskService},
+		graphql.UseStringDescriptions(),
+		graphql.MaxDepth(MaxQueryDepth),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := &Handler{
+		schema:      schema,
+		taskService: taskService,
+		codecs:      codecs,
+		logger:      logger,
+		upgrader:    websocket.Upgrader{Subprotocols: []string{wsSubprotocol}},
+	}
+
+	reg.Handle("POST /graphql", handler.ServeHTTP, &openapi.Op{
+		ID:           "graphql",
+		Summary:      "Execute a GraphQL query or mutation",
+		Description:  "Schema errors, depth and complexity limit violations are returned in the errors field with status 200.",
+		Tags:         []string{"graphql"},
+		Request:      request{},
+		MaxBodyBytes: maxRequestBytes,
+		Responses: map[int]openapi.Resp{
+			// Ответ GraphQL всегда передается в JSON, так как data содержит уже закодированный JSON
+			http.StatusOK: {Description: "GraphQL response", Body: response{}, ContentType: "application/json"},
+		},
+	})
+	reg.Handle("GET /graphql", handler.ServeWebSocket, &openapi.Op{
+		ID:          "graphqlSubscriptions",
+		Summary:     "GraphQL subscriptions over WebSocket",
+		Description: "Upgrades the connection to WebSocket with the graphql-transport-ws subprotocol.",
+		Tags:        []string{"graphql"},
+		Responses: map[int]openapi.Resp{
+			http.StatusSwitchingProtocols: {Description: "The connection was upgraded to WebSocket"},
+			http.StatusBadRequest:         {Description: "The request is not a graphql-transport-ws handshake", Body: "", ContentType: "text/plain"},
+		},
+	})
+
+	return handler, nil
+}
+
+// ServeHTTP функция, которая выполняет запрос GraphQL, переданный в теле POST запроса
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	if err := h.codecs.Read(r, &req); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, codec.ErrUnsupportedMediaType) {
+			status = http.StatusUnsupportedMediaType
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	resp := h.execute(r.Context(), req)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("failed to encode graphql response", zap.Error(err))
+	}
+}
+
+// execute функция, которая проверяет стоимость запроса и выполняет его с отдельным загрузчиком задач
+// @param ctx context.Context - контекст запроса
+// @param req request - запрос GraphQL
+// @return *graphql.Response - ответ GraphQL
+func (h *Handler) execute(ctx context.Context, req request) *graphql.Response {
+	if err := checkComplexity(req.Query, req.OperationName, req.Variables); err != nil {
+		return errorResponse(err)
+	}
+
+	ctx = withLoader(ctx, newTaskLoader(ctx, h.taskService.GetTasksByIDs))
+	return h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+}
+
+// errorResponse функция, которая создает ответ GraphQL с ошибкой, возникшей до выполнения запроса
+// @param err error - ошибка
+// @return *graphql.Response - ответ GraphQL
+func errorResponse(err error) *graphql.Response {
+	queryErr := &qerrors.QueryError{Message: err.Error()}
+	if errors.Is(err, ErrQueryTooComplex) {
+		queryErr.Extensions = map[string]any{"code": codeQueryTooComplex}
+	}
+	return &graphql.Response{Errors: []*qerrors.QueryError{queryErr}}
+}
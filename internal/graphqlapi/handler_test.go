@@ -0,0 +1,272 @@
+package graphqlapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pers0na2dev/todo-api/internal/api/codec"
+	"github.com/pers0na2dev/todo-api/internal/api/openapi"
+	"github.com/pers0na2dev/todo-api/internal/config"
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/pers0na2dev/todo-api/internal/service"
+	"github.com/pers0na2dev/todo-api/internal/service/mocks"
+	"github.com/pers0na2dev/todo-api/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+// setupGraphQLTest запускает HTTP сервер с обработчиком GraphQL поверх сервиса с моком репозитория
+func setupGraphQLTest(t *testing.T) (*httptest.Server, *mocks.TaskRepository) {
+	t.Helper()
+
+	logger := zap.NewNop()
+	mockRepo := new(mocks.TaskRepository)
+	// События рассылаются только внутри процесса: кеш в памяти не поддерживает каналы
+	memoryCache, err := cache.NewMemoryCache(cache.MemoryOptions{})
+	require.NoError(t, err)
+	events, err := service.NewEventBroker(fxtest.NewLifecycle(t), memoryCache, logger)
+	require.NoError(t, err)
+	taskService := service.NewTaskService(mockRepo, events, &config.Config{SearchConfig: "english"}, logger)
+
+	mux := http.NewServeMux()
+	codecs := codec.NewRegistry()
+	_, err = NewHandler(taskService, openapi.NewRegistry(mux, codecs), codecs, logger)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server, mockRepo
+}
+
+// gqlResponse ответ GraphQL в тестах
+type gqlResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+// postQuery отправляет запрос GraphQL и возвращает разобранный ответ
+func postQuery(t *testing.T, server *httptest.Server, query string, variables map[string]any) gqlResponse {
+	t.Helper()
+
+	body, err := json.Marshal(request{Query: query, Variables: variables})
+	require.NoError(t, err)
+
+	resp, err := http.Post(server.URL+"/graphql", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result gqlResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	return result
+}
+
+// TestGraphQLQueries тестирует запросы и пакетную загрузку задач
+func TestGraphQLQueries(t *testing.T) {
+	server, mockRepo := setupGraphQLTest(t)
+
+	t.Run("Задачи из разных полей загружаются одним запросом", func(t *testing.T) {
+		mockRepo.On("GetTasksByIDs", mock.Anything, []int{1, 2, 3}).Return([]*models.Task{
+			{ID: 1, Title: "Первая", Version: 1},
+			{ID: 3, Title: "Третья", Version: 2},
+		}, nil).Once()
+
+		resp := postQuery(t, server, `{
+			a: task(id: "1") { title }
+			b: task(id: "2") { title }
+			list: tasks(ids: ["3", "2", "1"]) { id version }
+		}`, nil)
+
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `{"title": "Первая"}`, string(resp.Data["a"]))
+		assert.JSONEq(t, `null`, string(resp.Data["b"]))
+		assert.JSONEq(t, `[{"id": "3", "version": 2}, {"id": "1", "version": 1}]`, string(resp.Data["list"]))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Некорректный id возвращает BAD_USER_INPUT", func(t *testing.T) {
+		resp := postQuery(t, server, `{ task(id: "abc") { id } }`, nil)
+
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, codeBadUserInput, resp.Errors[0].Extensions["code"])
+	})
+
+	t.Run("Слишком глубокий запрос отклоняется", func(t *testing.T) {
+		resp := postQuery(t, server, `{ __schema { types { fields { type { ofType { ofType { ofType { ofType { name } } } } } } } } }`, nil)
+
+		require.NotEmpty(t, resp.Errors)
+		assert.Contains(t, resp.Errors[0].Message, "exceeds max depth")
+	})
+
+	t.Run("Слишком дорогой запрос отклоняется до выполнения", func(t *testing.T) {
+		// Каждый поиск стоит 1 + 100 * 7, вместе они превышают лимит
+		query := `query($limit: Int) {
+			a: search(q: "x", limit: $limit) { task { id title completed version } rank headline }
+			b: search(q: "y", limit: $limit) { task { id title completed version } rank headline }
+		}`
+
+		resp := postQuery(t, server, query, map[string]any{"limit": 100})
+
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, codeQueryTooComplex, resp.Errors[0].Extensions["code"])
+		mockRepo.AssertNotCalled(t, "SearchTasks", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+// TestGraphQLMutations тестирует мутации и преобразование ошибок сервиса
+func TestGraphQLMutations(t *testing.T) {
+	server, mockRepo := setupGraphQLTest(t)
+
+	t.Run("Изменение статуса возвращает новую версию", func(t *testing.T) {
+		mockRepo.On("GetTaskByID", mock.Anything, 1).Return(&models.Task{ID: 1, Title: "Задача", Version: 2}, nil).Once()
+		mockRepo.On("UpdateTask", mock.Anything, mock.AnythingOfType("*models.Task")).Run(func(args mock.Arguments) {
+			args.Get(1).(*models.Task).Version++
+		}).Return(nil).Once()
+
+		resp := postQuery(t, server, `mutation { toggleTask(id: "1", version: 2) { completed version } }`, nil)
+
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `{"completed": true, "version": 3}`, string(resp.Data["toggleTask"]))
+	})
+
+	t.Run("Конфликт версий возвращает VERSION_CONFLICT", func(t *testing.T) {
+		mockRepo.On("GetTaskByID", mock.Anything, 2).Return(&models.Task{ID: 2, Version: 5}, nil).Once()
+
+		resp := postQuery(t, server, `mutation { toggleTask(id: "2", version: 4) { id } }`, nil)
+
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, codeVersionConflict, resp.Errors[0].Extensions["code"])
+	})
+
+	t.Run("Пустое название отклоняется", func(t *testing.T) {
+		resp := postQuery(t, server, `mutation { createTask(title: "  ") { id } }`, nil)
+
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, codeBadUserInput, resp.Errors[0].Extensions["code"])
+		mockRepo.AssertNotCalled(t, "CreateTask", mock.Anything, mock.Anything)
+	})
+}
+
+// TestGraphQLSubscription тестирует подписку на события задач по протоколу graphql-transport-ws
+func TestGraphQLSubscription(t *testing.T) {
+	server, mockRepo := setupGraphQLTest(t)
+
+	dialer := websocket.Dialer{Subprotocols: []string{wsSubprotocol}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/graphql", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	require.NoError(t, conn.WriteJSON(wsMessage{Type: wsConnectionInit}))
+	var msg wsMessage
+	require.NoError(t, conn.ReadJSON(&msg))
+	require.Equal(t, wsConnectionAck, msg.Type)
+
+	payload, _ := json.Marshal(request{Query: `subscription { taskEvents { type task { id title } } }`})
+	require.NoError(t, conn.WriteJSON(wsMessage{ID: "1", Type: wsSubscribe, Payload: payload}))
+
+	// Задача создается, пока подписка устанавливается, поэтому создаем ее до тех пор, пока не придет событие
+	mockRepo.On("CreateTask", mock.Anything, mock.AnythingOfType("*models.Task")).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Task).ID = 7
+	}).Return(nil)
+	events := make(chan wsMessage)
+	go func() {
+		var msg wsMessage
+		if conn.ReadJSON(&msg) == nil {
+			events <- msg
+		}
+		close(events)
+	}()
+
+	for {
+		postQuery(t, server, `mutation { createTask(title: "Новая") { id } }`, nil)
+		select {
+		case msg, ok := <-events:
+			require.True(t, ok, "connection closed")
+			assert.Equal(t, wsNext, msg.Type)
+			assert.Equal(t, "1", msg.ID)
+			assert.JSONEq(t, `{"data": {"taskEvents": {"type": "CREATED", "task": {"id": "7", "title": "Новая"}}}}`, string(msg.Payload))
+			return
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+// TestGraphQLWebSocketProtocol тестирует закрытие соединения при нарушении протокола
+func TestGraphQLWebSocketProtocol(t *testing.T) {
+	server, _ := setupGraphQLTest(t)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/graphql"
+
+	t.Run("Без подпротокола соединение не устанавливается", func(t *testing.T) {
+		_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+
+		require.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Подписка до connection_init закрывает соединение с кодом 4401", func(t *testing.T) {
+		dialer := websocket.Dialer{Subprotocols: []string{wsSubprotocol}}
+		conn, _, err := dialer.Dial(url, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		payload, _ := json.Marshal(request{Query: `{ tasks { id } }`})
+		require.NoError(t, conn.WriteJSON(wsMessage{ID: "1", Type: wsSubscribe, Payload: payload}))
+
+		_, _, err = conn.ReadMessage()
+		var closeErr *websocket.CloseError
+		require.ErrorAs(t, err, &closeErr)
+		assert.Equal(t, wsCloseUnauthorized, closeErr.Code)
+	})
+	t.Run("Сообщение больше ограничения закрывает соединение", func(t *testing.T) {
+		dialer := websocket.Dialer{Subprotocols: []string{wsSubprotocol}}
+		conn, _, err := dialer.Dial(url, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		payload, _ := json.Marshal(request{Query: strings.Repeat(" ", maxRequestBytes) + `{ tasks { id } }`})
+		require.NoError(t, conn.WriteJSON(wsMessage{ID: "1", Type: wsSubscribe, Payload: payload}))
+
+		_, _, err = conn.ReadMessage()
+		var closeErr *websocket.CloseError
+		require.ErrorAs(t, err, &closeErr)
+		assert.Equal(t, websocket.CloseMessageTooBig, closeErr.Code)
+	})
+
+	t.Run("Слишком много операций закрывает соединение с кодом 4429", func(t *testing.T) {
+		dialer := websocket.Dialer{Subprotocols: []string{wsSubprotocol}}
+		conn, _, err := dialer.Dial(url, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+		require.NoError(t, conn.WriteJSON(wsMessage{Type: wsConnectionInit}))
+		var msg wsMessage
+		require.NoError(t, conn.ReadJSON(&msg))
+		require.Equal(t, wsConnectionAck, msg.Type)
+
+		payload, _ := json.Marshal(request{Query: `subscription { taskEvents { type } }`})
+		for i := 0; i <= wsMaxOperations; i++ {
+			require.NoError(t, conn.WriteJSON(wsMessage{ID: strconv.Itoa(i), Type: wsSubscribe, Payload: payload}))
+		}
+
+		_, _, err = conn.ReadMessage()
+		var closeErr *websocket.CloseError
+		require.ErrorAs(t, err, &closeErr)
+		assert.Equal(t, wsCloseTooManyRequests, closeErr.Code)
+	})
+}
@@ -0,0 +1,207 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+)
+
+const (
+	// loaderWait - время, в течение которого загрузчик собирает id в один запрос
+	loaderWait = 2 * time.Millisecond
+	// loaderMaxBatch - максимальное количество id в одном запросе, при достижении запрос отправляется сразу
+	loaderMaxBatch = 100
+)
+
+// fetchTasksFunc функция, которая загружает задачи по списку id одним запросом
+type fetchTasksFunc func(ctx context.Context, ids []int) ([]*models.Task, error)
+
+// taskResult результат загрузки одной задачи
+// Канал done закрывается после того, как task и err заполнены
+type taskResult struct {
+	done chan struct{}
+	task *models.Task
+	err  error
+}
+
+// taskLoader структура, которая объединяет загрузку задач по id, выполняемую параллельными резолверами, в один запрос
+// Загрузчик создается на каждый запрос GraphQL и кеширует результаты до его завершения,
+// поэтому одна и та же задача в пределах запроса загружается не больше одного раза
+type taskLoader struct {
+	ctx   context.Context
+	fetch fetchTasksFunc
+
+	mu      sync.Mutex
+	results map[int]*taskResult
+	// pending - результаты загрузки, ожидающие отправки в следующем запросе
+	pending map[int]*taskResult
+}
+
+// newTaskLoader функция, которая создает новый загрузчик задач
+// @param ctx context.Context - контекст запроса GraphQL, в котором выполняются загрузки
+// @param fetch fetchTasksFunc - функция загрузки задач по списку id
+// @return *taskLoader - новый загрузчик
+func newTaskLoader(ctx context.Context, fetch fetchTasksFunc) *taskLoader {
+	return &taskLoader{
+		ctx:     ctx,
+		fetch:   fetch,
+		results: make(map[int]*taskResult),
+	}
+}
+
+// Load функция, которая загружает задачу по id
+// @param ctx context.Context - контекст резолвера
+// @param id int - id задачи
+// @return *models.Task - задача
+// @return error - ошибка, models.ErrTaskNotFound если задача не найдена
+func (l *taskLoader) Load(ctx context.Context, id int) (*models.Task, error) {
+	return l.wait(ctx, l.enqueue(id))
+}
+
+// LoadMany функция, которая загружает задачи по списку id
+// Отсутствующие задачи пропускаются, порядок найденных задач совпадает с порядком id
+// @param ctx context.Context - контекст резолвера
+// @param ids []int - id задач
+// @return []*models.Task - найденные задачи
+// @return error - ошибка
+func (l *taskLoader) LoadMany(ctx context.Context, ids []int) ([]*models.Task, error) {
+	results := make([]*taskResult, len(ids))
+	for i, id := range ids {
+		results[i] = l.enqueue(id)
+	}
+
+	tasks := make([]*models.Task, 0, len(ids))
+	for _, result := range results {
+		task, err := l.wait(ctx, result)
+		if errors.Is(err, models.ErrTaskNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
+
+// Prime функция, которая сохраняет задачу в кеш загрузчика
+// Используется после мутаций и загрузки всех задач, чтобы последующие резолверы видели актуальную версию
+// @param task *models.Task - задача
+func (l *taskLoader) Prime(task *models.Task) {
+	result := &taskResult{done: make(chan struct{}), task: task}
+	close(result.done)
+
+	l.mu.Lock()
+	l.results[task.ID] = result
+	l.mu.Unlock()
+}
+
+// Clear функция, которая удаляет задачу из кеша загрузчика
+// @param id int - id задачи
+func (l *taskLoader) Clear(id int) {
+	l.mu.Lock()
+	delete(l.results, id)
+	l.mu.Unlock()
+}
+
+// enqueue функция, которая добавляет id в следующий запрос, если задача еще не загружалась
+// @param id int - id задачи
+// @return *taskResult - результат загрузки
+func (l *taskLoader) enqueue(id int) *taskResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if result, ok := l.results[id]; ok {
+		return result
+	}
+
+	result := &taskResult{done: make(chan struct{})}
+	l.results[id] = result
+	if l.pending == nil {
+		l.pending = make(map[int]*taskResult)
+	}
+	l.pending[id] = result
+
+	switch len(l.pending) {
+	case 1:
+		// Первый id в пачке запускает таймер, остальные id успевают присоединиться к запросу
+		time.AfterFunc(loaderWait, l.dispatch)
+	case loaderMaxBatch:
+		go l.dispatch()
+	}
+
+	return result
+}
+
+// dispatch функция, которая отправляет накопленные id одним запросом и раздает результаты
+func (l *taskLoader) dispatch() {
+	l.mu.Lock()
+	results := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+
+	// Пачка могла уже уйти по достижении loaderMaxBatch, тогда таймеру отправлять нечего
+	if len(results) == 0 {
+		return
+	}
+
+	ids := make([]int, 0, len(results))
+	for id := range results {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	tasks, err := l.fetch(l.ctx, ids)
+	for _, task := range tasks {
+		if result := results[task.ID]; result != nil {
+			result.task = task
+		}
+	}
+	for _, result := range results {
+		switch {
+		case err != nil:
+			result.err = err
+		case result.task == nil:
+			result.err = models.ErrTaskNotFound
+		}
+		close(result.done)
+	}
+}
+
+// wait функция, которая ожидает результат загрузки
+// @param ctx context.Context - контекст резолвера
+// @param result *taskResult - результат загрузки
+// @return *models.Task - задача
+// @return error - ошибка
+func (l *taskLoader) wait(ctx context.Context, result *taskResult) (*models.Task, error) {
+	select {
+	case <-result.done:
+		return result.task, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// loaderKey ключ контекста, под которым хранится загрузчик задач
+type loaderKey struct{}
+
+// withLoader функция, которая сохраняет загрузчик задач в контексте запроса
+// @param ctx context.Context - контекст запроса
+// @param loader *taskLoader - загрузчик задач
+// @return context.Context - контекст с загрузчиком
+func withLoader(ctx context.Context, loader *taskLoader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, loader)
+}
+
+// loaderFrom функция, которая возвращает загрузчик задач из контекста запроса
+// @param ctx context.Context - контекст запроса
+// @return *taskLoader - загрузчик задач, nil если его нет
+func loaderFrom(ctx context.Context) *taskLoader {
+	loader, _ := ctx.Value(loaderKey{}).(*taskLoader)
+	return loader
+}
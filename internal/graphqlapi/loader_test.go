@@ -0,0 +1,85 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTaskLoader тестирует объединение загрузок задач в один запрос
+func TestTaskLoader(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Параллельные загрузки объединяются и не повторяются", func(t *testing.T) {
+		var mu sync.Mutex
+		var calls [][]int
+		loader := newTaskLoader(ctx, func(_ context.Context, ids []int) ([]*models.Task, error) {
+			mu.Lock()
+			calls = append(calls, ids)
+			mu.Unlock()
+
+			tasks := make([]*models.Task, 0, len(ids))
+			for _, id := range ids {
+				if id != 404 {
+					tasks = append(tasks, &models.Task{ID: id})
+				}
+			}
+			return tasks, nil
+		})
+
+		var wg sync.WaitGroup
+		for _, id := range []int{3, 1, 2, 1, 404} {
+			wg.Add(1)
+			go func(id int) {
+				defer wg.Done()
+				task, err := loader.Load(ctx, id)
+				if id == 404 {
+					assert.ErrorIs(t, err, models.ErrTaskNotFound)
+					return
+				}
+				assert.NoError(t, err)
+				assert.Equal(t, id, task.ID)
+			}(id)
+		}
+		wg.Wait()
+
+		// Повторная загрузка берется из кеша загрузчика
+		tasks, err := loader.LoadMany(ctx, []int{2, 404, 1})
+		require.NoError(t, err)
+		assert.Equal(t, []*models.Task{{ID: 2}, {ID: 1}}, tasks)
+
+		assert.Equal(t, [][]int{{1, 2, 3, 404}}, calls)
+	})
+
+	t.Run("Ошибка загрузки возвращается всем ожидающим", func(t *testing.T) {
+		expectedError := errors.New("ошибка базы данных")
+		loader := newTaskLoader(ctx, func(context.Context, []int) ([]*models.Task, error) {
+			return nil, expectedError
+		})
+
+		_, err := loader.LoadMany(ctx, []int{1, 2})
+
+		assert.ErrorIs(t, err, expectedError)
+	})
+
+	t.Run("Prime и Clear обновляют кеш загрузчика", func(t *testing.T) {
+		loader := newTaskLoader(ctx, func(_ context.Context, ids []int) ([]*models.Task, error) {
+			return []*models.Task{{ID: ids[0], Version: 1}}, nil
+		})
+
+		loader.Prime(&models.Task{ID: 1, Version: 5})
+		task, err := loader.Load(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 5, task.Version)
+
+		loader.Clear(1)
+		task, err = loader.Load(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, task.Version)
+	})
+}
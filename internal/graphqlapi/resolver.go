@@ -0,0 +1,254 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/pers0na2dev/todo-api/internal/models"
+)
+
+// TaskService интерфейс, который определяет методы сервиса задач, нужные GraphQL API
+type TaskService interface {
+	CreateTask(ctx context.Context, title string) (*models.Task, error)
+	GetTasks(ctx context.Context) ([]*models.Task, error)
+	GetTasksByIDs(ctx context.Context, ids []int) ([]*models.Task, error)
+	OpenCloseTask(ctx context.Context, id int, version int) (*models.Task, error)
+	RemoveTask(ctx context.Context, id int, version int) error
+	SearchTasks(ctx context.Context, query string, lang string, limit int) ([]*models.TaskSearchResult, error)
+	WatchTasks(ctx context.Context) <-chan models.TaskEvent
+}
+
+// resolver корневой резолвер схемы GraphQL
+type resolver struct {
+	taskService TaskService
+}
+
+// loader функция, которая возвращает загрузчик задач текущего запроса
+// Если запрос выполняется без загрузчика, создается отдельный загрузчик только для этого поля
+// @param ctx context.Context - контекст запроса
+// @return *taskLoader - загрузчик задач
+func (r *resolver) loader(ctx context.Context) *taskLoader {
+	if loader := loaderFrom(ctx); loader != nil {
+		return loader
+	}
+	return newTaskLoader(ctx, r.taskService.GetTasksByIDs)
+}
+
+// Task функция, которая возвращает задачу по id
+func (r *resolver) Task(ctx context.Context, args struct{ ID graphql.ID }) (*taskResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := r.loader(ctx).Load(ctx, id)
+	if errors.Is(err, models.ErrTaskNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+
+	return &taskResolver{task: task}, nil
+}
+
+// Tasks функция, которая возвращает задачи по списку id или все задачи
+func (r *resolver) Tasks(ctx context.Context, args struct{ IDs *[]graphql.ID }) ([]*taskResolver, error) {
+	loader := r.loader(ctx)
+
+	if args.IDs == nil {
+		tasks, err := r.taskService.GetTasks(ctx)
+		if err != nil {
+			return nil, toGraphQLError(err)
+		}
+		for _, task := range tasks {
+			loader.Prime(task)
+		}
+		return taskResolvers(tasks), nil
+	}
+
+	ids := make([]int, 0, len(*args.IDs))
+	for _, rawID := range *args.IDs {
+		id, err := parseID(rawID)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	tasks, err := loader.LoadMany(ctx, ids)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+
+	return taskResolvers(tasks), nil
+}
+
+// Search функция, которая выполняет полнотекстовый поиск задач
+func (r *resolver) Search(ctx context.Context, args struct {
+	Q     string
+	Lang  *string
+	Limit *int32
+}) ([]*searchResultResolver, error) {
+	lang, limit := "", 0
+	if args.Lang != nil {
+		lang = *args.Lang
+	}
+	if args.Limit != nil {
+		limit = int(*args.Limit)
+	}
+
+	results, err := r.taskService.SearchTasks(ctx, args.Q, lang, limit)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+
+	resolvers := make([]*searchResultResolver, 0, len(results))
+	for _, result := range results {
+		resolvers = append(resolvers, &searchResultResolver{result: result})
+	}
+	return resolvers, nil
+}
+
+// CreateTask функция, которая создает новую задачу
+func (r *resolver) CreateTask(ctx context.Context, args struct{ Title string }) (*taskResolver, error) {
+	task := models.Task{Title: args.Title}
+	if err := task.Validate(); err != nil {
+		return nil, &gqlError{code: codeBadUserInput, message: err.Error()}
+	}
+
+	created, err := r.taskService.CreateTask(ctx, task.Title)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	r.loader(ctx).Prime(created)
+
+	return &taskResolver{task: created}, nil
+}
+
+// ToggleTask функция, которая открывает или закрывает задачу
+func (r *resolver) ToggleTask(ctx context.Context, args struct {
+	ID      graphql.ID
+	Version int32
+}) (*taskResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	if args.Version <= 0 {
+		return nil, &gqlError{code: codeBadUserInput, message: "version must be positive"}
+	}
+
+	task, err := r.taskService.OpenCloseTask(ctx, id, int(args.Version))
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	r.loader(ctx).Prime(task)
+
+	return &taskResolver{task: task}, nil
+}
+
+// DeleteTask функция, которая удаляет задачу
+func (r *resolver) DeleteTask(ctx context.Context, args struct {
+	ID      graphql.ID
+	Version int32
+}) (bool, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return false, err
+	}
+	if args.Version <= 0 {
+		return false, &gqlError{code: codeBadUserInput, message: "version must be positive"}
+	}
+
+	if err := r.taskService.RemoveTask(ctx, id, int(args.Version)); err != nil {
+		return false, toGraphQLError(err)
+	}
+	r.loader(ctx).Clear(id)
+
+	return true, nil
+}
+
+// TaskEvents функция, которая подписывает клиента на события об изменении задач
+// Канал закрывается после завершения подписки или если клиент не успевает читать события,
+// тогда клиент получает complete и должен перечитать задачи
+func (r *resolver) TaskEvents(ctx context.Context) <-chan *taskEventResolver {
+	events := r.taskService.WatchTasks(ctx)
+	out := make(chan *taskEventResolver)
+
+	go func() {
+		defer close(out)
+		for event := range events {
+			select {
+			case out <- &taskEventResolver{event: event}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// taskResolver резолвер типа Task
+type taskResolver struct {
+	task *models.Task
+}
+
+func (r *taskResolver) ID() graphql.ID  { return graphql.ID(strconv.Itoa(r.task.ID)) }
+func (r *taskResolver) Title() string   { return r.task.Title }
+func (r *taskResolver) Completed() bool { return r.task.Completed }
+func (r *taskResolver) Version() int32  { return int32(r.task.Version) }
+
+// DueAt функция, которая возвращает срок выполнения задачи в RFC 3339
+func (r *taskResolver) DueAt() *string {
+	if r.task.DueAt == nil {
+		return nil
+	}
+	dueAt := r.task.DueAt.Format(time.RFC3339)
+	return &dueAt
+}
+
+// searchResultResolver резолвер типа SearchResult
+type searchResultResolver struct {
+	result *models.TaskSearchResult
+}
+
+func (r *searchResultResolver) Task() *taskResolver { return &taskResolver{task: &r.result.Task} }
+func (r *searchResultResolver) Rank() float64       { return float64(r.result.Rank) }
+func (r *searchResultResolver) Headline() string    { return r.result.Headline }
+
+// taskEventResolver резолвер типа TaskEvent
+type taskEventResolver struct {
+	event models.TaskEvent
+}
+
+func (r *taskEventResolver) Type() string        { return strings.ToUpper(string(r.event.Type)) }
+func (r *taskEventResolver) Task() *taskResolver { return &taskResolver{task: r.event.Task} }
+
+// taskResolvers функция, которая оборачивает задачи в резолверы
+// @param tasks []*models.Task - задачи
+// @return []*taskResolver - резолверы задач
+func taskResolvers(tasks []*models.Task) []*taskResolver {
+	resolvers := make([]*taskResolver, 0, len(tasks))
+	for _, task := range tasks {
+		resolvers = append(resolvers, &taskResolver{task: task})
+	}
+	return resolvers
+}
+
+// parseID функция, которая преобразует ID GraphQL в id задачи
+// @param id graphql.ID - ID GraphQL
+// @return int - id задачи
+// @return error - ошибка, если ID не является положительным числом
+func parseID(id graphql.ID) (int, error) {
+	value, err := strconv.Atoi(string(id))
+	if err != nil || value <= 0 {
+		return 0, &gqlError{code: codeBadUserInput, message: "id must be a positive integer"}
+	}
+	return value, nil
+}
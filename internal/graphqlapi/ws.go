@@ -0,0 +1,295 @@
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/graph-gophers/graphql-go"
+	"go.uber.org/zap"
+)
+
+const (
+	// wsSubprotocol - подпротокол WebSocket для GraphQL
+	// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md
+	wsSubprotocol = "graphql-transport-ws"
+	// wsInitTimeout - время, за которое клиент должен отправить connection_init
+	wsInitTimeout = 10 * time.Second
+	// wsWriteTimeout - максимальное время записи одного сообщения
+	wsWriteTimeout = 10 * time.Second
+	// wsMaxOperations - максимальное число одновременно выполняемых операций одного соединения
+	wsMaxOperations = 32
+)
+
+// Типы сообщений протокола graphql-transport-ws
+const (
+	wsConnectionInit = "connection_init"
+	wsConnectionAck  = "connection_ack"
+	wsPing           = "ping"
+	wsPong           = "pong"
+	wsSubscribe      = "subscribe"
+	wsNext           = "next"
+	wsError          = "error"
+	wsComplete       = "complete"
+)
+
+// Коды закрытия соединения протокола graphql-transport-ws
+const (
+	wsCloseBadRequest      = 4400
+	wsCloseUnauthorized    = 4401
+	wsCloseInitTimeout     = 4408
+	wsCloseDuplicateID     = 4409
+	wsCloseTooManyRequests = 4429
+	wsCloseInternalServer  = 4500
+)
+
+// wsMessage сообщение протокола graphql-transport-ws
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// ServeWebSocket функция, которая принимает соединение WebSocket и обслуживает подписки GraphQL
+func (h *Handler) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	if !slices.Contains(websocket.Subprotocols(r), wsSubprotocol) {
+		http.Error(w, "websocket subprotocol "+wsSubprotocol+" is required", http.StatusBadRequest)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrader уже отправил клиенту ответ с ошибкой
+		h.logger.Warn("failed to upgrade graphql websocket", zap.Error(err))
+		return
+	}
+	// Сообщение subscribe содержит тот же запрос, что и тело POST /graphql, поэтому и ограничение размера то же
+	conn.SetReadLimit(maxRequestBytes)
+
+	session := &wsSession{
+		handler:    h,
+		conn:       conn,
+		operations: make(map[string]context.CancelFunc),
+	}
+	session.run(r.Context())
+}
+
+// wsSession структура, которая хранит состояние одного соединения graphql-transport-ws
+type wsSession struct {
+	handler *Handler
+	conn    *websocket.Conn
+	// writeMu - соединение не поддерживает параллельную запись, а ответы операций отправляются из разных горутин
+	writeMu sync.Mutex
+
+	mu           sync.Mutex
+	initReceived bool
+	acknowledged bool
+	operations   map[string]context.CancelFunc
+}
+
+// run функция, которая читает сообщения клиента до закрытия соединения
+// После выхода все операции соединения отменяются
+// @param ctx context.Context - контекст соединения
+func (s *wsSession) run(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer s.conn.Close()
+
+	// Клиент, не отправивший connection_init вовремя, отключается
+	initTimer := time.AfterFunc(wsInitTimeout, func() {
+		s.mu.Lock()
+		acknowledged := s.acknowledged
+		s.mu.Unlock()
+		if !acknowledged {
+			s.close(wsCloseInitTimeout, "Connection initialisation timeout")
+		}
+	})
+	defer initTimer.Stop()
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg wsMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			s.close(wsCloseBadRequest, "Invalid message received")
+			return
+		}
+
+		if !s.handle(ctx, msg) {
+			return
+		}
+	}
+}
+
+// handle функция, которая обрабатывает одно сообщение клиента
+// @param ctx context.Context - контекст соединения
+// @param msg wsMessage - сообщение
+// @return bool - false если соединение закрыто
+func (s *wsSession) handle(ctx context.Context, msg wsMessage) bool {
+	switch msg.Type {
+	case wsConnectionInit:
+		s.mu.Lock()
+		repeated := s.initReceived
+		s.initReceived = true
+		s.acknowledged = true
+		s.mu.Unlock()
+
+		if repeated {
+			s.close(wsCloseTooManyRequests, "Too many initialisation requests")
+			return false
+		}
+		return s.write(wsMessage{Type: wsConnectionAck}) == nil
+	case wsPing:
+		return s.write(wsMessage{Type: wsPong}) == nil
+	case wsPong:
+		return true
+	case wsSubscribe:
+		return s.subscribe(ctx, msg)
+	case wsComplete:
+		s.mu.Lock()
+		if cancel, ok := s.operations[msg.ID]; ok {
+			cancel()
+			delete(s.operations, msg.ID)
+		}
+		s.mu.Unlock()
+		return true
+	default:
+		s.close(wsCloseBadRequest, fmt.Sprintf("Unexpected message type %q", msg.Type))
+		return false
+	}
+}
+
+// subscribe функция, которая запускает операцию GraphQL из сообщения subscribe
+// @param ctx context.Context - контекст соединения
+// @param msg wsMessage - сообщение subscribe
+// @return bool - false если соединение закрыто
+func (s *wsSession) subscribe(ctx context.Context, msg wsMessage) bool {
+	var req request
+	if msg.ID == "" || json.Unmarshal(msg.Payload, &req) != nil || req.Query == "" {
+		s.close(wsCloseBadRequest, "Invalid subscribe message")
+		return false
+	}
+
+	s.mu.Lock()
+	if !s.acknowledged {
+		s.mu.Unlock()
+		s.close(wsCloseUnauthorized, "Unauthorized")
+		return false
+	}
+	if _, ok := s.operations[msg.ID]; ok {
+		s.mu.Unlock()
+		s.close(wsCloseDuplicateID, fmt.Sprintf("Subscriber for %s already exists", msg.ID))
+		return false
+	}
+	// Каждая операция держит горутину и подписку на события, поэтому их число на соединение ограничено
+	if len(s.operations) >= wsMaxOperations {
+		s.mu.Unlock()
+		s.close(wsCloseTooManyRequests, "Too many operations")
+		return false
+	}
+	opCtx, cancel := context.WithCancel(ctx)
+	s.operations[msg.ID] = cancel
+	s.mu.Unlock()
+
+	go s.execute(opCtx, msg.ID, req)
+	return true
+}
+
+// execute функция, которая выполняет операцию и отправляет клиенту ее результаты
+// Запросы и мутации отправляют один результат, подписки - результат на каждое событие
+// @param ctx context.Context - контекст операции, отменяется сообщением complete от клиента
+// @param id string - id операции
+// @param req request - запрос GraphQL
+func (s *wsSession) execute(ctx context.Context, id string, req request) {
+	defer func() {
+		s.mu.Lock()
+		if cancel, ok := s.operations[id]; ok {
+			cancel()
+			delete(s.operations, id)
+		}
+		s.mu.Unlock()
+	}()
+
+	if err := checkComplexity(req.Query, req.OperationName, req.Variables); err != nil {
+		s.writeError(id, errorResponse(err))
+		return
+	}
+
+	ctx = withLoader(ctx, newTaskLoader(ctx, s.handler.taskService.GetTasksByIDs))
+	responses, err := s.handler.schema.Subscribe(ctx, req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		s.writeError(id, errorResponse(err))
+		return
+	}
+
+	first := true
+	for value := range responses {
+		resp, ok := value.(*graphql.Response)
+		if !ok {
+			continue
+		}
+		// Ошибки до начала выполнения, например ошибки валидации, отправляются сообщением error без complete
+		if first && resp.Data == nil && len(resp.Errors) > 0 {
+			s.writeError(id, resp)
+			return
+		}
+		first = false
+
+		payload, err := json.Marshal(resp)
+		if err != nil {
+			s.handler.logger.Error("failed to encode graphql response", zap.Error(err))
+			s.close(wsCloseInternalServer, "Internal server error")
+			return
+		}
+		if s.write(wsMessage{ID: id, Type: wsNext, Payload: payload}) != nil {
+			return
+		}
+	}
+
+	// Если операцию завершил клиент, complete отправлять не нужно
+	if ctx.Err() == nil {
+		s.write(wsMessage{ID: id, Type: wsComplete})
+	}
+}
+
+// writeError функция, которая отправляет клиенту сообщение error с ошибками ответа
+// @param id string - id операции
+// @param resp *graphql.Response - ответ с ошибками
+func (s *wsSession) writeError(id string, resp *graphql.Response) {
+	payload, err := json.Marshal(resp.Errors)
+	if err != nil {
+		s.handler.logger.Error("failed to encode graphql errors", zap.Error(err))
+		return
+	}
+	s.write(wsMessage{ID: id, Type: wsError, Payload: payload})
+}
+
+// write функция, которая отправляет сообщение клиенту
+// @param msg wsMessage - сообщение
+// @return error - ошибка записи
+func (s *wsSession) write(msg wsMessage) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return s.conn.WriteJSON(msg)
+}
+
+// close функция, которая закрывает соединение с кодом протокола
+// @param code int - код закрытия
+// @param reason string - причина закрытия
+func (s *wsSession) close(code int, reason string) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(wsWriteTimeout))
+	s.conn.Close()
+}
@@ -0,0 +1,75 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/pers0na2dev/todo-api/internal/config"
+	todov1 "github.com/pers0na2dev/todo-api/pkg/pb/todo/v1"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// NewServer создает новый gRPC сервер и запускает его вместе с приложением
+func NewServer(lc fx.Lifecycle, cfg *config.Config, taskService TaskService, logger *zap.Logger) *grpc.Server {
+	server, healthServer := newGRPCServer(taskService)
+
+	// Добавление хука жизненного цикла fx для запуска и завершения сервера
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			listener, err := net.Listen("tcp", cfg.GRPCPort)
+			if err != nil {
+				return fmt.Errorf("failed to listen grpc port: %w", err)
+			}
+
+			logger.Info("starting grpc server", zap.String("port", cfg.GRPCPort))
+			go server.Serve(listener) // Запуск gRPC сервера в отдельной горутине
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			logger.Info("shutting down grpc server")
+			// Сообщаем балансировщикам, что сервер больше не принимает запросы
+			healthServer.Shutdown()
+
+			stopped := make(chan struct{})
+			go func() {
+				server.GracefulStop()
+				close(stopped)
+			}()
+
+			select {
+			case <-stopped:
+			case <-ctx.Done():
+				// Потоки WatchTasks могут не завершиться сами, поэтому закрываем их принудительно
+				server.Stop()
+			}
+			return nil
+		},
+	})
+
+	return server
+}
+
+// newGRPCServer функция, которая создает gRPC сервер с зарегистрированными сервисами
+// @param taskService TaskService - сервис задач
+// @return *grpc.Server - gRPC сервер
+// @return *health.Server - сервис проверки состояния
+func newGRPCServer(taskService TaskService) (*grpc.Server, *health.Server) {
+	server := grpc.NewServer()
+
+	todov1.RegisterTaskServiceServer(server, NewTaskServer(taskService))
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(todov1.TaskService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	// Reflection позволяет вызывать методы через grpcurl без .proto файлов
+	reflection.Register(server)
+
+	return server, healthServer
+}
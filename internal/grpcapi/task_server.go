@@ -0,0 +1,192 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+	todov1 "github.com/pers0na2dev/todo-api/pkg/pb/todo/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TaskService интерфейс, который определяет методы сервиса задач, нужные gRPC API
+type TaskService interface {
+	CreateTask(ctx context.Context, title string) (*models.Task, error)
+	GetTasks(ctx context.Context) ([]*models.Task, error)
+	GetTaskByID(ctx context.Context, id int) (*models.Task, error)
+	OpenCloseTask(ctx context.Context, id int, version int) (*models.Task, error)
+	RemoveTask(ctx context.Context, id int, version int) error
+	WatchTasks(ctx context.Context) <-chan models.TaskEvent
+}
+
+// TaskServer структура, которая реализует gRPC сервис todo.v1.TaskService поверх TaskService
+type TaskServer struct {
+	todov1.UnimplementedTaskServiceServer
+	taskService TaskService
+}
+
+// NewTaskServer функция, которая создает новый экземпляр TaskServer
+// @param taskService TaskService - сервис задач
+// @return *TaskServer - новый экземпляр TaskServer
+func NewTaskServer(taskService TaskService) *TaskServer {
+	return &TaskServer{taskService: taskService}
+}
+
+// CreateTask функция, которая создает новую задачу
+func (s *TaskServer) CreateTask(ctx context.Context, req *todov1.CreateTaskRequest) (*todov1.Task, error) {
+	task := models.Task{Title: req.GetTitle()}
+	if err := task.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	created, err := s.taskService.CreateTask(ctx, task.Title)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toProto(created), nil
+}
+
+// GetTask функция, которая возвращает задачу по id
+func (s *TaskServer) GetTask(ctx context.Context, req *todov1.GetTaskRequest) (*todov1.Task, error) {
+	if req.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "id must be positive")
+	}
+
+	task, err := s.taskService.GetTaskByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toProto(task), nil
+}
+
+// ListTasks функция, которая возвращает все задачи
+func (s *TaskServer) ListTasks(ctx context.Context, _ *todov1.ListTasksRequest) (*todov1.ListTasksResponse, error) {
+	tasks, err := s.taskService.GetTasks(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &todov1.ListTasksResponse{Tasks: make([]*todov1.Task, 0, len(tasks))}
+	for _, task := range tasks {
+		resp.Tasks = append(resp.Tasks, toProto(task))
+	}
+
+	return resp, nil
+}
+
+// ToggleTask функция, которая открывает или закрывает задачу
+func (s *TaskServer) ToggleTask(ctx context.Context, req *todov1.ToggleTaskRequest) (*todov1.Task, error) {
+	if req.GetId() <= 0 || req.GetVersion() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "id and version must be positive")
+	}
+
+	task, err := s.taskService.OpenCloseTask(ctx, int(req.GetId()), int(req.GetVersion()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toProto(task), nil
+}
+
+// DeleteTask функция, которая удаляет задачу
+func (s *TaskServer) DeleteTask(ctx context.Context, req *todov1.DeleteTaskRequest) (*emptypb.Empty, error) {
+	if req.GetId() <= 0 || req.GetVersion() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "id and version must be positive")
+	}
+
+	if err := s.taskService.RemoveTask(ctx, int(req.GetId()), int(req.GetVersion())); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// WatchTasks функция, которая отправляет клиенту события об изменении задач
+// Поток завершается, когда клиент закрывает его или сервер останавливается.
+// Если клиент не успевает читать события, поток завершается с ResourceExhausted, и клиент должен перечитать задачи
+func (s *TaskServer) WatchTasks(_ *todov1.WatchTasksRequest, stream todov1.TaskService_WatchTasksServer) error {
+	ctx := stream.Context()
+	events := s.taskService.WatchTasks(ctx)
+
+	// Заголовки отправляются сразу после подписки, чтобы клиент знал, что ни одно последующее событие не будет пропущено
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return toStatus(ctx.Err())
+		case event, ok := <-events:
+			if !ok {
+				if ctx.Err() != nil {
+					return toStatus(ctx.Err())
+				}
+				return status.Error(codes.ResourceExhausted, "task events were dropped, list tasks again and resubscribe")
+			}
+			if err := stream.Send(toProtoEvent(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// toStatus функция, которая преобразует ошибку сервиса в статус gRPC
+// @param err error - ошибка сервиса
+// @return error - ошибка со статусом gRPC
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, models.ErrTaskNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, models.ErrVersionConflict):
+		// Aborted означает, что клиенту нужно перечитать задачу и повторить операцию
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, models.ErrInvalidBatch), errors.Is(err, models.ErrInvalidSearch):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// toProto функция, которая преобразует задачу в сообщение protobuf
+// @param task *models.Task - задача
+// @return *todov1.Task - сообщение protobuf
+func toProto(task *models.Task) *todov1.Task {
+	message := &todov1.Task{
+		Id:        int64(task.ID),
+		Title:     task.Title,
+		Completed: task.Completed,
+		Version:   int64(task.Version),
+	}
+	if task.DueAt != nil {
+		message.DueAt = timestamppb.New(*task.DueAt)
+	}
+	return message
+}
+
+// toProtoEvent функция, которая преобразует событие в сообщение protobuf
+// @param event models.TaskEvent - событие
+// @return *todov1.TaskEvent - сообщение protobuf
+func toProtoEvent(event models.TaskEvent) *todov1.TaskEvent {
+	eventType := todov1.TaskEvent_TYPE_UNSPECIFIED
+	switch event.Type {
+	case models.TaskCreated:
+		eventType = todov1.TaskEvent_TYPE_CREATED
+	case models.TaskUpdated:
+		eventType = todov1.TaskEvent_TYPE_UPDATED
+	case models.TaskDeleted:
+		eventType = todov1.TaskEvent_TYPE_DELETED
+	}
+
+	return &todov1.TaskEvent{Type: eventType, Task: toProto(event.Task)}
+}
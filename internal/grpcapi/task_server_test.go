@@ -0,0 +1,178 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/config"
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/pers0na2dev/todo-api/internal/service"
+	"github.com/pers0na2dev/todo-api/internal/service/mocks"
+	"github.com/pers0na2dev/todo-api/pkg/cache"
+	todov1 "github.com/pers0na2dev/todo-api/pkg/pb/todo/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// setupGRPCTest запускает gRPC сервер поверх bufconn и возвращает подключенного к нему клиента
+func setupGRPCTest(t *testing.T) (*grpc.ClientConn, *mocks.TaskRepository) {
+	t.Helper()
+
+	logger := zap.NewNop()
+	mockRepo := new(mocks.TaskRepository)
+	// События рассылаются только внутри процесса: кеш в памяти не поддерживает каналы
+	memoryCache, err := cache.NewMemoryCache(cache.MemoryOptions{})
+	require.NoError(t, err)
+	events, err := service.NewEventBroker(fxtest.NewLifecycle(t), memoryCache, logger)
+	require.NoError(t, err)
+	taskService := service.NewTaskService(mockRepo, events, &config.Config{SearchConfig: "english"}, logger)
+
+	return dialGRPCTest(t, taskService), mockRepo
+}
+
+// dialGRPCTest запускает gRPC сервер поверх bufconn с переданным сервисом задач и подключается к нему
+func dialGRPCTest(t *testing.T, taskService TaskService) *grpc.ClientConn {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server, _ := newGRPCServer(taskService)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// TestTaskServerUnary тестирует unary методы и преобразование ошибок в статусы gRPC
+func TestTaskServerUnary(t *testing.T) {
+	conn, mockRepo := setupGRPCTest(t)
+	client := todov1.NewTaskServiceClient(conn)
+	ctx := context.Background()
+
+	t.Run("Успешное получение задачи", func(t *testing.T) {
+		mockRepo.On("GetTaskByID", mock.Anything, 1).Return(&models.Task{ID: 1, Title: "Задача", Version: 2}, nil).Once()
+
+		task, err := client.GetTask(ctx, &todov1.GetTaskRequest{Id: 1})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Задача", task.GetTitle())
+		assert.Equal(t, int64(2), task.GetVersion())
+	})
+
+	t.Run("Несуществующая задача возвращает NotFound", func(t *testing.T) {
+		mockRepo.On("GetTaskByID", mock.Anything, 2).Return(nil, models.ErrTaskNotFound).Once()
+
+		_, err := client.GetTask(ctx, &todov1.GetTaskRequest{Id: 2})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("Конфликт версий возвращает Aborted", func(t *testing.T) {
+		mockRepo.On("GetTaskByID", mock.Anything, 3).Return(&models.Task{ID: 3, Title: "Задача", Version: 5}, nil).Once()
+
+		_, err := client.ToggleTask(ctx, &todov1.ToggleTaskRequest{Id: 3, Version: 4})
+
+		assert.Equal(t, codes.Aborted, status.Code(err))
+	})
+
+	t.Run("Пустое название возвращает InvalidArgument", func(t *testing.T) {
+		_, err := client.CreateTask(ctx, &todov1.CreateTaskRequest{Title: " "})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		mockRepo.AssertNotCalled(t, "CreateTask", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Ошибка базы данных возвращает Internal", func(t *testing.T) {
+		mockRepo.On("DeleteTask", mock.Anything, 4, 1).Return(assert.AnError).Once()
+
+		_, err := client.DeleteTask(ctx, &todov1.DeleteTaskRequest{Id: 4, Version: 1})
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestTaskServerWatchTasks тестирует получение событий через серверный поток
+func TestTaskServerWatchTasks(t *testing.T) {
+	conn, mockRepo := setupGRPCTest(t)
+	client := todov1.NewTaskServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.WatchTasks(ctx, &todov1.WatchTasksRequest{})
+	require.NoError(t, err)
+
+	// Сервер отправляет заголовки после подписки, поэтому дальше события не теряются
+	_, err = stream.Header()
+	require.NoError(t, err)
+
+	mockRepo.On("CreateTask", mock.Anything, &models.Task{Title: "Новая задача"}).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Task).ID = 10
+	}).Return(nil).Once()
+	_, err = client.CreateTask(ctx, &todov1.CreateTaskRequest{Title: "Новая задача"})
+	require.NoError(t, err)
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, todov1.TaskEvent_TYPE_CREATED, event.GetType())
+	assert.Equal(t, int64(10), event.GetTask().GetId())
+}
+
+// laggingTaskService сервис задач, который сразу отключает подписчика, как EventBroker отключает медленных подписчиков
+type laggingTaskService struct {
+	TaskService
+}
+
+// WatchTasks возвращает закрытый канал
+func (laggingTaskService) WatchTasks(context.Context) <-chan models.TaskEvent {
+	events := make(chan models.TaskEvent)
+	close(events)
+	return events
+}
+
+// TestTaskServerWatchTasksLagging тестирует завершение потока, если клиент не успевает читать события
+func TestTaskServerWatchTasksLagging(t *testing.T) {
+	client := todov1.NewTaskServiceClient(dialGRPCTest(t, laggingTaskService{}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.WatchTasks(ctx, &todov1.WatchTasksRequest{})
+	require.NoError(t, err)
+
+	// Клиент должен перечитать задачи и подписаться снова
+	_, err = stream.Recv()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+// TestHealth тестирует сервис проверки состояния
+func TestHealth(t *testing.T) {
+	conn, _ := setupGRPCTest(t)
+	client := healthpb.NewHealthClient(conn)
+
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: todov1.TaskService_ServiceDesc.ServiceName})
+
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+}
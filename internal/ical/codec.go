@@ -0,0 +1,79 @@
+package ical
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/config"
+	"github.com/pers0na2dev/todo-api/internal/models"
+)
+
+// Codec формат text/calendar для импорта задач из VTODO
+// Поддерживает только []models.TaskRecord, поэтому в ответах других маршрутов не предлагается
+type Codec struct {
+	loc *time.Location
+}
+
+// NewCodec функция, которая создает формат text/calendar
+// @param cfg *config.Config - конфигурация, из CALENDAR_TIMEZONE берется пояс для времени без пояса
+// @return *Codec - новый экземпляр Codec
+// @return error - ошибка, если часовой пояс неизвестен
+func NewCodec(cfg *config.Config) (*Codec, error) {
+	loc, err := cfg.CalendarLocation()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CALENDAR_TIMEZONE: %w", err)
+	}
+	return &Codec{loc: loc}, nil
+}
+
+// MediaTypes функция, которая возвращает типы содержимого iCalendar
+func (c *Codec) MediaTypes() []string {
+	return []string{MediaType}
+}
+
+// Supports функция, которая проверяет поддержку типа
+func (c *Codec) Supports(t reflect.Type) bool {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t == reflect.TypeFor[[]models.TaskRecord]()
+}
+
+// Encode функция, которая кодирует задачи в календарь из VTODO
+func (c *Codec) Encode(w io.Writer, v any) error {
+	records, ok := reflect.Indirect(reflect.ValueOf(v)).Interface().([]models.TaskRecord)
+	if !ok {
+		return fmt.Errorf("type %T can not be encoded as icalendar", v)
+	}
+
+	now := time.Now()
+	enc := NewEncoder(w)
+	BeginCalendar(enc, "Tasks", c.loc, now)
+	for i := range records {
+		enc.Component(Todo(&records[i], c.loc, now))
+	}
+	enc.End("VCALENDAR")
+	return enc.Flush()
+}
+
+// Decode функция, которая декодирует VTODO календаря в задачи импорта
+func (c *Codec) Decode(r io.Reader, v any) error {
+	target, ok := v.(*[]models.TaskRecord)
+	if !ok {
+		return fmt.Errorf("icalendar decode target must be *[]models.TaskRecord, got %T", v)
+	}
+
+	calendar, err := Decode(r)
+	if err != nil {
+		return err
+	}
+	records, err := RecordsFromCalendar(calendar, c.loc)
+	if err != nil {
+		return err
+	}
+
+	*target = append(*target, records...)
+	return nil
+}
@@ -0,0 +1,76 @@
+package ical
+
+import (
+	"fmt"
+	"time"
+
+	// База часовых поясов встроена, чтобы TZID из загружаемых календарей распознавались и в контейнерах без tzdata
+	_ "time/tzdata"
+)
+
+const (
+	dateTimeLayout = "20060102T150405"
+	dateLayout     = "20060102"
+)
+
+// DateTime функция, которая создает свойство со временем
+// Время в UTC записывается с суффиксом Z, в остальных поясах - как местное время с параметром TZID
+// @param name string - имя свойства
+// @param t time.Time - время
+// @param loc *time.Location - часовой пояс, в котором записывается время
+// @return Property - свойство
+func DateTime(name string, t time.Time, loc *time.Location) Property {
+	if loc == time.UTC {
+		return Property{Name: name, Value: t.UTC().Format(dateTimeLayout) + "Z"}
+	}
+	return Property{
+		Name:   name,
+		Params: map[string]string{"TZID": loc.String()},
+		Value:  t.In(loc).Format(dateTimeLayout),
+	}
+}
+
+// UTCDateTime функция, которая создает свойство со временем в UTC, например DTSTAMP
+// @param name string - имя свойства
+// @param t time.Time - время
+// @return Property - свойство
+func UTCDateTime(name string, t time.Time) Property {
+	return DateTime(name, t, time.UTC)
+}
+
+// ParseDateTime функция, которая разбирает значение свойства со временем или датой
+// Время без пояса и даты без времени считаются местными для loc. Если TZID не найден в базе часовых поясов,
+// например у календарей Outlook с именами вида "Russian Standard Time", тоже используется loc
+// @param p *Property - свойство
+// @param loc *time.Location - часовой пояс по умолчанию
+// @return time.Time - время
+// @return error - ошибка разбора
+func ParseDateTime(p *Property, loc *time.Location) (time.Time, error) {
+	if tzid, ok := p.Params["TZID"]; ok {
+		if zone, err := time.LoadLocation(tzid); err == nil {
+			loc = zone
+		}
+	}
+
+	value := p.Value
+	switch {
+	case p.Params["VALUE"] == "DATE" || len(value) == len(dateLayout):
+		t, err := time.ParseInLocation(dateLayout, value, loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s: invalid date %q", p.Name, value)
+		}
+		return t, nil
+	case len(value) == len(dateTimeLayout)+1 && value[len(value)-1] == 'Z':
+		t, err := time.Parse(dateTimeLayout, value[:len(value)-1])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s: invalid date-time %q", p.Name, value)
+		}
+		return t, nil
+	default:
+		t, err := time.ParseInLocation(dateTimeLayout, value, loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s: invalid date-time %q", p.Name, value)
+		}
+		return t, nil
+	}
+}
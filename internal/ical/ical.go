@@ -0,0 +1,375 @@
+// Package ical реализует чтение и запись данных iCalendar (RFC 5545) в объеме, нужном для календарей задач
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// MediaType - тип содержимого iCalendar
+const MediaType = "text/calendar"
+
+// maxLineOctets - максимальная длина строки без учета CRLF, более длинные строки переносятся
+const maxLineOctets = 75
+
+// ErrInvalidCalendar ошибка, которая возвращается если данные не являются корректным iCalendar
+var ErrInvalidCalendar = errors.New("invalid icalendar")
+
+// Property свойство компонента iCalendar
+type Property struct {
+	Name string
+	// Params - параметры свойства, например TZID или VALUE
+	Params map[string]string
+	// Value - значение в том виде, в котором оно записывается, текст хранится экранированным
+	Value string
+}
+
+// Component компонент iCalendar, например VCALENDAR, VTODO или VTIMEZONE
+type Component struct {
+	Name       string
+	Properties []Property
+	Components []*Component
+}
+
+// NewComponent функция, которая создает пустой компонент
+// @param name string - имя компонента
+// @return *Component - новый компонент
+func NewComponent(name string) *Component {
+	return &Component{Name: name}
+}
+
+// Add функция, которая добавляет свойство с уже подготовленным значением
+// @param name string - имя свойства
+// @param value string - значение
+// @param params map[string]string - параметры, может быть nil
+func (c *Component) Add(name, value string, params map[string]string) {
+	c.Properties = append(c.Properties, Property{Name: name, Params: params, Value: value})
+}
+
+// AddText функция, которая добавляет текстовое свойство, экранируя значение
+// @param name string - имя свойства
+// @param text string - текст
+func (c *Component) AddText(name, text string) {
+	c.Add(name, EscapeText(text), nil)
+}
+
+// Prop функция, которая возвращает первое свойство с заданным именем
+// @param name string - имя свойства
+// @return *Property - свойство или nil, если его нет
+func (c *Component) Prop(name string) *Property {
+	for i := range c.Properties {
+		if c.Properties[i].Name == name {
+			return &c.Properties[i]
+		}
+	}
+	return nil
+}
+
+// Text функция, которая возвращает текст первого свойства с заданным именем
+// @param name string - имя свойства
+// @return string - текст без экранирования или пустая строка, если свойства нет
+func (c *Component) Text(name string) string {
+	if p := c.Prop(name); p != nil {
+		return UnescapeText(p.Value)
+	}
+	return ""
+}
+
+// Children функция, которая возвращает вложенные компоненты с заданным именем
+// @param name string - имя компонента
+// @return []*Component - компоненты в порядке следования
+func (c *Component) Children(name string) []*Component {
+	var children []*Component
+	for _, child := range c.Components {
+		if child.Name == name {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// EscapeText функция, которая экранирует текст для значения свойства
+// @param text string - текст
+// @return string - экранированный текст
+func EscapeText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// UnescapeText функция, которая снимает экранирование текста
+// @param value string - экранированный текст
+// @return string - текст
+func UnescapeText(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i == len(value)-1 {
+			b.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
+
+// Encoder структура, которая записывает iCalendar с переносом длинных строк
+// Компоненты можно записывать целиком или по частям через Begin и End, что позволяет отдавать календарь потоком.
+// Первая ошибка записи сохраняется и возвращается из Err, последующие вызовы ничего не делают
+type Encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// NewEncoder функция, которая создает Encoder
+// @param w io.Writer - куда записывается календарь
+// @return *Encoder - новый экземпляр Encoder
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w)}
+}
+
+// Begin функция, которая начинает компонент
+// @param name string - имя компонента
+func (e *Encoder) Begin(name string) {
+	e.line("BEGIN:" + name)
+}
+
+// End функция, которая завершает компонент
+// @param name string - имя компонента
+func (e *Encoder) End(name string) {
+	e.line("END:" + name)
+}
+
+// Property функция, которая записывает свойство
+// @param p Property - свойство
+func (e *Encoder) Property(p Property) {
+	var b strings.Builder
+	b.WriteString(p.Name)
+
+	// Параметры сортируются, чтобы одинаковые данные всегда давали одинаковый результат
+	names := make([]string, 0, len(p.Params))
+	for name := range p.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString(";" + name + "=" + quoteParam(p.Params[name]))
+	}
+
+	b.WriteString(":" + p.Value)
+	e.line(b.String())
+}
+
+// Component функция, которая записывает компонент вместе с вложенными компонентами
+// @param c *Component - компонент
+func (e *Encoder) Component(c *Component) {
+	e.Begin(c.Name)
+	for _, p := range c.Properties {
+		e.Property(p)
+	}
+	for _, child := range c.Components {
+		e.Component(child)
+	}
+	e.End(c.Name)
+}
+
+// Flush функция, которая записывает буферизованные данные
+// @return error - первая ошибка записи
+func (e *Encoder) Flush() error {
+	if e.err == nil {
+		e.err = e.w.Flush()
+	}
+	return e.err
+}
+
+// Err функция, которая возвращает первую ошибку записи
+// @return error - ошибка
+func (e *Encoder) Err() error {
+	return e.err
+}
+
+// line функция, которая записывает строку, перенося её по 75 байт без разрыва символов UTF-8
+// @param line string - строка без CRLF
+func (e *Encoder) line(line string) {
+	if e.err != nil {
+		return
+	}
+
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		e.write(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// Строка продолжения начинается с пробела, который входит в её длину
+		limit = maxLineOctets - 1
+	}
+	e.write(line + "\r\n")
+}
+
+// write функция, которая записывает данные и сохраняет ошибку
+// @param s string - данные
+func (e *Encoder) write(s string) {
+	if e.err == nil {
+		_, e.err = e.w.WriteString(s)
+	}
+}
+
+// quoteParam функция, которая заключает значение параметра в кавычки, если оно содержит разделители
+// @param value string - значение параметра
+// @return string - значение для записи
+func quoteParam(value string) string {
+	if strings.ContainsAny(value, ":;,") {
+		return `"` + strings.ReplaceAll(value, `"`, "") + `"`
+	}
+	return value
+}
+
+// Decode функция, которая читает календарь
+// Перенесенные строки склеиваются, вложенность компонентов проверяется по парам BEGIN и END
+// @param r io.Reader - данные iCalendar
+// @return *Component - компонент верхнего уровня, обычно VCALENDAR
+// @return error - ошибка, ErrInvalidCalendar если данные некорректны
+func Decode(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var root *Component
+	var stack []*Component
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		if root != nil && len(stack) == 0 {
+			return nil, fmt.Errorf("%w: line %d: data after END:%s", ErrInvalidCalendar, i+1, root.Name)
+		}
+
+		p, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidCalendar, i+1, err)
+		}
+
+		switch p.Name {
+		case "BEGIN":
+			c := NewComponent(strings.ToUpper(p.Value))
+			if len(stack) == 0 {
+				root = c
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, c)
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(p.Value) {
+				return nil, fmt.Errorf("%w: line %d: unexpected END:%s", ErrInvalidCalendar, i+1, p.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("%w: line %d: property %s outside of a component", ErrInvalidCalendar, i+1, p.Name)
+			}
+			c := stack[len(stack)-1]
+			c.Properties = append(c.Properties, p)
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("%w: no components", ErrInvalidCalendar)
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("%w: END:%s is missing", ErrInvalidCalendar, stack[len(stack)-1].Name)
+	}
+	return root, nil
+}
+
+// unfold функция, которая читает строки и склеивает перенесенные строки
+// @param r io.Reader - данные iCalendar
+// @return []string - логические строки
+// @return error - ошибка чтения
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(lines) > 0 && line != "" && (line[0] == ' ' || line[0] == '\t') {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCalendar, err)
+	}
+	return lines, nil
+}
+
+// parseLine функция, которая разбирает строку вида NAME;PARAM=value:VALUE
+// Значения параметров в кавычках могут содержать ":" и ";"
+// @param line string - логическая строка
+// @return Property - свойство
+// @return error - ошибка разбора
+func parseLine(line string) (Property, error) {
+	end := strings.IndexAny(line, ";:")
+	if end <= 0 {
+		return Property{}, fmt.Errorf("malformed content line %q", line)
+	}
+	p := Property{Name: strings.ToUpper(line[:end])}
+
+	rest := line[end:]
+	for rest[0] == ';' {
+		rest = rest[1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return Property{}, fmt.Errorf("malformed parameter in %s", p.Name)
+		}
+		name := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			closing := strings.IndexByte(rest[1:], '"')
+			if closing < 0 {
+				return Property{}, fmt.Errorf("unterminated quoted parameter %s", name)
+			}
+			value, rest = rest[1:closing+1], rest[closing+2:]
+		} else {
+			stop := strings.IndexAny(rest, ";:")
+			if stop < 0 {
+				return Property{}, fmt.Errorf("value of %s is missing", p.Name)
+			}
+			value, rest = rest[:stop], rest[stop:]
+		}
+
+		if p.Params == nil {
+			p.Params = make(map[string]string)
+		}
+		p.Params[name] = value
+		if rest == "" {
+			return Property{}, fmt.Errorf("value of %s is missing", p.Name)
+		}
+	}
+
+	if rest[0] != ':' {
+		return Property{}, fmt.Errorf("value of %s is missing", p.Name)
+	}
+	p.Value = rest[1:]
+	return p, nil
+}
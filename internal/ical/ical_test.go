@@ -0,0 +1,162 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEncoderFolding тестирует перенос длинных строк и их склейку при чтении
+func TestEncoderFolding(t *testing.T) {
+	summary := strings.Repeat("Задача с длинным названием, ", 6)
+	todo := NewComponent("VTODO")
+	todo.AddText("SUMMARY", summary)
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.Component(todo)
+	require.NoError(t, enc.Flush())
+
+	t.Run("Строки не длиннее 75 байт и заканчиваются CRLF", func(t *testing.T) {
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+		assert.Greater(t, len(lines), 3)
+		for _, line := range lines {
+			assert.LessOrEqual(t, len(line), 75)
+			assert.True(t, strings.ToValidUTF8(line, "?") == line, "line %q splits a character", line)
+		}
+	})
+
+	t.Run("Прочитанный текст совпадает с исходным", func(t *testing.T) {
+		decoded, err := Decode(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, summary, decoded.Text("SUMMARY"))
+	})
+}
+
+// TestEscapeText тестирует экранирование текста
+func TestEscapeText(t *testing.T) {
+	text := "Купить: молоко, хлеб; сыр\\\nи чай"
+	escaped := EscapeText(text)
+
+	assert.Equal(t, `Купить: молоко\, хлеб\; сыр\\\nи чай`, escaped)
+	assert.Equal(t, text, UnescapeText(escaped))
+}
+
+// TestTimezone тестирует описание часового пояса
+func TestTimezone(t *testing.T) {
+	t.Run("Переходы Europe/Berlin записываются правилами", func(t *testing.T) {
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		require.NoError(t, err)
+
+		tz := Timezone(berlin, 2024)
+		require.Len(t, tz.Components, 2)
+
+		daylight, standard := tz.Components[0], tz.Components[1]
+		assert.Equal(t, "DAYLIGHT", daylight.Name)
+		assert.Equal(t, "19700329T020000", daylight.Prop("DTSTART").Value)
+		assert.Equal(t, "FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU", daylight.Prop("RRULE").Value)
+		assert.Equal(t, "+0100", daylight.Prop("TZOFFSETFROM").Value)
+		assert.Equal(t, "+0200", daylight.Prop("TZOFFSETTO").Value)
+		assert.Equal(t, "CEST", daylight.Text("TZNAME"))
+
+		assert.Equal(t, "STANDARD", standard.Name)
+		assert.Equal(t, "19701025T030000", standard.Prop("DTSTART").Value)
+		assert.Equal(t, "FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU", standard.Prop("RRULE").Value)
+	})
+
+	t.Run("Пояс без переходов описывается одним STANDARD", func(t *testing.T) {
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		require.NoError(t, err)
+
+		tz := Timezone(tokyo, 2024)
+		require.Len(t, tz.Components, 1)
+		assert.Equal(t, "STANDARD", tz.Components[0].Name)
+		assert.Equal(t, "+0900", tz.Components[0].Prop("TZOFFSETTO").Value)
+		assert.Nil(t, tz.Components[0].Prop("RRULE"))
+	})
+}
+
+// TestTodo тестирует запись задачи в VTODO и VEVENT
+func TestTodo(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	dueAt := time.Date(2024, 12, 20, 17, 0, 0, 0, time.UTC)
+	stamp := time.Date(2024, 12, 18, 9, 0, 0, 0, time.UTC)
+	record := &models.TaskRecord{ID: 3, Title: "Сдать отчет", Completed: true, Version: 2, DueAt: &dueAt}
+
+	t.Run("VTODO содержит срок в заданном поясе и статус", func(t *testing.T) {
+		todo := Todo(record, berlin, stamp)
+
+		assert.Equal(t, "task-3@todo-api", todo.Text("UID"))
+		assert.Equal(t, "20241218T090000Z", todo.Prop("DTSTAMP").Value)
+		assert.Equal(t, Property{Name: "DUE", Params: map[string]string{"TZID": "Europe/Berlin"}, Value: "20241220T180000"}, *todo.Prop("DUE"))
+		assert.Equal(t, "COMPLETED", todo.Prop("STATUS").Value)
+		assert.Equal(t, "1", todo.Prop("SEQUENCE").Value)
+	})
+
+	t.Run("VEVENT создается только для задач со сроком", func(t *testing.T) {
+		event := Event(record, berlin, stamp)
+		require.NotNil(t, event)
+		assert.Equal(t, "due-task-3@todo-api", event.Text("UID"))
+		assert.Equal(t, "✓ Сдать отчет", event.Text("SUMMARY"))
+
+		assert.Nil(t, Event(&models.TaskRecord{ID: 4, Title: "Без срока"}, berlin, stamp))
+	})
+}
+
+// TestRecordsFromCalendar тестирует чтение задач из календаря
+func TestRecordsFromCalendar(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:abc-1@example.com\r\n" +
+		"SUMMARY:Купить молоко\\, хлеб\r\n" +
+		"DUE;TZID=Europe/Berlin:20241220T180000\r\n" +
+		"STATUS:COMPLETED\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:event-1\r\n" +
+		"SUMMARY:Встреча\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:abc-2@example.com\r\n" +
+		"SUMMARY:Позвонить\r\n" +
+		"DUE;VALUE=DATE:20241221\r\n" +
+		"END:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+
+	t.Run("VTODO становятся задачами, VEVENT пропускаются", func(t *testing.T) {
+		calendar, err := Decode(strings.NewReader(data))
+		require.NoError(t, err)
+
+		records, err := RecordsFromCalendar(calendar, time.UTC)
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+
+		assert.Equal(t, "abc-1@example.com", records[0].ExternalID)
+		assert.Equal(t, "Купить молоко, хлеб", records[0].Title)
+		assert.True(t, records[0].Completed)
+		assert.True(t, time.Date(2024, 12, 20, 17, 0, 0, 0, time.UTC).Equal(*records[0].DueAt))
+
+		assert.False(t, records[1].Completed)
+		assert.True(t, time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC).Equal(*records[1].DueAt))
+	})
+
+	t.Run("Незакрытый компонент отклоняется", func(t *testing.T) {
+		_, err := Decode(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nEND:VCALENDAR\r\n"))
+		assert.ErrorIs(t, err, ErrInvalidCalendar)
+	})
+
+	t.Run("Некорректный срок отклоняется", func(t *testing.T) {
+		calendar, err := Decode(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nDUE:завтра\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"))
+		require.NoError(t, err)
+
+		_, err = RecordsFromCalendar(calendar, time.UTC)
+		assert.ErrorIs(t, err, ErrInvalidCalendar)
+	})
+}
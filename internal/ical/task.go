@@ -0,0 +1,192 @@
+package ical
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+)
+
+// ProdID - идентификатор приложения, которое создало календарь
+const ProdID = "-//todo-api//Tasks//EN"
+
+// Части UID задачи без внешнего id
+const (
+	taskUIDPrefix = "task-"
+	taskUIDSuffix = "@todo-api"
+)
+
+// TaskUID функция, которая возвращает UID компонента задачи
+// Задачи, импортированные из другого календаря, сохраняют UID исходного VTODO, который хранится как внешний id
+// @param record *models.TaskRecord - задача
+// @return string - UID
+func TaskUID(record *models.TaskRecord) string {
+	if record.ExternalID != "" {
+		return record.ExternalID
+	}
+	return taskUIDPrefix + strconv.Itoa(record.ID) + taskUIDSuffix
+}
+
+// ParseTaskUID функция, которая возвращает id задачи из UID, созданного TaskUID для задачи без внешнего id
+// @param uid string - UID
+// @return int - id задачи или 0, если UID создан не TaskUID
+func ParseTaskUID(uid string) int {
+	digits, ok := strings.CutPrefix(uid, taskUIDPrefix)
+	if !ok {
+		return 0
+	}
+	digits, ok = strings.CutSuffix(digits, taskUIDSuffix)
+	if !ok {
+		return 0
+	}
+	id, err := strconv.Atoi(digits)
+	if err != nil || id <= 0 || strconv.Itoa(id) != digits {
+		return 0
+	}
+	return id
+}
+
+// Todo функция, которая создает компонент VTODO для задачи
+// @param record *models.TaskRecord - задача
+// @param loc *time.Location - часовой пояс, в котором записывается срок выполнения
+// @param stamp time.Time - время формирования календаря для DTSTAMP
+// @return *Component - компонент VTODO
+func Todo(record *models.TaskRecord, loc *time.Location, stamp time.Time) *Component {
+	todo := NewComponent("VTODO")
+	todo.Add("UID", EscapeText(TaskUID(record)), nil)
+	todo.Properties = append(todo.Properties, UTCDateTime("DTSTAMP", stamp))
+	todo.AddText("SUMMARY", record.Title)
+	if record.DueAt != nil {
+		todo.Properties = append(todo.Properties, DateTime("DUE", *record.DueAt, loc))
+	}
+	if record.Completed {
+		todo.Add("STATUS", "COMPLETED", nil)
+		todo.Add("PERCENT-COMPLETE", "100", nil)
+	} else {
+		todo.Add("STATUS", "NEEDS-ACTION", nil)
+	}
+	// Версия задачи начинается с 1, а SEQUENCE - с 0
+	if record.Version > 0 {
+		todo.Add("SEQUENCE", strconv.Itoa(record.Version-1), nil)
+	}
+	return todo
+}
+
+// Event функция, которая создает компонент VEVENT в момент срока выполнения задачи
+// Многие календари не показывают VTODO, поэтому задачи со сроком дополнительно передаются событиями
+// @param record *models.TaskRecord - задача
+// @param loc *time.Location - часовой пояс, в котором записывается время события
+// @param stamp time.Time - время формирования календаря для DTSTAMP
+// @return *Component - компонент VEVENT или nil, если у задачи нет срока
+func Event(record *models.TaskRecord, loc *time.Location, stamp time.Time) *Component {
+	if record.DueAt == nil {
+		return nil
+	}
+
+	event := NewComponent("VEVENT")
+	// UID события отличается от UID задачи, так как UID должен быть уникальным в пределах календаря
+	event.Add("UID", EscapeText("due-"+TaskUID(record)), nil)
+	event.Properties = append(event.Properties, UTCDateTime("DTSTAMP", stamp))
+	// Событие без DTEND и DURATION длится мгновение и показывается в момент срока
+	event.Properties = append(event.Properties, DateTime("DTSTART", *record.DueAt, loc))
+	summary := record.Title
+	if record.Completed {
+		summary = "✓ " + summary
+	}
+	event.AddText("SUMMARY", summary)
+	event.Add("TRANSP", "TRANSPARENT", nil)
+	if record.Version > 0 {
+		event.Add("SEQUENCE", strconv.Itoa(record.Version-1), nil)
+	}
+	return event
+}
+
+// RecordFromTodo функция, которая создает задачу импорта из компонента VTODO
+// UID становится внешним id задачи, поэтому повторная загрузка того же календаря обновляет задачи, а не дублирует их.
+// Задача считается выполненной при STATUS:COMPLETED
+// @param todo *Component - компонент VTODO
+// @param loc *time.Location - часовой пояс для времени без пояса
+// @return models.TaskRecord - задача
+// @return error - ошибка разбора срока выполнения
+func RecordFromTodo(todo *Component, loc *time.Location) (models.TaskRecord, error) {
+	record := models.TaskRecord{
+		ExternalID: todo.Text("UID"),
+		Title:      todo.Text("SUMMARY"),
+		Completed:  strings.EqualFold(todo.Text("STATUS"), "COMPLETED"),
+	}
+
+	if due := todo.Prop("DUE"); due != nil {
+		dueAt, err := ParseDateTime(due, loc)
+		if err != nil {
+			return models.TaskRecord{}, err
+		}
+		record.DueAt = &dueAt
+	}
+
+	return record, nil
+}
+
+// RecordsFromCalendar функция, которая создает задачи импорта из всех VTODO календаря
+// Остальные компоненты, например VEVENT, пропускаются
+// @param calendar *Component - компонент VCALENDAR
+// @param loc *time.Location - часовой пояс для времени без пояса
+// @return []models.TaskRecord - задачи в порядке следования VTODO
+// @return error - ошибка, ErrInvalidCalendar если компонент не VCALENDAR или срок выполнения некорректен
+func RecordsFromCalendar(calendar *Component, loc *time.Location) ([]models.TaskRecord, error) {
+	if calendar.Name != "VCALENDAR" {
+		return nil, fmt.Errorf("%w: expected VCALENDAR, got %s", ErrInvalidCalendar, calendar.Name)
+	}
+
+	todos := calendar.Children("VTODO")
+	records := make([]models.TaskRecord, 0, len(todos))
+	for i, todo := range todos {
+		record, err := RecordFromTodo(todo, loc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: VTODO %d: %v", ErrInvalidCalendar, i+1, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// BeginCalendar функция, которая начинает VCALENDAR и записывает его свойства и VTIMEZONE
+// Календарь завершается вызовом enc.End("VCALENDAR") после записи задач
+// @param enc *Encoder - запись календаря
+// @param name string - название календаря
+// @param loc *time.Location - часовой пояс, в котором записываются сроки задач
+// @param now time.Time - текущее время, по нему выбирается год переходов часового пояса
+func BeginCalendar(enc *Encoder, name string, loc *time.Location, now time.Time) {
+	enc.Begin("VCALENDAR")
+	enc.Property(Property{Name: "VERSION", Value: "2.0"})
+	enc.Property(Property{Name: "PRODID", Value: ProdID})
+	enc.Property(Property{Name: "CALSCALE", Value: "GREGORIAN"})
+	enc.Property(Property{Name: "X-WR-CALNAME", Value: EscapeText(name)})
+	enc.Property(Property{Name: "X-WR-TIMEZONE", Value: loc.String()})
+	// Для UTC VTIMEZONE не нужен, время записывается с суффиксом Z
+	if loc != time.UTC {
+		enc.Component(Timezone(loc, now.In(loc).Year()))
+	}
+}
+
+// WriteTodo функция, которая записывает календарь из одной задачи, например ресурс CalDAV
+// VTIMEZONE добавляется только если у задачи есть срок и пояс не UTC
+// @param w io.Writer - куда записывается календарь
+// @param record *models.TaskRecord - задача
+// @param loc *time.Location - часовой пояс, в котором записывается срок выполнения
+// @param stamp time.Time - время формирования календаря для DTSTAMP
+// @return error - ошибка записи
+func WriteTodo(w io.Writer, record *models.TaskRecord, loc *time.Location, stamp time.Time) error {
+	enc := NewEncoder(w)
+	enc.Begin("VCALENDAR")
+	enc.Property(Property{Name: "VERSION", Value: "2.0"})
+	enc.Property(Property{Name: "PRODID", Value: ProdID})
+	if record.DueAt != nil && loc != time.UTC {
+		enc.Component(Timezone(loc, record.DueAt.In(loc).Year()))
+	}
+	enc.Component(Todo(record, loc, stamp))
+	enc.End("VCALENDAR")
+	return enc.Flush()
+}
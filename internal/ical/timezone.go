@@ -0,0 +1,179 @@
+package ical
+
+import (
+	"fmt"
+	"time"
+)
+
+// weekdays - обозначения дней недели в RRULE
+var weekdays = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// transition переход между стандартным и летним временем
+type transition struct {
+	// at - момент перехода
+	at         time.Time
+	offsetFrom int
+	offsetTo   int
+	name       string
+	dst        bool
+}
+
+// Timezone функция, которая создает компонент VTIMEZONE для часового пояса
+// Переходы на летнее и стандартное время берутся из базы часовых поясов за год year. Если в следующем году переход
+// происходит по тому же правилу, например в последнее воскресенье марта, он записывается как RRULE с начала 1970 года,
+// иначе записывается только переход года year. Пояс без переходов описывается одним компонентом STANDARD
+// @param loc *time.Location - часовой пояс
+// @param year int - год, переходы которого описываются
+// @return *Component - компонент VTIMEZONE
+func Timezone(loc *time.Location, year int) *Component {
+	tz := NewComponent("VTIMEZONE")
+	tz.Add("TZID", loc.String(), nil)
+
+	transitions := yearTransitions(loc, year)
+	if len(transitions) == 0 {
+		name, offset := time.Date(year, time.January, 1, 0, 0, 0, 0, loc).Zone()
+		standard := NewComponent("STANDARD")
+		standard.Add("DTSTART", "19700101T000000", nil)
+		standard.Add("TZOFFSETFROM", formatOffset(offset), nil)
+		standard.Add("TZOFFSETTO", formatOffset(offset), nil)
+		standard.AddText("TZNAME", name)
+		tz.Components = append(tz.Components, standard)
+		return tz
+	}
+
+	next := yearTransitions(loc, year+1)
+	for _, tr := range transitions {
+		observance := NewComponent("STANDARD")
+		if tr.dst {
+			observance.Name = "DAYLIGHT"
+		}
+
+		// DTSTART записывается в местном времени, действовавшем до перехода
+		wall := tr.at.In(time.FixedZone("", tr.offsetFrom))
+		start := wall.Format(dateTimeLayout)
+		rule, repeats := yearlyRule(wall, next, tr)
+		if repeats {
+			// DTSTART должен совпадать с первым срабатыванием правила
+			first := nthWeekday(1970, rule.month, rule.n, rule.weekday)
+			start = first.Format(dateLayout) + "T" + wall.Format("150405")
+		}
+
+		observance.Add("DTSTART", start, nil)
+		if repeats {
+			observance.Add("RRULE", rule.String(), nil)
+		}
+		observance.Add("TZOFFSETFROM", formatOffset(tr.offsetFrom), nil)
+		observance.Add("TZOFFSETTO", formatOffset(tr.offsetTo), nil)
+		observance.AddText("TZNAME", tr.name)
+		tz.Components = append(tz.Components, observance)
+	}
+	return tz
+}
+
+// yearTransitions функция, которая находит переходы часового пояса в течение года
+// @param loc *time.Location - часовой пояс
+// @param year int - год
+// @return []transition - переходы в хронологическом порядке
+func yearTransitions(loc *time.Location, year int) []transition {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	end := time.Date(year+1, time.January, 1, 0, 0, 0, 0, loc)
+
+	var transitions []transition
+	for t := start; ; {
+		_, zoneEnd := t.ZoneBounds()
+		if zoneEnd.IsZero() || !zoneEnd.Before(end) {
+			return transitions
+		}
+
+		_, offsetFrom := zoneEnd.Add(-time.Second).Zone()
+		name, offsetTo := zoneEnd.Zone()
+		transitions = append(transitions, transition{
+			at:         zoneEnd,
+			offsetFrom: offsetFrom,
+			offsetTo:   offsetTo,
+			name:       name,
+			dst:        zoneEnd.IsDST(),
+		})
+		t = zoneEnd
+	}
+}
+
+// rrule ежегодное правило перехода вида "n-й день недели месяца"
+type rrule struct {
+	month   time.Month
+	n       int
+	weekday time.Weekday
+}
+
+// String функция, которая возвращает правило в формате RRULE
+func (r rrule) String() string {
+	return fmt.Sprintf("FREQ=YEARLY;BYMONTH=%d;BYDAY=%d%s", r.month, r.n, weekdays[r.weekday])
+}
+
+// yearlyRule функция, которая подбирает ежегодное правило для перехода и проверяет его по переходам следующего года
+// Для дат в последней неделе месяца сначала проверяется правило "последний день недели месяца"
+// @param wall time.Time - местное время перехода
+// @param next []transition - переходы следующего года
+// @param tr transition - переход
+// @return rrule - правило
+// @return bool - false, если переходы следующего года не подчиняются одному правилу
+func yearlyRule(wall time.Time, next []transition, tr transition) (rrule, bool) {
+	candidates := make([]rrule, 0, 2)
+	if wall.Day()+7 > daysIn(wall.Year(), wall.Month()) {
+		candidates = append(candidates, rrule{month: wall.Month(), n: -1, weekday: wall.Weekday()})
+	}
+	candidates = append(candidates, rrule{month: wall.Month(), n: (wall.Day()-1)/7 + 1, weekday: wall.Weekday()})
+
+	for _, rule := range candidates {
+		for _, other := range next {
+			if other.dst != tr.dst || other.offsetFrom != tr.offsetFrom || other.offsetTo != tr.offsetTo {
+				continue
+			}
+			otherWall := other.at.In(time.FixedZone("", other.offsetFrom))
+			expected := nthWeekday(otherWall.Year(), rule.month, rule.n, rule.weekday)
+			if otherWall.Format(dateLayout) == expected.Format(dateLayout) && otherWall.Format("150405") == wall.Format("150405") {
+				return rule, true
+			}
+		}
+	}
+	return rrule{}, false
+}
+
+// nthWeekday функция, которая возвращает n-й день недели месяца, отрицательное n считается с конца месяца
+// @param year int - год
+// @param month time.Month - месяц
+// @param n int - номер дня недели, -1 означает последний
+// @param weekday time.Weekday - день недели
+// @return time.Time - дата в UTC
+func nthWeekday(year int, month time.Month, n int, weekday time.Weekday) time.Time {
+	if n < 0 {
+		last := time.Date(year, month, daysIn(year, month), 0, 0, 0, 0, time.UTC)
+		shift := (int(last.Weekday()) - int(weekday) + 7) % 7
+		return last.AddDate(0, 0, -shift+7*(n+1))
+	}
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	shift := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, shift+7*(n-1))
+}
+
+// daysIn функция, которая возвращает количество дней в месяце
+// @param year int - год
+// @param month time.Month - месяц
+// @return int - количество дней
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// formatOffset функция, которая записывает смещение от UTC в формате +HHMM
+// @param seconds int - смещение в секундах
+// @return string - смещение
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	if seconds%60 != 0 {
+		return fmt.Sprintf("%s%02d%02d%02d", sign, seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds/60%60)
+}
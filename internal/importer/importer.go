@@ -0,0 +1,208 @@
+// Package importer разбирает файлы экспорта Todoist, Trello и Microsoft To Do в задачи импорта.
+// Списки и проекты переносятся в название тегами +проект, пункты чек-листов и подзадачи становятся
+// отдельными задачами с названием "родитель: пункт". Внешние id задач строятся из id исходной системы,
+// поэтому повторный импорт того же файла обновляет задачи, а не создает их заново
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/pers0na2dev/todo-api/internal/todotxt"
+)
+
+// Source формат файла импорта
+type Source string
+
+const (
+	// TodoistCSV - CSV одного проекта из резервной копии Todoist
+	TodoistCSV Source = "todoist-csv"
+	// TodoistJSON - JSON резервной копии или Sync API Todoist с массивами projects, sections и items
+	TodoistJSON Source = "todoist-json"
+	// Trello - JSON экспорт доски Trello
+	Trello Source = "trello"
+	// MicrosoftToDo - JSON списков Microsoft To Do из Graph API (/me/todo/lists с раскрытыми tasks и checklistItems)
+	MicrosoftToDo Source = "mstodo"
+)
+
+// minTruncatedTitle - минимальная длина обрезанного названия, при которой в нем остаются теги списков
+const minTruncatedTitle = 32
+
+// Sources - поддерживаемые форматы в порядке описания в документации
+var Sources = []Source{TodoistCSV, TodoistJSON, Trello, MicrosoftToDo}
+
+// ErrInvalidFile ошибка, которая возвращается если файл нельзя разобрать
+var ErrInvalidFile = errors.New("invalid import file")
+
+// Options параметры разбора файла
+type Options struct {
+	// List - название списка для форматов, в которых оно не хранится в файле (CSV Todoist)
+	List string
+	// Loc - часовой пояс для сроков без часового пояса
+	Loc *time.Location
+}
+
+// Result результат разбора файла
+type Result struct {
+	Records []models.TaskRecord
+	// Warnings - данные, которые не удалось перенести, например повторяющиеся сроки
+	Warnings []string
+}
+
+// Parse функция, которая разбирает файл экспорта
+// @param source Source - формат файла
+// @param r io.Reader - файл
+// @param opts Options - параметры разбора
+// @return *Result - задачи и предупреждения
+// @return error - ErrInvalidFile если файл поврежден или формат неизвестен
+func Parse(source Source, r io.Reader, opts Options) (*Result, error) {
+	if opts.Loc == nil {
+		opts.Loc = time.UTC
+	}
+	b := &builder{source: source, seen: make(map[string]struct{})}
+
+	var err error
+	switch source {
+	case TodoistCSV:
+		err = parseTodoistCSV(b, r, opts)
+	case TodoistJSON:
+		err = parseTodoistJSON(b, r, opts)
+	case Trello:
+		err = parseTrello(b, r)
+	case MicrosoftToDo:
+		err = parseMicrosoftToDo(b, r, opts)
+	default:
+		return nil, fmt.Errorf("%w: unknown source %q", ErrInvalidFile, source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	return &Result{Records: b.records, Warnings: b.warnings}, nil
+}
+
+// item задача исходной системы перед преобразованием в запись импорта
+type item struct {
+	// id - части внешнего id, например id карточки и пункта чек-листа
+	id []string
+	// parent - название родительской задачи для пунктов чек-листов и подзадач
+	parent    string
+	title     string
+	lists     []string
+	priority  string
+	completed bool
+	dueAt     *time.Time
+}
+
+// builder структура, которая собирает записи импорта и предупреждения
+type builder struct {
+	source   Source
+	records  []models.TaskRecord
+	warnings []string
+	// seen - внешние id добавленных записей, импорт не может содержать одну задачу дважды
+	seen map[string]struct{}
+}
+
+// warn функция, которая добавляет предупреждение
+// @param format string - формат сообщения
+// @param args ...any - аргументы
+func (b *builder) warn(format string, args ...any) {
+	b.warnings = append(b.warnings, fmt.Sprintf(format, args...))
+}
+
+// add функция, которая преобразует задачу в запись импорта
+// Задачи без названия и повторы пропускаются с предупреждением
+// @param it item - задача
+func (b *builder) add(it item) {
+	text := strings.Join(strings.Fields(it.title), " ")
+	if text == "" {
+		b.warn("%s task %s has no title and was skipped", b.source, strings.Join(it.id, "/"))
+		return
+	}
+	if parent := strings.Join(strings.Fields(it.parent), " "); parent != "" {
+		text = parent + ": " + text
+	}
+
+	id := externalID(b.source, it.id...)
+	if _, ok := b.seen[id]; ok {
+		b.warn("%s task %q is duplicated and was skipped", b.source, text)
+		return
+	}
+	b.seen[id] = struct{}{}
+
+	var suffix string
+	for _, list := range it.lists {
+		if tag := projectTag(list); tag != "" {
+			suffix += " " + tag
+		}
+	}
+	if it.priority != "" {
+		suffix += " " + todotxt.TagPriority + ":" + it.priority
+	}
+
+	// Название обрезается до допустимой длины, теги списков и приоритета сохраняются,
+	// если только они сами не занимают почти все название
+	limit := models.MaxTitleLength - utf8.RuneCountInString(suffix)
+	if limit < minTruncatedTitle {
+		suffix, limit = "", models.MaxTitleLength
+	}
+	if utf8.RuneCountInString(text) > limit {
+		b.warn("%s task %q was truncated to %d characters", b.source, text, models.MaxTitleLength)
+		text = strings.TrimSpace(string([]rune(text)[:limit-1])) + "…"
+	}
+
+	b.records = append(b.records, models.TaskRecord{
+		ExternalID: id,
+		Title:      text + suffix,
+		Completed:  it.completed,
+		DueAt:      it.dueAt,
+	})
+}
+
+// projectTag функция, которая преобразует название списка в тег проекта todo.txt
+// @param name string - название списка
+// @return string - тег вида +Название-списка или пустая строка
+func projectTag(name string) string {
+	name = strings.Join(strings.Fields(strings.TrimLeft(name, "+")), "-")
+	if name == "" {
+		return ""
+	}
+	return "+" + name
+}
+
+// externalID функция, которая строит внешний id задачи из id исходной системы
+// Слишком длинные id, например у Microsoft To Do, заменяются хешем
+// @param source Source - формат файла
+// @param parts ...string - части id
+// @return string - внешний id
+func externalID(source Source, parts ...string) string {
+	prefix := strings.SplitN(string(source), "-", 2)[0] + ":"
+	id := prefix + strings.Join(parts, ":")
+	if utf8.RuneCountInString(id) <= models.MaxExternalIDLength {
+		return id
+	}
+	sum := sha256.Sum256([]byte(id))
+	return prefix + hex.EncodeToString(sum[:])
+}
+
+// parseDate функция, которая разбирает срок в одном из форматов
+// Время без часового пояса считается временем в loc
+// @param value string - срок
+// @param loc *time.Location - часовой пояс
+// @param layouts ...string - форматы
+// @return *time.Time - срок или nil, если формат не подходит
+func parseDate(value string, loc *time.Location, layouts ...string) *time.Time {
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
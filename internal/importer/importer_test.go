@@ -0,0 +1,193 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseFixture функция, которая разбирает файл из testdata
+func parseFixture(t *testing.T, source Source, name string, opts Options) *Result {
+	t.Helper()
+	file, err := os.Open(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	defer file.Close()
+
+	result, err := Parse(source, file, opts)
+	require.NoError(t, err)
+	return result
+}
+
+// titles функция, которая возвращает названия записей
+func titles(records []models.TaskRecord) []string {
+	out := make([]string, 0, len(records))
+	for _, record := range records {
+		out = append(out, record.Title)
+	}
+	return out
+}
+
+// TestParseTodoistCSV тестирует разбор CSV Todoist
+func TestParseTodoistCSV(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	result := parseFixture(t, TodoistCSV, "todoist.csv", Options{List: "Личное", Loc: berlin})
+
+	assert.Equal(t, []string{
+		"Купить продукты +Личное pri:A",
+		"Купить продукты: Молоко +Личное",
+		"Купить продукты: Хлеб +Личное",
+		"Сдать отчет +Личное +Работа pri:B",
+		"Сдать отчет +Личное +Работа",
+	}, titles(result.Records))
+
+	require.NotNil(t, result.Records[0].DueAt)
+	assert.True(t, result.Records[0].DueAt.Equal(time.Date(2024, 3, 10, 0, 0, 0, 0, berlin)))
+	assert.Nil(t, result.Records[3].DueAt)
+	assert.Equal(t, []string{`todoist-csv line 8: due date "every monday" is not supported`}, result.Warnings)
+
+	t.Run("Одинаковые задачи получают разные внешние id", func(t *testing.T) {
+		assert.NotEqual(t, result.Records[3].ExternalID, result.Records[4].ExternalID)
+		assert.True(t, strings.HasPrefix(result.Records[3].ExternalID, "todoist:csv:"))
+	})
+
+	t.Run("Повторный разбор дает те же внешние id", func(t *testing.T) {
+		again := parseFixture(t, TodoistCSV, "todoist.csv", Options{List: "Личное", Loc: berlin})
+
+		assert.Equal(t, result.Records, again.Records)
+	})
+
+	t.Run("Без колонки CONTENT файл не разбирается", func(t *testing.T) {
+		_, err := Parse(TodoistCSV, strings.NewReader("TYPE,TEXT\ntask,Задача\n"), Options{})
+
+		assert.ErrorIs(t, err, ErrInvalidFile)
+	})
+}
+
+// TestParseTodoistJSON тестирует разбор JSON Todoist
+func TestParseTodoistJSON(t *testing.T) {
+	result := parseFixture(t, TodoistJSON, "todoist.json", Options{})
+
+	require.Len(t, result.Records, 3)
+	due := time.Date(2024, 3, 10, 18, 0, 0, 0, time.UTC)
+	assert.Equal(t, models.TaskRecord{
+		ExternalID: "todoist:6X7rM8997g3RQmvh",
+		Title:      "Покрасить стены +Дом +Ремонт pri:A",
+		DueAt:      &due,
+	}, result.Records[0])
+	assert.Equal(t, models.TaskRecord{
+		ExternalID: "todoist:6X7rfFVPjhvv84XG",
+		Title:      "Покрасить стены: Купить краску +Дом +Ремонт",
+		Completed:  true,
+	}, result.Records[1])
+
+	// id в виде числа из старых версий API
+	assert.Equal(t, "todoist:2995104339", result.Records[2].ExternalID)
+	assert.Equal(t, "Полить цветы +Inbox pri:C", result.Records[2].Title)
+	assert.Equal(t, []string{`todoist task 2995104339: recurrence "every day" is not supported, only the next due date is imported`}, result.Warnings)
+}
+
+// TestParseTrello тестирует разбор доски Trello
+func TestParseTrello(t *testing.T) {
+	result := parseFixture(t, Trello, "trello.json", Options{})
+
+	assert.Equal(t, []string{
+		"Упаковать вещи +To-Do",
+		"Заказать машину +Готово",
+		"Упаковать вещи: Кухня +To-Do",
+		"Упаковать вещи: Спальня +To-Do",
+	}, titles(result.Records))
+	assert.Equal(t, []string{"trello: 2 archived cards were skipped"}, result.Warnings)
+
+	assert.Equal(t, "trello:card-1", result.Records[0].ExternalID)
+	require.NotNil(t, result.Records[0].DueAt)
+	assert.True(t, result.Records[0].DueAt.Equal(time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)))
+	assert.True(t, result.Records[1].Completed)
+
+	assert.Equal(t, "trello:card-1:item-1", result.Records[2].ExternalID)
+	assert.True(t, result.Records[2].Completed)
+	assert.False(t, result.Records[3].Completed)
+	require.NotNil(t, result.Records[3].DueAt)
+}
+
+// TestParseMicrosoftToDo тестирует разбор списков Microsoft To Do
+func TestParseMicrosoftToDo(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	result := parseFixture(t, MicrosoftToDo, "mstodo.json", Options{Loc: moscow})
+
+	assert.Equal(t, []string{
+		"Подарок на день рождения +Покупки pri:A",
+		"Подарок на день рождения: Открытка +Покупки",
+		"Подарок на день рождения: Цветы +Покупки",
+		"Оплатить интернет +Покупки",
+	}, titles(result.Records))
+	assert.Empty(t, result.Warnings)
+
+	assert.Equal(t, "mstodo:AAMkADIyAAAhrbPXAAA=", result.Records[0].ExternalID)
+	require.NotNil(t, result.Records[0].DueAt)
+	assert.True(t, result.Records[0].DueAt.Equal(time.Date(2024, 3, 20, 0, 0, 0, 0, berlin)))
+	assert.True(t, result.Records[1].Completed)
+	assert.False(t, result.Records[2].Completed)
+
+	// Часовой пояс Windows не распознается, срок считается сроком в часовом поясе по умолчанию
+	assert.True(t, result.Records[3].Completed)
+	require.NotNil(t, result.Records[3].DueAt)
+	assert.True(t, result.Records[3].DueAt.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, moscow)))
+
+	t.Run("Массив списков без обертки value", func(t *testing.T) {
+		result, err := Parse(MicrosoftToDo, strings.NewReader(`[{"id":"l","displayName":"Дом","tasks":[{"id":"t","title":"Убраться"}]}]`), Options{})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"Убраться +Дом"}, titles(result.Records))
+	})
+}
+
+// TestParse тестирует общие правила преобразования
+func TestParse(t *testing.T) {
+	t.Run("Неизвестный формат и поврежденный файл возвращают ErrInvalidFile", func(t *testing.T) {
+		_, err := Parse("asana", strings.NewReader("{}"), Options{})
+		assert.ErrorIs(t, err, ErrInvalidFile)
+
+		_, err = Parse(Trello, strings.NewReader("{"), Options{})
+		assert.ErrorIs(t, err, ErrInvalidFile)
+	})
+
+	t.Run("Задачи без названия и повторы пропускаются", func(t *testing.T) {
+		board := `{"lists":[{"id":"l","name":"Дом"}],"cards":[{"id":"c","name":"  ","idList":"l"},{"id":"d","name":"Убраться","idList":"l"},{"id":"d","name":"Убраться","idList":"l"}]}`
+
+		result, err := Parse(Trello, strings.NewReader(board), Options{})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"Убраться +Дом"}, titles(result.Records))
+		assert.Len(t, result.Warnings, 2)
+	})
+
+	t.Run("Длинное название обрезается с сохранением тегов", func(t *testing.T) {
+		long := strings.Repeat("а", models.MaxTitleLength)
+		board := `{"lists":[{"id":"l","name":"Дом"}],"cards":[{"id":"c","name":"` + long + `","idList":"l"}]}`
+
+		result, err := Parse(Trello, strings.NewReader(board), Options{})
+		require.NoError(t, err)
+
+		assert.Equal(t, strings.Repeat("а", models.MaxTitleLength-6)+"… +Дом", result.Records[0].Title)
+		assert.Len(t, result.Warnings, 1)
+	})
+
+	t.Run("Слишком длинный внешний id заменяется хешем", func(t *testing.T) {
+		id := externalID(MicrosoftToDo, strings.Repeat("x", models.MaxExternalIDLength))
+
+		assert.True(t, strings.HasPrefix(id, "mstodo:"))
+		assert.Len(t, id, len("mstodo:")+64)
+	})
+}
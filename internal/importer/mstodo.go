@@ -0,0 +1,108 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// microsoftDateTimeLayout - формат dateTime в dateTimeTimeZone Graph API
+const microsoftDateTimeLayout = "2006-01-02T15:04:05.9999999"
+
+// microsoftList список задач Microsoft To Do
+type microsoftList struct {
+	ID          string          `json:"id"`
+	DisplayName string          `json:"displayName"`
+	Tasks       []microsoftTask `json:"tasks"`
+}
+
+// microsoftTask задача Microsoft To Do
+type microsoftTask struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	Status     string `json:"status"`
+	Importance string `json:"importance"`
+	// DueDateTime - срок в виде даты и часового пояса, Microsoft To Do хранит только дату
+	DueDateTime *struct {
+		DateTime string `json:"dateTime"`
+		TimeZone string `json:"timeZone"`
+	} `json:"dueDateTime"`
+	ChecklistItems []struct {
+		ID          string `json:"id"`
+		DisplayName string `json:"displayName"`
+		IsChecked   bool   `json:"isChecked"`
+	} `json:"checklistItems"`
+}
+
+// parseMicrosoftToDo функция, которая разбирает списки Microsoft To Do
+// Принимается ответ Graph API {"value": [...]} или массив списков, у каждого списка раскрыты tasks
+// @param b *builder - записи импорта
+// @param r io.Reader - файл
+// @param opts Options - параметры разбора
+// @return error - ошибка разбора
+func parseMicrosoftToDo(b *builder, r io.Reader, opts Options) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read microsoft to do export: %w", err)
+	}
+
+	var lists []microsoftList
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &lists)
+	} else {
+		var page struct {
+			Value []microsoftList `json:"value"`
+		}
+		err = json.Unmarshal(data, &page)
+		lists = page.Value
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode microsoft to do export: %w", err)
+	}
+
+	for _, list := range lists {
+		for _, task := range list.Tasks {
+			it := item{
+				id:        []string{task.ID},
+				title:     task.Title,
+				lists:     []string{list.DisplayName},
+				completed: task.Status == "completed",
+			}
+			if task.Importance == "high" {
+				it.priority = "A"
+			}
+			if due := task.DueDateTime; due != nil && due.DateTime != "" {
+				if it.dueAt = microsoftDueAt(due.DateTime, due.TimeZone, opts.Loc); it.dueAt == nil {
+					b.warn("mstodo task %q: due date %q is not supported", task.Title, due.DateTime)
+				}
+			}
+			b.add(it)
+
+			for _, checklistItem := range task.ChecklistItems {
+				b.add(item{
+					id:        []string{task.ID, checklistItem.ID},
+					parent:    task.Title,
+					title:     checklistItem.DisplayName,
+					lists:     []string{list.DisplayName},
+					completed: checklistItem.IsChecked,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// microsoftDueAt функция, которая разбирает срок Graph API
+// Часовые пояса Windows (например "Pacific Standard Time") не распознаются, срок в них считается сроком в loc
+// @param dateTime string - время без часового пояса
+// @param timeZone string - часовой пояс
+// @param loc *time.Location - часовой пояс по умолчанию
+// @return *time.Time - срок или nil, если время записано в другом формате
+func microsoftDueAt(dateTime, timeZone string, loc *time.Location) *time.Time {
+	if zone, err := time.LoadLocation(timeZone); err == nil && timeZone != "" {
+		loc = zone
+	}
+	return parseDate(dateTime, loc, microsoftDateTimeLayout)
+}
@@ -0,0 +1,230 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// todoistCSVDateLayouts - форматы колонки DATE, которые можно перенести в срок.
+// Остальные значения, например повторения "every monday", пропускаются с предупреждением
+var todoistCSVDateLayouts = []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02T15:04:05", "2 Jan 2006", "Jan 2 2006", "02.01.2006"}
+
+// todoistDueLayouts - форматы поля due.date JSON Todoist: дата, время без пояса и время в UTC
+var todoistDueLayouts = []string{"2006-01-02", "2006-01-02T15:04:05", time.RFC3339}
+
+// parseTodoistCSV функция, которая разбирает CSV проекта из резервной копии Todoist
+// Файл содержит строки task, section и note. Подзадачи задаются колонкой INDENT, id задач в файле нет,
+// поэтому внешний id строится из пути задачи в проекте
+// @param b *builder - записи импорта
+// @param r io.Reader - файл
+// @param opts Options - параметры разбора, opts.List - название проекта
+// @return error - ошибка разбора
+func parseTodoistCSV(b *builder, r io.Reader, opts Options) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"TYPE", "CONTENT"} {
+		if _, ok := columns[required]; !ok {
+			return fmt.Errorf("column %s is missing", required)
+		}
+	}
+	cell := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var section string
+	// parents - названия задач по уровням вложенности, parents[0] - задача первого уровня
+	var parents []string
+	// occurrences - количество задач с одинаковым путем, чтобы одинаковые задачи получили разные внешние id
+	occurrences := make(map[string]int)
+
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read csv: %w", err)
+		}
+
+		switch strings.ToLower(cell(row, "TYPE")) {
+		case "section":
+			section, parents = cell(row, "CONTENT"), nil
+			continue
+		case "task":
+		default:
+			// Комментарии и пустые строки-разделители не переносятся
+			continue
+		}
+
+		content := cell(row, "CONTENT")
+		indent, err := strconv.Atoi(cell(row, "INDENT"))
+		if err != nil || indent < 1 {
+			indent = 1
+		}
+		if indent > len(parents)+1 {
+			indent = len(parents) + 1
+		}
+		parents = append(parents[:indent-1], content)
+
+		path := strings.Join([]string{opts.List, section, strings.Join(parents, "\x00")}, "\x01")
+		occurrences[path]++
+		sum := sha256.Sum256([]byte(path + "\x02" + strconv.Itoa(occurrences[path])))
+
+		it := item{
+			id:       []string{"csv", hex.EncodeToString(sum[:16])},
+			title:    content,
+			lists:    []string{opts.List, section},
+			priority: todoistPriority(cell(row, "PRIORITY"), false),
+		}
+		if indent > 1 {
+			it.parent = parents[indent-2]
+		}
+		if date := cell(row, "DATE"); date != "" {
+			if it.dueAt = parseDate(date, opts.Loc, todoistCSVDateLayouts...); it.dueAt == nil {
+				b.warn("todoist-csv line %d: due date %q is not supported", line, date)
+			}
+		}
+		b.add(it)
+	}
+}
+
+// todoistBackup JSON резервной копии или ответа Sync API Todoist
+type todoistBackup struct {
+	Projects []struct {
+		ID   todoistID `json:"id"`
+		Name string    `json:"name"`
+	} `json:"projects"`
+	Sections []struct {
+		ID   todoistID `json:"id"`
+		Name string    `json:"name"`
+	} `json:"sections"`
+	Items []todoistItem `json:"items"`
+}
+
+// todoistItem задача Todoist
+type todoistItem struct {
+	ID        todoistID `json:"id"`
+	Content   string    `json:"content"`
+	ProjectID todoistID `json:"project_id"`
+	SectionID todoistID `json:"section_id"`
+	ParentID  todoistID `json:"parent_id"`
+	Checked   bool      `json:"checked"`
+	IsDeleted bool      `json:"is_deleted"`
+	// Priority - приоритет API: 4 соответствует p1, 1 - задача без приоритета
+	Priority int `json:"priority"`
+	Due      *struct {
+		Date        string `json:"date"`
+		String      string `json:"string"`
+		IsRecurring bool   `json:"is_recurring"`
+	} `json:"due"`
+}
+
+// todoistID id Todoist, старые версии API передают его числом, новые - строкой
+type todoistID string
+
+// UnmarshalJSON функция, которая принимает id в виде строки или числа
+func (id *todoistID) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*id = ""
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*id = todoistID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("todoist id must be a string or a number: %w", err)
+	}
+	*id = todoistID(n.String())
+	return nil
+}
+
+// parseTodoistJSON функция, которая разбирает JSON Todoist с проектами, разделами и задачами
+// @param b *builder - записи импорта
+// @param r io.Reader - файл
+// @param opts Options - параметры разбора
+// @return error - ошибка разбора
+func parseTodoistJSON(b *builder, r io.Reader, opts Options) error {
+	var backup todoistBackup
+	if err := json.NewDecoder(r).Decode(&backup); err != nil {
+		return fmt.Errorf("failed to decode todoist json: %w", err)
+	}
+
+	projects := make(map[todoistID]string, len(backup.Projects))
+	for _, p := range backup.Projects {
+		projects[p.ID] = p.Name
+	}
+	sections := make(map[todoistID]string, len(backup.Sections))
+	for _, s := range backup.Sections {
+		sections[s.ID] = s.Name
+	}
+	titles := make(map[todoistID]string, len(backup.Items))
+	for _, it := range backup.Items {
+		titles[it.ID] = it.Content
+	}
+
+	for _, it := range backup.Items {
+		if it.IsDeleted {
+			continue
+		}
+		converted := item{
+			id:        []string{string(it.ID)},
+			parent:    titles[it.ParentID],
+			title:     it.Content,
+			lists:     []string{projects[it.ProjectID], sections[it.SectionID]},
+			priority:  todoistPriority(strconv.Itoa(it.Priority), true),
+			completed: it.Checked,
+		}
+		if it.Due != nil && it.Due.Date != "" {
+			if converted.dueAt = parseDate(it.Due.Date, opts.Loc, todoistDueLayouts...); converted.dueAt == nil {
+				b.warn("todoist task %s: due date %q is not supported", it.ID, it.Due.Date)
+			}
+			if it.Due.IsRecurring {
+				b.warn("todoist task %s: recurrence %q is not supported, only the next due date is imported", it.ID, it.Due.String)
+			}
+		}
+		b.add(converted)
+	}
+	return nil
+}
+
+// todoistPriority функция, которая преобразует приоритет Todoist в приоритет todo.txt
+// В CSV приоритет записан как в интерфейсе (1 - p1, самый высокий), в API наоборот (4 - p1)
+// @param value string - приоритет
+// @param api bool - true, если приоритет в нумерации API
+// @return string - A, B, C или пустая строка для задач без приоритета
+func todoistPriority(value string, api bool) string {
+	p, err := strconv.Atoi(value)
+	if err != nil || p < 1 || p > 4 {
+		return ""
+	}
+	if api {
+		p = 5 - p
+	}
+	return [...]string{"", "A", "B", "C", ""}[p]
+}
@@ -0,0 +1,98 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// trelloBoard JSON экспорт доски Trello
+type trelloBoard struct {
+	Lists []struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		Closed bool   `json:"closed"`
+	} `json:"lists"`
+	Cards []struct {
+		ID          string     `json:"id"`
+		Name        string     `json:"name"`
+		IDList      string     `json:"idList"`
+		Closed      bool       `json:"closed"`
+		Due         *time.Time `json:"due"`
+		DueComplete bool       `json:"dueComplete"`
+	} `json:"cards"`
+	Checklists []struct {
+		ID         string `json:"id"`
+		IDCard     string `json:"idCard"`
+		CheckItems []struct {
+			ID    string     `json:"id"`
+			Name  string     `json:"name"`
+			State string     `json:"state"`
+			Due   *time.Time `json:"due"`
+		} `json:"checkItems"`
+	} `json:"checklists"`
+}
+
+// parseTrello функция, которая разбирает JSON экспорт доски Trello
+// Списки доски становятся тегами проектов, карточки и пункты их чек-листов - задачами.
+// Архивные карточки и карточки архивных списков не переносятся
+// @param b *builder - записи импорта
+// @param r io.Reader - файл
+// @return error - ошибка разбора
+func parseTrello(b *builder, r io.Reader) error {
+	var board trelloBoard
+	if err := json.NewDecoder(r).Decode(&board); err != nil {
+		return fmt.Errorf("failed to decode trello board: %w", err)
+	}
+
+	lists := make(map[string]string, len(board.Lists))
+	closedLists := make(map[string]bool)
+	for _, list := range board.Lists {
+		lists[list.ID] = list.Name
+		closedLists[list.ID] = list.Closed
+	}
+
+	// cards - карточки, которые переносятся, по id для пунктов чек-листов
+	type card struct {
+		name string
+		list string
+	}
+	cards := make(map[string]card, len(board.Cards))
+	skipped := 0
+	for _, c := range board.Cards {
+		if c.Closed || closedLists[c.IDList] {
+			skipped++
+			continue
+		}
+		cards[c.ID] = card{name: c.Name, list: lists[c.IDList]}
+		b.add(item{
+			id:        []string{c.ID},
+			title:     c.Name,
+			lists:     []string{lists[c.IDList]},
+			completed: c.DueComplete,
+			dueAt:     c.Due,
+		})
+	}
+	if skipped > 0 {
+		b.warn("trello: %d archived cards were skipped", skipped)
+	}
+
+	for _, checklist := range board.Checklists {
+		parent, ok := cards[checklist.IDCard]
+		if !ok {
+			continue
+		}
+		for _, checkItem := range checklist.CheckItems {
+			b.add(item{
+				id:        []string{checklist.IDCard, checkItem.ID},
+				parent:    parent.name,
+				title:     checkItem.Name,
+				lists:     []string{parent.list},
+				completed: checkItem.State == "complete",
+				dueAt:     checkItem.Due,
+			})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxBatchOperations - максимальное количество операций в одном пакетном запросе
+const MaxBatchOperations = 1000
+
+var (
+	// ErrBatchAborted ошибка, которая возвращается для операций, отмененных из-за ошибки в другой операции пакета
+	ErrBatchAborted = errors.New("batch aborted")
+	// ErrInvalidBatch ошибка, которая возвращается если пакетный запрос составлен некорректно
+	ErrInvalidBatch = errors.New("invalid batch")
+)
+
+// BatchOperationType тип операции в пакетном запросе
+type BatchOperationType string
+
+const (
+	// BatchCreate - создание задачи
+	BatchCreate BatchOperationType = "create"
+	// BatchUpdate - изменение названия и/или статуса задачи
+	BatchUpdate BatchOperationType = "update"
+	// BatchComplete - отметка задачи выполненной
+	BatchComplete BatchOperationType = "complete"
+	// BatchDelete - удаление задачи
+	BatchDelete BatchOperationType = "delete"
+)
+
+// BatchMode режим выполнения пакетного запроса
+type BatchMode string
+
+const (
+	// BatchAllOrNothing - при ошибке любой операции откатываются все операции пакета
+	BatchAllOrNothing BatchMode = "all_or_nothing"
+	// BatchBestEffort - ошибка одной операции не влияет на остальные
+	BatchBestEffort BatchMode = "best_effort"
+)
+
+// BatchOperation операция над задачей в пакетном запросе
+type BatchOperation struct {
+	Op BatchOperationType `json:"op" schema:"enum=create|update|complete|delete"`
+	// ID - id задачи, обязателен для всех операций кроме create
+	ID int `json:"id,omitempty"`
+	// Version - ожидаемая версия задачи, обязательна для всех операций кроме create
+	Version   int     `json:"version,omitempty"`
+	Title     *string `json:"title,omitempty" schema:"maxLength=255"`
+	Completed *bool   `json:"completed,omitempty"`
+}
+
+// BatchResult результат выполнения одной операции пакета
+type BatchResult struct {
+	// Task - задача после выполнения операции, nil для delete и при ошибке
+	Task *Task
+	// Err - ошибка выполнения операции
+	Err error
+}
+
+// Validate проверка операции на валидность
+// @return error - ошибка
+func (op *BatchOperation) Validate() error {
+	switch op.Op {
+	case BatchCreate:
+		if op.Title == nil {
+			return errors.New("title is required")
+		}
+		task := Task{Title: *op.Title}
+		return task.Validate()
+	case BatchUpdate:
+		if op.Title == nil && op.Completed == nil {
+			return errors.New("title or completed is required")
+		}
+		if op.Title != nil && *op.Title == "" {
+			return errors.New("title must not be empty")
+		}
+	case BatchComplete, BatchDelete:
+	default:
+		return fmt.Errorf("unknown operation %q", op.Op)
+	}
+
+	if op.ID <= 0 {
+		return errors.New("id is required")
+	}
+	if op.Version <= 0 {
+		return errors.New("version is required")
+	}
+
+	return nil
+}
+
+// Validate проверка режима выполнения на валидность
+// @return error - ошибка
+func (m BatchMode) Validate() error {
+	switch m {
+	case BatchAllOrNothing, BatchBestEffort:
+		return nil
+	default:
+		return fmt.Errorf("unknown batch mode %q", m)
+	}
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrCalendarTokenNotFound ошибка, которая возвращается если токен ленты календаря не найден или отозван
+var ErrCalendarTokenNotFound = errors.New("calendar token not found")
+
+// CalendarToken токен доступа к ленте календаря только для чтения
+// Сам токен известен только клиенту, в базе данных хранится его хеш
+type CalendarToken struct {
+	ID int `json:"id"`
+	// Name - название, по которому токен можно узнать в списке, например имя календаря или устройства
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// TaskEventType тип события об изменении задачи
+type TaskEventType string
+
+const (
+	// TaskCreated - задача создана
+	TaskCreated TaskEventType = "created"
+	// TaskUpdated - задача изменена
+	TaskUpdated TaskEventType = "updated"
+	// TaskDeleted - задача удалена
+	TaskDeleted TaskEventType = "deleted"
+)
+
+// TaskEvent событие об изменении задачи
+type TaskEvent struct {
+	Type TaskEventType `json:"type"`
+	// Task - задача после изменения, для TaskDeleted заполнен только ID
+	Task *Task `json:"task"`
+}
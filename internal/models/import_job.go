@@ -0,0 +1,51 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrImportJobNotFound ошибка, которая возвращается если задание импорта не найдено или уже удалено
+var ErrImportJobNotFound = errors.New("import job not found")
+
+// ImportJobStatus статус задания импорта
+type ImportJobStatus string
+
+const (
+	// ImportPending - задание создано и ждет запуска
+	ImportPending ImportJobStatus = "pending"
+	// ImportRunning - задачи импортируются
+	ImportRunning ImportJobStatus = "running"
+	// ImportSucceeded - все задачи импортированы
+	ImportSucceeded ImportJobStatus = "succeeded"
+	// ImportFailed - импорт прерван ошибкой, задачи уже записанных частей остаются
+	ImportFailed ImportJobStatus = "failed"
+)
+
+// ImportJob задание импорта файла другого приложения, выполняемое в фоне
+type ImportJob struct {
+	ID string `json:"id"`
+	// Source - формат файла, например todoist-csv или trello
+	Source string          `json:"source"`
+	Status ImportJobStatus `json:"status" schema:"enum=pending|running|succeeded|failed"`
+	// Total - количество задач в файле
+	Total int `json:"total"`
+	// Processed - количество обработанных задач, включая пропущенные
+	Processed int `json:"processed"`
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	// Skipped - количество задач, которые не прошли проверку и не были импортированы
+	Skipped int `json:"skipped"`
+	// Warnings - данные, которые не удалось перенести
+	Warnings []string `json:"warnings"`
+	// Error - причина ошибки для статуса failed
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Finished функция, которая проверяет, завершено ли задание
+// @return bool - true для статусов succeeded и failed
+func (j *ImportJob) Finished() bool {
+	return j.Status == ImportSucceeded || j.Status == ImportFailed
+}
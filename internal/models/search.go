@@ -0,0 +1,55 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// DefaultSearchLimit - количество результатов поиска по умолчанию
+	DefaultSearchLimit = 20
+	// MaxSearchLimit - максимальное количество результатов поиска
+	MaxSearchLimit = 100
+	// DefaultSuggestLimit - количество подсказок по умолчанию
+	DefaultSuggestLimit = 10
+	// MaxSuggestLimit - максимальное количество подсказок
+	MaxSuggestLimit = 50
+)
+
+// ErrInvalidSearch ошибка, которая возвращается если параметры поиска некорректны
+var ErrInvalidSearch = errors.New("invalid search")
+
+// searchConfigs - поддерживаемые конфигурации полнотекстового поиска Postgres
+// Для каждой конфигурации в миграциях создан отдельный GIN индекс
+var searchConfigs = map[string]struct{}{
+	"simple":  {},
+	"english": {},
+	"russian": {},
+}
+
+// TaskSearchResult результат полнотекстового поиска задач
+type TaskSearchResult struct {
+	Task
+	// Rank - релевантность задачи запросу, чем больше тем выше в выдаче
+	Rank float32 `json:"rank"`
+	// Headline - экранированное для HTML название задачи, совпадения обернуты в <mark>
+	Headline string `json:"headline"`
+}
+
+// TaskSuggestion результат быстрого нечеткого поиска задачи по названию
+type TaskSuggestion struct {
+	Task
+	// Score - похожесть названия задачи на запрос от 0 до 1
+	Score float32 `json:"score"`
+}
+
+// ValidateSearchConfig проверка конфигурации полнотекстового поиска
+// @param name string - название конфигурации
+// @return error - ошибка
+func ValidateSearchConfig(name string) error {
+	if _, ok := searchConfigs[name]; !ok {
+		return fmt.Errorf("%w: unsupported text search configuration %q", ErrInvalidSearch, name)
+	}
+
+	return nil
+}
@@ -1,20 +1,45 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxTitleLength - максимальная длина названия задачи, совпадает с размером колонки title
+const MaxTitleLength = 255
+
+var (
+	// ErrTaskNotFound ошибка, которая возвращается если задача не найдена
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrVersionConflict ошибка, которая возвращается если версия задачи не совпадает с ожидаемой
+	ErrVersionConflict = errors.New("task version conflict")
+	// ErrInvalidTask ошибка, которая возвращается если задача не прошла проверку
+	ErrInvalidTask = errors.New("invalid task")
+)
 
 type Task struct {
 	ID        int    `json:"id"`
-	Title     string `json:"title"`
+	Title     string `json:"title" schema:"maxLength=255"`
 	Completed bool   `json:"completed"`
+	// Version - версия задачи, увеличивается при каждом изменении
+	Version int `json:"version"`
+	// DueAt - срок выполнения задачи, nil если срок не задан
+	DueAt *time.Time `json:"due_at,omitempty"`
 }
 
 // Validate проверка задачи на валидность
 // @return error - ошибка
 func (t *Task) Validate() error {
-	if t.Title == "" { // проверка на наличие названия задачи
+	if strings.TrimSpace(t.Title) == "" { // проверка на наличие названия задачи
 		return errors.New("title is required") // возврат ошибки
 	}
 
+	if utf8.RuneCountInString(t.Title) > MaxTitleLength { // проверка длины названия задачи
+		return errors.New("title is too long")
+	}
+
 	// возврат nil, если задача валидна
 	return nil
 }
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxImportRows - максимальное количество задач в одном импорте
+	MaxImportRows = 10000
+	// MaxExternalIDLength - максимальная длина внешнего id задачи
+	MaxExternalIDLength = 255
+)
+
+var (
+	// ErrInvalidImport ошибка, которая возвращается если импорт составлен некорректно
+	ErrInvalidImport = errors.New("invalid import")
+	// ErrImportAborted ошибка, которая возвращается для корректных строк импорта, не записанных из-за ошибок в других строках
+	ErrImportAborted = errors.New("import aborted")
+)
+
+// TaskRecord задача в формате импорта и экспорта
+type TaskRecord struct {
+	// ID - id задачи, заполняется при экспорте и игнорируется при импорте
+	ID int `json:"id,omitempty"`
+	// ExternalID - id задачи во внешней системе, по нему повторный импорт обновляет задачу вместо создания новой
+	ExternalID string `json:"external_id,omitempty"`
+	// Title - название задачи, проверяется построчно через Task.Validate
+	Title     string `json:"title" schema:"optional"`
+	Completed bool   `json:"completed" schema:"optional"`
+	// Version - версия задачи, заполняется при экспорте и игнорируется при импорте
+	Version int `json:"version,omitempty"`
+	// DueAt - срок выполнения задачи
+	DueAt *time.Time `json:"due_at,omitempty"`
+}
+
+// ImportResult результат импорта одной задачи
+type ImportResult struct {
+	// Task - задача после импорта, nil при ошибке
+	Task *Task
+	// Created - true, если задача создана, false если обновлена задача с тем же внешним id
+	Created bool
+	// Err - ошибка проверки задачи
+	Err error
+}
+
+// Task функция, которая возвращает задачу из записи импорта
+// @return Task - задача
+func (r *TaskRecord) Task() Task {
+	return Task{ID: r.ID, Title: r.Title, Completed: r.Completed, Version: r.Version, DueAt: r.DueAt}
+}
+
+// Validate проверка записи импорта на валидность
+// @return error - ошибка
+func (r *TaskRecord) Validate() error {
+	if utf8.RuneCountInString(r.ExternalID) > MaxExternalIDLength {
+		return errors.New("external_id is too long")
+	}
+
+	task := r.Task()
+	return task.Validate()
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/pers0na2dev/todo-api/internal/config"
+	"github.com/pers0na2dev/todo-api/internal/repository/postgres"
+	"github.com/pers0na2dev/todo-api/pkg/cache"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// NewCache функция, которая создает кеш в хранилище, выбранном в CACHE_BACKEND, и собирает статистику обращений к нему
+// @param lc fx.Lifecycle - жизненный цикл приложения, при остановке закрываются соединения с Redis
+// @param cfg *config.Config - конфигурация
+// @param logger *zap.Logger - логгер
+// @return *cache.MetricsCache - кеш со статистикой по префиксам ключей
+// @return error - ошибка, если хранилище неизвестно или недоступно
+func NewCache(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) (*cache.MetricsCache, error) {
+	c, err := newCache(lc, cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	return cache.NewMetricsCache(c, cache.MetricsOptions{Prefixes: postgres.CacheKeyPrefixes}), nil
+}
+
+// newCache функция, которая создает кеш в хранилище, выбранном в CACHE_BACKEND
+// @param lc fx.Lifecycle - жизненный цикл приложения
+// @param cfg *config.Config - конфигурация
+// @param logger *zap.Logger - логгер
+// @return cache.Cache - кеш
+// @return error - ошибка, если хранилище неизвестно или недоступно
+func newCache(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) (cache.Cache, error) {
+	switch cfg.CacheBackend {
+	case "", "redis":
+		return newRedisCache(lc, cfg, logger)
+	case "memory":
+		logger.Info("using in-memory cache",
+			zap.Int("max_entries", cfg.CacheMaxEntries),
+			zap.Int64("max_bytes", cfg.CacheMaxBytes),
+			zap.String("eviction", cfg.CacheEviction),
+		)
+		return newMemoryCache(cfg)
+	case "tiered":
+		remote, err := newRedisCache(lc, cfg, logger)
+		if err != nil {
+			return nil, err
+		}
+		local, err := newMemoryCache(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		tiered, err := cache.NewTieredCache(local, remote, cache.TieredOptions{
+			LocalTTL: cfg.CacheLocalTTL,
+			Channel:  cfg.CacheInvalidationChannel,
+		}, logger)
+		if err != nil {
+			logger.Error("failed to create tiered cache", zap.Error(err))
+			return nil, err
+		}
+		// Хуки остановки выполняются в обратном порядке, поэтому подписка закрывается раньше соединений Redis
+		lc.Append(fx.Hook{
+			OnStop: func(ctx context.Context) error {
+				return tiered.Close()
+			},
+		})
+
+		logger.Info("using tiered cache", zap.Duration("local_ttl", cfg.CacheLocalTTL))
+		return tiered, nil
+	default:
+		return nil, fmt.Errorf("unknown CACHE_BACKEND %q", cfg.CacheBackend)
+	}
+}
+
+// newRedisCache функция, которая подключается к кешу в Redis
+// Соединения закрываются при остановке приложения
+// @param lc fx.Lifecycle - жизненный цикл приложения
+// @param cfg *config.Config - конфигурация
+// @param logger *zap.Logger - логгер
+// @return *cache.RedisCache - кеш
+// @return error - ошибка подключения или неизвестный формат значений
+func newRedisCache(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) (*cache.RedisCache, error) {
+	codec, err := cache.CodecByName(cfg.CacheCodec)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := redisTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("connecting to redis cache",
+		zap.String("mode", redisMode(cfg)),
+		zap.Bool("tls", cfg.RedisTLS),
+		zap.String("codec", cfg.CacheCodec),
+		zap.String("compression", cfg.CacheCompression),
+	)
+	c, err := cache.NewRedisCache(cache.RedisOptions{
+		DSN:                cfg.RedisDSN,
+		SentinelMasterName: cfg.RedisSentinelMaster,
+		SentinelAddrs:      cfg.RedisSentinelAddrs,
+		SentinelPassword:   cfg.RedisSentinelPassword,
+		ClusterAddrs:       cfg.RedisClusterAddrs,
+		Username:           cfg.RedisUsername,
+		Password:           cfg.RedisPassword,
+		DB:                 cfg.RedisDB,
+		TLSConfig:          tlsConfig,
+		PoolSize:           cfg.RedisPoolSize,
+		MinIdleConns:       cfg.RedisMinIdleConns,
+		DialTimeout:        cfg.RedisDialTimeout,
+		ReadTimeout:        cfg.RedisReadTimeout,
+		WriteTimeout:       cfg.RedisWriteTimeout,
+		PoolTimeout:        cfg.RedisPoolTimeout,
+		BreakerThreshold:   cfg.CacheBreakerThreshold,
+		BreakerCooldown:    cfg.CacheBreakerCooldown,
+		Encoding: cache.Encoding{
+			Codec:             codec,
+			Compression:       cache.Compression(cfg.CacheCompression),
+			CompressThreshold: cfg.CacheCompressThreshold,
+		},
+		AccessLog: cache.AccessLogOptions{
+			Key:  cfg.CacheAccessLogKey,
+			Size: cfg.CacheAccessLogSize,
+		},
+	}, logger)
+	if err != nil {
+		logger.Error("failed to connect to redis cache", zap.Error(err))
+		return nil, err
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return c.Close()
+		},
+	})
+	return c, nil
+}
+
+// redisMode функция, которая возвращает режим подключения к Redis для логов
+// @param cfg *config.Config - конфигурация
+// @return string - sentinel, cluster или single
+func redisMode(cfg *config.Config) string {
+	switch {
+	case cfg.RedisSentinelMaster != "":
+		return "sentinel"
+	case len(cfg.RedisClusterAddrs) > 0:
+		return "cluster"
+	default:
+		return "single"
+	}
+}
+
+// redisTLSConfig функция, которая создает параметры TLS для подключения к Redis
+// @param cfg *config.Config - конфигурация
+// @return *tls.Config - параметры TLS, nil если REDIS_TLS выключен
+// @return error - ошибка чтения файла CA
+func redisTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	if !cfg.RedisTLS {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.RedisTLSServerName,
+	}
+	if cfg.RedisTLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.RedisTLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read REDIS_TLS_CA_FILE: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in REDIS_TLS_CA_FILE %s", cfg.RedisTLSCAFile)
+		}
+	}
+	return tlsConfig, nil
+}
+
+// newMemoryCache функция, которая создает кеш в памяти процесса
+// @param cfg *config.Config - конфигурация
+// @return *cache.MemoryCache - кеш
+// @return error - ошибка, если политика вытеснения неизвестна
+func newMemoryCache(cfg *config.Config) (*cache.MemoryCache, error) {
+	return cache.NewMemoryCache(cache.MemoryOptions{
+		MaxEntries: cfg.CacheMaxEntries,
+		MaxBytes:   cfg.CacheMaxBytes,
+		Policy:     cache.EvictionPolicy(cfg.CacheEviction),
+	})
+}
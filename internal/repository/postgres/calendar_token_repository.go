@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pers0na2dev/todo-api/internal/models"
+)
+
+// CalendarTokenRepository структура, которая хранит токены лент календаря
+type CalendarTokenRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewCalendarTokenRepository функция, которая создает новый экземпляр CalendarTokenRepository
+// @param pool *pgxpool.Pool - подключение к базе данных
+// @return *CalendarTokenRepository - новый экземпляр CalendarTokenRepository
+func NewCalendarTokenRepository(pool *pgxpool.Pool) *CalendarTokenRepository {
+	return &CalendarTokenRepository{pool: pool}
+}
+
+// CreateToken функция, которая сохраняет хеш нового токена
+// @param ctx context.Context - контекст выполнения
+// @param name string - название токена
+// @param hash []byte - хеш токена
+// @return *models.CalendarToken - созданный токен
+// @return error - ошибка
+func (r *CalendarTokenRepository) CreateToken(ctx context.Context, name string, hash []byte) (*models.CalendarToken, error) {
+	token := &models.CalendarToken{Name: name}
+	query := `INSERT INTO calendar_tokens (token_hash, name) VALUES ($1, $2) RETURNING id, created_at`
+	if err := r.pool.QueryRow(ctx, query, hash, name).Scan(&token.ID, &token.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to create calendar token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ListTokens функция, которая возвращает все токены
+// @param ctx context.Context - контекст выполнения
+// @return []*models.CalendarToken - токены в порядке создания
+// @return error - ошибка
+func (r *CalendarTokenRepository) ListTokens(ctx context.Context) ([]*models.CalendarToken, error) {
+	rows, err := r.pool.Query(ctx, `SELECT id, name, created_at FROM calendar_tokens ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calendar tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := make([]*models.CalendarToken, 0)
+	for rows.Next() {
+		token := &models.CalendarToken{}
+		if err := rows.Scan(&token.ID, &token.Name, &token.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan calendar token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list calendar tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// FindToken функция, которая находит токен по хешу
+// @param ctx context.Context - контекст выполнения
+// @param hash []byte - хеш токена
+// @return *models.CalendarToken - токен
+// @return error - ошибка, models.ErrCalendarTokenNotFound если токена нет
+func (r *CalendarTokenRepository) FindToken(ctx context.Context, hash []byte) (*models.CalendarToken, error) {
+	token := &models.CalendarToken{}
+	query := `SELECT id, name, created_at FROM calendar_tokens WHERE token_hash = $1`
+	err := r.pool.QueryRow(ctx, query, hash).Scan(&token.ID, &token.Name, &token.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, models.ErrCalendarTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find calendar token: %w", err)
+	}
+
+	return token, nil
+}
+
+// DeleteToken функция, которая удаляет токен
+// @param ctx context.Context - контекст выполнения
+// @param id int - id токена
+// @return error - ошибка, models.ErrCalendarTokenNotFound если токена нет
+func (r *CalendarTokenRepository) DeleteToken(ctx context.Context, id int) error {
+	result, err := r.pool.Exec(ctx, `DELETE FROM calendar_tokens WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete calendar token: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return models.ErrCalendarTokenNotFound
+	}
+
+	return nil
+}
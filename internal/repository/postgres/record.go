@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pers0na2dev/todo-api/internal/models"
+)
+
+// GetTaskRecord функция, которая возвращает задачу вместе с внешним id
+// Ищется задача с внешним id externalID, а если такой нет - задача без внешнего id с заданным id
+// @param ctx context.Context - контекст выполнения
+// @param externalID string - внешний id задачи
+// @param id int - id задачи без внешнего id, 0 если искать только по внешнему id
+// @return *models.TaskRecord - задача
+// @return error - ошибка, models.ErrTaskNotFound если задачи нет
+func (r *TaskRepository) GetTaskRecord(ctx context.Context, externalID string, id int) (*models.TaskRecord, error) {
+	query := `SELECT id, COALESCE(external_id, ''), title, completed, version, due_at FROM tasks
+		WHERE external_id = $1 OR (external_id IS NULL AND id = $2)
+		ORDER BY external_id IS NULL LIMIT 1`
+
+	record := &models.TaskRecord{}
+	err := r.pool.QueryRow(ctx, query, externalID, id).
+		Scan(&record.ID, &record.ExternalID, &record.Title, &record.Completed, &record.Version, &record.DueAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, models.ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task record: %w", err)
+	}
+
+	return record, nil
+}
+
+// PutTaskRecord функция, которая заменяет название, статус и срок задачи или создает её
+// Если record.ID задан, изменяется задача с этим id. Иначе задача ищется по внешнему id и создается, если её нет.
+// При version > 0 задача изменяется только если её текущая версия совпадает с version и не создается
+// @param ctx context.Context - контекст выполнения
+// @param record models.TaskRecord - задача
+// @param version int - ожидаемая версия задачи, 0 если версия не проверяется
+// @return *models.Task - задача после изменения
+// @return bool - true, если задача создана
+// @return error - ошибка, models.ErrTaskNotFound или models.ErrVersionConflict если условие не выполнено
+func (r *TaskRepository) PutTaskRecord(ctx context.Context, record models.TaskRecord, version int) (*models.Task, bool, error) {
+	var query string
+	args := []any{record.Title, record.Completed, record.DueAt}
+	switch {
+	case record.ID > 0:
+		query = `UPDATE tasks SET title = $1, completed = $2, due_at = $3, version = version + 1
+			WHERE id = $4 AND ($5 = 0 OR version = $5) RETURNING id, title, completed, version, due_at, false`
+		args = append(args, record.ID, version)
+	case version > 0:
+		query = `UPDATE tasks SET title = $1, completed = $2, due_at = $3, version = version + 1
+			WHERE external_id = $4 AND version = $5 RETURNING id, title, completed, version, due_at, false`
+		args = append(args, record.ExternalID, version)
+	default:
+		// xmax = 0 только у вставленных строк, как и при импорте
+		query = `INSERT INTO tasks (external_id, title, completed, due_at) VALUES ($4, $1, $2, $3)
+			ON CONFLICT (external_id) DO UPDATE
+			SET title = EXCLUDED.title, completed = EXCLUDED.completed, due_at = EXCLUDED.due_at, version = tasks.version + 1
+			RETURNING id, title, completed, version, due_at, xmax = 0`
+		args = append(args, record.ExternalID)
+	}
+
+	task := &models.Task{}
+	var created bool
+	err := r.pool.QueryRow(ctx, query, args...).Scan(&task.ID, &task.Title, &task.Completed, &task.Version, &task.DueAt, &created)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if record.ID == 0 {
+			return nil, false, externalPreconditionError(ctx, r.pool, record.ExternalID)
+		}
+		// Закешированная копия задачи могла устареть, раз её версия не совпала
+		r.invalidateTasks(ctx, record.ID)
+		return nil, false, preconditionError(ctx, r.pool, record.ID)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to put task: %w", err)
+	}
+
+	// У созданной задачи удаляется отметка об отсутствии, у обновленной - закешированная копия
+	r.invalidateTasks(ctx, task.ID)
+
+	return task, created, nil
+}
+
+// externalPreconditionError функция, которая определяет почему условное изменение задачи с внешним id не затронуло строк
+// @param ctx context.Context - контекст выполнения
+// @param q querier - пул соединений или транзакция
+// @param externalID string - внешний id задачи
+// @return error - models.ErrTaskNotFound если задачи нет, иначе models.ErrVersionConflict
+func externalPreconditionError(ctx context.Context, q querier, externalID string) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM tasks WHERE external_id = $1)`
+	if err := q.QueryRow(ctx, query, externalID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check task existence: %w", err)
+	}
+
+	if !exists {
+		return models.ErrTaskNotFound
+	}
+
+	return models.ErrVersionConflict
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+)
+
+// Метки совпадений, которые ts_headline расставляет в названии. Это символы из области частного использования
+// Unicode, они удаляются из названия перед подсветкой, поэтому не могут совпасть с текстом задачи
+const (
+	headlineStartSel = "\uE000"
+	headlineStopSel  = "\uE001"
+)
+
+// headlineReplacer - заменяет метки совпадений на теги <mark> после экранирования названия
+var headlineReplacer = strings.NewReplacer(headlineStartSel, "<mark>", headlineStopSel, "</mark>")
+
+// SearchTasks функция, которая выполняет полнотекстовый поиск задач по названию
+// Название конфигурации подставляется в запрос напрямую, иначе Postgres не сможет использовать GIN индекс,
+// поэтому оно должно быть предварительно проверено через models.ValidateSearchConfig
+// @param ctx context.Context - контекст выполнения
+// @param query string - поисковый запрос в синтаксисе websearch_to_tsquery
+// @param config string - конфигурация полнотекстового поиска
+// @param limit int - максимальное количество результатов
+// @return []*models.TaskSearchResult - найденные задачи, отсортированные по релевантности
+// @return error - ошибка
+func (r *TaskRepository) SearchTasks(ctx context.Context, query string, config string, limit int) ([]*models.TaskSearchResult, error) {
+	if err := models.ValidateSearchConfig(config); err != nil {
+		return nil, err
+	}
+
+	sql := fmt.Sprintf(`SELECT id, title, completed, version, due_at,
+			ts_rank(to_tsvector('%[1]s', title), q) AS rank,
+			ts_headline('%[1]s', translate(title, '%[2]s%[3]s', ''), q,
+				'StartSel="%[2]s", StopSel="%[3]s", HighlightAll=true') AS headline
+		FROM tasks, websearch_to_tsquery('%[1]s', $1) AS q
+		WHERE to_tsvector('%[1]s', title) @@ q
+		ORDER BY rank DESC, id
+		LIMIT $2`, config, headlineStartSel, headlineStopSel)
+
+	rows, err := r.pool.Query(ctx, sql, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tasks: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]*models.TaskSearchResult, 0)
+	for rows.Next() {
+		result := &models.TaskSearchResult{}
+		err := rows.Scan(&result.ID, &result.Title, &result.Completed, &result.Version, &result.DueAt, &result.Rank, &result.Headline)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		result.Headline = renderHeadline(result.Headline)
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search tasks: %w", err)
+	}
+
+	return results, nil
+}
+
+// renderHeadline функция, которая превращает результат ts_headline в HTML
+// Название задачи - пользовательский текст, поэтому оно экранируется, и только метки совпадений становятся тегами <mark>
+// @param headline string - название с метками совпадений
+// @return string - HTML с подсвеченными совпадениями
+func renderHeadline(headline string) string {
+	return headlineReplacer.Replace(html.EscapeString(headline))
+}
@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRenderHeadline тестирует преобразование результата ts_headline в HTML
+func TestRenderHeadline(t *testing.T) {
+	t.Run("Совпадения оборачиваются в mark", func(t *testing.T) {
+		headline := "Купить " + headlineStartSel + "молоко" + headlineStopSel
+
+		assert.Equal(t, "Купить <mark>молоко</mark>", renderHeadline(headline))
+	})
+
+	t.Run("HTML в названии задачи экранируется", func(t *testing.T) {
+		headline := `<img src=x onerror="alert(1)"> ` + headlineStartSel + "молоко" + headlineStopSel + " & <b>хлеб</b>"
+
+		assert.Equal(t,
+			"&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>молоко</mark> &amp; &lt;b&gt;хлеб&lt;/b&gt;",
+			renderHeadline(headline),
+		)
+	})
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	suggestCacheKeyPrefix = "tasks:suggest:"
+	// suggestCacheDuration - подсказки удаляются по тегу tasksCacheTag при изменении любой задачи
+	suggestCacheDuration = 30 * time.Second
+)
+
+// SuggestTasks функция, которая возвращает задачи с наиболее похожими на запрос названиями
+// Используется похожесть по триграммам, поэтому запрос может быть началом слова или содержать опечатки
+// @param ctx context.Context - контекст выполнения
+// @param query string - нормализованный запрос
+// @param limit int - максимальное количество подсказок
+// @return []*models.TaskSuggestion - подсказки, отсортированные по похожести
+// @return error - ошибка
+func (r *TaskRepository) SuggestTasks(ctx context.Context, query string, limit int) ([]*models.TaskSuggestion, error) {
+	suggestions := make([]*models.TaskSuggestion, 0, limit)
+	cacheKey := fmt.Sprintf("%s%d:%s", suggestCacheKeyPrefix, limit, query)
+
+	// Пробуем получить из кеша
+	err := r.cache.Get(ctx, cacheKey, &suggestions)
+	if err == nil {
+		r.logger.Debug("suggestions retrieved from cache", zap.String("query", query))
+		return suggestions, nil
+	}
+	r.logCacheError("failed to read suggestions from cache", err, zap.String("query", query))
+
+	// Оператор <<-> использует GiST индекс и сразу возвращает ближайшие задачи
+	sql := `SELECT id, title, completed, version, due_at, word_similarity($1, lower(title)) AS score
+		FROM tasks
+		WHERE $1 <% lower(title)
+		ORDER BY $1 <<-> lower(title), id
+		LIMIT $2`
+
+	rows, err := r.pool.Query(ctx, sql, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest tasks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		suggestion := &models.TaskSuggestion{}
+		err := rows.Scan(&suggestion.ID, &suggestion.Title, &suggestion.Completed, &suggestion.Version, &suggestion.DueAt, &suggestion.Score)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan suggestion: %w", err)
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to suggest tasks: %w", err)
+	}
+
+	// Сохраняем в кеш
+	if err := r.cache.SetWithTags(ctx, cacheKey, suggestions, suggestCacheDuration, tasksCacheTag); err != nil {
+		r.logCacheError("failed to cache suggestions", err, zap.String("query", query))
+	}
+
+	return suggestions, nil
+}
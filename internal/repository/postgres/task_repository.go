@@ -2,9 +2,12 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pers0na2dev/todo-api/internal/models"
 	"github.com/pers0na2dev/todo-api/pkg/cache"
@@ -15,13 +18,36 @@ const (
 	taskCacheKeyPrefix = "task:"
 	tasksCacheKey      = "tasks:all"
 	cacheDuration      = 5 * time.Minute
+	// tasksCacheTag - тег записей кеша, которые зависят от любой задачи: списков, подсказок, результатов поиска
+	tasksCacheTag = "tasks"
+	// staleCacheDuration - время после истечения кеша, в течение которого отдается устаревшее значение,
+	// пока одна из реплик загружает новое из БД
+	staleCacheDuration = time.Minute
+	// cacheEarlyRefreshBeta - коэффициент вероятностного раннего обновления кеша
+	cacheEarlyRefreshBeta = 1
+	// absentTaskCacheDuration - срок отметки об отсутствии задачи, она защищает БД от запросов
+	// удаленных и несуществующих id
+	absentTaskCacheDuration = 30 * time.Second
 )
 
+// CacheKeyPrefixes - префиксы ключей кеша репозитория, по которым группируется статистика кеша
+var CacheKeyPrefixes = []string{taskCacheKeyPrefix, tasksCacheKey, suggestCacheKeyPrefix}
+
+// querier интерфейс, который позволяет выполнять одни и те же запросы как через пул соединений, так и внутри транзакции
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 // TaskRepository структура, которая содержит подключение к базе данных
 type TaskRepository struct {
 	pool   *pgxpool.Pool
 	cache  cache.Cache
-	logger *zap.Logger
+	loader *cache.Loader
+	// tracker - журнал прочитанных ключей для прогрева кеша, nil если кеш его не ведет
+	tracker cache.AccessTracker
+	logger  *zap.Logger
 }
 
 // NewTaskRepository функция, которая создает новый экземпляр TaskRepository
@@ -29,12 +55,21 @@ type TaskRepository struct {
 // @param cache cache.Cache - кеш
 // @param logger *zap.Logger - логгер
 // @return *TaskRepository - новый экземпляр TaskRepository
-func NewTaskRepository(pool *pgxpool.Pool, cache cache.Cache, logger *zap.Logger) *TaskRepository {
-	return &TaskRepository{
-		pool:   pool,
-		cache:  cache,
+func NewTaskRepository(pool *pgxpool.Pool, c cache.Cache, logger *zap.Logger) *TaskRepository {
+	r := &TaskRepository{
+		pool:  pool,
+		cache: c,
+		// Задачи загружаются через Loader, чтобы истечение или удаление ключа не приводило к лавине запросов в БД
+		loader: cache.NewLoader(c, cache.LoaderOptions{
+			StaleTTL:  staleCacheDuration,
+			Beta:      cacheEarlyRefreshBeta,
+			AbsentTTL: absentTaskCacheDuration,
+		}, logger),
 		logger: logger,
 	}
+	r.tracker, _ = c.(cache.AccessTracker)
+
+	return r
 }
 
 // CreateTask функция, которая создает новую задачу
@@ -42,16 +77,12 @@ func NewTaskRepository(pool *pgxpool.Pool, cache cache.Cache, logger *zap.Logger
 // @param task *models.Task - задача
 // @return error - ошибка
 func (r *TaskRepository) CreateTask(ctx context.Context, task *models.Task) error {
-	query := `INSERT INTO tasks (title, completed) VALUES ($1, $2) RETURNING id`
-	err := r.pool.QueryRow(ctx, query, task.Title, task.Completed).Scan(&task.ID)
-	if err != nil {
-		return fmt.Errorf("failed to create task: %w", err)
+	if err := insertTask(ctx, r.pool, task); err != nil {
+		return err
 	}
 
-	// Инвалидируем кеш списка всех задач
-	if err := r.cache.Delete(ctx, tasksCacheKey); err != nil {
-		r.logger.Warn("failed to invalidate tasks cache", zap.Error(err))
-	}
+	// Удаляем и отметку об отсутствии задачи, если ее id уже запрашивали
+	r.invalidateTasks(ctx, task.ID)
 
 	return nil
 }
@@ -61,17 +92,36 @@ func (r *TaskRepository) CreateTask(ctx context.Context, task *models.Task) erro
 // @return []*models.Task - список задач
 // @return error - ошибка
 func (r *TaskRepository) GetTasks(ctx context.Context) ([]*models.Task, error) {
+	r.trackAccess(tasksCacheKey)
+	return r.getTasks(ctx)
+}
+
+// getTasks функция, которая возвращает все задачи, не отмечая обращение в журнале
+// @param ctx context.Context - контекст выполнения
+// @return []*models.Task - список задач
+// @return error - ошибка
+func (r *TaskRepository) getTasks(ctx context.Context) ([]*models.Task, error) {
 	var tasks []*models.Task
 
-	// Пробуем получить из кеша
-	err := r.cache.Get(ctx, tasksCacheKey, &tasks)
-	if err == nil {
-		r.logger.Debug("tasks retrieved from cache")
-		return tasks, nil
+	// Одновременные промахи по кешу объединяются в один запрос к БД
+	err := r.loader.Fetch(ctx, tasksCacheKey, &tasks, cacheDuration, func(ctx context.Context) (interface{}, error) {
+		return r.queryTasks(ctx)
+	}, tasksCacheTag)
+	if err != nil {
+		return nil, err
 	}
 
-	// Если в кеше нет, получаем из БД
-	query := `SELECT id, title, completed FROM tasks`
+	return tasks, nil
+}
+
+// queryTasks функция, которая получает все задачи из БД
+// @param ctx context.Context - контекст выполнения
+// @return []*models.Task - список задач
+// @return error - ошибка
+func (r *TaskRepository) queryTasks(ctx context.Context) ([]*models.Task, error) {
+	var tasks []*models.Task
+
+	query := `SELECT id, title, completed, version, due_at FROM tasks`
 	rows, err := r.pool.Query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query tasks: %w", err)
@@ -80,17 +130,12 @@ func (r *TaskRepository) GetTasks(ctx context.Context) ([]*models.Task, error) {
 
 	for rows.Next() {
 		task := &models.Task{}
-		if err := rows.Scan(&task.ID, &task.Title, &task.Completed); err != nil {
+		if err := rows.Scan(&task.ID, &task.Title, &task.Completed, &task.Version, &task.DueAt); err != nil {
 			return nil, fmt.Errorf("failed to scan task: %w", err)
 		}
 		tasks = append(tasks, task)
 	}
 
-	// Сохраняем в кеш
-	if err := r.cache.Set(ctx, tasksCacheKey, tasks, cacheDuration); err != nil {
-		r.logger.Warn("failed to cache tasks", zap.Error(err))
-	}
-
 	return tasks, nil
 }
 
@@ -100,81 +145,426 @@ func (r *TaskRepository) GetTasks(ctx context.Context) ([]*models.Task, error) {
 // @return *models.Task - задача
 // @return error - ошибка
 func (r *TaskRepository) GetTaskByID(ctx context.Context, id int) (*models.Task, error) {
+	r.trackAccess(taskCacheKey(id))
 	task := &models.Task{}
-	cacheKey := fmt.Sprintf("%s%d", taskCacheKeyPrefix, id)
 
-	// Пробуем получить из кеша
-	err := r.cache.Get(ctx, cacheKey, task)
-	if err == nil {
-		r.logger.Debug("task retrieved from cache", zap.Int("id", id))
-		return task, nil
+	// Отсутствие задачи кешируется ненадолго, отметка удаляется по тегу задачи при ее создании
+	err := r.loader.Fetch(ctx, taskCacheKey(id), task, cacheDuration, func(ctx context.Context) (interface{}, error) {
+		task, err := r.queryTask(ctx, id)
+		if errors.Is(err, models.ErrTaskNotFound) {
+			return nil, cache.ErrAbsent
+		}
+		return task, err
+	}, taskCacheTag(id))
+	if errors.Is(err, cache.ErrAbsent) {
+		return nil, models.ErrTaskNotFound
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// queryTask функция, которая получает задачу по id из БД
+// @param ctx context.Context - контекст выполнения
+// @param id int - id задачи
+// @return *models.Task - задача
+// @return error - ошибка, models.ErrTaskNotFound если задача не найдена
+func (r *TaskRepository) queryTask(ctx context.Context, id int) (*models.Task, error) {
+	task := &models.Task{}
 
-	// Если в кеше нет, получаем из БД
-	query := `SELECT id, title, completed FROM tasks WHERE id = $1`
-	err = r.pool.QueryRow(ctx, query, id).Scan(&task.ID, &task.Title, &task.Completed)
+	query := `SELECT id, title, completed, version, due_at FROM tasks WHERE id = $1`
+	err := r.pool.QueryRow(ctx, query, id).Scan(&task.ID, &task.Title, &task.Completed, &task.Version, &task.DueAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, models.ErrTaskNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get task: %w", err)
 	}
 
-	// Сохраняем в кеш
-	if err := r.cache.Set(ctx, cacheKey, task, cacheDuration); err != nil {
-		r.logger.Warn("failed to cache task", zap.Error(err))
+	return task, nil
+}
+
+// GetTasksByIDs функция, которая возвращает задачи по списку id одним запросом к базе данных
+// Задачи, найденные в кеше или отмеченные в нем как отсутствующие, в запрос не попадают.
+// Отсутствующие задачи в результат не включаются
+// @param ctx context.Context - контекст выполнения
+// @param ids []int - id задач
+// @return []*models.Task - найденные задачи
+// @return error - ошибка
+func (r *TaskRepository) GetTasksByIDs(ctx context.Context, ids []int) ([]*models.Task, error) {
+	for _, id := range ids {
+		r.trackAccess(taskCacheKey(id))
+	}
+	return r.getTasksByIDs(ctx, ids)
+}
+
+// getTasksByIDs функция, которая возвращает задачи по списку id, не отмечая обращения в журнале
+// @param ctx context.Context - контекст выполнения
+// @param ids []int - id задач
+// @return []*models.Task - найденные задачи
+// @return error - ошибка
+func (r *TaskRepository) getTasksByIDs(ctx context.Context, ids []int) ([]*models.Task, error) {
+	tasks := make([]*models.Task, 0, len(ids))
+	missing := make([]int, 0, len(ids))
+
+	// Пробуем получить из кеша
+	for _, id := range ids {
+		task := &models.Task{}
+		err := r.loader.Get(ctx, taskCacheKey(id), task)
+		if err == nil {
+			tasks = append(tasks, task)
+			continue
+		}
+		if errors.Is(err, cache.ErrAbsent) {
+			continue
+		}
+		r.logCacheError("failed to read task from cache", err, zap.Int("id", id))
+		missing = append(missing, id)
+	}
+	if len(missing) == 0 {
+		return tasks, nil
 	}
 
-	return task, nil
+	// Остальные задачи получаем из БД одним запросом
+	query := `SELECT id, title, completed, version, due_at FROM tasks WHERE id = ANY($1)`
+	rows, err := r.pool.Query(ctx, query, missing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tasks: %w", err)
+	}
+	defer rows.Close()
+
+	found := make(map[int]struct{}, len(missing))
+	for rows.Next() {
+		task := &models.Task{}
+		if err := rows.Scan(&task.ID, &task.Title, &task.Completed, &task.Version, &task.DueAt); err != nil {
+			return nil, fmt.Errorf("failed to scan task: %w", err)
+		}
+		tasks = append(tasks, task)
+		found[task.ID] = struct{}{}
+
+		// Сохраняем в кеш
+		if err := r.loader.Set(ctx, taskCacheKey(task.ID), task, cacheDuration, taskCacheTag(task.ID)); err != nil {
+			r.logCacheError("failed to cache task", err, zap.Int("id", task.ID))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query tasks: %w", err)
+	}
+
+	// Отмечаем отсутствующие задачи, чтобы повторные запросы тех же id не доходили до БД
+	for _, id := range missing {
+		if _, ok := found[id]; ok {
+			continue
+		}
+		if err := r.loader.SetAbsent(ctx, taskCacheKey(id), taskCacheTag(id)); err != nil {
+			r.logCacheError("failed to cache absent task", err, zap.Int("id", id))
+		}
+	}
+
+	return tasks, nil
 }
 
 // UpdateTask функция, которая обновляет задачу
+// Обновление выполняется только если версия задачи в базе совпадает с task.Version,
+// после успешного обновления task.Version содержит новую версию
 // @param ctx context.Context - контекст выполнения
 // @param task *models.Task - задача
 // @return error - ошибка
 func (r *TaskRepository) UpdateTask(ctx context.Context, task *models.Task) error {
-	query := `UPDATE tasks SET title = $1, completed = $2 WHERE id = $3`
-	result, err := r.pool.Exec(ctx, query, task.Title, task.Completed, task.ID)
+	updated, err := patchTask(ctx, r.pool, task.ID, task.Version, &task.Title, &task.Completed)
+
+	// Закешированная копия могла устареть, поэтому при несовпадении версии удаляем её в любом случае
+	if err == nil || isPreconditionError(err) {
+		r.invalidateTasks(ctx, task.ID)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to update task: %w", err)
+		return err
 	}
 
-	if result.RowsAffected() == 0 {
-		return fmt.Errorf("task not found")
+	task.Version = updated.Version
+
+	return nil
+}
+
+// DeleteTask функция, которая удаляет задачу
+// Удаление выполняется только если версия задачи в базе совпадает с version
+// @param ctx context.Context - контекст выполнения
+// @param id int - id задачи
+// @param version int - ожидаемая версия задачи
+// @return error - ошибка
+func (r *TaskRepository) DeleteTask(ctx context.Context, id int, version int) error {
+	err := deleteTask(ctx, r.pool, id, version)
+
+	if err == nil || isPreconditionError(err) {
+		r.invalidateTasks(ctx, id)
 	}
 
-	// Инвалидируем кеши
-	cacheKey := fmt.Sprintf("%s%d", taskCacheKeyPrefix, task.ID)
-	if err := r.cache.Delete(ctx, cacheKey); err != nil {
-		r.logger.Warn("failed to invalidate task cache", zap.Error(err))
+	return err
+}
+
+// ApplyBatch функция, которая выполняет пакет операций в одной транзакции
+// В режиме all_or_nothing первая ошибка откатывает всю транзакцию, а остальные операции получают models.ErrBatchAborted.
+// В режиме best_effort каждая операция выполняется в своей точке сохранения и её ошибка не влияет на остальные.
+// @param ctx context.Context - контекст выполнения
+// @param ops []models.BatchOperation - операции
+// @param mode models.BatchMode - режим выполнения
+// @return []models.BatchResult - результаты операций в том же порядке
+// @return error - ошибка работы с транзакцией
+func (r *TaskRepository) ApplyBatch(ctx context.Context, ops []models.BatchOperation, mode models.BatchMode) ([]models.BatchResult, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	if err := r.cache.Delete(ctx, tasksCacheKey); err != nil {
-		r.logger.Warn("failed to invalidate tasks cache", zap.Error(err))
+	defer tx.Rollback(ctx)
+
+	results := make([]models.BatchResult, len(ops))
+	// touched - id задач, закешированные копии которых нужно удалить после фиксации транзакции
+	touched := make([]int, 0, len(ops))
+	changed, failed := false, false
+
+	for i, op := range ops {
+		if failed {
+			results[i].Err = models.ErrBatchAborted
+			continue
+		}
+
+		if mode == models.BatchAllOrNothing {
+			results[i].Task, results[i].Err = applyOperation(ctx, tx, op)
+		} else {
+			results[i].Task, results[i].Err = applySavepoint(ctx, tx, op)
+		}
+
+		if results[i].Err != nil {
+			failed = mode == models.BatchAllOrNothing
+			continue
+		}
+
+		changed = true
+		if op.Op == models.BatchCreate {
+			// id новой задачи могли запрашивать до создания, и в кеше осталась отметка об ее отсутствии
+			touched = append(touched, results[i].Task.ID)
+		} else {
+			touched = append(touched, op.ID)
+		}
+	}
+
+	if failed {
+		// Успешные операции откатываются вместе с транзакцией
+		for i := range results {
+			if results[i].Err == nil {
+				results[i] = models.BatchResult{Err: models.ErrBatchAborted}
+			}
+		}
+		return results, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// Инвалидируем кеш один раз для всего пакета, а не после каждой операции
+	if changed {
+		r.invalidateTasks(ctx, touched...)
+	}
+
+	return results, nil
+}
+
+// applySavepoint функция, которая выполняет операцию внутри точки сохранения
+// @param ctx context.Context - контекст выполнения
+// @param tx pgx.Tx - транзакция
+// @param op models.BatchOperation - операция
+// @return *models.Task - задача после выполнения операции
+// @return error - ошибка
+func applySavepoint(ctx context.Context, tx pgx.Tx, op models.BatchOperation) (*models.Task, error) {
+	// Вложенная транзакция в pgx создает SAVEPOINT
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	task, err := applyOperation(ctx, sp, op)
+	if err != nil {
+		if rbErr := sp.Rollback(ctx); rbErr != nil {
+			return nil, fmt.Errorf("failed to rollback savepoint: %w", rbErr)
+		}
+		return nil, err
+	}
+
+	if err := sp.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to release savepoint: %w", err)
+	}
+
+	return task, nil
+}
+
+// applyOperation функция, которая выполняет одну операцию пакета
+// @param ctx context.Context - контекст выполнения
+// @param q querier - пул соединений или транзакция
+// @param op models.BatchOperation - операция
+// @return *models.Task - задача после выполнения операции
+// @return error - ошибка
+func applyOperation(ctx context.Context, q querier, op models.BatchOperation) (*models.Task, error) {
+	switch op.Op {
+	case models.BatchCreate:
+		task := &models.Task{Title: *op.Title}
+		if op.Completed != nil {
+			task.Completed = *op.Completed
+		}
+		if err := insertTask(ctx, q, task); err != nil {
+			return nil, err
+		}
+		return task, nil
+	case models.BatchUpdate:
+		return patchTask(ctx, q, op.ID, op.Version, op.Title, op.Completed)
+	case models.BatchComplete:
+		completed := true
+		return patchTask(ctx, q, op.ID, op.Version, nil, &completed)
+	case models.BatchDelete:
+		return nil, deleteTask(ctx, q, op.ID, op.Version)
+	default:
+		return nil, fmt.Errorf("unknown operation %q", op.Op)
+	}
+}
+
+// insertTask функция, которая добавляет задачу в базу данных
+// @param ctx context.Context - контекст выполнения
+// @param q querier - пул соединений или транзакция
+// @param task *models.Task - задача, в которую записываются id и версия
+// @return error - ошибка
+func insertTask(ctx context.Context, q querier, task *models.Task) error {
+	query := `INSERT INTO tasks (title, completed, due_at) VALUES ($1, $2, $3) RETURNING id, version`
+	err := q.QueryRow(ctx, query, task.Title, task.Completed, task.DueAt).Scan(&task.ID, &task.Version)
+	if err != nil {
+		return fmt.Errorf("failed to create task: %w", err)
 	}
 
 	return nil
 }
 
-// DeleteTask функция, которая удаляет задачу
+// patchTask функция, которая изменяет переданные поля задачи если её версия совпадает с ожидаемой
 // @param ctx context.Context - контекст выполнения
+// @param q querier - пул соединений или транзакция
 // @param id int - id задачи
+// @param version int - ожидаемая версия задачи
+// @param title *string - новое название или nil
+// @param completed *bool - новый статус или nil
+// @return *models.Task - обновленная задача
 // @return error - ошибка
-func (r *TaskRepository) DeleteTask(ctx context.Context, id int) error {
-	query := `DELETE FROM tasks WHERE id = $1`
-	result, err := r.pool.Exec(ctx, query, id)
+func patchTask(ctx context.Context, q querier, id, version int, title *string, completed *bool) (*models.Task, error) {
+	query := `UPDATE tasks SET title = COALESCE($1, title), completed = COALESCE($2, completed), version = version + 1
+		WHERE id = $3 AND version = $4 RETURNING id, title, completed, version, due_at`
+
+	task := &models.Task{}
+	err := q.QueryRow(ctx, query, title, completed, id, version).Scan(&task.ID, &task.Title, &task.Completed, &task.Version, &task.DueAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, preconditionError(ctx, q, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update task: %w", err)
+	}
+
+	return task, nil
+}
+
+// deleteTask функция, которая удаляет задачу если её версия совпадает с ожидаемой
+// @param ctx context.Context - контекст выполнения
+// @param q querier - пул соединений или транзакция
+// @param id int - id задачи
+// @param version int - ожидаемая версия задачи
+// @return error - ошибка
+func deleteTask(ctx context.Context, q querier, id, version int) error {
+	query := `DELETE FROM tasks WHERE id = $1 AND version = $2`
+	result, err := q.Exec(ctx, query, id, version)
 	if err != nil {
 		return fmt.Errorf("failed to delete task: %w", err)
 	}
 
 	if result.RowsAffected() == 0 {
-		return fmt.Errorf("task not found")
+		return preconditionError(ctx, q, id)
 	}
 
-	// Инвалидируем кеши
-	cacheKey := fmt.Sprintf("%s%d", taskCacheKeyPrefix, id)
-	if err := r.cache.Delete(ctx, cacheKey); err != nil {
-		r.logger.Warn("failed to invalidate task cache", zap.Error(err))
+	return nil
+}
+
+// preconditionError функция, которая определяет почему условное изменение не затронуло ни одной строки
+// @param ctx context.Context - контекст выполнения
+// @param q querier - пул соединений или транзакция
+// @param id int - id задачи
+// @return error - models.ErrTaskNotFound если задачи нет, иначе models.ErrVersionConflict
+func preconditionError(ctx context.Context, q querier, id int) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1)`
+	if err := q.QueryRow(ctx, query, id).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check task existence: %w", err)
 	}
-	if err := r.cache.Delete(ctx, tasksCacheKey); err != nil {
-		r.logger.Warn("failed to invalidate tasks cache", zap.Error(err))
+
+	if !exists {
+		return models.ErrTaskNotFound
 	}
 
-	return nil
+	return models.ErrVersionConflict
+}
+
+// isPreconditionError функция, которая проверяет что ошибка вызвана отсутствием задачи или несовпадением версии
+// @param err error - ошибка
+// @return bool - true, если закешированная копия задачи могла устареть
+func isPreconditionError(err error) bool {
+	return errors.Is(err, models.ErrTaskNotFound) || errors.Is(err, models.ErrVersionConflict)
+}
+
+// invalidateTasks функция, которая удаляет из кеша записи, зависящие от переданных задач, и записи,
+// зависящие от всех задач
+// @param ctx context.Context - контекст выполнения
+// @param ids ...int - id задач
+func (r *TaskRepository) invalidateTasks(ctx context.Context, ids ...int) {
+	tags := make([]string, 0, len(ids)+1)
+	for _, id := range ids {
+		tags = append(tags, taskCacheTag(id))
+	}
+	tags = append(tags, tasksCacheTag)
+
+	if err := r.cache.InvalidateTags(ctx, tags...); err != nil {
+		r.logger.Warn("failed to invalidate tasks cache", zap.Ints("ids", ids), zap.Error(err))
+	}
+}
+
+// logCacheError функция, которая логирует ошибку чтения или записи кеша
+// Промах не логируется, а обращения к кешу, отключенному после ошибок Redis, логируются только на уровне debug
+// @param msg string - сообщение
+// @param err error - ошибка кеша
+// @param fields ...zap.Field - дополнительные поля
+func (r *TaskRepository) logCacheError(msg string, err error, fields ...zap.Field) {
+	fields = append(fields, zap.Error(err))
+	switch {
+	case errors.Is(err, cache.ErrMiss):
+	case errors.Is(err, cache.ErrUnavailable):
+		r.logger.Debug(msg, fields...)
+	default:
+		r.logger.Warn(msg, fields...)
+	}
+}
+
+// trackAccess функция, которая отмечает обращение к ключу кеша в журнале для прогрева
+// @param key string - ключ
+func (r *TaskRepository) trackAccess(key string) {
+	if r.tracker != nil {
+		r.tracker.TrackAccess(key)
+	}
+}
+
+// taskCacheTag функция, которая возвращает тег записей кеша, зависящих от задачи
+// @param id int - id задачи
+// @return string - тег
+func taskCacheTag(id int) string {
+	return fmt.Sprintf("task:%d", id)
+}
+
+// taskCacheKey функция, которая возвращает ключ кеша для задачи
+// @param id int - id задачи
+// @return string - ключ кеша
+func taskCacheKey(id int) string {
+	return fmt.Sprintf("%s%d", taskCacheKeyPrefix, id)
 }
@@ -0,0 +1,202 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pers0na2dev/todo-api/internal/models"
+)
+
+// exportFetchSize - количество строк, которые экспорт получает из курсора за один запрос
+const exportFetchSize = 500
+
+// ExportTasks функция, которая передает все задачи в fn по одной, не загружая весь список в память
+// Задачи читаются порциями из серверного курсора внутри транзакции только для чтения,
+// поэтому экспорт видит один снимок данных даже если задачи изменяются во время выгрузки
+// @param ctx context.Context - контекст выполнения
+// @param fn func(*models.TaskRecord) error - обработчик задачи, ошибка обработчика прерывает экспорт
+// @return error - ошибка
+func (r *TaskRepository) ExportTasks(ctx context.Context, fn func(*models.TaskRecord) error) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `DECLARE tasks_export NO SCROLL CURSOR FOR
+		SELECT id, COALESCE(external_id, ''), title, completed, version, due_at FROM tasks ORDER BY id`
+	if _, err := tx.Exec(ctx, query); err != nil {
+		return fmt.Errorf("failed to declare export cursor: %w", err)
+	}
+
+	fetch := fmt.Sprintf(`FETCH %d FROM tasks_export`, exportFetchSize)
+	for {
+		fetched, err := fetchRecords(ctx, tx, fetch, fn)
+		if err != nil {
+			return err
+		}
+		if fetched < exportFetchSize {
+			break
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// fetchRecords функция, которая получает очередную порцию задач из курсора и передает их в fn
+// @param ctx context.Context - контекст выполнения
+// @param tx pgx.Tx - транзакция, в которой объявлен курсор
+// @param fetch string - запрос FETCH
+// @param fn func(*models.TaskRecord) error - обработчик задачи
+// @return int - количество полученных задач
+// @return error - ошибка
+func fetchRecords(ctx context.Context, tx pgx.Tx, fetch string, fn func(*models.TaskRecord) error) (int, error) {
+	rows, err := tx.Query(ctx, fetch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch tasks: %w", err)
+	}
+	defer rows.Close()
+
+	fetched := 0
+	for rows.Next() {
+		record := &models.TaskRecord{}
+		if err := rows.Scan(&record.ID, &record.ExternalID, &record.Title, &record.Completed, &record.Version, &record.DueAt); err != nil {
+			return 0, fmt.Errorf("failed to scan task: %w", err)
+		}
+		if err := fn(record); err != nil {
+			return 0, err
+		}
+		fetched++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to fetch tasks: %w", err)
+	}
+
+	return fetched, nil
+}
+
+// ImportTasks функция, которая создает задачи и обновляет задачи с совпадающим внешним id в одной транзакции
+// Записи без внешнего id всегда создают новые задачи. В режиме dryRun транзакция откатывается,
+// поэтому результаты показывают что изменится, но база данных и кеш остаются прежними
+// @param ctx context.Context - контекст выполнения
+// @param records []models.TaskRecord - проверенные записи импорта
+// @param dryRun bool - true, если изменения не нужно сохранять
+// @return []models.ImportResult - результаты в том же порядке
+// @return error - ошибка
+func (r *TaskRepository) ImportTasks(ctx context.Context, records []models.TaskRecord, dryRun bool) ([]models.ImportResult, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// xmax = 0 только у вставленных строк, у строк, обновленных через ON CONFLICT, xmax содержит id транзакции
+	query := `INSERT INTO tasks (external_id, title, completed, due_at) VALUES (NULLIF($1, ''), $2, $3, $4)
+		ON CONFLICT (external_id) DO UPDATE
+		SET title = EXCLUDED.title, completed = EXCLUDED.completed, due_at = EXCLUDED.due_at, version = tasks.version + 1
+		RETURNING id, title, completed, version, due_at, xmax = 0`
+
+	batch := &pgx.Batch{}
+	for _, record := range records {
+		batch.Queue(query, record.ExternalID, record.Title, record.Completed, record.DueAt)
+	}
+
+	results := make([]models.ImportResult, len(records))
+	// touched - id задач, записи кеша которых нужно удалить после фиксации транзакции: копии обновленных
+	// задач и отметки об отсутствии созданных
+	touched := make([]int, 0, len(records))
+
+	br := tx.SendBatch(ctx, batch)
+	for i := range records {
+		task := &models.Task{}
+		err := br.QueryRow().Scan(&task.ID, &task.Title, &task.Completed, &task.Version, &task.DueAt, &results[i].Created)
+		if err != nil {
+			br.Close()
+			return nil, fmt.Errorf("failed to import task %d: %w", i, err)
+		}
+		results[i].Task = task
+		touched = append(touched, task.ID)
+	}
+	if err := br.Close(); err != nil {
+		return nil, fmt.Errorf("failed to import tasks: %w", err)
+	}
+
+	if dryRun {
+		return results, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if len(records) > 0 {
+		r.invalidateTasks(ctx, touched...)
+	}
+
+	return results, nil
+}
+
+// SyncTasks функция, которая в одной транзакции создает задачи без id и изменяет задачи с id
+// Задача изменяется, только если ее версия совпадает с record.Version. Если хотя бы одна задача
+// изменена или удалена с момента чтения, транзакция откатывается
+// @param ctx context.Context - контекст выполнения
+// @param records []models.TaskRecord - проверенные задачи
+// @return []models.ImportResult - результаты в том же порядке
+// @return error - ошибка, models.ErrVersionConflict если версия одной из задач не совпадает
+func (r *TaskRepository) SyncTasks(ctx context.Context, records []models.TaskRecord) ([]models.ImportResult, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	insert := `INSERT INTO tasks (title, completed, due_at) VALUES ($1, $2, $3)
+		RETURNING id, title, completed, version, due_at`
+	update := `UPDATE tasks SET title = $3, completed = $4, due_at = $5, version = version + 1
+		WHERE id = $1 AND version = $2
+		RETURNING id, title, completed, version, due_at`
+
+	batch := &pgx.Batch{}
+	for _, record := range records {
+		if record.ID > 0 {
+			batch.Queue(update, record.ID, record.Version, record.Title, record.Completed, record.DueAt)
+		} else {
+			batch.Queue(insert, record.Title, record.Completed, record.DueAt)
+		}
+	}
+
+	results := make([]models.ImportResult, len(records))
+	touched := make([]int, 0, len(records))
+
+	br := tx.SendBatch(ctx, batch)
+	for i, record := range records {
+		task := &models.Task{}
+		err := br.QueryRow().Scan(&task.ID, &task.Title, &task.Completed, &task.Version, &task.DueAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			br.Close()
+			r.invalidateTasks(ctx, record.ID)
+			return nil, fmt.Errorf("%w: task %d was changed or deleted", models.ErrVersionConflict, record.ID)
+		}
+		if err != nil {
+			br.Close()
+			return nil, fmt.Errorf("failed to sync task %d: %w", i, err)
+		}
+		results[i] = models.ImportResult{Task: task, Created: record.ID == 0}
+		touched = append(touched, task.ID)
+	}
+	if err := br.Close(); err != nil {
+		return nil, fmt.Errorf("failed to sync tasks: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if len(records) > 0 {
+		r.invalidateTasks(ctx, touched...)
+	}
+
+	return results, nil
+}
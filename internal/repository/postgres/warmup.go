@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// warmupBatch - количество задач, которые прогрев загружает одним запросом к БД
+const warmupBatch = 100
+
+// WarmCache функция, которая загружает в кеш задачи и списки из журнала недавно прочитанных ключей
+// Ключи загружаются начиная с прочитанных последними, поэтому если время прогрева истекло, в кеше уже
+// самые нужные из них. Ключи, которые уже есть в кеше, из БД не загружаются, а загрузка не отмечается в журнале
+// @param ctx context.Context - контекст выполнения, ограничивает время прогрева
+// @param limit int - максимальное количество ключей из журнала
+// @return int - количество прогретых ключей
+// @return error - ошибка или errors.ErrUnsupported, если кеш не ведет журнал обращений
+func (r *TaskRepository) WarmCache(ctx context.Context, limit int) (int, error) {
+	if r.tracker == nil {
+		return 0, errors.ErrUnsupported
+	}
+	keys, err := r.tracker.RecentKeys(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	warmed := 0
+	ids := make([]int, 0, warmupBatch)
+	warmTasks := func() error {
+		if len(ids) == 0 {
+			return nil
+		}
+		if _, err := r.getTasksByIDs(ctx, ids); err != nil {
+			return err
+		}
+		warmed += len(ids)
+		ids = ids[:0]
+		return nil
+	}
+
+	for _, key := range keys {
+		switch {
+		case key == tasksCacheKey:
+			if _, err := r.getTasks(ctx); err != nil {
+				return warmed, err
+			}
+			warmed++
+		case strings.HasPrefix(key, taskCacheKeyPrefix):
+			id, err := strconv.Atoi(strings.TrimPrefix(key, taskCacheKeyPrefix))
+			if err != nil {
+				continue
+			}
+			ids = append(ids, id)
+			if len(ids) == warmupBatch {
+				if err := warmTasks(); err != nil {
+					return warmed, err
+				}
+			}
+		}
+	}
+	if err := warmTasks(); err != nil {
+		return warmed, err
+	}
+
+	return warmed, nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"go.uber.org/zap"
+)
+
+// calendarTokenBytes - количество случайных байт в токене ленты календаря
+const calendarTokenBytes = 32
+
+// CalendarTokenRepository интерфейс, который содержит методы для хранения токенов лент календаря
+type CalendarTokenRepository interface {
+	CreateToken(ctx context.Context, name string, hash []byte) (*models.CalendarToken, error)
+	ListTokens(ctx context.Context) ([]*models.CalendarToken, error)
+	FindToken(ctx context.Context, hash []byte) (*models.CalendarToken, error)
+	DeleteToken(ctx context.Context, id int) error
+}
+
+// CalendarService структура, которая выдает и проверяет токены лент календаря
+// Лента доступна по ссылке с токеном без заголовков авторизации, так как календари умеют подписываться только по URL
+type CalendarService struct {
+	repo   CalendarTokenRepository
+	logger *zap.Logger
+}
+
+// NewCalendarService функция, которая создает новый экземпляр CalendarService
+// @param repo CalendarTokenRepository - хранилище токенов
+// @param logger *zap.Logger - логгер
+// @return *CalendarService - новый экземпляр CalendarService
+func NewCalendarService(repo CalendarTokenRepository, logger *zap.Logger) *CalendarService {
+	return &CalendarService{repo: repo, logger: logger}
+}
+
+// CreateToken функция, которая выдает новый токен ленты календаря
+// Токен возвращается только один раз, в базе данных сохраняется его хеш
+// @param ctx context.Context - контекст выполнения
+// @param name string - название токена
+// @return *models.CalendarToken - созданный токен
+// @return string - токен для ссылки на ленту
+// @return error - ошибка
+func (s *CalendarService) CreateToken(ctx context.Context, name string) (*models.CalendarToken, string, error) {
+	raw := make([]byte, calendarTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", fmt.Errorf("failed to generate calendar token: %w", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(raw)
+
+	token, err := s.repo.CreateToken(ctx, name, hashCalendarToken(secret))
+	if err != nil {
+		s.logger.Error("failed to create calendar token", zap.Error(err))
+		return nil, "", err
+	}
+
+	s.logger.Info("calendar token created", zap.Int("id", token.ID))
+	return token, secret, nil
+}
+
+// ListTokens функция, которая возвращает выданные токены без их значений
+// @param ctx context.Context - контекст выполнения
+// @return []*models.CalendarToken - токены
+// @return error - ошибка
+func (s *CalendarService) ListTokens(ctx context.Context) ([]*models.CalendarToken, error) {
+	return s.repo.ListTokens(ctx)
+}
+
+// RevokeToken функция, которая отзывает токен, после чего лента по нему перестает открываться
+// @param ctx context.Context - контекст выполнения
+// @param id int - id токена
+// @return error - ошибка, models.ErrCalendarTokenNotFound если токена нет
+func (s *CalendarService) RevokeToken(ctx context.Context, id int) error {
+	if err := s.repo.DeleteToken(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.Info("calendar token revoked", zap.Int("id", id))
+	return nil
+}
+
+// VerifyToken функция, которая проверяет токен из ссылки на ленту
+// @param ctx context.Context - контекст выполнения
+// @param secret string - токен
+// @return *models.CalendarToken - токен
+// @return error - ошибка, models.ErrCalendarTokenNotFound если токен не выдавался или отозван
+func (s *CalendarService) VerifyToken(ctx context.Context, secret string) (*models.CalendarToken, error) {
+	// Строки другой длины не могут быть выданным токеном, поэтому проверяются без обращения к базе данных
+	if len(secret) != base64.RawURLEncoding.EncodedLen(calendarTokenBytes) {
+		return nil, models.ErrCalendarTokenNotFound
+	}
+
+	return s.repo.FindToken(ctx, hashCalendarToken(secret))
+}
+
+// hashCalendarToken функция, которая возвращает хеш токена для хранения в базе данных
+// Токен содержит 256 случайных бит, поэтому соль и медленное хеширование не нужны
+// @param secret string - токен
+// @return []byte - SHA-256 токена
+func hashCalendarToken(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
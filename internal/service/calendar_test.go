@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/pers0na2dev/todo-api/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// TestCalendarTokens тестирует выдачу и проверку токенов лент календаря
+func TestCalendarTokens(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	mockRepo := new(mocks.CalendarTokenRepository)
+	service := NewCalendarService(mockRepo, logger)
+	ctx := context.Background()
+
+	t.Run("В базе данных сохраняется хеш выданного токена", func(t *testing.T) {
+		// Запоминаем хеш, переданный в репозиторий
+		var stored []byte
+		mockRepo.On("CreateToken", ctx, "Телефон", mock.Anything).Run(func(args mock.Arguments) {
+			stored = args.Get(2).([]byte)
+		}).Return(&models.CalendarToken{ID: 1, Name: "Телефон"}, nil).Once()
+
+		// Вызываем тестируемый метод
+		token, secret, err := service.CreateToken(ctx, "Телефон")
+
+		// Проверяем результаты
+		assert.NoError(t, err)
+		assert.Equal(t, 1, token.ID)
+		assert.Len(t, secret, 43)
+		sum := sha256.Sum256([]byte(secret))
+		assert.Equal(t, sum[:], stored)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Токен проверяется по хешу", func(t *testing.T) {
+		// Подготавливаем тестовые данные
+		secret := "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+		sum := sha256.Sum256([]byte(secret))
+		expected := &models.CalendarToken{ID: 2}
+
+		// Настраиваем ожидаемое поведение мока
+		mockRepo.On("FindToken", ctx, sum[:]).Return(expected, nil).Once()
+
+		// Вызываем тестируемый метод
+		token, err := service.VerifyToken(ctx, secret)
+
+		// Проверяем результаты
+		assert.NoError(t, err)
+		assert.Equal(t, expected, token)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Строка другой длины отклоняется без обращения к базе данных", func(t *testing.T) {
+		// Вызываем тестируемый метод
+		token, err := service.VerifyToken(ctx, "short")
+
+		// Проверяем результаты
+		assert.ErrorIs(t, err, models.ErrCalendarTokenNotFound)
+		assert.Nil(t, token)
+		// FindToken вызывался только в предыдущей проверке
+		mockRepo.AssertNumberOfCalls(t, "FindToken", 1)
+	})
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/pers0na2dev/todo-api/pkg/cache"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	// subscriberBuffer - размер буфера канала подписчика, при переполнении подписчик отключается
+	subscriberBuffer = 64
+	// taskEventsChannel - канал Redis, через который события передаются подписчикам всех реплик
+	taskEventsChannel = "tasks:events"
+)
+
+// EventBroker структура, которая рассылает события об изменении задач подписчикам всех реплик
+// Если кеш реализует cache.PubSub, события передаются через канал Redis, иначе только подписчикам внутри процесса.
+// Подписчик, который не успевает читать события, отключается: его канал закрывается, чтобы клиент перечитал
+// задачи, а не пропускал события молча
+type EventBroker struct {
+	// pubsub - каналы Redis, nil если события рассылаются только внутри процесса
+	pubsub cache.PubSub
+	logger *zap.Logger
+
+	mu          sync.Mutex
+	subscribers map[chan models.TaskEvent]struct{}
+}
+
+// NewEventBroker функция, которая создает новый экземпляр EventBroker и подписывается на события других реплик
+// Подписка закрывается при остановке приложения
+// @param lc fx.Lifecycle - жизненный цикл приложения
+// @param c cache.Cache - кеш, через каналы которого события передаются между репликами
+// @param logger *zap.Logger - логгер
+// @return *EventBroker - новый экземпляр EventBroker
+// @return error - ошибка подписки на канал Redis
+func NewEventBroker(lc fx.Lifecycle, c cache.Cache, logger *zap.Logger) (*EventBroker, error) {
+	b := &EventBroker{
+		subscribers: make(map[chan models.TaskEvent]struct{}),
+		logger:      logger,
+	}
+
+	pubsub, ok := c.(cache.PubSub)
+	if !ok {
+		logger.Info("task events are delivered within the replica only")
+		return b, nil
+	}
+	subscription, err := pubsub.Subscribe(context.Background(), taskEventsChannel, b.receive, b.resubscribed)
+	if errors.Is(err, errors.ErrUnsupported) {
+		logger.Info("task events are delivered within the replica only")
+		return b, nil
+	}
+	if err != nil {
+		logger.Error("failed to subscribe to task events", zap.Error(err))
+		return nil, err
+	}
+
+	b.pubsub = pubsub
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return subscription.Close()
+		},
+	})
+
+	return b, nil
+}
+
+// Subscribe функция, которая подписывает на события до завершения контекста
+// Канал закрывается после завершения контекста или раньше, если подписчик не успевает читать события
+// @param ctx context.Context - контекст подписки
+// @return <-chan models.TaskEvent - канал событий
+func (b *EventBroker) Subscribe(ctx context.Context) <-chan models.TaskEvent {
+	ch := make(chan models.TaskEvent, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		// Канал уже закрыт, если подписчик был отключен
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}()
+
+	return ch
+}
+
+// Publish функция, которая отправляет событие подписчикам всех реплик
+// Если отправить событие в Redis не удалось, оно доставляется хотя бы подписчикам текущей реплики
+// @param event models.TaskEvent - событие
+func (b *EventBroker) Publish(event models.TaskEvent) {
+	if b.pubsub == nil {
+		b.deliver(event)
+		return
+	}
+
+	message, err := json.Marshal(event)
+	if err == nil {
+		err = b.pubsub.Publish(context.Background(), taskEventsChannel, message)
+	}
+	if err != nil {
+		b.logger.Warn("failed to publish task event, delivering it within the replica only", zap.Error(err))
+		b.deliver(event)
+	}
+}
+
+// receive функция, которая доставляет подписчикам событие из канала Redis
+// @param message []byte - событие в JSON
+func (b *EventBroker) receive(message []byte) {
+	var event models.TaskEvent
+	if err := json.Unmarshal(message, &event); err != nil {
+		b.logger.Warn("skipping undecodable task event", zap.Error(err))
+		return
+	}
+	b.deliver(event)
+}
+
+// resubscribed функция, которая отключает всех подписчиков после переподключения к Redis,
+// так как события за время разрыва потеряны
+func (b *EventBroker) resubscribed() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+	b.logger.Warn("task events resubscribed, subscribers were disconnected")
+}
+
+// deliver функция, которая отправляет событие подписчикам текущей реплики
+// Медленные подписчики не блокируют отправку: если их буфер заполнен, они отключаются
+// @param event models.TaskEvent - событие
+func (b *EventBroker) deliver(event models.TaskEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+			b.logger.Warn("disconnecting slow task event subscriber", zap.String("type", string(event.Type)))
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/pers0na2dev/todo-api/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+// newRedisEventBroker создает EventBroker реплики, подключенной к общему Redis
+func newRedisEventBroker(t *testing.T, server *miniredis.Miniredis) *EventBroker {
+	t.Helper()
+
+	c, err := cache.NewRedisCache(cache.RedisOptions{DSN: "redis://" + server.Addr()}, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+
+	lc := fxtest.NewLifecycle(t)
+	broker, err := NewEventBroker(lc, c, zap.NewNop())
+	require.NoError(t, err)
+	lc.RequireStart()
+	t.Cleanup(lc.RequireStop)
+
+	return broker
+}
+
+// receiveEvent ждет следующее событие из канала подписчика
+func receiveEvent(t *testing.T, events <-chan models.TaskEvent) (models.TaskEvent, bool) {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		return event, ok
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not received")
+		return models.TaskEvent{}, false
+	}
+}
+
+// TestEventBroker тестирует рассылку событий об изменении задач
+func TestEventBroker(t *testing.T) {
+	event := models.TaskEvent{Type: models.TaskCreated, Task: &models.Task{ID: 1, Title: "Задача"}}
+
+	t.Run("Событие одной реплики получают подписчики другой", func(t *testing.T) {
+		// Подготавливаем две реплики с общим Redis
+		server := miniredis.RunT(t)
+		publisher, subscriber := newRedisEventBroker(t, server), newRedisEventBroker(t, server)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events := subscriber.Subscribe(ctx)
+		local := publisher.Subscribe(ctx)
+
+		// Вызываем тестируемый метод
+		publisher.Publish(event)
+
+		// Проверяем, что событие получили подписчики обеих реплик
+		for _, ch := range []<-chan models.TaskEvent{events, local} {
+			received, ok := receiveEvent(t, ch)
+			assert.True(t, ok)
+			assert.Equal(t, event, received)
+		}
+	})
+
+	t.Run("Без Redis событие доставляется подписчикам реплики", func(t *testing.T) {
+		// Подготавливаем реплику, Redis которой недоступен
+		server := miniredis.RunT(t)
+		broker := newRedisEventBroker(t, server)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events := broker.Subscribe(ctx)
+		server.Close()
+
+		// Вызываем тестируемый метод
+		broker.Publish(event)
+
+		// Проверяем результат
+		received, ok := receiveEvent(t, events)
+		assert.True(t, ok)
+		assert.Equal(t, event, received)
+	})
+
+	t.Run("Подписчик, который не успевает читать события, отключается", func(t *testing.T) {
+		// Подготавливаем рассылку внутри процесса
+		memory, err := cache.NewMemoryCache(cache.MemoryOptions{})
+		require.NoError(t, err)
+		broker, err := NewEventBroker(fxtest.NewLifecycle(t), memory, zap.NewNop())
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		slow := broker.Subscribe(ctx)
+
+		// Вызываем тестируемый метод: событий больше, чем помещается в буфер подписчика
+		for range subscriberBuffer + 1 {
+			broker.Publish(event)
+		}
+
+		// Проверяем, что подписчик получает события из буфера, после чего канал закрывается
+		for range subscriberBuffer {
+			_, ok := receiveEvent(t, slow)
+			require.True(t, ok)
+		}
+		_, ok := receiveEvent(t, slow)
+		assert.False(t, ok)
+
+		// Новые подписчики продолжают получать события
+		events := broker.Subscribe(ctx)
+		broker.Publish(event)
+		_, ok = receiveEvent(t, events)
+		assert.True(t, ok)
+	})
+
+	t.Run("После переподключения к Redis подписчики отключаются", func(t *testing.T) {
+		// Подготавливаем данные
+		broker := newRedisEventBroker(t, miniredis.RunT(t))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events := broker.Subscribe(ctx)
+
+		// Вызываем тестируемый метод
+		broker.resubscribed()
+
+		// Проверяем, что канал закрыт: события за время разрыва потеряны
+		_, ok := receiveEvent(t, events)
+		assert.False(t, ok)
+	})
+
+	t.Run("Канал закрывается после завершения контекста", func(t *testing.T) {
+		// Подготавливаем данные
+		broker := newRedisEventBroker(t, miniredis.RunT(t))
+		ctx, cancel := context.WithCancel(context.Background())
+		events := broker.Subscribe(ctx)
+
+		// Вызываем тестируемый метод
+		cancel()
+
+		// Проверяем результат
+		_, ok := receiveEvent(t, events)
+		assert.False(t, ok)
+	})
+}
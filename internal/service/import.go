@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/pers0na2dev/todo-api/pkg/cache"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	// importChunkSize - количество задач, которые импортируются одной транзакцией
+	importChunkSize = 500
+	// importJobCacheKeyPrefix - префикс ключей кеша с состоянием заданий
+	importJobCacheKeyPrefix = "import:"
+	// importJobTTL - время, в течение которого доступен статус задания после последнего изменения
+	importJobTTL = 24 * time.Hour
+	// maxImportWarnings - максимальное количество предупреждений в задании, остальные сводятся в одно
+	maxImportWarnings = 100
+)
+
+// TaskImporter интерфейс, который содержит метод для записи задач импорта
+type TaskImporter interface {
+	ImportTasks(ctx context.Context, records []models.TaskRecord, dryRun bool) ([]models.ImportResult, error)
+}
+
+// ImportService структура, которая выполняет импорт файлов других приложений в фоне
+// Задание выполняет реплика, которая его приняла, а его состояние после каждого изменения записывается в кеш,
+// поэтому прогресс можно запросить у любой реплики. Если реплика остановится аварийно, задание останется
+// в статусе running до истечения importJobTTL; импорт можно повторить тем же файлом, так как задачи
+// с совпадающим внешним id обновляются
+type ImportService struct {
+	tasks  TaskImporter
+	cache  cache.Cache
+	logger *zap.Logger
+
+	// mu и running - задания, которые выполняются в этой реплике
+	mu      sync.Mutex
+	running map[string]*models.ImportJob
+
+	// ctx - контекст фоновых заданий, отменяется при остановке приложения
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewImportService функция, которая создает новый экземпляр ImportService
+// При остановке приложения незавершенные задания прерываются и получают статус failed
+// @param lc fx.Lifecycle - жизненный цикл приложения
+// @param tasks TaskImporter - сервис задач
+// @param c cache.Cache - кеш, в котором хранится состояние заданий
+// @param logger *zap.Logger - логгер
+// @return *ImportService - новый экземпляр ImportService
+func NewImportService(lc fx.Lifecycle, tasks TaskImporter, c cache.Cache, logger *zap.Logger) *ImportService {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &ImportService{
+		tasks:   tasks,
+		cache:   c,
+		logger:  logger,
+		running: make(map[string]*models.ImportJob),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			s.cancel()
+			done := make(chan struct{})
+			go func() {
+				s.wg.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	return s
+}
+
+// StartImport функция, которая создает задание импорта и запускает его в фоне
+// @param ctx context.Context - контекст выполнения
+// @param source string - формат файла
+// @param records []models.TaskRecord - задачи из файла
+// @param warnings []string - предупреждения разбора файла
+// @return *models.ImportJob - созданное задание
+// @return error - ошибка
+func (s *ImportService) StartImport(ctx context.Context, source string, records []models.TaskRecord, warnings []string) (*models.ImportJob, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate import job id: %w", err)
+	}
+
+	job := &models.ImportJob{
+		ID:        hex.EncodeToString(raw),
+		Source:    source,
+		Status:    models.ImportPending,
+		Total:     len(records),
+		Warnings:  []string{},
+		CreatedAt: time.Now().UTC(),
+	}
+	for _, warning := range warnings {
+		addWarning(job, warning)
+	}
+
+	// Задание записывается в кеш до ответа клиенту, чтобы следующий запрос прогресса нашел его на любой реплике
+	snapshot := copyJob(job)
+	if err := s.cache.Set(ctx, importJobCacheKey(job.ID), snapshot, importJobTTL); err != nil {
+		return nil, fmt.Errorf("failed to store import job: %w", err)
+	}
+
+	s.mu.Lock()
+	s.running[job.ID] = job
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(job.ID, records)
+	}()
+
+	s.logger.Info("import started", zap.String("id", job.ID), zap.String("source", source), zap.Int("records", len(records)))
+	return snapshot, nil
+}
+
+// GetImport функция, которая возвращает состояние задания импорта
+// @param ctx context.Context - контекст выполнения
+// @param id string - id задания
+// @return *models.ImportJob - последнее записанное состояние задания
+// @return error - ошибка, models.ErrImportJobNotFound если задания нет
+func (s *ImportService) GetImport(ctx context.Context, id string) (*models.ImportJob, error) {
+	var job models.ImportJob
+	err := s.cache.Get(ctx, importJobCacheKey(id), &job)
+	switch {
+	case errors.Is(err, cache.ErrMiss), errors.Is(err, cache.ErrAbsent):
+		return nil, models.ErrImportJobNotFound
+	case err != nil:
+		return nil, fmt.Errorf("failed to read import job: %w", err)
+	}
+	return &job, nil
+}
+
+// run функция, которая импортирует задачи задания частями
+// Каждая часть записывается отдельной транзакцией, поэтому прогресс виден во время импорта,
+// а при ошибке уже записанные части остаются
+// @param id string - id задания
+// @param records []models.TaskRecord - задачи из файла
+func (s *ImportService) run(id string, records []models.TaskRecord) {
+	s.update(id, func(job *models.ImportJob) {
+		job.Status = models.ImportRunning
+	})
+
+	// Задачи, которые не проходят проверку, пропускаются, чтобы одна строка не отменяла импорт своей части
+	valid := make([]models.TaskRecord, 0, len(records))
+	var skipped []string
+	for i := range records {
+		if err := records[i].Validate(); err != nil {
+			skipped = append(skipped, fmt.Sprintf("task %q was skipped: %v", records[i].Title, err))
+			continue
+		}
+		valid = append(valid, records[i])
+	}
+	s.update(id, func(job *models.ImportJob) {
+		job.Skipped = len(skipped)
+		job.Processed = len(skipped)
+		for _, warning := range skipped {
+			addWarning(job, warning)
+		}
+	})
+
+	for start := 0; start < len(valid); start += importChunkSize {
+		chunk := valid[start:min(start+importChunkSize, len(valid))]
+
+		results, err := s.tasks.ImportTasks(s.ctx, chunk, false)
+		if err == nil {
+			err = errors.Join(resultErrors(results)...)
+		}
+		if err != nil {
+			if s.ctx.Err() != nil {
+				err = errors.New("import was interrupted by shutdown")
+			}
+			s.logger.Error("import failed", zap.String("id", id), zap.Error(err))
+			s.finish(id, models.ImportFailed, err.Error())
+			return
+		}
+
+		s.update(id, func(job *models.ImportJob) {
+			job.Processed += len(chunk)
+			for _, result := range results {
+				if result.Created {
+					job.Created++
+				} else {
+					job.Updated++
+				}
+			}
+		})
+	}
+
+	s.logger.Info("import finished", zap.String("id", id))
+	s.finish(id, models.ImportSucceeded, "")
+}
+
+// update функция, которая изменяет задание под блокировкой и записывает его состояние в кеш
+// Задание изменяет только горутина, которая его выполняет, поэтому записи в кеш не переупорядочиваются
+// @param id string - id задания
+// @param fn func(job *models.ImportJob) - изменение
+func (s *ImportService) update(id string, fn func(job *models.ImportJob)) {
+	s.mu.Lock()
+	job, ok := s.running[id]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	fn(job)
+	snapshot := copyJob(job)
+	s.mu.Unlock()
+
+	// Контекст заданий отменяется при остановке, а статус прерванного задания все равно нужно записать
+	if err := s.cache.Set(context.Background(), importJobCacheKey(id), snapshot, importJobTTL); err != nil {
+		s.logger.Warn("failed to store import job", zap.String("id", id), zap.Error(err))
+	}
+}
+
+// finish функция, которая завершает задание и перестает хранить его в памяти реплики
+// @param id string - id задания
+// @param status models.ImportJobStatus - итоговый статус
+// @param reason string - причина ошибки для статуса failed
+func (s *ImportService) finish(id string, status models.ImportJobStatus, reason string) {
+	now := time.Now().UTC()
+	s.update(id, func(job *models.ImportJob) {
+		job.Status = status
+		job.Error = reason
+		job.FinishedAt = &now
+	})
+
+	s.mu.Lock()
+	delete(s.running, id)
+	s.mu.Unlock()
+}
+
+// importJobCacheKey функция, которая возвращает ключ кеша задания
+// @param id string - id задания
+// @return string - ключ кеша
+func importJobCacheKey(id string) string {
+	return importJobCacheKeyPrefix + id
+}
+
+// resultErrors функция, которая собирает ошибки записей импорта
+// @param results []models.ImportResult - результаты импорта
+// @return []error - ошибки без models.ErrImportAborted, который лишь повторяет ошибки других записей
+func resultErrors(results []models.ImportResult) []error {
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil && !errors.Is(result.Err, models.ErrImportAborted) {
+			errs = append(errs, result.Err)
+		}
+	}
+	return errs
+}
+
+// addWarning функция, которая добавляет предупреждение в задание с учетом ограничения maxImportWarnings
+// @param job *models.ImportJob - задание
+// @param warning string - предупреждение
+func addWarning(job *models.ImportJob, warning string) {
+	switch n := len(job.Warnings); {
+	case n < maxImportWarnings:
+		job.Warnings = append(job.Warnings, warning)
+	case n == maxImportWarnings:
+		job.Warnings = append(job.Warnings, "further warnings were omitted")
+	}
+}
+
+// copyJob функция, которая копирует задание, чтобы его можно было записать в кеш вне блокировки
+// @param job *models.ImportJob - задание
+// @return *models.ImportJob - копия
+func copyJob(job *models.ImportJob) *models.ImportJob {
+	c := *job
+	c.Warnings = append([]string{}, job.Warnings...)
+	if job.FinishedAt != nil {
+		finishedAt := *job.FinishedAt
+		c.FinishedAt = &finishedAt
+	}
+	return &c
+}
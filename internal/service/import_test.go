@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/pers0na2dev/todo-api/internal/service/mocks"
+	"github.com/pers0na2dev/todo-api/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+// waitImport функция, которая ждет завершения задания импорта
+func waitImport(t *testing.T, service *ImportService, id string) *models.ImportJob {
+	t.Helper()
+	var job *models.ImportJob
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = service.GetImport(context.Background(), id)
+		require.NoError(t, err)
+		return job.Finished()
+	}, 5*time.Second, 5*time.Millisecond)
+	return job
+}
+
+// newImportCache функция, которая создает кеш для состояния заданий импорта
+func newImportCache(t *testing.T) cache.Cache {
+	t.Helper()
+	c, err := cache.NewMemoryCache(cache.MemoryOptions{})
+	require.NoError(t, err)
+	return c
+}
+
+// TestImportJobs тестирует фоновый импорт задач
+func TestImportJobs(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	ctx := context.Background()
+
+	t.Run("Задачи импортируются частями с подсчетом созданных и обновленных", func(t *testing.T) {
+		// Подготавливаем тестовые данные
+		mockTasks := new(mocks.TaskImporter)
+		service := NewImportService(fxtest.NewLifecycle(t), mockTasks, newImportCache(t), logger)
+		records := make([]models.TaskRecord, importChunkSize+2)
+		for i := range records {
+			records[i] = models.TaskRecord{ExternalID: fmt.Sprintf("trello:%d", i), Title: fmt.Sprintf("Задача %d", i)}
+		}
+		// Задача с пустым названием пропускается
+		records[1].Title = ""
+
+		// Настраиваем ожидаемое поведение мока
+		first := make([]models.ImportResult, importChunkSize)
+		for i := range first {
+			first[i].Created = i%2 == 0
+		}
+		mockTasks.On("ImportTasks", mock.Anything, mock.MatchedBy(func(chunk []models.TaskRecord) bool {
+			return len(chunk) == importChunkSize
+		}), false).Return(first, nil).Once()
+		mockTasks.On("ImportTasks", mock.Anything, mock.MatchedBy(func(chunk []models.TaskRecord) bool {
+			return len(chunk) == 1
+		}), false).Return([]models.ImportResult{{Created: true}}, nil).Once()
+
+		// Вызываем тестируемый метод
+		started, err := service.StartImport(ctx, "trello", records, []string{"trello: 1 archived cards were skipped"})
+		require.NoError(t, err)
+		job := waitImport(t, service, started.ID)
+
+		// Проверяем результаты
+		assert.Len(t, started.ID, 32)
+		assert.Equal(t, models.ImportSucceeded, job.Status)
+		assert.Equal(t, "trello", job.Source)
+		assert.Equal(t, len(records), job.Total)
+		assert.Equal(t, len(records), job.Processed)
+		assert.Equal(t, importChunkSize/2+1, job.Created)
+		assert.Equal(t, importChunkSize/2, job.Updated)
+		assert.Equal(t, 1, job.Skipped)
+		assert.Len(t, job.Warnings, 2)
+		assert.NotNil(t, job.FinishedAt)
+		mockTasks.AssertExpectations(t)
+	})
+
+	t.Run("Ошибка записи завершает задание статусом failed", func(t *testing.T) {
+		// Подготавливаем тестовые данные
+		mockTasks := new(mocks.TaskImporter)
+		service := NewImportService(fxtest.NewLifecycle(t), mockTasks, newImportCache(t), logger)
+		records := []models.TaskRecord{{Title: "Задача"}}
+
+		// Настраиваем ожидаемое поведение мока
+		mockTasks.On("ImportTasks", mock.Anything, records, false).Return(nil, errors.New("database is down")).Once()
+
+		// Вызываем тестируемый метод
+		started, err := service.StartImport(ctx, "mstodo", records, nil)
+		require.NoError(t, err)
+		job := waitImport(t, service, started.ID)
+
+		// Проверяем результаты
+		assert.Equal(t, models.ImportFailed, job.Status)
+		assert.Equal(t, "database is down", job.Error)
+		assert.Zero(t, job.Processed)
+		mockTasks.AssertExpectations(t)
+	})
+
+	t.Run("Остановка приложения прерывает задание", func(t *testing.T) {
+		// Подготавливаем тестовые данные
+		mockTasks := new(mocks.TaskImporter)
+		lc := fxtest.NewLifecycle(t)
+		service := NewImportService(lc, mockTasks, newImportCache(t), logger)
+		lc.RequireStart()
+
+		// Настраиваем ожидаемое поведение мока: импорт ждет отмены контекста
+		running := make(chan struct{})
+		mockTasks.On("ImportTasks", mock.Anything, mock.Anything, false).Run(func(args mock.Arguments) {
+			close(running)
+			<-args.Get(0).(context.Context).Done()
+		}).Return(nil, context.Canceled).Once()
+
+		// Вызываем тестируемый метод
+		started, err := service.StartImport(ctx, "todoist-json", []models.TaskRecord{{Title: "Задача"}}, nil)
+		require.NoError(t, err)
+		<-running
+		lc.RequireStop()
+
+		// Проверяем результаты
+		job, err := service.GetImport(ctx, started.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ImportFailed, job.Status)
+		assert.Equal(t, "import was interrupted by shutdown", job.Error)
+	})
+
+	t.Run("Прогресс задания доступен на другой реплике", func(t *testing.T) {
+		// Подготавливаем тестовые данные: две реплики с общим кешем
+		shared := newImportCache(t)
+		mockTasks := new(mocks.TaskImporter)
+		running := NewImportService(fxtest.NewLifecycle(t), mockTasks, shared, logger)
+		other := NewImportService(fxtest.NewLifecycle(t), new(mocks.TaskImporter), shared, logger)
+
+		// Настраиваем ожидаемое поведение мока: первая часть ждет, пока другая реплика не увидит задание
+		release := make(chan struct{})
+		mockTasks.On("ImportTasks", mock.Anything, mock.Anything, false).Run(func(mock.Arguments) {
+			<-release
+		}).Return([]models.ImportResult{{Created: true}}, nil).Once()
+
+		// Вызываем тестируемый метод
+		started, err := running.StartImport(ctx, "trello", []models.TaskRecord{{Title: "Задача"}}, nil)
+		require.NoError(t, err)
+
+		// Проверяем результаты
+		require.Eventually(t, func() bool {
+			job, err := other.GetImport(ctx, started.ID)
+			require.NoError(t, err)
+			return job.Status == models.ImportRunning
+		}, 5*time.Second, 5*time.Millisecond)
+		close(release)
+
+		job := waitImport(t, other, started.ID)
+		assert.Equal(t, models.ImportSucceeded, job.Status)
+		assert.Equal(t, 1, job.Created)
+		assert.Equal(t, 1, job.Processed)
+		mockTasks.AssertExpectations(t)
+	})
+
+	t.Run("Неизвестное задание", func(t *testing.T) {
+		service := NewImportService(fxtest.NewLifecycle(t), new(mocks.TaskImporter), newImportCache(t), logger)
+
+		// Вызываем тестируемый метод
+		job, err := service.GetImport(ctx, "unknown")
+
+		// Проверяем результаты
+		assert.ErrorIs(t, err, models.ErrImportJobNotFound)
+		assert.Nil(t, job)
+	})
+
+	t.Run("Предупреждения ограничиваются", func(t *testing.T) {
+		job := &models.ImportJob{}
+		for i := 0; i < maxImportWarnings+10; i++ {
+			addWarning(job, "warning")
+		}
+
+		assert.Len(t, job.Warnings, maxImportWarnings+1)
+		assert.Equal(t, "further warnings were omitted", job.Warnings[maxImportWarnings])
+	})
+}
@@ -0,0 +1,18 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// CacheWarmer это автоматически сгенерированный мок для интерфейса CacheWarmer
+type CacheWarmer struct {
+	mock.Mock
+}
+
+// WarmCache мок для метода WarmCache
+func (m *CacheWarmer) WarmCache(ctx context.Context, limit int) (int, error) {
+	args := m.Called(ctx, limit)
+	return args.Int(0), args.Error(1)
+}
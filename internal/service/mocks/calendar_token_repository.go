@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+// CalendarTokenRepository это автоматически сгенерированный мок для интерфейса CalendarTokenRepository
+type CalendarTokenRepository struct {
+	mock.Mock
+}
+
+// CreateToken мок для метода CreateToken
+func (m *CalendarTokenRepository) CreateToken(ctx context.Context, name string, hash []byte) (*models.CalendarToken, error) {
+	args := m.Called(ctx, name, hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CalendarToken), args.Error(1)
+}
+
+// ListTokens мок для метода ListTokens
+func (m *CalendarTokenRepository) ListTokens(ctx context.Context) ([]*models.CalendarToken, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.CalendarToken), args.Error(1)
+}
+
+// FindToken мок для метода FindToken
+func (m *CalendarTokenRepository) FindToken(ctx context.Context, hash []byte) (*models.CalendarToken, error) {
+	args := m.Called(ctx, hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CalendarToken), args.Error(1)
+}
+
+// DeleteToken мок для метода DeleteToken
+func (m *CalendarTokenRepository) DeleteToken(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
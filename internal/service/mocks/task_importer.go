@@ -0,0 +1,22 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+// TaskImporter это автоматически сгенерированный мок для интерфейса TaskImporter
+type TaskImporter struct {
+	mock.Mock
+}
+
+// ImportTasks мок для метода ImportTasks
+func (m *TaskImporter) ImportTasks(ctx context.Context, records []models.TaskRecord, dryRun bool) ([]models.ImportResult, error) {
+	args := m.Called(ctx, records, dryRun)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ImportResult), args.Error(1)
+}
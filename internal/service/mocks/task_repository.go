@@ -40,7 +40,85 @@ func (m *TaskRepository) UpdateTask(ctx context.Context, task *models.Task) erro
 }
 
 // DeleteTask мок для метода DeleteTask
-func (m *TaskRepository) DeleteTask(ctx context.Context, id int) error {
-	args := m.Called(ctx, id)
+func (m *TaskRepository) DeleteTask(ctx context.Context, id int, version int) error {
+	args := m.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+// ApplyBatch мок для метода ApplyBatch
+func (m *TaskRepository) ApplyBatch(ctx context.Context, ops []models.BatchOperation, mode models.BatchMode) ([]models.BatchResult, error) {
+	args := m.Called(ctx, ops, mode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.BatchResult), args.Error(1)
+}
+
+// SearchTasks мок для метода SearchTasks
+func (m *TaskRepository) SearchTasks(ctx context.Context, query string, config string, limit int) ([]*models.TaskSearchResult, error) {
+	args := m.Called(ctx, query, config, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.TaskSearchResult), args.Error(1)
+}
+
+// SuggestTasks мок для метода SuggestTasks
+func (m *TaskRepository) SuggestTasks(ctx context.Context, query string, limit int) ([]*models.TaskSuggestion, error) {
+	args := m.Called(ctx, query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.TaskSuggestion), args.Error(1)
+}
+
+// GetTasksByIDs мок для метода GetTasksByIDs
+func (m *TaskRepository) GetTasksByIDs(ctx context.Context, ids []int) ([]*models.Task, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Task), args.Error(1)
+}
+
+// ExportTasks мок для метода ExportTasks
+func (m *TaskRepository) ExportTasks(ctx context.Context, fn func(*models.TaskRecord) error) error {
+	args := m.Called(ctx, fn)
 	return args.Error(0)
 }
+
+// ImportTasks мок для метода ImportTasks
+func (m *TaskRepository) ImportTasks(ctx context.Context, records []models.TaskRecord, dryRun bool) ([]models.ImportResult, error) {
+	args := m.Called(ctx, records, dryRun)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ImportResult), args.Error(1)
+}
+
+// GetTaskRecord мок для метода GetTaskRecord
+func (m *TaskRepository) GetTaskRecord(ctx context.Context, externalID string, id int) (*models.TaskRecord, error) {
+	args := m.Called(ctx, externalID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TaskRecord), args.Error(1)
+}
+
+// PutTaskRecord мок для метода PutTaskRecord
+func (m *TaskRepository) PutTaskRecord(ctx context.Context, record models.TaskRecord, version int) (*models.Task, bool, error) {
+	args := m.Called(ctx, record, version)
+	if args.Get(0) == nil {
+		return nil, false, args.Error(2)
+	}
+	return args.Get(0).(*models.Task), args.Bool(1), args.Error(2)
+}
+
+// SyncTasks мок для метода SyncTasks
+func (m *TaskRepository) SyncTasks(ctx context.Context, records []models.TaskRecord) ([]models.ImportResult, error) {
+	args := m.Called(ctx, records)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ImportResult), args.Error(1)
+}
@@ -2,7 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/pers0na2dev/todo-api/internal/config"
 	"github.com/pers0na2dev/todo-api/internal/models"
 	"go.uber.org/zap"
 )
@@ -14,42 +18,61 @@ type TaskRepository interface {
 	CreateTask(ctx context.Context, task *models.Task) error
 	GetTasks(ctx context.Context) ([]*models.Task, error)
 	GetTaskByID(ctx context.Context, id int) (*models.Task, error)
+	GetTasksByIDs(ctx context.Context, ids []int) ([]*models.Task, error)
 	UpdateTask(ctx context.Context, task *models.Task) error
-	DeleteTask(ctx context.Context, id int) error
+	DeleteTask(ctx context.Context, id int, version int) error
+	ApplyBatch(ctx context.Context, ops []models.BatchOperation, mode models.BatchMode) ([]models.BatchResult, error)
+	SearchTasks(ctx context.Context, query string, config string, limit int) ([]*models.TaskSearchResult, error)
+	SuggestTasks(ctx context.Context, query string, limit int) ([]*models.TaskSuggestion, error)
+	ExportTasks(ctx context.Context, fn func(*models.TaskRecord) error) error
+	ImportTasks(ctx context.Context, records []models.TaskRecord, dryRun bool) ([]models.ImportResult, error)
+	GetTaskRecord(ctx context.Context, externalID string, id int) (*models.TaskRecord, error)
+	PutTaskRecord(ctx context.Context, record models.TaskRecord, version int) (*models.Task, bool, error)
+	SyncTasks(ctx context.Context, records []models.TaskRecord) ([]models.ImportResult, error)
 }
 
 // TaskService структура, которая содержит методы для работы с задачами
 type TaskService struct {
 	repo   TaskRepository
+	events *EventBroker
 	logger *zap.Logger
+	// searchConfig - конфигурация полнотекстового поиска по умолчанию
+	searchConfig string
 }
 
 // NewTaskService функция, которая создает новый экземпляр TaskService
 // @param repo *postgres.TaskRepository - репозиторий для задач
+// @param events *EventBroker - рассылка событий об изменении задач
+// @param cfg *config.Config - конфигурация
+// @param logger *zap.Logger - логгер
 // @return *TaskService - новый экземпляр TaskService
-func NewTaskService(repo TaskRepository, logger *zap.Logger) *TaskService {
+func NewTaskService(repo TaskRepository, events *EventBroker, cfg *config.Config, logger *zap.Logger) *TaskService {
 	return &TaskService{
-		repo:   repo,
-		logger: logger,
+		repo:         repo,
+		events:       events,
+		logger:       logger,
+		searchConfig: cfg.SearchConfig,
 	}
 }
 
 // CreateTask функция, которая создает новую задачу
 // @param ctx context.Context - контекст выполнения
-// @param task *models.Task - задача
+// @param title string - название задачи
+// @return *models.Task - созданная задача
 // @return error - ошибка
-func (s *TaskService) CreateTask(ctx context.Context, title string) error {
+func (s *TaskService) CreateTask(ctx context.Context, title string) (*models.Task, error) {
 	s.logger.Info("creating new task", zap.String("title", title))
 
 	task := &models.Task{Title: title}
 	err := s.repo.CreateTask(ctx, task)
 	if err != nil {
 		s.logger.Error("failed to create task", zap.Error(err))
-		return err
+		return nil, err
 	}
 
-	s.logger.Info("task created successfully")
-	return nil
+	s.logger.Info("task created successfully", zap.Int("id", task.ID))
+	s.publish(models.TaskCreated, task)
+	return task, nil
 }
 
 // GetTasks функция, которая возвращает все задачи
@@ -87,15 +110,40 @@ func (s *TaskService) GetTaskByID(ctx context.Context, id int) (*models.Task, er
 	return task, nil
 }
 
+// GetTasksByIDs функция, которая возвращает задачи по списку id
+// @param ctx context.Context - контекст выполнения
+// @param ids []int - id задач
+// @return []*models.Task - найденные задачи в произвольном порядке
+// @return error - ошибка
+func (s *TaskService) GetTasksByIDs(ctx context.Context, ids []int) ([]*models.Task, error) {
+	s.logger.Info("getting tasks by ids", zap.Ints("ids", ids))
+
+	tasks, err := s.repo.GetTasksByIDs(ctx, ids)
+	if err != nil {
+		s.logger.Error("failed to get tasks by ids", zap.Error(err))
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
 // OpenCloseTask функция, которая открывает или закрывает задачу
+// Изменение применяется только если текущая версия задачи совпадает с version
 // @param ctx context.Context - контекст выполнения
-// @param task *models.Task - задача
+// @param id int - id задачи
+// @param version int - ожидаемая версия задачи
+// @return *models.Task - обновленная задача
 // @return error - ошибка
-func (s *TaskService) OpenCloseTask(ctx context.Context, id int) error {
+func (s *TaskService) OpenCloseTask(ctx context.Context, id int, version int) (*models.Task, error) {
 	task, err := s.repo.GetTaskByID(ctx, id)
 	if err != nil {
 		s.logger.Error("failed to get task by id", zap.Error(err))
-		return err
+		return nil, err
+	}
+
+	if task.Version != version {
+		s.logger.Info("task version mismatch", zap.Int("id", id), zap.Int("expected", version), zap.Int("actual", task.Version))
+		return nil, models.ErrVersionConflict
 	}
 
 	task.Completed = !task.Completed
@@ -103,26 +151,354 @@ func (s *TaskService) OpenCloseTask(ctx context.Context, id int) error {
 	err = s.repo.UpdateTask(ctx, task)
 	if err != nil {
 		s.logger.Error("failed to update task", zap.Error(err))
-		return err
+		return nil, err
 	}
 
 	s.logger.Info("task updated successfully")
-	return nil
+	s.publish(models.TaskUpdated, task)
+	return task, nil
 }
 
 // RemoveTask функция, которая удаляет задачу
 // @param ctx context.Context - контекст выполнения
 // @param id int - id задачи
+// @param version int - ожидаемая версия задачи
 // @return error - ошибка
-func (s *TaskService) RemoveTask(ctx context.Context, id int) error {
+func (s *TaskService) RemoveTask(ctx context.Context, id int, version int) error {
 	s.logger.Info("removing task", zap.Int("id", id))
 
-	err := s.repo.DeleteTask(ctx, id)
+	err := s.repo.DeleteTask(ctx, id, version)
 	if err != nil {
 		s.logger.Error("failed to remove task", zap.Error(err))
 		return err
 	}
 
 	s.logger.Info("task removed successfully")
+	s.publish(models.TaskDeleted, &models.Task{ID: id})
 	return nil
 }
+
+// ApplyBatch функция, которая выполняет пакет операций над задачами
+// Некорректный пакет отклоняется целиком до обращения к базе данных
+// @param ctx context.Context - контекст выполнения
+// @param ops []models.BatchOperation - операции
+// @param mode models.BatchMode - режим выполнения
+// @return []models.BatchResult - результаты операций в том же порядке
+// @return error - ошибка
+func (s *TaskService) ApplyBatch(ctx context.Context, ops []models.BatchOperation, mode models.BatchMode) ([]models.BatchResult, error) {
+	s.logger.Info("applying batch", zap.Int("operations", len(ops)), zap.String("mode", string(mode)))
+
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("%w: no operations", models.ErrInvalidBatch)
+	}
+	if len(ops) > models.MaxBatchOperations {
+		return nil, fmt.Errorf("%w: at most %d operations are allowed", models.ErrInvalidBatch, models.MaxBatchOperations)
+	}
+	if err := mode.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidBatch, err)
+	}
+	for i := range ops {
+		if err := ops[i].Validate(); err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", models.ErrInvalidBatch, i, err)
+		}
+	}
+
+	results, err := s.repo.ApplyBatch(ctx, ops, mode)
+	if err != nil {
+		s.logger.Error("failed to apply batch", zap.Error(err))
+		return nil, err
+	}
+
+	for i, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		switch ops[i].Op {
+		case models.BatchCreate:
+			s.publish(models.TaskCreated, result.Task)
+		case models.BatchDelete:
+			s.publish(models.TaskDeleted, &models.Task{ID: ops[i].ID})
+		default:
+			s.publish(models.TaskUpdated, result.Task)
+		}
+	}
+
+	s.logger.Info("batch applied successfully")
+	return results, nil
+}
+
+// SearchTasks функция, которая выполняет полнотекстовый поиск задач
+// @param ctx context.Context - контекст выполнения
+// @param query string - поисковый запрос
+// @param lang string - конфигурация полнотекстового поиска, пустая строка означает конфигурацию по умолчанию
+// @param limit int - максимальное количество результатов, 0 означает значение по умолчанию
+// @return []*models.TaskSearchResult - найденные задачи
+// @return error - ошибка
+func (s *TaskService) SearchTasks(ctx context.Context, query string, lang string, limit int) ([]*models.TaskSearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("%w: query is required", models.ErrInvalidSearch)
+	}
+
+	if lang == "" {
+		lang = s.searchConfig
+	}
+	if err := models.ValidateSearchConfig(lang); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case limit == 0:
+		limit = models.DefaultSearchLimit
+	case limit < 0 || limit > models.MaxSearchLimit:
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", models.ErrInvalidSearch, models.MaxSearchLimit)
+	}
+
+	s.logger.Info("searching tasks", zap.String("query", query), zap.String("config", lang))
+
+	results, err := s.repo.SearchTasks(ctx, query, lang, limit)
+	if err != nil {
+		s.logger.Error("failed to search tasks", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("tasks found successfully", zap.Int("count", len(results)))
+	return results, nil
+}
+
+// SuggestTasks функция, которая возвращает подсказки для быстрого перехода к задаче
+// Запрос нормализуется, чтобы "Купить  Молоко" и "купить молоко" попадали в один ключ кеша
+// @param ctx context.Context - контекст выполнения
+// @param query string - запрос
+// @param limit int - максимальное количество подсказок, 0 означает значение по умолчанию
+// @return []*models.TaskSuggestion - подсказки
+// @return error - ошибка
+func (s *TaskService) SuggestTasks(ctx context.Context, query string, limit int) ([]*models.TaskSuggestion, error) {
+	query = strings.ToLower(strings.Join(strings.Fields(query), " "))
+	if query == "" {
+		return nil, fmt.Errorf("%w: query is required", models.ErrInvalidSearch)
+	}
+
+	switch {
+	case limit == 0:
+		limit = models.DefaultSuggestLimit
+	case limit < 0 || limit > models.MaxSuggestLimit:
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", models.ErrInvalidSearch, models.MaxSuggestLimit)
+	}
+
+	suggestions, err := s.repo.SuggestTasks(ctx, query, limit)
+	if err != nil {
+		s.logger.Error("failed to suggest tasks", zap.Error(err))
+		return nil, err
+	}
+
+	return suggestions, nil
+}
+
+// ExportTasks функция, которая передает все задачи в fn по одной в порядке id
+// @param ctx context.Context - контекст выполнения
+// @param fn func(*models.TaskRecord) error - обработчик задачи, ошибка обработчика прерывает экспорт
+// @return error - ошибка
+func (s *TaskService) ExportTasks(ctx context.Context, fn func(*models.TaskRecord) error) error {
+	s.logger.Info("exporting tasks")
+
+	exported := 0
+	err := s.repo.ExportTasks(ctx, func(record *models.TaskRecord) error {
+		exported++
+		return fn(record)
+	})
+	if err != nil {
+		s.logger.Error("failed to export tasks", zap.Int("exported", exported), zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("tasks exported successfully", zap.Int("count", exported))
+	return nil
+}
+
+// ImportTasks функция, которая импортирует задачи, обновляя задачи с совпадающим внешним id
+// Каждая запись проверяется отдельно. Если хотя бы одна запись некорректна, ничего не записывается,
+// а корректные записи получают models.ErrImportAborted
+// @param ctx context.Context - контекст выполнения
+// @param records []models.TaskRecord - записи импорта
+// @param dryRun bool - true, если нужно только проверить импорт без сохранения изменений
+// @return []models.ImportResult - результаты записей в том же порядке
+// @return error - ошибка
+func (s *TaskService) ImportTasks(ctx context.Context, records []models.TaskRecord, dryRun bool) ([]models.ImportResult, error) {
+	s.logger.Info("importing tasks", zap.Int("records", len(records)), zap.Bool("dry_run", dryRun))
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: no tasks", models.ErrInvalidImport)
+	}
+	if len(records) > models.MaxImportRows {
+		return nil, fmt.Errorf("%w: at most %d tasks are allowed", models.ErrInvalidImport, models.MaxImportRows)
+	}
+
+	results := make([]models.ImportResult, len(records))
+	// seen - номер первой записи с каждым внешним id, одна задача не может импортироваться дважды
+	seen := make(map[string]int)
+	invalid := false
+	for i := range records {
+		if err := records[i].Validate(); err != nil {
+			results[i].Err, invalid = err, true
+			continue
+		}
+		if id := records[i].ExternalID; id != "" {
+			if first, ok := seen[id]; ok {
+				results[i].Err, invalid = fmt.Errorf("external_id %q is already used in row %d", id, first+1), true
+				continue
+			}
+			seen[id] = i
+		}
+	}
+
+	if invalid {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i].Err = models.ErrImportAborted
+			}
+		}
+		s.logger.Info("import rejected")
+		return results, nil
+	}
+
+	results, err := s.repo.ImportTasks(ctx, records, dryRun)
+	if err != nil {
+		s.logger.Error("failed to import tasks", zap.Error(err))
+		return nil, err
+	}
+
+	if !dryRun {
+		for _, result := range results {
+			if result.Created {
+				s.publish(models.TaskCreated, result.Task)
+			} else {
+				s.publish(models.TaskUpdated, result.Task)
+			}
+		}
+	}
+
+	s.logger.Info("tasks imported successfully")
+	return results, nil
+}
+
+// GetTaskRecord функция, которая возвращает задачу вместе с внешним id
+// Ищется задача с внешним id externalID, а если такой нет - задача без внешнего id с заданным id
+// @param ctx context.Context - контекст выполнения
+// @param externalID string - внешний id задачи
+// @param id int - id задачи без внешнего id, 0 если искать только по внешнему id
+// @return *models.TaskRecord - задача
+// @return error - ошибка, models.ErrTaskNotFound если задачи нет
+func (s *TaskService) GetTaskRecord(ctx context.Context, externalID string, id int) (*models.TaskRecord, error) {
+	record, err := s.repo.GetTaskRecord(ctx, externalID, id)
+	if err != nil && !errors.Is(err, models.ErrTaskNotFound) {
+		s.logger.Error("failed to get task record", zap.String("external_id", externalID), zap.Int("id", id), zap.Error(err))
+	}
+	return record, err
+}
+
+// PutTaskRecord функция, которая заменяет название, статус и срок задачи или создает задачу с внешним id
+// Если record.ID задан, изменяется задача с этим id, иначе задача с внешним id record.ExternalID
+// @param ctx context.Context - контекст выполнения
+// @param record models.TaskRecord - задача
+// @param version int - ожидаемая версия задачи, 0 если версия не проверяется
+// @return *models.Task - задача после изменения
+// @return bool - true, если задача создана
+// @return error - ошибка, models.ErrInvalidTask если задача некорректна
+func (s *TaskService) PutTaskRecord(ctx context.Context, record models.TaskRecord, version int) (*models.Task, bool, error) {
+	s.logger.Info("putting task", zap.Int("id", record.ID), zap.String("external_id", record.ExternalID), zap.Int("version", version))
+
+	if err := record.Validate(); err != nil {
+		return nil, false, fmt.Errorf("%w: %v", models.ErrInvalidTask, err)
+	}
+	if record.ID == 0 && record.ExternalID == "" {
+		return nil, false, fmt.Errorf("%w: id or external_id is required", models.ErrInvalidTask)
+	}
+
+	task, created, err := s.repo.PutTaskRecord(ctx, record, version)
+	if err != nil {
+		s.logger.Error("failed to put task", zap.Error(err))
+		return nil, false, err
+	}
+
+	if created {
+		s.publish(models.TaskCreated, task)
+	} else {
+		s.publish(models.TaskUpdated, task)
+	}
+
+	s.logger.Info("task put successfully", zap.Int("id", task.ID), zap.Bool("created", created))
+	return task, created, nil
+}
+
+// SyncTasks функция, которая создает задачи без id и изменяет задачи с id в одной транзакции
+// Задачи с id изменяются только если их версия совпадает с record.Version. Каждая запись проверяется отдельно,
+// если хотя бы одна запись некорректна, ничего не записывается, а корректные записи получают models.ErrImportAborted
+// @param ctx context.Context - контекст выполнения
+// @param records []models.TaskRecord - задачи
+// @return []models.ImportResult - результаты в том же порядке
+// @return error - ошибка, models.ErrVersionConflict если одна из задач изменена с момента чтения
+func (s *TaskService) SyncTasks(ctx context.Context, records []models.TaskRecord) ([]models.ImportResult, error) {
+	s.logger.Info("syncing tasks", zap.Int("records", len(records)))
+
+	if len(records) > models.MaxImportRows {
+		return nil, fmt.Errorf("%w: at most %d tasks are allowed", models.ErrInvalidImport, models.MaxImportRows)
+	}
+
+	results := make([]models.ImportResult, len(records))
+	invalid := false
+	for i := range records {
+		if err := records[i].Validate(); err != nil {
+			results[i].Err, invalid = err, true
+		}
+	}
+	if invalid {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i].Err = models.ErrImportAborted
+			}
+		}
+		s.logger.Info("sync rejected")
+		return results, nil
+	}
+	if len(records) == 0 {
+		return results, nil
+	}
+
+	results, err := s.repo.SyncTasks(ctx, records)
+	if err != nil {
+		s.logger.Error("failed to sync tasks", zap.Error(err))
+		return nil, err
+	}
+
+	for _, result := range results {
+		if result.Created {
+			s.publish(models.TaskCreated, result.Task)
+		} else {
+			s.publish(models.TaskUpdated, result.Task)
+		}
+	}
+
+	s.logger.Info("tasks synced successfully")
+	return results, nil
+}
+
+// WatchTasks функция, которая подписывает на события об изменении задач на всех репликах
+// Канал закрывается после завершения контекста или раньше, если подписчик не успевает читать события
+// @param ctx context.Context - контекст подписки
+// @return <-chan models.TaskEvent - канал событий
+func (s *TaskService) WatchTasks(ctx context.Context) <-chan models.TaskEvent {
+	s.logger.Info("subscribing to task events")
+	return s.events.Subscribe(ctx)
+}
+
+// publish функция, которая отправляет событие об изменении задачи, если рассылка событий настроена
+// @param eventType models.TaskEventType - тип события
+// @param task *models.Task - задача
+func (s *TaskService) publish(eventType models.TaskEventType, task *models.Task) {
+	if s.events == nil {
+		return
+	}
+
+	s.events.Publish(models.TaskEvent{Type: eventType, Task: task})
+}
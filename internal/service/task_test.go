@@ -23,8 +23,9 @@ func setupTest(t *testing.T) (*TaskService, *mocks.TaskRepository) {
 
 	// Создаем сервис с моком репозитория
 	service := &TaskService{
-		repo:   mockRepo,
-		logger: logger,
+		repo:         mockRepo,
+		logger:       logger,
+		searchConfig: "english",
 	}
 
 	return service, mockRepo
@@ -42,10 +43,11 @@ func TestCreateTask(t *testing.T) {
 		mockRepo.On("CreateTask", ctx, &models.Task{Title: title}).Return(nil).Once()
 
 		// Вызываем тестируемый метод
-		err := service.CreateTask(ctx, title)
+		task, err := service.CreateTask(ctx, title)
 
 		// Проверяем результаты
 		assert.NoError(t, err)
+		assert.Equal(t, title, task.Title)
 		mockRepo.AssertExpectations(t)
 	})
 
@@ -55,10 +57,11 @@ func TestCreateTask(t *testing.T) {
 		mockRepo.On("CreateTask", ctx, &models.Task{Title: title}).Return(expectedError).Once()
 
 		// Вызываем тестируемый метод
-		err := service.CreateTask(ctx, title)
+		task, err := service.CreateTask(ctx, title)
 
 		// Проверяем результаты
 		assert.Error(t, err)
+		assert.Nil(t, task)
 		assert.Equal(t, expectedError, err)
 		mockRepo.AssertExpectations(t)
 	})
@@ -141,3 +144,398 @@ func TestGetTaskByID(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 }
+
+// TestGetTasksByIDs тестирует получение задач по списку id
+func TestGetTasksByIDs(t *testing.T) {
+	service, mockRepo := setupTest(t)
+	ctx := context.Background()
+
+	t.Run("Успешное получение задач", func(t *testing.T) {
+		expectedTasks := []*models.Task{{ID: 1, Title: "Первая"}, {ID: 3, Title: "Третья"}}
+		mockRepo.On("GetTasksByIDs", ctx, []int{1, 2, 3}).Return(expectedTasks, nil).Once()
+
+		tasks, err := service.GetTasksByIDs(ctx, []int{1, 2, 3})
+
+		assert.NoError(t, err)
+		assert.Equal(t, expectedTasks, tasks)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Ошибка при получении задач", func(t *testing.T) {
+		expectedError := errors.New("ошибка базы данных")
+		mockRepo.On("GetTasksByIDs", ctx, []int{4}).Return(nil, expectedError).Once()
+
+		tasks, err := service.GetTasksByIDs(ctx, []int{4})
+
+		assert.Equal(t, expectedError, err)
+		assert.Nil(t, tasks)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+// TestOpenCloseTask тестирует изменение статуса задачи с проверкой версии
+func TestOpenCloseTask(t *testing.T) {
+	service, mockRepo := setupTest(t)
+	ctx := context.Background()
+	taskID := 1
+
+	t.Run("Успешное изменение статуса задачи", func(t *testing.T) {
+		// Подготавливаем тестовые данные
+		current := &models.Task{ID: taskID, Title: "Тестовая задача", Version: 3}
+		updated := &models.Task{ID: taskID, Title: "Тестовая задача", Completed: true, Version: 3}
+
+		// Настраиваем ожидаемое поведение мока
+		mockRepo.On("GetTaskByID", ctx, taskID).Return(current, nil).Once()
+		mockRepo.On("UpdateTask", ctx, updated).Return(nil).Once()
+
+		// Вызываем тестируемый метод
+		task, err := service.OpenCloseTask(ctx, taskID, 3)
+
+		// Проверяем результаты
+		assert.NoError(t, err)
+		assert.True(t, task.Completed)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Устаревшая версия задачи", func(t *testing.T) {
+		// Подготавливаем тестовые данные
+		current := &models.Task{ID: taskID, Title: "Тестовая задача", Version: 4}
+
+		// Настраиваем ожидаемое поведение мока, UpdateTask вызываться не должен
+		mockRepo.On("GetTaskByID", ctx, taskID).Return(current, nil).Once()
+
+		// Вызываем тестируемый метод
+		task, err := service.OpenCloseTask(ctx, taskID, 3)
+
+		// Проверяем результаты
+		assert.ErrorIs(t, err, models.ErrVersionConflict)
+		assert.Nil(t, task)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Конфликт при записи в базу данных", func(t *testing.T) {
+		// Подготавливаем тестовые данные
+		current := &models.Task{ID: taskID, Title: "Тестовая задача", Version: 3}
+
+		// Настраиваем ожидаемое поведение мока с ошибкой конфликта версий
+		mockRepo.On("GetTaskByID", ctx, taskID).Return(current, nil).Once()
+		mockRepo.On("UpdateTask", ctx, current).Return(models.ErrVersionConflict).Once()
+
+		// Вызываем тестируемый метод
+		task, err := service.OpenCloseTask(ctx, taskID, 3)
+
+		// Проверяем результаты
+		assert.ErrorIs(t, err, models.ErrVersionConflict)
+		assert.Nil(t, task)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+// TestRemoveTask тестирует удаление задачи с проверкой версии
+func TestRemoveTask(t *testing.T) {
+	service, mockRepo := setupTest(t)
+	ctx := context.Background()
+	taskID := 1
+
+	t.Run("Успешное удаление задачи", func(t *testing.T) {
+		// Настраиваем ожидаемое поведение мока
+		mockRepo.On("DeleteTask", ctx, taskID, 2).Return(nil).Once()
+
+		// Вызываем тестируемый метод
+		err := service.RemoveTask(ctx, taskID, 2)
+
+		// Проверяем результаты
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Конфликт версий при удалении", func(t *testing.T) {
+		// Настраиваем ожидаемое поведение мока с ошибкой конфликта версий
+		mockRepo.On("DeleteTask", ctx, taskID, 1).Return(models.ErrVersionConflict).Once()
+
+		// Вызываем тестируемый метод
+		err := service.RemoveTask(ctx, taskID, 1)
+
+		// Проверяем результаты
+		assert.ErrorIs(t, err, models.ErrVersionConflict)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+// TestApplyBatch тестирует выполнение пакета операций
+func TestApplyBatch(t *testing.T) {
+	service, mockRepo := setupTest(t)
+	ctx := context.Background()
+	title := "Тестовая задача"
+
+	t.Run("Успешное выполнение пакета", func(t *testing.T) {
+		// Подготавливаем тестовые данные
+		ops := []models.BatchOperation{
+			{Op: models.BatchCreate, Title: &title},
+			{Op: models.BatchDelete, ID: 2, Version: 1},
+		}
+		expected := []models.BatchResult{{Task: &models.Task{ID: 3, Title: title, Version: 1}}, {}}
+
+		// Настраиваем ожидаемое поведение мока
+		mockRepo.On("ApplyBatch", ctx, ops, models.BatchBestEffort).Return(expected, nil).Once()
+
+		// Вызываем тестируемый метод
+		results, err := service.ApplyBatch(ctx, ops, models.BatchBestEffort)
+
+		// Проверяем результаты
+		assert.NoError(t, err)
+		assert.Equal(t, expected, results)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Некорректный пакет отклоняется без обращения к базе данных", func(t *testing.T) {
+		// Подготавливаем тестовые данные, у операции delete нет версии
+		ops := []models.BatchOperation{
+			{Op: models.BatchCreate, Title: &title},
+			{Op: models.BatchDelete, ID: 2},
+		}
+
+		// Вызываем тестируемый метод
+		results, err := service.ApplyBatch(ctx, ops, models.BatchAllOrNothing)
+
+		// Проверяем результаты
+		assert.ErrorIs(t, err, models.ErrInvalidBatch)
+		assert.Nil(t, results)
+		mockRepo.AssertNotCalled(t, "ApplyBatch", ctx, ops, models.BatchAllOrNothing)
+	})
+
+	t.Run("Неизвестный режим выполнения", func(t *testing.T) {
+		ops := []models.BatchOperation{{Op: models.BatchCreate, Title: &title}}
+
+		_, err := service.ApplyBatch(ctx, ops, models.BatchMode("sometimes"))
+
+		assert.ErrorIs(t, err, models.ErrInvalidBatch)
+	})
+}
+
+// TestSearchTasks тестирует полнотекстовый поиск задач
+func TestSearchTasks(t *testing.T) {
+	service, mockRepo := setupTest(t)
+	ctx := context.Background()
+
+	t.Run("Поиск с конфигурацией по умолчанию", func(t *testing.T) {
+		// Подготавливаем тестовые данные
+		expected := []*models.TaskSearchResult{{Task: models.Task{ID: 1, Title: "Купить молоко"}, Rank: 0.5}}
+
+		// Настраиваем ожидаемое поведение мока
+		mockRepo.On("SearchTasks", ctx, "молоко", "english", models.DefaultSearchLimit).Return(expected, nil).Once()
+
+		// Вызываем тестируемый метод
+		results, err := service.SearchTasks(ctx, "  молоко ", "", 0)
+
+		// Проверяем результаты
+		assert.NoError(t, err)
+		assert.Equal(t, expected, results)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Поиск с явно заданной конфигурацией", func(t *testing.T) {
+		mockRepo.On("SearchTasks", ctx, "молоко", "russian", 5).Return([]*models.TaskSearchResult{}, nil).Once()
+
+		_, err := service.SearchTasks(ctx, "молоко", "russian", 5)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Некорректные параметры поиска", func(t *testing.T) {
+		_, err := service.SearchTasks(ctx, " ", "", 0)
+		assert.ErrorIs(t, err, models.ErrInvalidSearch)
+
+		_, err = service.SearchTasks(ctx, "молоко", "german'; DROP TABLE tasks; --", 0)
+		assert.ErrorIs(t, err, models.ErrInvalidSearch)
+
+		_, err = service.SearchTasks(ctx, "молоко", "", models.MaxSearchLimit+1)
+		assert.ErrorIs(t, err, models.ErrInvalidSearch)
+	})
+}
+
+// TestSuggestTasks тестирует нормализацию запроса для подсказок
+func TestSuggestTasks(t *testing.T) {
+	service, mockRepo := setupTest(t)
+	ctx := context.Background()
+
+	t.Run("Запрос нормализуется перед поиском", func(t *testing.T) {
+		// Подготавливаем тестовые данные
+		expected := []*models.TaskSuggestion{{Task: models.Task{ID: 1, Title: "Купить молоко"}, Score: 0.8}}
+
+		// Настраиваем ожидаемое поведение мока
+		mockRepo.On("SuggestTasks", ctx, "купить молко", models.DefaultSuggestLimit).Return(expected, nil).Once()
+
+		// Вызываем тестируемый метод
+		suggestions, err := service.SuggestTasks(ctx, "  Купить \t МОЛКО ", 0)
+
+		// Проверяем результаты
+		assert.NoError(t, err)
+		assert.Equal(t, expected, suggestions)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Пустой запрос", func(t *testing.T) {
+		_, err := service.SuggestTasks(ctx, "   ", 0)
+		assert.ErrorIs(t, err, models.ErrInvalidSearch)
+	})
+}
+
+// TestImportTasks тестирует импорт задач
+func TestImportTasks(t *testing.T) {
+	service, mockRepo := setupTest(t)
+	ctx := context.Background()
+
+	t.Run("Успешный импорт", func(t *testing.T) {
+		// Подготавливаем тестовые данные
+		records := []models.TaskRecord{{ExternalID: "a", Title: "Первая"}, {Title: "Вторая"}}
+		expected := []models.ImportResult{
+			{Task: &models.Task{ID: 1, Title: "Первая", Version: 2}},
+			{Task: &models.Task{ID: 2, Title: "Вторая", Version: 1}, Created: true},
+		}
+
+		// Настраиваем ожидаемое поведение мока
+		mockRepo.On("ImportTasks", ctx, records, true).Return(expected, nil).Once()
+
+		// Вызываем тестируемый метод
+		results, err := service.ImportTasks(ctx, records, true)
+
+		// Проверяем результаты
+		assert.NoError(t, err)
+		assert.Equal(t, expected, results)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Некорректные строки отменяют импорт без обращения к базе данных", func(t *testing.T) {
+		// Подготавливаем тестовые данные: пустое название и повторный внешний id
+		records := []models.TaskRecord{{ExternalID: "a", Title: "Первая"}, {Title: ""}, {ExternalID: "a", Title: "Третья"}}
+
+		// Вызываем тестируемый метод
+		results, err := service.ImportTasks(ctx, records, false)
+
+		// Проверяем результаты
+		assert.NoError(t, err)
+		assert.ErrorIs(t, results[0].Err, models.ErrImportAborted)
+		assert.EqualError(t, results[1].Err, "title is required")
+		assert.EqualError(t, results[2].Err, `external_id "a" is already used in row 1`)
+		mockRepo.AssertNotCalled(t, "ImportTasks", ctx, records, false)
+	})
+
+	t.Run("Пустой импорт отклоняется", func(t *testing.T) {
+		// Вызываем тестируемый метод
+		results, err := service.ImportTasks(ctx, nil, false)
+
+		// Проверяем результаты
+		assert.ErrorIs(t, err, models.ErrInvalidImport)
+		assert.Nil(t, results)
+	})
+}
+
+// TestPutTaskRecord тестирует создание и замену задачи по внешнему id
+func TestPutTaskRecord(t *testing.T) {
+	service, mockRepo := setupTest(t)
+	ctx := context.Background()
+
+	t.Run("Задача сохраняется с проверкой версии", func(t *testing.T) {
+		// Подготавливаем тестовые данные
+		record := models.TaskRecord{ExternalID: "uid", Title: "Задача", Completed: true}
+		expected := &models.Task{ID: 1, Title: "Задача", Completed: true, Version: 3}
+
+		// Настраиваем ожидаемое поведение мока
+		mockRepo.On("PutTaskRecord", ctx, record, 2).Return(expected, false, nil).Once()
+
+		// Вызываем тестируемый метод
+		task, created, err := service.PutTaskRecord(ctx, record, 2)
+
+		// Проверяем результаты
+		assert.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, expected, task)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Задача без названия отклоняется без обращения к базе данных", func(t *testing.T) {
+		// Вызываем тестируемый метод
+		task, _, err := service.PutTaskRecord(ctx, models.TaskRecord{ExternalID: "uid"}, 0)
+
+		// Проверяем результаты
+		assert.ErrorIs(t, err, models.ErrInvalidTask)
+		assert.Nil(t, task)
+		mockRepo.AssertNumberOfCalls(t, "PutTaskRecord", 1)
+	})
+
+	t.Run("Задача без id и внешнего id отклоняется", func(t *testing.T) {
+		// Вызываем тестируемый метод
+		_, _, err := service.PutTaskRecord(ctx, models.TaskRecord{Title: "Задача"}, 0)
+
+		// Проверяем результаты
+		assert.ErrorIs(t, err, models.ErrInvalidTask)
+	})
+}
+
+// TestSyncTasks тестирует применение изменений файла задач
+func TestSyncTasks(t *testing.T) {
+	service, mockRepo := setupTest(t)
+	ctx := context.Background()
+
+	t.Run("Успешная синхронизация", func(t *testing.T) {
+		// Подготавливаем тестовые данные
+		records := []models.TaskRecord{{ID: 1, Title: "Первая", Completed: true, Version: 2}, {Title: "Вторая"}}
+		expected := []models.ImportResult{
+			{Task: &models.Task{ID: 1, Title: "Первая", Completed: true, Version: 3}},
+			{Task: &models.Task{ID: 5, Title: "Вторая", Version: 1}, Created: true},
+		}
+
+		// Настраиваем ожидаемое поведение мока
+		mockRepo.On("SyncTasks", ctx, records).Return(expected, nil).Once()
+
+		// Вызываем тестируемый метод
+		results, err := service.SyncTasks(ctx, records)
+
+		// Проверяем результаты
+		assert.NoError(t, err)
+		assert.Equal(t, expected, results)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Некорректные задачи отменяют синхронизацию без обращения к базе данных", func(t *testing.T) {
+		// Подготавливаем тестовые данные: пустое название
+		records := []models.TaskRecord{{ID: 1, Title: "", Version: 2}, {Title: "Вторая"}}
+
+		// Вызываем тестируемый метод
+		results, err := service.SyncTasks(ctx, records)
+
+		// Проверяем результаты
+		assert.NoError(t, err)
+		assert.EqualError(t, results[0].Err, "title is required")
+		assert.ErrorIs(t, results[1].Err, models.ErrImportAborted)
+		mockRepo.AssertNumberOfCalls(t, "SyncTasks", 1)
+	})
+
+	t.Run("Без изменений база данных не используется", func(t *testing.T) {
+		// Вызываем тестируемый метод
+		results, err := service.SyncTasks(ctx, nil)
+
+		// Проверяем результаты
+		assert.NoError(t, err)
+		assert.Empty(t, results)
+		mockRepo.AssertNumberOfCalls(t, "SyncTasks", 1)
+	})
+
+	t.Run("Конфликт версий", func(t *testing.T) {
+		// Подготавливаем тестовые данные
+		records := []models.TaskRecord{{ID: 3, Title: "Третья", Version: 1}}
+
+		// Настраиваем ожидаемое поведение мока
+		mockRepo.On("SyncTasks", ctx, records).Return(nil, models.ErrVersionConflict).Once()
+
+		// Вызываем тестируемый метод
+		results, err := service.SyncTasks(ctx, records)
+
+		// Проверяем результаты
+		assert.ErrorIs(t, err, models.ErrVersionConflict)
+		assert.Nil(t, results)
+		mockRepo.AssertExpectations(t)
+	})
+}
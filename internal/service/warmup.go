@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// CacheWarmer интерфейс, который содержит метод для загрузки в кеш недавно прочитанных ключей
+type CacheWarmer interface {
+	WarmCache(ctx context.Context, limit int) (int, error)
+}
+
+// Warmup структура, которая прогревает кеш после запуска приложения
+// Прогрев идет в фоне и ограничен CACHE_WARMUP_TIMEOUT. Пока он не завершен, приложение не готово
+// принимать трафик, поэтому после деплоя запросы не приходят на реплику с пустым кешем
+type Warmup struct {
+	warmer  CacheWarmer
+	timeout time.Duration
+	limit   int
+	logger  *zap.Logger
+
+	ready  atomic.Bool
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewWarmup функция, которая создает новый экземпляр Warmup
+// Прогрев запускается при старте приложения и прерывается при остановке. Если CACHE_WARMUP_TIMEOUT равен 0,
+// прогрев отключен и приложение готово сразу
+// @param lc fx.Lifecycle - жизненный цикл приложения
+// @param cfg *config.Config - конфигурация
+// @param warmer CacheWarmer - репозиторий, который загружает ключи в кеш
+// @param logger *zap.Logger - логгер
+// @return *Warmup - новый экземпляр Warmup
+func NewWarmup(lc fx.Lifecycle, cfg *config.Config, warmer CacheWarmer, logger *zap.Logger) *Warmup {
+	w := &Warmup{
+		warmer:  warmer,
+		timeout: cfg.CacheWarmupTimeout,
+		limit:   cfg.CacheWarmupKeys,
+		logger:  logger,
+		done:    make(chan struct{}),
+	}
+	if w.timeout <= 0 {
+		w.ready.Store(true)
+		close(w.done)
+		return w
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			// Контекст хука завершается вместе с запуском приложения, поэтому время прогрева отсчитывается отдельно
+			ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
+			w.cancel = cancel
+			go w.run(ctx)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			w.cancel()
+			select {
+			case <-w.done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	return w
+}
+
+// Ready функция, которая сообщает, завершен ли прогрев кеша
+// @return bool - true, если прогрев завершен, прерван по времени или отключен
+func (w *Warmup) Ready() bool {
+	return w.ready.Load()
+}
+
+// Done функция, которая возвращает канал, закрываемый после завершения прогрева
+// @return <-chan struct{} - канал
+func (w *Warmup) Done() <-chan struct{} {
+	return w.done
+}
+
+// run функция, которая прогревает кеш и отмечает приложение готовым
+// Ошибки прогрева не останавливают приложение: без прогрева оно работает, только медленнее
+// @param ctx context.Context - контекст с ограничением времени прогрева
+func (w *Warmup) run(ctx context.Context) {
+	defer close(w.done)
+	defer w.ready.Store(true)
+	defer w.cancel()
+
+	start := time.Now()
+	warmed, err := w.warmer.WarmCache(ctx, w.limit)
+	fields := []zap.Field{zap.Int("keys", warmed), zap.Duration("duration", time.Since(start))}
+	switch {
+	case err == nil:
+		w.logger.Info("cache warmup finished", fields...)
+	case errors.Is(err, errors.ErrUnsupported):
+		w.logger.Info("cache warmup skipped, cache does not track access")
+	case errors.Is(err, context.DeadlineExceeded):
+		w.logger.Warn("cache warmup timed out", append(fields, zap.Duration("timeout", w.timeout))...)
+	case errors.Is(err, context.Canceled):
+		w.logger.Info("cache warmup interrupted by shutdown", fields...)
+	default:
+		w.logger.Warn("cache warmup failed", append(fields, zap.Error(err))...)
+	}
+}
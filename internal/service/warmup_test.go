@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/config"
+	"github.com/pers0na2dev/todo-api/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+// TestWarmup тестирует прогрев кеша после запуска приложения
+func TestWarmup(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	cfg := &config.Config{CacheWarmupTimeout: time.Second, CacheWarmupKeys: 500}
+
+	t.Run("Приложение готово после завершения прогрева", func(t *testing.T) {
+		// Подготавливаем тестовые данные
+		mockWarmer := new(mocks.CacheWarmer)
+		lc := fxtest.NewLifecycle(t)
+		warmup := NewWarmup(lc, cfg, mockWarmer, logger)
+
+		// Настраиваем ожидаемое поведение мока: прогрев ждет сигнала теста
+		release := make(chan struct{})
+		mockWarmer.On("WarmCache", mock.Anything, 500).Run(func(args mock.Arguments) {
+			<-release
+		}).Return(42, nil).Once()
+
+		// Вызываем тестируемый метод
+		assert.False(t, warmup.Ready())
+		lc.RequireStart()
+		assert.False(t, warmup.Ready())
+		close(release)
+		<-warmup.Done()
+
+		// Проверяем результаты
+		assert.True(t, warmup.Ready())
+		lc.RequireStop()
+		mockWarmer.AssertExpectations(t)
+	})
+
+	t.Run("Приложение готово, если время прогрева истекло", func(t *testing.T) {
+		// Подготавливаем тестовые данные
+		mockWarmer := new(mocks.CacheWarmer)
+		lc := fxtest.NewLifecycle(t)
+		warmup := NewWarmup(lc, &config.Config{CacheWarmupTimeout: 20 * time.Millisecond}, mockWarmer, logger)
+
+		// Настраиваем ожидаемое поведение мока: прогрев длится до отмены контекста
+		mockWarmer.On("WarmCache", mock.Anything, 0).Run(func(args mock.Arguments) {
+			<-args.Get(0).(context.Context).Done()
+		}).Return(3, context.DeadlineExceeded).Once()
+
+		// Вызываем тестируемый метод
+		lc.RequireStart()
+		select {
+		case <-warmup.Done():
+		case <-time.After(time.Second):
+			t.Fatal("warmup did not time out")
+		}
+
+		// Проверяем результаты
+		assert.True(t, warmup.Ready())
+		lc.RequireStop()
+	})
+
+	t.Run("Ошибка прогрева не останавливает приложение", func(t *testing.T) {
+		// Подготавливаем тестовые данные
+		mockWarmer := new(mocks.CacheWarmer)
+		lc := fxtest.NewLifecycle(t)
+		warmup := NewWarmup(lc, cfg, mockWarmer, logger)
+		mockWarmer.On("WarmCache", mock.Anything, 500).Return(0, errors.New("database is down")).Once()
+
+		// Вызываем тестируемый метод
+		lc.RequireStart()
+		<-warmup.Done()
+
+		// Проверяем результаты
+		assert.True(t, warmup.Ready())
+		lc.RequireStop()
+	})
+
+	t.Run("Остановка приложения прерывает прогрев", func(t *testing.T) {
+		// Подготавливаем тестовые данные
+		mockWarmer := new(mocks.CacheWarmer)
+		lc := fxtest.NewLifecycle(t)
+		warmup := NewWarmup(lc, &config.Config{CacheWarmupTimeout: time.Hour}, mockWarmer, logger)
+
+		running := make(chan struct{})
+		mockWarmer.On("WarmCache", mock.Anything, 0).Run(func(args mock.Arguments) {
+			close(running)
+			<-args.Get(0).(context.Context).Done()
+		}).Return(0, context.Canceled).Once()
+
+		// Вызываем тестируемый метод
+		lc.RequireStart()
+		<-running
+		lc.RequireStop()
+
+		// Проверяем результаты
+		assert.True(t, warmup.Ready())
+	})
+
+	t.Run("Без времени прогрева приложение готово сразу", func(t *testing.T) {
+		// Подготавливаем тестовые данные
+		mockWarmer := new(mocks.CacheWarmer)
+		lc := fxtest.NewLifecycle(t)
+
+		// Вызываем тестируемый метод
+		warmup := NewWarmup(lc, &config.Config{}, mockWarmer, logger)
+		lc.RequireStart()
+
+		// Проверяем результаты
+		assert.True(t, warmup.Ready())
+		lc.RequireStop()
+		mockWarmer.AssertNotCalled(t, "WarmCache", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,80 @@
+package todotxt
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/config"
+	"github.com/pers0na2dev/todo-api/internal/models"
+)
+
+// Codec формат todo.txt для импорта и экспорта задач
+// Поддерживает только []models.TaskRecord, поэтому в ответах других маршрутов не предлагается
+type Codec struct {
+	loc *time.Location
+}
+
+// NewCodec функция, которая создает формат todo.txt
+// @param cfg *config.Config - конфигурация, в поясе CALENDAR_TIMEZONE записываются даты сроков
+// @return *Codec - новый экземпляр Codec
+// @return error - ошибка, если часовой пояс неизвестен
+func NewCodec(cfg *config.Config) (*Codec, error) {
+	loc, err := cfg.CalendarLocation()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CALENDAR_TIMEZONE: %w", err)
+	}
+	return &Codec{loc: loc}, nil
+}
+
+// MediaTypes функция, которая возвращает типы содержимого todo.txt
+// Файлы todo.txt обычно передаются как text/plain, поэтому он принимается наравне с собственным типом
+func (c *Codec) MediaTypes() []string {
+	return []string{MediaType, "text/plain"}
+}
+
+// Supports функция, которая проверяет поддержку типа
+func (c *Codec) Supports(t reflect.Type) bool {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t == reflect.TypeFor[[]models.TaskRecord]()
+}
+
+// Encode функция, которая кодирует задачи строками todo.txt
+func (c *Codec) Encode(w io.Writer, v any) error {
+	records, ok := reflect.Indirect(reflect.ValueOf(v)).Interface().([]models.TaskRecord)
+	if !ok {
+		return fmt.Errorf("type %T can not be encoded as todo.txt", v)
+	}
+
+	writer := NewWriter(w)
+	for i := range records {
+		if err := writer.Write(FromRecord(&records[i], c.loc)); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// Decode функция, которая декодирует строки todo.txt в задачи импорта
+func (c *Codec) Decode(r io.Reader, v any) error {
+	target, ok := v.(*[]models.TaskRecord)
+	if !ok {
+		return fmt.Errorf("todo.txt decode target must be *[]models.TaskRecord, got %T", v)
+	}
+
+	tasks, err := Read(r)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		record, err := ToRecord(task, c.loc)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", task.Line, err)
+		}
+		*target = append(*target, record)
+	}
+	return nil
+}
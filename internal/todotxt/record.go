@@ -0,0 +1,207 @@
+package todotxt
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/models"
+)
+
+// Теги, через которые поля задач передаются в todo.txt
+const (
+	// TagDue - срок выполнения задачи в формате YYYY-MM-DD
+	TagDue = "due"
+	// TagID - id задачи, по нему загруженный файл сопоставляется с задачами
+	TagID = "id"
+	// TagRevision - версия задачи при выгрузке файла, по ней обнаруживаются изменения задачи после выгрузки
+	TagRevision = "rev"
+	// TagPriority - приоритет в названии задачи, у задач нет отдельного поля приоритета.
+	// По соглашению todo.txt так же сохраняется приоритет выполненных задач
+	TagPriority = "pri"
+)
+
+// ChangeStatus изменение задачи строкой загруженного файла
+type ChangeStatus string
+
+const (
+	// Created - строка без id или с id удаленной задачи создает новую задачу
+	Created ChangeStatus = "created"
+	// Updated - строка изменяет название, срок или снимает отметку выполнения
+	Updated ChangeStatus = "updated"
+	// Completed - строка отмечает задачу выполненной
+	Completed ChangeStatus = "completed"
+	// Unchanged - строка совпадает с задачей
+	Unchanged ChangeStatus = "unchanged"
+)
+
+// Change изменение, которое строка загруженного файла вносит в задачи
+type Change struct {
+	// Line - номер строки в файле
+	Line   int
+	Status ChangeStatus
+	// Record - задача после изменения. У обновляемых задач ID совпадает с текущей задачей, а Version -
+	// версия из тега rev, с которой файл был выгружен, или текущая версия, если тега нет.
+	// У неизмененных задач это текущая задача
+	Record models.TaskRecord
+	// Err - ошибка в строке, остальные поля в этом случае не заполнены
+	Err error
+}
+
+// FromRecord функция, которая преобразует задачу в строку todo.txt
+// Срок записывается датой в поясе loc, id и версия задачи - тегами id и rev, чтобы файл можно было загрузить обратно
+// @param record *models.TaskRecord - задача
+// @param loc *time.Location - часовой пояс сроков
+// @return Task - строка todo.txt
+func FromRecord(record *models.TaskRecord, loc *time.Location) Task {
+	t := Task{Done: record.Completed}
+	t.SetText(record.Title)
+
+	// Теги из названия заменяются полями задачи, иначе при загрузке файла они перекрыли бы поля
+	t.RemoveTag(TagDue)
+	t.RemoveTag(TagID)
+	t.RemoveTag(TagRevision)
+	if pri, ok := t.Tag(TagPriority); ok && !t.Done && isPriority(pri) {
+		t.Priority = pri
+		t.RemoveTag(TagPriority)
+	}
+
+	if record.DueAt != nil {
+		t.AddTag(TagDue, record.DueAt.In(loc).Format(DateLayout))
+	}
+	if record.ID > 0 {
+		t.AddTag(TagID, strconv.Itoa(record.ID))
+	}
+	if record.ID > 0 && record.Version > 0 {
+		t.AddTag(TagRevision, strconv.Itoa(record.Version))
+	}
+	return t
+}
+
+// ToRecord функция, которая преобразует строку todo.txt в задачу
+// Даты создания и выполнения не сохраняются, приоритет остается в названии тегом pri
+// @param t Task - строка todo.txt
+// @param loc *time.Location - часовой пояс сроков
+// @return models.TaskRecord - задача, ID и Version заполняются из тегов id и rev
+// @return error - ошибка, если тег id, rev или due записан некорректно
+func ToRecord(t Task, loc *time.Location) (models.TaskRecord, error) {
+	record := models.TaskRecord{Completed: t.Done}
+
+	if value, ok := t.Tag(TagID); ok {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			return models.TaskRecord{}, fmt.Errorf("invalid %s:%s, expected a positive number", TagID, value)
+		}
+		record.ID = id
+		t.RemoveTag(TagID)
+	}
+
+	if value, ok := t.Tag(TagRevision); ok {
+		version, err := strconv.Atoi(value)
+		if err != nil || version <= 0 {
+			return models.TaskRecord{}, fmt.Errorf("invalid %s:%s, expected a positive number", TagRevision, value)
+		}
+		record.Version = version
+		t.RemoveTag(TagRevision)
+	}
+
+	if value, ok := t.Tag(TagDue); ok {
+		due, err := time.ParseInLocation(DateLayout, value, loc)
+		if err != nil {
+			return models.TaskRecord{}, fmt.Errorf("invalid %s:%s, expected YYYY-MM-DD", TagDue, value)
+		}
+		record.DueAt = &due
+		t.RemoveTag(TagDue)
+	}
+
+	if _, ok := t.Tag(TagPriority); t.Priority != "" && !ok {
+		t.AddTag(TagPriority, t.Priority)
+	}
+
+	record.Title = t.Text
+	return record, nil
+}
+
+// Diff функция, которая сравнивает строки загруженного файла с текущими задачами
+// Строки сопоставляются с задачами по тегу id. Задачи, которых нет в файле, не изменяются,
+// так как выполненные задачи в todo.txt принято переносить в done.txt.
+// Сроки сравниваются по дате, поэтому время срока, заданное через API, сохраняется.
+// Измененные строки сохраняются с версией из тега rev, поэтому если задача изменена после выгрузки файла,
+// запись отклоняется проверкой версии. Строки без тега rev изменяют текущую версию задачи
+// @param current map[int]*models.TaskRecord - текущие задачи по id
+// @param tasks []Task - строки файла
+// @param loc *time.Location - часовой пояс сроков
+// @return []Change - изменения в порядке строк
+func Diff(current map[int]*models.TaskRecord, tasks []Task, loc *time.Location) []Change {
+	changes := make([]Change, len(tasks))
+	// seen - строка, в которой впервые встретился каждый id
+	seen := make(map[int]int)
+
+	for i, task := range tasks {
+		change := &changes[i]
+		change.Line = task.Line
+
+		record, err := ToRecord(task, loc)
+		if err != nil {
+			change.Err = err
+			continue
+		}
+
+		if record.ID > 0 {
+			if line, ok := seen[record.ID]; ok {
+				change.Err = fmt.Errorf("%s:%d is already used in line %d", TagID, record.ID, line)
+				continue
+			}
+			seen[record.ID] = task.Line
+		}
+
+		existing, ok := current[record.ID]
+		if !ok {
+			// Задача могла быть удалена после выгрузки файла, тогда она создается заново
+			record.ID, record.Version = 0, 0
+			change.Status, change.Record = Created, record
+			continue
+		}
+
+		// Текущая задача приводится к виду, который она получила бы после выгрузки и загрузки файла
+		normalized, _ := ToRecord(FromRecord(existing, loc), loc)
+		sameDue := sameDate(normalized.DueAt, record.DueAt, loc)
+		if normalized.Title == record.Title && normalized.Completed == record.Completed && sameDue {
+			change.Status, change.Record = Unchanged, *existing
+			continue
+		}
+
+		if sameDue {
+			record.DueAt = existing.DueAt
+		}
+		record.ExternalID = existing.ExternalID
+		if record.Version == 0 {
+			record.Version = existing.Version
+		}
+		change.Status, change.Record = Updated, record
+		if record.Completed && !existing.Completed {
+			change.Status = Completed
+		}
+	}
+
+	return changes
+}
+
+// sameDate функция, которая проверяет, что сроки приходятся на один день
+// @param a *time.Time - первый срок
+// @param b *time.Time - второй срок
+// @param loc *time.Location - часовой пояс, в котором сравниваются даты
+// @return bool - true, если оба срока не заданы или совпадают по дате
+func sameDate(a, b *time.Time, loc *time.Location) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.In(loc).Format(DateLayout) == b.In(loc).Format(DateLayout)
+}
+
+// isPriority функция, которая проверяет значение приоритета
+// @param value string - значение
+// @return bool - true, если значение - заглавная латинская буква
+func isPriority(value string) bool {
+	return len(value) == 1 && value[0] >= 'A' && value[0] <= 'Z'
+}
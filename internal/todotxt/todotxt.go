@@ -0,0 +1,239 @@
+// Package todotxt реализует формат todo.txt (https://github.com/todotxt/todo.txt):
+// одна задача на строку с отметкой выполнения, приоритетом, датами, +проектами, @контекстами и тегами key:value
+package todotxt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// MediaType - тип содержимого todo.txt
+// Зарегистрированного типа у формата нет, поэтому используется тип с префиксом x-
+const MediaType = "text/x-todo-txt"
+
+// DateLayout - формат дат todo.txt
+const DateLayout = "2006-01-02"
+
+// maxLineBytes - максимальная длина строки файла
+const maxLineBytes = 64 << 10
+
+// Tag тег key:value из текста задачи
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// Task строка файла todo.txt
+type Task struct {
+	// Line - номер строки в файле, начиная с 1, 0 если задача создана не из файла
+	Line int
+	// Done - задача выполнена (строка начинается с "x ")
+	Done bool
+	// Priority - приоритет от A до Z или пустая строка
+	Priority string
+	// CompletionDate - дата выполнения, нулевое время если не указана
+	CompletionDate time.Time
+	// CreationDate - дата создания, нулевое время если не указана
+	CreationDate time.Time
+	// Text - текст задачи вместе с проектами, контекстами и тегами
+	Text string
+	// Projects - проекты (+project) в порядке появления в тексте
+	Projects []string
+	// Contexts - контексты (@context) в порядке появления в тексте
+	Contexts []string
+	// Tags - теги key:value в порядке появления в тексте
+	Tags []Tag
+}
+
+// Parse функция, которая разбирает строку todo.txt
+// Формат не допускает ошибок: все, что не подходит под отметки в начале строки, считается текстом задачи
+// @param line string - строка без перевода строки
+// @return Task - задача
+func Parse(line string) Task {
+	var t Task
+	rest := strings.TrimSpace(line)
+
+	if strings.HasPrefix(rest, "x ") {
+		t.Done = true
+		rest = strings.TrimLeft(rest[2:], " ")
+		// Дата создания указывается только вместе с датой выполнения
+		if date, ok := cutDate(&rest); ok {
+			t.CompletionDate = date
+			t.CreationDate, _ = cutDate(&rest)
+		}
+	} else {
+		if len(rest) >= 4 && rest[0] == '(' && rest[1] >= 'A' && rest[1] <= 'Z' && rest[2] == ')' && rest[3] == ' ' {
+			t.Priority = rest[1:2]
+			rest = strings.TrimLeft(rest[4:], " ")
+		}
+		t.CreationDate, _ = cutDate(&rest)
+	}
+
+	t.SetText(rest)
+	return t
+}
+
+// cutDate функция, которая отрезает дату в начале текста
+// @param rest *string - текст, после успешного разбора в нем остается все после даты
+// @return time.Time - дата
+// @return bool - true, если текст начинается с даты
+func cutDate(rest *string) (time.Time, bool) {
+	word, tail, _ := strings.Cut(*rest, " ")
+	if len(word) != len(DateLayout) {
+		return time.Time{}, false
+	}
+	date, err := time.Parse(DateLayout, word)
+	if err != nil {
+		return time.Time{}, false
+	}
+	*rest = strings.TrimLeft(tail, " ")
+	return date, true
+}
+
+// SetText функция, которая заменяет текст задачи и заново извлекает из него проекты, контексты и теги
+// @param text string - текст задачи
+func (t *Task) SetText(text string) {
+	t.Text = strings.Join(strings.Fields(text), " ")
+	t.Projects, t.Contexts, t.Tags = nil, nil, nil
+
+	for _, word := range strings.Fields(t.Text) {
+		switch {
+		case len(word) > 1 && word[0] == '+':
+			t.Projects = append(t.Projects, word[1:])
+		case len(word) > 1 && word[0] == '@':
+			t.Contexts = append(t.Contexts, word[1:])
+		default:
+			if tag, ok := parseTag(word); ok {
+				t.Tags = append(t.Tags, tag)
+			}
+		}
+	}
+}
+
+// parseTag функция, которая разбирает слово как тег key:value
+// Слова со значением, начинающимся с "/", не считаются тегами, чтобы ссылки вида https://... оставались текстом
+// @param word string - слово текста
+// @return Tag - тег
+// @return bool - true, если слово является тегом
+func parseTag(word string) (Tag, bool) {
+	key, value, ok := strings.Cut(word, ":")
+	if !ok || key == "" || value == "" || strings.HasPrefix(value, "/") || strings.Contains(value, ":") {
+		return Tag{}, false
+	}
+	return Tag{Key: key, Value: value}, true
+}
+
+// Tag функция, которая возвращает значение первого тега с ключом key
+// @param key string - ключ тега
+// @return string - значение
+// @return bool - false, если тега нет
+func (t *Task) Tag(key string) (string, bool) {
+	for _, tag := range t.Tags {
+		if tag.Key == key {
+			return tag.Value, true
+		}
+	}
+	return "", false
+}
+
+// AddTag функция, которая дописывает тег key:value в конец текста
+// @param key string - ключ тега
+// @param value string - значение
+func (t *Task) AddTag(key, value string) {
+	t.SetText(t.Text + " " + key + ":" + value)
+}
+
+// RemoveTag функция, которая удаляет из текста все теги с ключом key
+// @param key string - ключ тега
+func (t *Task) RemoveTag(key string) {
+	words := strings.Fields(t.Text)
+	kept := words[:0]
+	for _, word := range words {
+		if tag, ok := parseTag(word); ok && tag.Key == key {
+			continue
+		}
+		kept = append(kept, word)
+	}
+	t.SetText(strings.Join(kept, " "))
+}
+
+// String функция, которая записывает задачу строкой todo.txt без перевода строки
+// @return string - строка задачи
+func (t Task) String() string {
+	var b strings.Builder
+	if t.Done {
+		b.WriteString("x ")
+		if !t.CompletionDate.IsZero() {
+			b.WriteString(t.CompletionDate.Format(DateLayout) + " ")
+			if !t.CreationDate.IsZero() {
+				b.WriteString(t.CreationDate.Format(DateLayout) + " ")
+			}
+		}
+	} else {
+		if t.Priority != "" {
+			b.WriteString("(" + t.Priority + ") ")
+		}
+		if !t.CreationDate.IsZero() {
+			b.WriteString(t.CreationDate.Format(DateLayout) + " ")
+		}
+	}
+	b.WriteString(t.Text)
+	return b.String()
+}
+
+// Read функция, которая читает все задачи файла todo.txt
+// Пустые строки пропускаются, номер строки сохраняется в Task.Line
+// @param r io.Reader - файл
+// @return []Task - задачи в порядке строк
+// @return error - ошибка чтения
+func Read(r io.Reader) ([]Task, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineBytes)
+
+	var tasks []Task
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		task := Parse(text)
+		task.Line = line
+		tasks = append(tasks, task)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read todo.txt: %w", err)
+	}
+	return tasks, nil
+}
+
+// Writer запись задач в файл todo.txt по одной на строку
+type Writer struct {
+	w *bufio.Writer
+}
+
+// NewWriter функция, которая создает запись задач в файл todo.txt
+// @param w io.Writer - файл
+// @return *Writer - новый экземпляр Writer
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Write функция, которая записывает задачу отдельной строкой
+// @param t Task - задача
+// @return error - ошибка записи
+func (w *Writer) Write(t Task) error {
+	_, err := w.w.WriteString(t.String() + "\n")
+	return err
+}
+
+// Flush функция, которая дописывает буферизованные строки
+// @return error - ошибка записи
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
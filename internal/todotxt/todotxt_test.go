@@ -0,0 +1,252 @@
+package todotxt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/internal/config"
+	"github.com/pers0na2dev/todo-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParse тестирует разбор строк todo.txt
+func TestParse(t *testing.T) {
+	t.Run("Открытая задача с приоритетом, датой, проектом, контекстом и тегом", func(t *testing.T) {
+		task := Parse("(A) 2024-03-01 Позвонить маме +семья @телефон due:2024-03-10")
+
+		assert.False(t, task.Done)
+		assert.Equal(t, "A", task.Priority)
+		assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), task.CreationDate)
+		assert.Equal(t, "Позвонить маме +семья @телефон due:2024-03-10", task.Text)
+		assert.Equal(t, []string{"семья"}, task.Projects)
+		assert.Equal(t, []string{"телефон"}, task.Contexts)
+		assert.Equal(t, []Tag{{Key: "due", Value: "2024-03-10"}}, task.Tags)
+	})
+
+	t.Run("Выполненная задача с датами выполнения и создания", func(t *testing.T) {
+		task := Parse("x 2024-03-05 2024-03-01 Купить молоко")
+
+		assert.True(t, task.Done)
+		assert.Equal(t, time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), task.CompletionDate)
+		assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), task.CreationDate)
+		assert.Equal(t, "Купить молоко", task.Text)
+	})
+
+	t.Run("Отметки не в начале строки остаются текстом", func(t *testing.T) {
+		task := Parse("Купить (A) x 2024-03-01 подарок https://example.com")
+
+		assert.False(t, task.Done)
+		assert.Empty(t, task.Priority)
+		assert.True(t, task.CreationDate.IsZero())
+		assert.Equal(t, "Купить (A) x 2024-03-01 подарок https://example.com", task.Text)
+		assert.Empty(t, task.Tags)
+	})
+
+	t.Run("Приоритет в нижнем регистре не распознается", func(t *testing.T) {
+		task := Parse("(a) Задача")
+
+		assert.Empty(t, task.Priority)
+		assert.Equal(t, "(a) Задача", task.Text)
+	})
+
+	t.Run("Строка записывается в исходном виде", func(t *testing.T) {
+		for _, line := range []string{
+			"(B) 2024-03-01 Позвонить маме +семья @телефон",
+			"x 2024-03-05 2024-03-01 Купить молоко pri:A",
+			"x Купить хлеб",
+			"Просто задача",
+		} {
+			assert.Equal(t, line, Parse(line).String())
+		}
+	})
+}
+
+// TestRead тестирует чтение файла
+func TestRead(t *testing.T) {
+	tasks, err := Read(strings.NewReader("\ufeffПервая\r\n\r\n   \nx Вторая\n"))
+	require.NoError(t, err)
+
+	require.Len(t, tasks, 2)
+	assert.Equal(t, 1, tasks[0].Line)
+	assert.Equal(t, "Первая", tasks[0].Text)
+	assert.Equal(t, 4, tasks[1].Line)
+	assert.True(t, tasks[1].Done)
+}
+
+// TestTags тестирует изменение тегов
+func TestTags(t *testing.T) {
+	task := Parse("Задача id:1 +проект id:2")
+
+	value, ok := task.Tag("id")
+	assert.True(t, ok)
+	assert.Equal(t, "1", value)
+
+	task.RemoveTag("id")
+	assert.Equal(t, "Задача +проект", task.Text)
+	assert.Empty(t, task.Tags)
+
+	task.AddTag("due", "2024-03-10")
+	assert.Equal(t, "Задача +проект due:2024-03-10", task.Text)
+	assert.Equal(t, []Tag{{Key: "due", Value: "2024-03-10"}}, task.Tags)
+}
+
+// TestRecords тестирует преобразование задач в строки todo.txt и обратно
+func TestRecords(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	// 23:30 UTC - это уже следующий день в Берлине
+	due := time.Date(2024, 3, 9, 23, 30, 0, 0, time.UTC)
+
+	t.Run("Срок записывается датой в часовом поясе, приоритет из названия выносится в начало", func(t *testing.T) {
+		record := &models.TaskRecord{ID: 7, Title: "Позвонить pri:B маме", DueAt: &due}
+
+		assert.Equal(t, "(B) Позвонить маме due:2024-03-10 id:7", FromRecord(record, berlin).String())
+	})
+
+	t.Run("У выполненной задачи приоритет остается тегом", func(t *testing.T) {
+		record := &models.TaskRecord{ID: 7, Title: "Позвонить маме pri:B", Completed: true}
+
+		assert.Equal(t, "x Позвонить маме pri:B id:7", FromRecord(record, berlin).String())
+	})
+
+	t.Run("Строка преобразуется в задачу", func(t *testing.T) {
+		record, err := ToRecord(Parse("(B) 2024-03-01 Позвонить маме +семья due:2024-03-10 id:7"), berlin)
+		require.NoError(t, err)
+
+		assert.Equal(t, 7, record.ID)
+		assert.Equal(t, "Позвонить маме +семья pri:B", record.Title)
+		assert.False(t, record.Completed)
+		require.NotNil(t, record.DueAt)
+		assert.True(t, record.DueAt.Equal(time.Date(2024, 3, 10, 0, 0, 0, 0, berlin)))
+	})
+
+	t.Run("Версия задачи записывается тегом rev и читается обратно", func(t *testing.T) {
+		record := &models.TaskRecord{ID: 7, Title: "Позвонить маме rev:1", Version: 4}
+
+		line := FromRecord(record, berlin).String()
+		assert.Equal(t, "Позвонить маме id:7 rev:4", line)
+
+		parsed, err := ToRecord(Parse(line), berlin)
+		require.NoError(t, err)
+		assert.Equal(t, models.TaskRecord{ID: 7, Title: "Позвонить маме", Version: 4}, parsed)
+	})
+
+	t.Run("Некорректные теги id, rev и due возвращают ошибку", func(t *testing.T) {
+		_, err := ToRecord(Parse("Задача id:abc"), berlin)
+		assert.ErrorContains(t, err, "id:abc")
+
+		_, err = ToRecord(Parse("Задача id:1 rev:0"), berlin)
+		assert.ErrorContains(t, err, "rev:0")
+
+		_, err = ToRecord(Parse("Задача due:завтра"), berlin)
+		assert.ErrorContains(t, err, "due:завтра")
+	})
+}
+
+// TestDiff тестирует сравнение файла с задачами
+func TestDiff(t *testing.T) {
+	due := time.Date(2024, 3, 10, 18, 0, 0, 0, time.UTC)
+	current := map[int]*models.TaskRecord{
+		1: {ID: 1, Title: "Купить молоко", Version: 3},
+		2: {ID: 2, Title: "Позвонить pri:A маме", Version: 1, DueAt: &due},
+		3: {ID: 3, Title: "Сдать отчет", Version: 5, ExternalID: "ext-3"},
+	}
+	diff := func(t *testing.T, file string) []Change {
+		t.Helper()
+		tasks, err := Read(strings.NewReader(file))
+		require.NoError(t, err)
+		return Diff(current, tasks, time.UTC)
+	}
+
+	t.Run("Выгруженный файл не изменяет задачи", func(t *testing.T) {
+		var lines []string
+		for _, id := range []int{1, 2, 3} {
+			lines = append(lines, FromRecord(current[id], time.UTC).String())
+		}
+
+		for _, change := range diff(t, strings.Join(lines, "\n")) {
+			assert.NoError(t, change.Err)
+			assert.Equal(t, Unchanged, change.Status)
+		}
+	})
+
+	t.Run("Изменения строк определяются по id", func(t *testing.T) {
+		changes := diff(t, "x Купить молоко id:1\n(B) Позвонить маме due:2024-03-10 id:2\nСдать отчет до обеда id:3\nНовая задача +дом\n")
+		require.Len(t, changes, 4)
+
+		assert.Equal(t, Completed, changes[0].Status)
+		assert.Equal(t, models.TaskRecord{ID: 1, Title: "Купить молоко", Completed: true, Version: 3}, changes[0].Record)
+
+		// Срок совпадает по дате, поэтому время срока сохраняется
+		assert.Equal(t, Updated, changes[1].Status)
+		assert.Equal(t, "Позвонить маме pri:B", changes[1].Record.Title)
+		assert.Equal(t, &due, changes[1].Record.DueAt)
+
+		assert.Equal(t, Updated, changes[2].Status)
+		assert.Equal(t, models.TaskRecord{ID: 3, ExternalID: "ext-3", Title: "Сдать отчет до обеда", Version: 5}, changes[2].Record)
+
+		assert.Equal(t, Created, changes[3].Status)
+		assert.Equal(t, 4, changes[3].Line)
+		assert.Equal(t, models.TaskRecord{Title: "Новая задача +дом"}, changes[3].Record)
+	})
+
+	t.Run("Измененная строка сохраняется с версией из файла", func(t *testing.T) {
+		// Файл выгружен с версией 2, после этого задача 1 изменена до версии 3
+		changes := diff(t, "x Купить молоко id:1 rev:2\n")
+
+		assert.Equal(t, Completed, changes[0].Status)
+		assert.Equal(t, models.TaskRecord{ID: 1, Title: "Купить молоко", Completed: true, Version: 2}, changes[0].Record)
+	})
+
+	t.Run("Неизмененная строка устаревшего файла не изменяет задачу", func(t *testing.T) {
+		changes := diff(t, "Купить молоко id:1 rev:2\n")
+
+		assert.Equal(t, Unchanged, changes[0].Status)
+		assert.Equal(t, 3, changes[0].Record.Version)
+	})
+
+	t.Run("Строка с id удаленной задачи создает задачу", func(t *testing.T) {
+		changes := diff(t, "Удаленная задача id:99 rev:4")
+
+		assert.Equal(t, Created, changes[0].Status)
+		assert.Equal(t, models.TaskRecord{Title: "Удаленная задача"}, changes[0].Record)
+	})
+
+	t.Run("Удаление срока изменяет задачу", func(t *testing.T) {
+		changes := diff(t, "(A) Позвонить маме id:2")
+
+		assert.Equal(t, Updated, changes[0].Status)
+		assert.Nil(t, changes[0].Record.DueAt)
+	})
+
+	t.Run("Повторный id и некорректные теги возвращают ошибки строк", func(t *testing.T) {
+		changes := diff(t, "Купить молоко id:1\n\nКупить хлеб id:1\nЗадача due:скоро")
+
+		assert.NoError(t, changes[0].Err)
+		assert.ErrorContains(t, changes[1].Err, "id:1 is already used in line 1")
+		assert.Equal(t, 3, changes[1].Line)
+		assert.ErrorContains(t, changes[2].Err, "due:скоро")
+	})
+}
+
+// TestCodec тестирует формат todo.txt для импорта и экспорта
+func TestCodec(t *testing.T) {
+	codec, err := NewCodec(&config.Config{CalendarTimezone: "UTC"})
+	require.NoError(t, err)
+	due := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	records := []models.TaskRecord{{ID: 1, Title: "Первая +дом", DueAt: &due}, {ID: 2, Title: "Вторая", Completed: true}}
+	require.NoError(t, codec.Encode(&buf, records))
+	assert.Equal(t, "Первая +дом due:2024-03-10 id:1\nx Вторая id:2\n", buf.String())
+
+	var decoded []models.TaskRecord
+	require.NoError(t, codec.Decode(&buf, &decoded))
+	assert.Equal(t, records, decoded)
+
+	err = codec.Decode(strings.NewReader("Первая\nВторая due:завтра"), &decoded)
+	assert.ErrorContains(t, err, "line 2")
+}
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE tasks ADD COLUMN version INTEGER NOT NULL DEFAULT 1; -- версия задачи для оптимистичной блокировки
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE tasks DROP COLUMN IF EXISTS version; -- удаление колонки версии
+-- +goose StatementEnd
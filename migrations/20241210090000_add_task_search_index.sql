@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Для каждой поддерживаемой конфигурации полнотекстового поиска создается свой индекс,
+-- так как выражение в запросе должно совпадать с выражением индекса
+CREATE INDEX tasks_title_fts_simple_idx ON tasks USING GIN (to_tsvector('simple', title));
+CREATE INDEX tasks_title_fts_english_idx ON tasks USING GIN (to_tsvector('english', title));
+CREATE INDEX tasks_title_fts_russian_idx ON tasks USING GIN (to_tsvector('russian', title));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS tasks_title_fts_simple_idx;
+DROP INDEX IF EXISTS tasks_title_fts_english_idx;
+DROP INDEX IF EXISTS tasks_title_fts_russian_idx;
+-- +goose StatementEnd
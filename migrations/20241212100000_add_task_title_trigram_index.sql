@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm; -- расширение для нечеткого поиска по триграммам
+-- GiST индекс позволяет выбирать N ближайших задач по оператору <<-> без сортировки всей таблицы
+CREATE INDEX tasks_title_trgm_idx ON tasks USING GIST (lower(title) gist_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS tasks_title_trgm_idx;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- id задачи во внешней системе, по нему повторный импорт обновляет задачу вместо создания новой
+-- NULL значения не считаются одинаковыми, поэтому задачи без внешнего id не конфликтуют друг с другом
+ALTER TABLE tasks ADD COLUMN external_id TEXT UNIQUE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE tasks DROP COLUMN IF EXISTS external_id; -- удаление колонки внешнего id вместе с индексом
+-- +goose StatementEnd
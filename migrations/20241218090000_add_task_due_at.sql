@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE tasks ADD COLUMN due_at TIMESTAMPTZ; -- срок выполнения задачи, NULL если срок не задан
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE tasks DROP COLUMN IF EXISTS due_at; -- удаление колонки срока выполнения
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS calendar_tokens (
+    id SERIAL PRIMARY KEY, -- уникальный идентификатор токена
+    token_hash BYTEA NOT NULL UNIQUE, -- SHA-256 токена, сам токен не хранится
+    name VARCHAR(255) NOT NULL DEFAULT '', -- название, по которому токен можно узнать в списке
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now() -- время создания токена
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS calendar_tokens; -- удаление таблицы токенов календаря
+-- +goose StatementEnd
@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultAccessLogSize - максимальное число ключей в журнале обращений по умолчанию
+	DefaultAccessLogSize = 10000
+	// DefaultAccessLogFlushInterval - интервал записи накопленных обращений в Redis по умолчанию
+	DefaultAccessLogFlushInterval = time.Second
+	// accessLogCloseTimeout - время на запись последних обращений при закрытии журнала
+	accessLogCloseTimeout = time.Second
+)
+
+// AccessTracker интерфейс кешей, которые запоминают недавно прочитанные ключи, чтобы прогреть кеш после перезапуска
+type AccessTracker interface {
+	// TrackAccess отмечает обращение к ключу, не дожидаясь записи в хранилище
+	TrackAccess(key string)
+	// RecentKeys возвращает не больше limit ключей, начиная с прочитанных последними
+	RecentKeys(ctx context.Context, limit int) ([]string, error)
+}
+
+// AccessLogOptions параметры журнала обращений RedisCache
+type AccessLogOptions struct {
+	// Key - ключ отсортированного множества Redis с журналом, пусто - обращения не учитываются
+	Key string
+	// Size - максимальное число ключей в журнале, ключи с самым давним обращением удаляются.
+	// По умолчанию DefaultAccessLogSize
+	Size int
+	// FlushInterval - интервал записи накопленных обращений в Redis, по умолчанию DefaultAccessLogFlushInterval
+	FlushInterval time.Duration
+}
+
+// accessLog журнал обращений в отсортированном множестве Redis, оценка ключа - время последнего обращения в мс
+// Обращения копятся в памяти и записываются одним пайплайном раз в FlushInterval, поэтому чтение из кеша
+// не ждет лишнего запроса к Redis. Журнал общий для всех реплик
+type accessLog struct {
+	client redis.UniversalClient
+	opts   AccessLogOptions
+	logger *zap.Logger
+	now    func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]int64
+	// failing - предыдущая запись завершилась ошибкой, чтобы не повторять предупреждение каждый FlushInterval
+	failing bool
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// newAccessLog функция, которая создает журнал обращений и запускает его запись в Redis
+// @param client redis.UniversalClient - клиент Redis
+// @param opts AccessLogOptions - параметры, Key должен быть задан
+// @param logger *zap.Logger - логгер
+// @return *accessLog - журнал, после использования его нужно закрыть через close
+func newAccessLog(client redis.UniversalClient, opts AccessLogOptions, logger *zap.Logger) *accessLog {
+	if opts.Size <= 0 {
+		opts.Size = DefaultAccessLogSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultAccessLogFlushInterval
+	}
+
+	l := &accessLog{
+		client:  client,
+		opts:    opts,
+		logger:  logger,
+		now:     time.Now,
+		pending: make(map[string]int64),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go l.run()
+
+	return l
+}
+
+// track функция, которая запоминает обращение к ключу до следующей записи в Redis
+// Пока Redis недоступен, в памяти хранится не больше Size ключей, обращения к остальным не учитываются
+// @param key string - ключ
+func (l *accessLog) track(key string) {
+	now := l.now().UnixMilli()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.pending[key]; ok || len(l.pending) < l.opts.Size {
+		l.pending[key] = now
+	}
+}
+
+// recent функция, которая возвращает ключи журнала, начиная с прочитанных последними
+// Обращения, еще не записанные в Redis, не учитываются
+// @param ctx context.Context - контекст выполнения
+// @param limit int - максимальное количество ключей
+// @return []string - ключи
+// @return error - ошибка чтения журнала
+func (l *accessLog) recent(ctx context.Context, limit int) ([]string, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	keys, err := l.client.ZRevRange(ctx, l.opts.Key, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache access log: %w", err)
+	}
+	return keys, nil
+}
+
+// flush функция, которая записывает накопленные обращения и удаляет из журнала ключи сверх Size
+// При ошибке накопленные обращения теряются: журнал нужен только для прогрева и не должен расти без Redis
+// @param ctx context.Context - контекст выполнения
+// @return error - ошибка записи
+func (l *accessLog) flush(ctx context.Context) error {
+	l.mu.Lock()
+	pending := l.pending
+	if len(pending) == 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	l.pending = make(map[string]int64, len(pending))
+	l.mu.Unlock()
+
+	members := make([]redis.Z, 0, len(pending))
+	for key, at := range pending {
+		members = append(members, redis.Z{Score: float64(at), Member: key})
+	}
+	_, err := l.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, l.opts.Key, members...)
+		pipe.ZRemRangeByRank(ctx, l.opts.Key, 0, int64(-l.opts.Size-1))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write cache access log: %w", err)
+	}
+	return nil
+}
+
+// run функция, которая записывает накопленные обращения раз в FlushInterval до закрытия журнала
+func (l *accessLog) run() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			err := l.flush(context.Background())
+			switch {
+			case err != nil && !l.failing:
+				l.logger.Warn("failed to write cache access log", zap.Error(err))
+			case err == nil && l.failing:
+				l.logger.Info("cache access log is written again")
+			}
+			l.failing = err != nil
+		}
+	}
+}
+
+// close функция, которая останавливает запись журнала и записывает последние обращения
+// @return error - ошибка записи последних обращений
+func (l *accessLog) close() error {
+	var err error
+	l.once.Do(func() {
+		close(l.stop)
+		<-l.done
+
+		ctx, cancel := context.WithTimeout(context.Background(), accessLogCloseTimeout)
+		defer cancel()
+		err = l.flush(ctx)
+	})
+	return err
+}
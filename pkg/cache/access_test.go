@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// TestAccessLog тестирует журнал недавно прочитанных ключей
+func TestAccessLog(t *testing.T) {
+	ctx := context.Background()
+
+	newCache := func(t *testing.T, server *miniredis.Miniredis, opts AccessLogOptions) *RedisCache {
+		t.Helper()
+		c, err := NewRedisCache(RedisOptions{DSN: "redis://" + server.Addr(), AccessLog: opts}, zap.NewNop())
+		require.NoError(t, err)
+		t.Cleanup(func() { c.Close() })
+		return c
+	}
+
+	t.Run("Ключи возвращаются начиная с прочитанных последними", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		c := newCache(t, server, AccessLogOptions{Key: "cache:access", FlushInterval: time.Hour})
+
+		clock := time.Unix(1000, 0)
+		c.access.now = func() time.Time { return clock }
+		for _, key := range []string{"task:1", "task:2", "tasks:all", "task:1"} {
+			c.TrackAccess(key)
+			clock = clock.Add(time.Millisecond)
+		}
+
+		// До записи в Redis обращения не видны
+		keys, err := c.RecentKeys(ctx, 10)
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+
+		require.NoError(t, c.access.flush(ctx))
+		keys, err = c.RecentKeys(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"task:1", "tasks:all", "task:2"}, keys)
+
+		keys, err = c.RecentKeys(ctx, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"task:1", "tasks:all"}, keys)
+	})
+
+	t.Run("Журнал ограничен размером", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		c := newCache(t, server, AccessLogOptions{Key: "cache:access", Size: 2, FlushInterval: time.Hour})
+
+		clock := time.Unix(1000, 0)
+		c.access.now = func() time.Time { return clock }
+		c.TrackAccess("task:1")
+		c.TrackAccess("task:2")
+		// Пока обращения не записаны, в памяти хранится не больше Size ключей
+		c.TrackAccess("task:3")
+		require.NoError(t, c.access.flush(ctx))
+
+		clock = clock.Add(time.Second)
+		c.TrackAccess("task:4")
+		require.NoError(t, c.access.flush(ctx))
+
+		members, err := server.ZMembers("cache:access")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"task:2", "task:4"}, members)
+	})
+
+	t.Run("Обращения записываются в фоне и при закрытии", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		c := newCache(t, server, AccessLogOptions{Key: "cache:access", FlushInterval: 10 * time.Millisecond})
+
+		c.TrackAccess("task:1")
+		assert.Eventually(t, func() bool {
+			return server.Exists("cache:access")
+		}, time.Second, 5*time.Millisecond)
+
+		c.TrackAccess("task:2")
+		require.NoError(t, c.Close())
+		members, err := server.ZMembers("cache:access")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"task:1", "task:2"}, members)
+	})
+
+	t.Run("Без ключа журнала обращения не учитываются", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		c := newCache(t, server, AccessLogOptions{})
+
+		c.TrackAccess("task:1")
+		_, err := c.RecentKeys(ctx, 10)
+		assert.True(t, errors.Is(err, errors.ErrUnsupported))
+
+		metrics := NewMetricsCache(c, MetricsOptions{})
+		_, err = metrics.RecentKeys(ctx, 10)
+		assert.True(t, errors.Is(err, errors.ErrUnsupported))
+
+		memory, err := NewMemoryCache(MemoryOptions{})
+		require.NoError(t, err)
+		_, err = NewMetricsCache(memory, MetricsOptions{}).RecentKeys(ctx, 10)
+		assert.True(t, errors.Is(err, errors.ErrUnsupported))
+	})
+}
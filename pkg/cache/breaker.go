@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBreakerThreshold - число ошибок хранилища подряд, после которого кеш временно не используется
+	DefaultBreakerThreshold = 5
+	// DefaultBreakerCooldown - время, в течение которого кеш не используется после ошибок хранилища
+	DefaultBreakerCooldown = 30 * time.Second
+)
+
+// breaker структура, которая отключает обращения к хранилищу после нескольких ошибок подряд
+// После cooldown пропускается одно пробное обращение: при успехе хранилище снова используется,
+// при ошибке отключается еще на cooldown
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+
+	// now - источник времени, заменяется в тестах
+	now func() time.Time
+}
+
+// newBreaker функция, которая создает новый экземпляр breaker
+// @param threshold int - число ошибок подряд, по умолчанию DefaultBreakerThreshold
+// @param cooldown time.Duration - время отключения, по умолчанию DefaultBreakerCooldown
+// @return *breaker - новый экземпляр breaker
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	if threshold <= 0 {
+		threshold = DefaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	return &breaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// allow функция, которая проверяет, можно ли обратиться к хранилищу
+// Результат разрешенного обращения нужно передать в success, failure или release
+// @return bool - false, если хранилище отключено
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.probing || b.now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// success функция, которая учитывает успешное обращение
+// @return bool - true, если хранилище снова используется после отключения
+func (b *breaker) success() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	recovered := b.failures >= b.threshold
+	b.failures = 0
+	b.probing = false
+	return recovered
+}
+
+// failure функция, которая учитывает ошибку хранилища
+// @return bool - true, если после этой ошибки хранилище отключено
+func (b *breaker) failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.failures < b.threshold || b.now().Before(b.openUntil) {
+		return false
+	}
+	b.openUntil = b.now().Add(b.cooldown)
+	return true
+}
+
+// release функция, которая завершает обращение, результат которого ничего не говорит о хранилище,
+// например отмененное вызывающим
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// TestBreaker тестирует отключение хранилища после ошибок
+func TestBreaker(t *testing.T) {
+	newTestBreaker := func() (*breaker, *time.Time) {
+		b := newBreaker(3, time.Minute)
+		now := time.Now()
+		b.now = func() time.Time { return now }
+		return b, &now
+	}
+
+	t.Run("Хранилище отключается после ошибок подряд", func(t *testing.T) {
+		b, _ := newTestBreaker()
+
+		assert.False(t, b.failure())
+		assert.False(t, b.failure())
+		assert.True(t, b.allow())
+		assert.True(t, b.failure())
+
+		assert.False(t, b.allow())
+	})
+
+	t.Run("Успешное обращение сбрасывает счетчик ошибок", func(t *testing.T) {
+		b, _ := newTestBreaker()
+
+		b.failure()
+		b.failure()
+		assert.False(t, b.success())
+		b.failure()
+
+		assert.True(t, b.allow())
+	})
+
+	t.Run("После cooldown пропускается одно пробное обращение", func(t *testing.T) {
+		b, now := newTestBreaker()
+		for i := 0; i < 3; i++ {
+			b.failure()
+		}
+
+		*now = now.Add(time.Minute)
+		assert.True(t, b.allow())
+		assert.False(t, b.allow())
+
+		// Ошибка пробного обращения отключает хранилище еще на cooldown
+		assert.True(t, b.failure())
+		assert.False(t, b.allow())
+
+		*now = now.Add(time.Minute)
+		assert.True(t, b.allow())
+		assert.True(t, b.success())
+		assert.True(t, b.allow())
+		assert.True(t, b.allow())
+	})
+
+	t.Run("Отмененное пробное обращение не блокирует следующее", func(t *testing.T) {
+		b, now := newTestBreaker()
+		for i := 0; i < 3; i++ {
+			b.failure()
+		}
+
+		*now = now.Add(time.Minute)
+		assert.True(t, b.allow())
+		b.release()
+		assert.True(t, b.allow())
+	})
+}
+
+// TestRedisCacheBreaker тестирует отключение кеша при ошибках Redis
+func TestRedisCacheBreaker(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	c, err := NewRedisCache(RedisOptions{DSN: "redis://" + server.Addr(), BreakerThreshold: 2, BreakerCooldown: time.Minute}, zap.NewNop())
+	require.NoError(t, err)
+	now := time.Now()
+	c.breaker.now = func() time.Time { return now }
+	require.NoError(t, c.Set(ctx, "task:1", 1, time.Hour))
+
+	server.SetError("LOADING Redis is loading the dataset in memory")
+	var v int
+	for i := 0; i < 2; i++ {
+		err := c.Get(ctx, "task:1", &v)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrUnavailable)
+	}
+
+	t.Run("После ошибок чтение и запись не обращаются к Redis", func(t *testing.T) {
+		server.SetError("")
+
+		assert.ErrorIs(t, c.Get(ctx, "task:1", &v), ErrUnavailable)
+		assert.ErrorIs(t, c.Set(ctx, "task:2", 2, time.Hour), ErrUnavailable)
+		_, _, err := c.Lock(ctx, "lock:task:1", time.Second)
+		assert.ErrorIs(t, err, ErrUnavailable)
+		assert.False(t, server.Exists("task:2"))
+	})
+
+	t.Run("Удаление выполняется, даже если кеш отключен", func(t *testing.T) {
+		require.NoError(t, c.Delete(ctx, "task:1"))
+
+		assert.False(t, server.Exists("task:1"))
+	})
+
+	t.Run("После cooldown кеш снова используется", func(t *testing.T) {
+		// Успешное удаление уже закрыло breaker, поэтому снова отключаем кеш
+		server.SetError("LOADING Redis is loading the dataset in memory")
+		for i := 0; i < 2; i++ {
+			_ = c.Get(ctx, "task:1", &v)
+		}
+		server.SetError("")
+		require.ErrorIs(t, c.Get(ctx, "task:1", &v), ErrUnavailable)
+
+		now = now.Add(time.Minute)
+
+		assert.ErrorIs(t, c.Get(ctx, "task:1", &v), ErrMiss)
+		assert.NoError(t, c.Set(ctx, "task:1", 1, time.Hour))
+	})
+
+	t.Run("Промах не считается ошибкой Redis", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			assert.ErrorIs(t, c.Get(ctx, "task:404", &v), ErrMiss)
+		}
+		assert.NoError(t, c.Get(ctx, "task:1", &v))
+	})
+}
@@ -2,11 +2,30 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+var (
+	// ErrMiss ошибка чтения ключа, которого нет в кеше или срок которого истек
+	ErrMiss = errors.New("cache miss")
+	// ErrUnavailable ошибка обращения к кешу, который временно не используется после ошибок хранилища
+	ErrUnavailable = errors.New("cache is unavailable")
+	// ErrAbsent ошибка чтения ключа, отмеченного через SetAbsent: значения нет и в источнике
+	ErrAbsent = errors.New("value is known to be absent")
+)
+
+// Cache интерфейс кеша
+// Get возвращает ErrMiss, если ключа нет, а значение, которое не удалось декодировать, удаляет из кеша.
+// SetWithTags помечает запись тегами, и InvalidateTags удаляет все записи, помеченные любым из тегов,
+// поэтому записи, зависящие от одних данных, удаляются без перечисления их ключей.
+// SetAbsent запоминает, что значения нет в источнике: пока срок отметки не истек, Get возвращает ErrAbsent
+// и источник не запрашивается. Отметка заменяется через Set и удаляется, как обычная запись
 type Cache interface {
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	SetWithTags(ctx context.Context, key string, value interface{}, expiration time.Duration, tags ...string) error
+	SetAbsent(ctx context.Context, key string, expiration time.Duration, tags ...string) error
 	Get(ctx context.Context, key string, dest interface{}) error
 	Delete(ctx context.Context, key string) error
+	InvalidateTags(ctx context.Context, tags ...string) error
 }
@@ -0,0 +1,220 @@
+package cache_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/pers0na2dev/todo-api/pkg/cache"
+	"github.com/pers0na2dev/todo-api/pkg/cache/cachetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeClock управляемый источник времени для MemoryCache
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// memoryHarness функция, которая возвращает реализацию MemoryCache для общего набора тестов
+func memoryHarness(policy cache.EvictionPolicy) cachetest.Harness {
+	clock := &fakeClock{now: time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)}
+	return cachetest.Harness{
+		New: func(t *testing.T) cache.Cache {
+			c, err := cache.NewMemoryCache(cache.MemoryOptions{MaxEntries: 1000, MaxBytes: 1 << 20, Policy: policy})
+			require.NoError(t, err)
+			cache.SetMemoryClock(c, clock.Now)
+			return c
+		},
+		Advance: clock.Advance,
+	}
+}
+
+// TestMemoryCacheConformance проверяет MemoryCache общим набором тестов
+func TestMemoryCacheConformance(t *testing.T) {
+	t.Run("LRU", func(t *testing.T) {
+		cachetest.Run(t, memoryHarness(cache.PolicyLRU))
+	})
+	t.Run("TinyLFU", func(t *testing.T) {
+		cachetest.Run(t, memoryHarness(cache.PolicyTinyLFU))
+	})
+}
+
+// TestMetricsCacheConformance проверяет, что MetricsCache не меняет поведение декорируемого кеша
+func TestMetricsCacheConformance(t *testing.T) {
+	h := memoryHarness(cache.PolicyLRU)
+	cachetest.Run(t, cachetest.Harness{
+		New: func(t *testing.T) cache.Cache {
+			return cache.NewMetricsCache(h.New(t), cache.MetricsOptions{})
+		},
+		Advance: h.Advance,
+	})
+}
+
+// TestRedisCacheConformance проверяет RedisCache общим набором тестов на miniredis во всех форматах
+func TestRedisCacheConformance(t *testing.T) {
+	encodings := map[string]cache.Encoding{
+		"JSON": {},
+		// Порог в 1 байт, чтобы сжимались все значения
+		"MessagePack и zstd": {Codec: cache.MessagePack{}, Compression: cache.CompressionZstd, CompressThreshold: 1},
+		"Gob и snappy":       {Codec: cache.Gob{}, Compression: cache.CompressionSnappy, CompressThreshold: 1},
+	}
+	for name, encoding := range encodings {
+		t.Run(name, func(t *testing.T) {
+			server := miniredis.RunT(t)
+
+			cachetest.Run(t, cachetest.Harness{
+				New: func(t *testing.T) cache.Cache {
+					server.FlushAll()
+					c, err := cache.NewRedisCache(cache.RedisOptions{DSN: "redis://" + server.Addr(), Encoding: encoding}, zap.NewNop())
+					require.NoError(t, err)
+					return c
+				},
+				Advance: server.FastForward,
+			})
+		})
+	}
+}
+
+// TestRedisClusterCacheConformance проверяет RedisCache в режиме Redis Cluster, miniredis отвечает как кластер из одного узла
+func TestRedisClusterCacheConformance(t *testing.T) {
+	server := miniredis.RunT(t)
+
+	cachetest.Run(t, cachetest.Harness{
+		New: func(t *testing.T) cache.Cache {
+			server.FlushAll()
+			c, err := cache.NewRedisCache(cache.RedisOptions{ClusterAddrs: []string{server.Addr()}}, zap.NewNop())
+			require.NoError(t, err)
+			t.Cleanup(func() { c.Close() })
+			return c
+		},
+		Advance: server.FastForward,
+	})
+}
+
+// newTieredCache функция, которая создает двухуровневый кеш поверх miniredis
+func newTieredCache(t *testing.T, server *miniredis.Miniredis, clock *fakeClock) *cache.TieredCache {
+	t.Helper()
+
+	local, err := cache.NewMemoryCache(cache.MemoryOptions{MaxEntries: 1000})
+	require.NoError(t, err)
+	cache.SetMemoryClock(local, clock.Now)
+	remote, err := cache.NewRedisCache(cache.RedisOptions{DSN: "redis://" + server.Addr()}, zap.NewNop())
+	require.NoError(t, err)
+
+	c, err := cache.NewTieredCache(local, remote, cache.TieredOptions{LocalTTL: 5 * time.Second}, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// TestTieredCacheConformance проверяет TieredCache общим набором тестов
+func TestTieredCacheConformance(t *testing.T) {
+	server := miniredis.RunT(t)
+	clock := &fakeClock{now: time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)}
+
+	cachetest.Run(t, cachetest.Harness{
+		New: func(t *testing.T) cache.Cache {
+			server.FlushAll()
+			return newTieredCache(t, server, clock)
+		},
+		Advance: func(d time.Duration) {
+			server.FastForward(d)
+			clock.Advance(d)
+		},
+	})
+}
+
+// TestTieredCache тестирует чтение из L1 и удаление копий на других репликах
+func TestTieredCache(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	clock := &fakeClock{now: time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)}
+	first := newTieredCache(t, server, clock)
+	second := newTieredCache(t, server, clock)
+
+	require.NoError(t, first.Set(ctx, "task:1", "Купить молоко", time.Minute))
+	var v string
+	require.NoError(t, second.Get(ctx, "task:1", &v))
+
+	t.Run("Копия в L1 читается без обращения к Redis", func(t *testing.T) {
+		server.Del("task:1")
+
+		require.NoError(t, second.Get(ctx, "task:1", &v))
+		assert.Equal(t, "Купить молоко", v)
+	})
+
+	t.Run("Копия в L1 живет не дольше LocalTTL", func(t *testing.T) {
+		clock.Advance(6 * time.Second)
+
+		assert.Error(t, second.Get(ctx, "task:1", &v))
+	})
+
+	t.Run("Удаление ключа на одной реплике удаляет копию на другой", func(t *testing.T) {
+		require.NoError(t, first.Set(ctx, "task:2", "Позвонить", time.Minute))
+		require.NoError(t, second.Get(ctx, "task:2", &v))
+
+		require.NoError(t, first.Delete(ctx, "task:2"))
+
+		// Значение возвращается в Redis в обход кеша, чтобы промах на второй реплике означал удаление из L1
+		require.NoError(t, server.Set("task:2", `"Новое"`))
+		assert.Eventually(t, func() bool {
+			return second.Get(ctx, "task:2", &v) == nil && v == "Новое"
+		}, time.Second, 5*time.Millisecond)
+	})
+	t.Run("Удаление по тегу на одной реплике удаляет копии на другой", func(t *testing.T) {
+		require.NoError(t, first.SetWithTags(ctx, "tasks:all", "Список", time.Minute, "tasks"))
+		require.NoError(t, second.Get(ctx, "tasks:all", &v))
+
+		require.NoError(t, first.InvalidateTags(ctx, "tasks"))
+
+		require.NoError(t, server.Set("tasks:all", `"Новый список"`))
+		assert.Eventually(t, func() bool {
+			return second.Get(ctx, "tasks:all", &v) == nil && v == "Новый список"
+		}, time.Second, 5*time.Millisecond)
+	})
+}
+
+// TestRedisCacheTags тестирует срок множеств тегов в Redis
+func TestRedisCacheTags(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	c, err := cache.NewRedisCache(cache.RedisOptions{DSN: "redis://" + server.Addr()}, zap.NewNop())
+	require.NoError(t, err)
+
+	t.Run("Множество тега живет не меньше самой долгой записи", func(t *testing.T) {
+		require.NoError(t, c.SetWithTags(ctx, "tasks:all", 1, time.Hour, "tasks"))
+		require.NoError(t, c.SetWithTags(ctx, "tasks:suggest", 2, time.Minute, "tasks"))
+
+		assert.Equal(t, time.Hour, server.TTL("tag:tasks"))
+	})
+
+	t.Run("Множество тега записи без срока не истекает", func(t *testing.T) {
+		require.NoError(t, c.SetWithTags(ctx, "settings", 1, 0, "tasks"))
+		require.NoError(t, c.SetWithTags(ctx, "tasks:suggest", 2, time.Minute, "tasks"))
+
+		assert.Zero(t, server.TTL("tag:tasks"))
+	})
+
+	t.Run("Удаление по тегу удаляет множество тега", func(t *testing.T) {
+		require.NoError(t, c.InvalidateTags(ctx, "tasks"))
+
+		assert.False(t, server.Exists("tag:tasks"))
+	})
+}
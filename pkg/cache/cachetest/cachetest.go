@@ -0,0 +1,300 @@
+// Package cachetest содержит общий набор тестов, которому должна соответствовать каждая реализация cache.Cache
+package cachetest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pers0na2dev/todo-api/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Harness реализация кеша для проверки набором тестов
+type Harness struct {
+	// New - создает пустой кеш для одной проверки
+	New func(t *testing.T) cache.Cache
+	// Advance - сдвигает время кеша вперед, чтобы проверить истечение срока без ожидания
+	Advance func(d time.Duration)
+}
+
+// value значение, которое сохраняется в кеш в проверках
+type value struct {
+	ID    int      `json:"id"`
+	Title string   `json:"title"`
+	Tags  []string `json:"tags"`
+	DueAt *time.Time
+}
+
+// Run функция, которая проверяет реализацию кеша общим набором тестов
+// @param t *testing.T - тест
+// @param h Harness - реализация кеша
+func Run(t *testing.T, h Harness) {
+	ctx := context.Background()
+
+	t.Run("Записанное значение читается", func(t *testing.T) {
+		c := h.New(t)
+		due := time.Date(2024, 3, 10, 18, 0, 0, 0, time.UTC)
+		stored := value{ID: 1, Title: "Купить молоко", Tags: []string{"дом"}, DueAt: &due}
+
+		require.NoError(t, c.Set(ctx, "task:1", stored, time.Minute))
+
+		var got value
+		require.NoError(t, c.Get(ctx, "task:1", &got))
+		assert.Equal(t, stored.ID, got.ID)
+		assert.Equal(t, stored.Title, got.Title)
+		assert.Equal(t, stored.Tags, got.Tags)
+		require.NotNil(t, got.DueAt)
+		assert.True(t, stored.DueAt.Equal(*got.DueAt))
+	})
+
+	t.Run("Чтение отсутствующего ключа возвращает ErrMiss", func(t *testing.T) {
+		c := h.New(t)
+
+		var got value
+		assert.ErrorIs(t, c.Get(ctx, "task:404", &got), cache.ErrMiss)
+	})
+
+	t.Run("Значение, которое не удалось декодировать, удаляется", func(t *testing.T) {
+		c := h.New(t)
+		require.NoError(t, c.Set(ctx, "task:1", "не задача", time.Minute))
+
+		var got value
+		err := c.Get(ctx, "task:1", &got)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, cache.ErrMiss)
+
+		var raw string
+		assert.ErrorIs(t, c.Get(ctx, "task:1", &raw), cache.ErrMiss)
+	})
+
+	t.Run("Повторная запись заменяет значение", func(t *testing.T) {
+		c := h.New(t)
+
+		require.NoError(t, c.Set(ctx, "task:1", value{Title: "Первое"}, time.Minute))
+		require.NoError(t, c.Set(ctx, "task:1", value{Title: "Второе"}, time.Minute))
+
+		var got value
+		require.NoError(t, c.Get(ctx, "task:1", &got))
+		assert.Equal(t, "Второе", got.Title)
+	})
+
+	t.Run("Удаленный ключ не читается, удаление отсутствующего ключа не ошибка", func(t *testing.T) {
+		c := h.New(t)
+		require.NoError(t, c.Set(ctx, "task:1", value{ID: 1}, time.Minute))
+
+		require.NoError(t, c.Delete(ctx, "task:1"))
+		require.NoError(t, c.Delete(ctx, "task:1"))
+
+		var got value
+		assert.ErrorIs(t, c.Get(ctx, "task:1", &got), cache.ErrMiss)
+	})
+
+	t.Run("Значение недоступно после истечения срока", func(t *testing.T) {
+		c := h.New(t)
+		require.NoError(t, c.Set(ctx, "short", value{ID: 1}, time.Second))
+		require.NoError(t, c.Set(ctx, "long", value{ID: 2}, time.Hour))
+		require.NoError(t, c.Set(ctx, "forever", value{ID: 3}, 0))
+
+		h.Advance(2 * time.Second)
+
+		var got value
+		assert.ErrorIs(t, c.Get(ctx, "short", &got), cache.ErrMiss)
+		assert.NoError(t, c.Get(ctx, "long", &got))
+		assert.NoError(t, c.Get(ctx, "forever", &got))
+	})
+
+	t.Run("Изменение прочитанного значения не меняет значение в кеше", func(t *testing.T) {
+		c := h.New(t)
+		require.NoError(t, c.Set(ctx, "task:1", value{Tags: []string{"дом"}}, time.Minute))
+
+		var first value
+		require.NoError(t, c.Get(ctx, "task:1", &first))
+		first.Tags[0] = "работа"
+
+		var second value
+		require.NoError(t, c.Get(ctx, "task:1", &second))
+		assert.Equal(t, []string{"дом"}, second.Tags)
+	})
+
+	t.Run("Значение, которое нельзя закодировать, возвращает ошибку", func(t *testing.T) {
+		c := h.New(t)
+
+		assert.Error(t, c.Set(ctx, "bad", make(chan int), time.Minute))
+	})
+
+	t.Run("Значения читаются в срезы и отображения", func(t *testing.T) {
+		c := h.New(t)
+		require.NoError(t, c.Set(ctx, "tasks:all", []value{{ID: 1}, {ID: 2}}, time.Minute))
+		require.NoError(t, c.Set(ctx, "counts", map[string]int{"open": 3}, time.Minute))
+
+		var list []value
+		require.NoError(t, c.Get(ctx, "tasks:all", &list))
+		assert.Len(t, list, 2)
+
+		var counts map[string]int
+		require.NoError(t, c.Get(ctx, "counts", &counts))
+		assert.Equal(t, map[string]int{"open": 3}, counts)
+	})
+
+	t.Run("Удаление по тегу удаляет все записи с любым из тегов", func(t *testing.T) {
+		c := h.New(t)
+		require.NoError(t, c.SetWithTags(ctx, "task:1", value{ID: 1}, time.Minute, "task:1", "tasks"))
+		require.NoError(t, c.SetWithTags(ctx, "task:2", value{ID: 2}, time.Minute, "task:2", "tasks"))
+		require.NoError(t, c.SetWithTags(ctx, "tasks:all", []value{{ID: 1}, {ID: 2}}, time.Minute, "tasks"))
+		require.NoError(t, c.SetWithTags(ctx, "projects:all", []value{}, time.Minute, "projects"))
+		require.NoError(t, c.Set(ctx, "untagged", value{}, time.Minute))
+
+		require.NoError(t, c.InvalidateTags(ctx, "task:1"))
+
+		var got value
+		assert.ErrorIs(t, c.Get(ctx, "task:1", &got), cache.ErrMiss)
+		assert.NoError(t, c.Get(ctx, "task:2", &got))
+
+		require.NoError(t, c.InvalidateTags(ctx, "tasks", "unknown"))
+
+		var list []value
+		assert.ErrorIs(t, c.Get(ctx, "task:2", &got), cache.ErrMiss)
+		assert.ErrorIs(t, c.Get(ctx, "tasks:all", &list), cache.ErrMiss)
+		assert.NoError(t, c.Get(ctx, "projects:all", &list))
+		assert.NoError(t, c.Get(ctx, "untagged", &got))
+	})
+
+	t.Run("Запись, помеченная после удаления по тегу, удаляется следующим удалением", func(t *testing.T) {
+		c := h.New(t)
+		require.NoError(t, c.SetWithTags(ctx, "tasks:all", []value{}, time.Minute, "tasks"))
+		require.NoError(t, c.InvalidateTags(ctx, "tasks"))
+		require.NoError(t, c.SetWithTags(ctx, "tasks:all", []value{{ID: 1}}, time.Minute, "tasks"))
+
+		var list []value
+		require.NoError(t, c.Get(ctx, "tasks:all", &list))
+		require.NoError(t, c.InvalidateTags(ctx, "tasks"))
+		assert.ErrorIs(t, c.Get(ctx, "tasks:all", &list), cache.ErrMiss)
+	})
+
+	t.Run("Отметка об отсутствии читается как ErrAbsent до истечения срока", func(t *testing.T) {
+		c := h.New(t)
+		require.NoError(t, c.SetAbsent(ctx, "task:404", time.Second))
+
+		var got value
+		err := c.Get(ctx, "task:404", &got)
+		assert.ErrorIs(t, err, cache.ErrAbsent)
+		assert.NotErrorIs(t, err, cache.ErrMiss)
+
+		h.Advance(2 * time.Second)
+		assert.ErrorIs(t, c.Get(ctx, "task:404", &got), cache.ErrMiss)
+	})
+
+	t.Run("Отметка об отсутствии заменяется значением и удаляется по тегу", func(t *testing.T) {
+		c := h.New(t)
+		require.NoError(t, c.SetAbsent(ctx, "task:1", time.Minute))
+		require.NoError(t, c.Set(ctx, "task:1", value{ID: 1}, time.Minute))
+
+		var got value
+		require.NoError(t, c.Get(ctx, "task:1", &got))
+		assert.Equal(t, 1, got.ID)
+
+		require.NoError(t, c.SetAbsent(ctx, "task:2", time.Minute, "task:2"))
+		require.NoError(t, c.InvalidateTags(ctx, "task:2"))
+		assert.ErrorIs(t, c.Get(ctx, "task:2", &got), cache.ErrMiss)
+	})
+
+	t.Run("Запись просматривается вместе со сроком и значением", func(t *testing.T) {
+		c := h.New(t)
+		inspector, ok := c.(cache.Inspector)
+		if !ok {
+			t.Skip("кеш не реализует cache.Inspector")
+		}
+		require.NoError(t, c.Set(ctx, "task:1", value{ID: 1, Title: "Купить молоко"}, time.Minute))
+		require.NoError(t, c.Set(ctx, "tasks:all", []value{{ID: 1}}, 0))
+		require.NoError(t, c.SetAbsent(ctx, "task:404", time.Minute))
+
+		entry, err := inspector.Inspect(ctx, "task:1")
+		require.NoError(t, err)
+		assert.Equal(t, "task:1", entry.Key)
+		assert.InDelta(t, time.Minute, entry.TTL, float64(time.Second))
+		assert.Positive(t, entry.Size)
+		assert.False(t, entry.Absent)
+		// Значения в формате без представления в JSON, например gob, возвращаются без Value
+		if entry.Value != nil {
+			var got value
+			require.NoError(t, json.Unmarshal(entry.Value, &got))
+			assert.Equal(t, "Купить молоко", got.Title)
+		}
+
+		absent, err := inspector.Inspect(ctx, "task:404")
+		require.NoError(t, err)
+		assert.True(t, absent.Absent)
+		assert.Nil(t, absent.Value)
+
+		_, err = inspector.Inspect(ctx, "task:405")
+		assert.ErrorIs(t, err, cache.ErrMiss)
+
+		infos, err := inspector.Keys(ctx, "task:", 10)
+		require.NoError(t, err)
+		require.Len(t, infos, 2)
+		assert.Equal(t, "task:1", infos[0].Key)
+		assert.Equal(t, "task:404", infos[1].Key)
+		assert.True(t, infos[1].Absent)
+
+		infos, err = inspector.Keys(ctx, "tasks:", 10)
+		require.NoError(t, err)
+		require.Len(t, infos, 1)
+		assert.Zero(t, infos[0].TTL)
+
+		infos, err = inspector.Keys(ctx, "task", 1)
+		require.NoError(t, err)
+		assert.Len(t, infos, 1)
+	})
+
+	t.Run("Удаление по префиксу удаляет только записи с префиксом", func(t *testing.T) {
+		c := h.New(t)
+		inspector, ok := c.(cache.Inspector)
+		if !ok {
+			t.Skip("кеш не реализует cache.Inspector")
+		}
+		for _, key := range []string{"task:1", "task:2", "task:[3]", "tasks:all"} {
+			require.NoError(t, c.SetWithTags(ctx, key, value{ID: 1}, time.Minute, "tasks"))
+		}
+
+		// Спецсимволы шаблонов в префиксе сравниваются как обычные символы
+		flushed, err := inspector.FlushPrefix(ctx, "task:[")
+		require.NoError(t, err)
+		assert.Equal(t, 1, flushed)
+
+		flushed, err = inspector.FlushPrefix(ctx, "task:")
+		require.NoError(t, err)
+		assert.Equal(t, 2, flushed)
+
+		var got value
+		assert.ErrorIs(t, c.Get(ctx, "task:1", &got), cache.ErrMiss)
+		assert.ErrorIs(t, c.Get(ctx, "task:[3]", &got), cache.ErrMiss)
+		require.NoError(t, c.Get(ctx, "tasks:all", &got))
+	})
+
+	t.Run("Параллельные чтения и записи", func(t *testing.T) {
+		c := h.New(t)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					key := fmt.Sprintf("task:%d", j%10)
+					assert.NoError(t, c.Set(ctx, key, value{ID: i*100 + j}, time.Minute))
+					var got value
+					_ = c.Get(ctx, key, &got)
+					if j%7 == 0 {
+						assert.NoError(t, c.Delete(ctx, key))
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+	})
+}
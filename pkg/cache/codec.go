@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Номера встроенных кодеков в префиксе значения
+const (
+	jsonCodecID    byte = 1
+	msgpackCodecID byte = 2
+	gobCodecID     byte = 3
+)
+
+// Codec интерфейс кодека, которым значения кодируются перед записью в кеш
+type Codec interface {
+	// ID возвращает номер кодека, который записывается в префикс значения. По нему значение декодируется
+	// и после смены кодека, номера 1-15 заняты встроенными кодеками
+	ID() byte
+	// Marshal кодирует значение
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal декодирует значение в v, v должен быть указателем
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// CodecByName функция, которая возвращает встроенный кодек по имени
+// @param name string - имя кодека: json, msgpack или gob
+// @return Codec - кодек
+// @return error - ошибка, если кодек неизвестен
+func CodecByName(name string) (Codec, error) {
+	switch name {
+	case "", "json":
+		return JSON{}, nil
+	case "msgpack":
+		return MessagePack{}, nil
+	case "gob":
+		return Gob{}, nil
+	default:
+		return nil, fmt.Errorf("unknown cache codec %q", name)
+	}
+}
+
+// builtinCodec функция, которая возвращает встроенный кодек по номеру
+// @param id byte - номер кодека
+// @return Codec - кодек
+// @return bool - false, если номер неизвестен
+func builtinCodec(id byte) (Codec, bool) {
+	switch id {
+	case jsonCodecID:
+		return JSON{}, true
+	case msgpackCodecID:
+		return MessagePack{}, true
+	case gobCodecID:
+		return Gob{}, true
+	default:
+		return nil, false
+	}
+}
+
+// JSON кодек encoding/json
+type JSON struct{}
+
+// ID функция, которая возвращает номер кодека JSON
+func (JSON) ID() byte {
+	return jsonCodecID
+}
+
+// Marshal функция, которая кодирует значение в JSON
+func (JSON) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal функция, которая декодирует JSON
+func (JSON) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// MessagePack кодек MessagePack, он компактнее и быстрее JSON на больших списках
+// Имена полей берутся из тегов json, поэтому структура данных совпадает с JSON
+type MessagePack struct{}
+
+// ID функция, которая возвращает номер кодека MessagePack
+func (MessagePack) ID() byte {
+	return msgpackCodecID
+}
+
+// Marshal функция, которая кодирует значение в MessagePack
+func (MessagePack) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := msgpack.NewEncoder(&buf)
+	encoder.SetCustomStructTag("json")
+	encoder.UseCompactInts(true)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal функция, которая декодирует MessagePack
+func (MessagePack) Unmarshal(data []byte, v interface{}) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.SetCustomStructTag("json")
+	return decoder.Decode(v)
+}
+
+// Gob кодек encoding/gob
+// Gob не кодирует поля-интерфейсы без регистрации типов и игнорирует теги json
+type Gob struct{}
+
+// ID функция, которая возвращает номер кодека Gob
+func (Gob) ID() byte {
+	return gobCodecID
+}
+
+// Marshal функция, которая кодирует значение в gob
+func (Gob) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal функция, которая декодирует gob
+func (Gob) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression сжатие значений кеша
+type Compression string
+
+const (
+	// CompressionNone - значения не сжимаются
+	CompressionNone Compression = "none"
+	// CompressionZstd - сжатие zstd, лучше сжимает большие списки
+	CompressionZstd Compression = "zstd"
+	// CompressionSnappy - сжатие snappy, сжимает хуже zstd, но быстрее
+	CompressionSnappy Compression = "snappy"
+)
+
+// DefaultCompressThreshold - минимальный размер закодированного значения в байтах, которое сжимается
+const DefaultCompressThreshold = 1024
+
+// formatVersion - версия формата значения. Значение начинается с байта версии, за ним следуют номер кодека,
+// номер сжатия и данные. Байт версии не может быть первым байтом JSON, поэтому значения, записанные
+// до появления префикса, читаются как JSON
+const formatVersion byte = 1
+
+// headerSize - размер префикса значения
+const headerSize = 3
+
+// absentValue - отметка SetAbsent. Номер кодека 0 не занят, поэтому отметка не совпадает ни с одним значением
+var absentValue = []byte{formatVersion, 0, noCompressionID}
+
+// Номера сжатия в префиксе значения
+const (
+	noCompressionID     byte = 0
+	zstdCompressionID   byte = 1
+	snappyCompressionID byte = 2
+)
+
+var (
+	// zstdEncoder и zstdDecoder - общие для всех кешей, EncodeAll и DecodeAll безопасны для одновременного вызова
+	zstdEncoder = sync.OnceValue(func() *zstd.Encoder {
+		encoder, _ := zstd.NewWriter(nil)
+		return encoder
+	})
+	zstdDecoder = sync.OnceValue(func() *zstd.Decoder {
+		decoder, _ := zstd.NewReader(nil)
+		return decoder
+	})
+)
+
+// Encoding формат, в котором значения хранятся в кеше
+// Значение читается по своему префиксу, поэтому кодек и сжатие можно сменить без очистки кеша:
+// старые значения читаются, пока не истечет их срок
+type Encoding struct {
+	// Codec - кодек, по умолчанию JSON
+	Codec Codec
+	// Compression - сжатие, по умолчанию CompressionNone
+	Compression Compression
+	// CompressThreshold - минимальный размер закодированного значения для сжатия, по умолчанию DefaultCompressThreshold
+	CompressThreshold int
+}
+
+// isAbsent функция, которая проверяет, является ли значение отметкой SetAbsent
+func isAbsent(data []byte) bool {
+	return bytes.Equal(data, absentValue)
+}
+
+// encodedCache интерфейс кешей, которые хранят значения в настраиваемом формате
+type encodedCache interface {
+	Encoding() Encoding
+}
+
+// withDefaults функция, которая заполняет параметры по умолчанию и проверяет сжатие
+// @return Encoding - формат с заполненными параметрами
+// @return error - ошибка, если сжатие неизвестно
+func (e Encoding) withDefaults() (Encoding, error) {
+	if e.Codec == nil {
+		e.Codec = JSON{}
+	}
+	if e.Compression == "" {
+		e.Compression = CompressionNone
+	}
+	if e.CompressThreshold <= 0 {
+		e.CompressThreshold = DefaultCompressThreshold
+	}
+
+	switch e.Compression {
+	case CompressionNone, CompressionZstd, CompressionSnappy:
+		return e, nil
+	default:
+		return Encoding{}, fmt.Errorf("unknown cache compression %q", e.Compression)
+	}
+}
+
+// marshal функция, которая кодирует значение и сжимает его, если оно не меньше CompressThreshold
+// @param v interface{} - значение
+// @return []byte - значение с префиксом формата
+// @return error - ошибка кодирования
+func (e Encoding) marshal(v interface{}) ([]byte, error) {
+	data, err := e.Codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	compression := noCompressionID
+	if len(data) >= e.CompressThreshold {
+		switch e.Compression {
+		case CompressionZstd:
+			data, compression = zstdEncoder().EncodeAll(data, nil), zstdCompressionID
+		case CompressionSnappy:
+			data, compression = snappy.Encode(nil, data), snappyCompressionID
+		}
+	}
+
+	out := make([]byte, 0, headerSize+len(data))
+	out = append(out, formatVersion, e.Codec.ID(), compression)
+	return append(out, data...), nil
+}
+
+// unmarshal функция, которая декодирует значение по его префиксу
+// Значения без префикса читаются как JSON
+// @param data []byte - значение
+// @param v interface{} - указатель, в который декодируется значение
+// @return error - ошибка декодирования
+func (e Encoding) unmarshal(data []byte, v interface{}) error {
+	if len(data) == 0 || data[0] != formatVersion {
+		return json.Unmarshal(data, v)
+	}
+	if len(data) < headerSize {
+		return errors.New("truncated cache value header")
+	}
+
+	codec, ok := e.codec(data[1])
+	if !ok {
+		return fmt.Errorf("unknown cache codec %d", data[1])
+	}
+
+	payload := data[headerSize:]
+	switch data[2] {
+	case noCompressionID:
+	case zstdCompressionID:
+		var err error
+		if payload, err = zstdDecoder().DecodeAll(payload, nil); err != nil {
+			return fmt.Errorf("failed to decompress value: %w", err)
+		}
+	case snappyCompressionID:
+		var err error
+		if payload, err = snappy.Decode(nil, payload); err != nil {
+			return fmt.Errorf("failed to decompress value: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown cache compression %d", data[2])
+	}
+
+	return codec.Unmarshal(payload, v)
+}
+
+// marshalNested функция, которая кодирует значение, вложенное в другое значение кеша, например в конверт Loader
+// Вложенное значение не сжимается, так как сжимается внешнее, а JSON записывается без префикса,
+// чтобы оставаться корректным JSON внутри JSON
+// @param v interface{} - значение
+// @return []byte - закодированное значение, которое читается через unmarshal
+// @return error - ошибка кодирования
+func (e Encoding) marshalNested(v interface{}) ([]byte, error) {
+	if e.Codec.ID() == jsonCodecID {
+		return e.Codec.Marshal(v)
+	}
+	return Encoding{Codec: e.Codec, Compression: CompressionNone}.marshal(v)
+}
+
+// codec функция, которая возвращает кодек по номеру из префикса
+// @param id byte - номер кодека
+// @return Codec - настроенный кодек, если номер совпадает, иначе встроенный
+// @return bool - false, если номер неизвестен
+func (e Encoding) codec(id byte) (Codec, bool) {
+	if e.Codec != nil && e.Codec.ID() == id {
+		return e.Codec, true
+	}
+	return builtinCodec(id)
+}
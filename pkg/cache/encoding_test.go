@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// TestEncoding тестирует формат значений кеша
+func TestEncoding(t *testing.T) {
+	type task struct {
+		ID    int    `json:"id"`
+		Title string `json:"title"`
+	}
+	newEncoding := func(t *testing.T, e Encoding) Encoding {
+		t.Helper()
+		e, err := e.withDefaults()
+		require.NoError(t, err)
+		return e
+	}
+
+	t.Run("Значение начинается с префикса версии, кодека и сжатия", func(t *testing.T) {
+		e := newEncoding(t, Encoding{Codec: MessagePack{}, Compression: CompressionZstd})
+
+		data, err := e.marshal(task{ID: 1})
+		require.NoError(t, err)
+
+		assert.Equal(t, []byte{formatVersion, msgpackCodecID, noCompressionID}, data[:headerSize])
+	})
+
+	t.Run("Сжимаются только значения не меньше порога", func(t *testing.T) {
+		e := newEncoding(t, Encoding{Compression: CompressionZstd, CompressThreshold: 100})
+		long := task{Title: strings.Repeat("Купить молоко ", 100)}
+
+		data, err := e.marshal(long)
+		require.NoError(t, err)
+		assert.Equal(t, zstdCompressionID, data[2])
+		assert.Less(t, len(data), len(long.Title))
+
+		var got task
+		require.NoError(t, e.unmarshal(data, &got))
+		assert.Equal(t, long, got)
+	})
+
+	t.Run("После смены кодека и сжатия старые значения читаются", func(t *testing.T) {
+		old := newEncoding(t, Encoding{Codec: Gob{}, Compression: CompressionSnappy, CompressThreshold: 1})
+		data, err := old.marshal(task{ID: 1, Title: "Купить молоко"})
+		require.NoError(t, err)
+
+		var got task
+		require.NoError(t, newEncoding(t, Encoding{}).unmarshal(data, &got))
+		assert.Equal(t, task{ID: 1, Title: "Купить молоко"}, got)
+	})
+
+	t.Run("Значения без префикса читаются как JSON", func(t *testing.T) {
+		e := newEncoding(t, Encoding{Codec: MessagePack{}})
+
+		var got task
+		require.NoError(t, e.unmarshal([]byte(`{"id":1,"title":"Купить молоко"}`), &got))
+		assert.Equal(t, task{ID: 1, Title: "Купить молоко"}, got)
+	})
+
+	t.Run("Неизвестный формат не декодируется", func(t *testing.T) {
+		e := newEncoding(t, Encoding{})
+		var got task
+
+		assert.Error(t, e.unmarshal([]byte{formatVersion, 42, noCompressionID, 0}, &got))
+		assert.Error(t, e.unmarshal([]byte{formatVersion, jsonCodecID, 42, '{', '}'}, &got))
+		assert.Error(t, e.unmarshal([]byte{formatVersion, jsonCodecID}, &got))
+	})
+
+	t.Run("Неизвестные кодек и сжатие", func(t *testing.T) {
+		_, err := CodecByName("xml")
+		assert.Error(t, err)
+
+		_, err = Encoding{Compression: "lz4"}.withDefaults()
+		assert.Error(t, err)
+	})
+
+	t.Run("Значения Loader кодируются форматом кеша", func(t *testing.T) {
+		ctx := context.Background()
+		server := miniredis.RunT(t)
+		newLoader := func(e Encoding) *Loader {
+			c, err := NewRedisCache(RedisOptions{DSN: "redis://" + server.Addr(), Encoding: e}, zap.NewNop())
+			require.NoError(t, err)
+			return NewLoader(c, LoaderOptions{}, zap.NewNop())
+		}
+		msgpackLoader := newLoader(Encoding{Codec: MessagePack{}, Compression: CompressionZstd, CompressThreshold: 1})
+
+		var got []task
+		require.NoError(t, msgpackLoader.Fetch(ctx, "tasks:all", &got, time.Minute, func(ctx context.Context) (interface{}, error) {
+			return []task{{ID: 1, Title: "Купить молоко"}}, nil
+		}))
+		stored, err := server.Get("tasks:all")
+		require.NoError(t, err)
+		assert.Equal(t, []byte{formatVersion, msgpackCodecID, zstdCompressionID}, []byte(stored[:headerSize]))
+
+		// Реплика с JSON читает значение, записанное репликой с MessagePack
+		got = nil
+		require.NoError(t, newLoader(Encoding{}).Get(ctx, "tasks:all", &got))
+		assert.Equal(t, []task{{ID: 1, Title: "Купить молоко"}}, got)
+	})
+}
@@ -0,0 +1,8 @@
+package cache
+
+import "time"
+
+// SetMemoryClock функция, которая заменяет источник времени MemoryCache в тестах
+func SetMemoryClock(c *MemoryCache, now func() time.Time) {
+	c.now = now
+}
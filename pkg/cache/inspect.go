@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Inspector интерфейс кешей, записи которых можно просматривать и удалять по префиксу ключа
+type Inspector interface {
+	// Inspect возвращает запись со значением, ErrMiss если ключа нет
+	Inspect(ctx context.Context, key string) (Entry, error)
+	// Keys возвращает не больше limit записей, ключи которых начинаются с prefix
+	Keys(ctx context.Context, prefix string, limit int) ([]EntryInfo, error)
+	// FlushPrefix удаляет все записи, ключи которых начинаются с prefix, и возвращает их количество
+	FlushPrefix(ctx context.Context, prefix string) (int, error)
+}
+
+// EntryInfo сведения о записи кеша
+type EntryInfo struct {
+	Key string
+	// TTL - оставшийся срок записи, 0 - без срока
+	TTL time.Duration
+	// Size - размер закодированного значения в байтах
+	Size int
+	// Absent - запись является отметкой SetAbsent
+	Absent bool
+}
+
+// Entry запись кеша вместе со значением
+type Entry struct {
+	EntryInfo
+	// Value - значение в JSON, nil если его нельзя представить в JSON, например значение в формате gob
+	Value json.RawMessage
+}
+
+// newEntry функция, которая создает запись по закодированному значению
+// @param key string - ключ
+// @param data []byte - закодированное значение
+// @param ttl time.Duration - оставшийся срок, 0 - без срока
+// @param e Encoding - формат значения
+// @return Entry - запись
+func newEntry(key string, data []byte, ttl time.Duration, e Encoding) Entry {
+	entry := Entry{EntryInfo: EntryInfo{Key: key, TTL: ttl, Size: len(data), Absent: isAbsent(data)}}
+	if !entry.Absent {
+		entry.Value = e.toJSON(data)
+	}
+	return entry
+}
+
+// toJSON функция, которая декодирует значение в JSON для просмотра
+// Значения в JSON возвращаются как есть, остальные декодируются в interface{} и кодируются в JSON
+// @param data []byte - закодированное значение
+// @return json.RawMessage - значение в JSON, nil если его не удалось декодировать
+func (e Encoding) toJSON(data []byte) json.RawMessage {
+	var raw json.RawMessage
+	if err := e.unmarshal(data, &raw); err == nil && json.Valid(raw) {
+		return raw
+	}
+
+	var v interface{}
+	if err := e.unmarshal(data, &v); err != nil {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
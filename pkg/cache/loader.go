@@ -0,0 +1,386 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// DefaultLockTTL - время жизни блокировки загрузки ключа, после которого блокировку может взять другая реплика
+	DefaultLockTTL = 10 * time.Second
+	// DefaultLockWait - время, в течение которого реплика без блокировки ждет значение, загруженное другой репликой
+	DefaultLockWait = 2 * time.Second
+	// DefaultRefreshTimeout - максимальное время загрузки значения
+	DefaultRefreshTimeout = 10 * time.Second
+	// lockPollInterval - интервал проверки кеша при ожидании значения другой реплики
+	lockPollInterval = 20 * time.Millisecond
+	// lockKeyPrefix - префикс ключей блокировок загрузки
+	lockKeyPrefix = "lock:"
+)
+
+// errLocked ошибка фонового обновления, которое уже выполняет другая реплика
+var errLocked = errors.New("key is being refreshed by another replica")
+
+// Locker интерфейс, который реализуют кеши с блокировками, общими для всех реплик
+type Locker interface {
+	// Lock - пытается взять блокировку без ожидания. unlock снимает блокировку, если она еще принадлежит вызывающему
+	Lock(ctx context.Context, key string, ttl time.Duration) (unlock func(), acquired bool, err error)
+}
+
+// LoaderOptions параметры Loader
+type LoaderOptions struct {
+	// StaleTTL - время после истечения свежести, в течение которого отдается устаревшее значение, пока одна
+	// из реплик загружает новое. 0 - устаревшие значения не отдаются
+	StaleTTL time.Duration
+	// Beta - коэффициент вероятностного раннего обновления (XFetch): чем он больше, тем раньше до истечения
+	// свежести значение обновляется в фоне. 0 - раннее обновление отключено
+	Beta float64
+	// LockTTL - время жизни блокировки загрузки, по умолчанию DefaultLockTTL
+	LockTTL time.Duration
+	// LockWait - время ожидания значения, загружаемого другой репликой, по умолчанию DefaultLockWait
+	LockWait time.Duration
+	// RefreshTimeout - максимальное время загрузки значения, по умолчанию DefaultRefreshTimeout
+	RefreshTimeout time.Duration
+	// AbsentTTL - срок отметки об отсутствии значения, если загрузка вернула ErrAbsent.
+	// 0 - отсутствие не кешируется
+	AbsentTTL time.Duration
+}
+
+// envelope значение в кеше вместе со сроком свежести
+// Value закодировано форматом кеша, поэтому при смене кодека меняется и формат самого значения
+type envelope struct {
+	Value json.RawMessage `json:"v"`
+	// FreshUntil - время, до которого значение считается свежим
+	FreshUntil time.Time `json:"f"`
+	// Delta - время загрузки значения, от которого зависит раннее обновление
+	Delta time.Duration `json:"d"`
+}
+
+// Loader структура, которая читает значения из кеша и загружает отсутствующие без лавины запросов
+// Одновременные промахи по одному ключу в процессе объединяются в одну загрузку, а между репликами -
+// блокировкой в кеше. Устаревшее значение отдается, пока его обновляет одна из реплик, а популярные ключи
+// обновляются немного раньше срока со случайным сдвигом, чтобы не истекать одновременно
+type Loader struct {
+	cache    Cache
+	locker   Locker
+	encoding Encoding
+	opts     LoaderOptions
+	logger   *zap.Logger
+	group    singleflight.Group
+
+	// now и random - источники времени и случайных чисел, заменяются в тестах
+	now    func() time.Time
+	random func() float64
+}
+
+// NewLoader функция, которая создает новый экземпляр Loader
+// Если кеш реализует Locker, загрузка ключа блокируется для всех реплик, иначе только внутри процесса.
+// Значения кодируются форматом кеша, если он настраивается, иначе в JSON
+// @param c Cache - кеш
+// @param opts LoaderOptions - параметры
+// @param logger *zap.Logger - логгер
+// @return *Loader - новый экземпляр Loader
+func NewLoader(c Cache, opts LoaderOptions, logger *zap.Logger) *Loader {
+	if opts.LockTTL <= 0 {
+		opts.LockTTL = DefaultLockTTL
+	}
+	if opts.LockWait <= 0 {
+		opts.LockWait = DefaultLockWait
+	}
+	if opts.RefreshTimeout <= 0 {
+		opts.RefreshTimeout = DefaultRefreshTimeout
+	}
+
+	locker, _ := c.(Locker)
+	encoding := Encoding{Codec: JSON{}}
+	if encoded, ok := c.(encodedCache); ok {
+		encoding = encoded.Encoding()
+	}
+	return &Loader{
+		cache:    c,
+		locker:   locker,
+		encoding: encoding,
+		opts:     opts,
+		logger:   logger,
+		now:      time.Now,
+		random:   rand.Float64,
+	}
+}
+
+// Fetch функция, которая возвращает значение из кеша или загружает его через load
+// @param ctx context.Context - контекст выполнения
+// @param key string - ключ
+// @param dest interface{} - указатель, в который декодируется значение
+// @param ttl time.Duration - время свежести значения
+// @param load func(ctx context.Context) (interface{}, error) - загрузка значения из источника, возвращает
+// ErrAbsent, если значения нет в источнике
+// @param tags ...string - теги, по которым загруженное значение удаляется через InvalidateTags
+// @return error - ошибка загрузки или декодирования, ErrAbsent если значения нет в источнике
+func (l *Loader) Fetch(ctx context.Context, key string, dest interface{}, ttl time.Duration, load func(ctx context.Context) (interface{}, error), tags ...string) error {
+	env, err := l.get(ctx, key)
+	switch {
+	case err == nil:
+		if err := l.decode(ctx, key, env, dest); err == nil {
+			if l.needsRefresh(env) {
+				l.refresh(ctx, key, ttl, load, tags)
+			}
+			return nil
+		}
+		// Значение, которое не удалось декодировать, удалено, загружаем новое
+	case errors.Is(err, ErrAbsent):
+		return err
+	case !errors.Is(err, ErrMiss):
+		l.warn("failed to read cache", key, err)
+	}
+
+	ch := l.group.DoChan(key, func() (interface{}, error) {
+		// Загрузка не прерывается, если отменен запрос первого вызывающего: ее результат ждут и другие
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), l.opts.RefreshTimeout)
+		defer cancel()
+		return l.loadLocked(loadCtx, key, ttl, load, tags, true)
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return res.Err
+		}
+		return l.decodeValue(res.Val.(json.RawMessage), dest)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Get функция, которая читает значение, записанное через Loader, без загрузки
+// @param ctx context.Context - контекст выполнения
+// @param key string - ключ
+// @param dest interface{} - указатель, в который декодируется значение
+// @return error - ошибка, ErrMiss при отсутствии ключа
+func (l *Loader) Get(ctx context.Context, key string, dest interface{}) error {
+	env, err := l.get(ctx, key)
+	if err != nil {
+		return err
+	}
+	return l.decode(ctx, key, env, dest)
+}
+
+// Set функция, которая записывает значение в формате Loader
+// @param ctx context.Context - контекст выполнения
+// @param key string - ключ
+// @param value interface{} - значение
+// @param ttl time.Duration - время свежести значения
+// @param tags ...string - теги значения
+// @return error - ошибка
+func (l *Loader) Set(ctx context.Context, key string, value interface{}, ttl time.Duration, tags ...string) error {
+	raw, err := l.encoding.marshalNested(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal value: %w", err)
+	}
+	return l.set(ctx, key, raw, ttl, 0, tags)
+}
+
+// SetAbsent функция, которая отмечает, что значения нет в источнике, на AbsentTTL
+// @param ctx context.Context - контекст выполнения
+// @param key string - ключ
+// @param tags ...string - теги отметки, по ним она удаляется, когда значение появляется в источнике
+// @return error - ошибка
+func (l *Loader) SetAbsent(ctx context.Context, key string, tags ...string) error {
+	if l.opts.AbsentTTL <= 0 {
+		return nil
+	}
+	return l.cache.SetAbsent(ctx, key, l.opts.AbsentTTL, tags...)
+}
+
+// get функция, которая читает значение вместе со сроком свежести
+// Значения, записанные не через Loader, считаются промахом
+func (l *Loader) get(ctx context.Context, key string) (envelope, error) {
+	var env envelope
+	if err := l.cache.Get(ctx, key, &env); err != nil {
+		return envelope{}, err
+	}
+	if len(env.Value) == 0 {
+		return envelope{}, fmt.Errorf("%w for key %s", ErrMiss, key)
+	}
+	return env, nil
+}
+
+// decode функция, которая декодирует значение и удаляет его из кеша, если декодировать не удалось
+func (l *Loader) decode(ctx context.Context, key string, env envelope, dest interface{}) error {
+	err := l.decodeValue(env.Value, dest)
+	if err == nil {
+		return nil
+	}
+
+	l.logger.Warn("deleting undecodable cache entry", zap.String("key", key), zap.Error(err))
+	if err := l.cache.Delete(ctx, key); err != nil {
+		l.logger.Warn("failed to delete undecodable cache entry", zap.String("key", key), zap.Error(err))
+	}
+	return err
+}
+
+// set функция, которая записывает значение вместе со сроком свежести
+// Запись хранится в кеше дольше свежести на StaleTTL, чтобы устаревшее значение можно было отдать во время обновления
+func (l *Loader) set(ctx context.Context, key string, raw json.RawMessage, ttl, delta time.Duration, tags []string) error {
+	env := envelope{Value: raw, FreshUntil: l.now().Add(ttl), Delta: delta}
+	return l.cache.SetWithTags(ctx, key, env, ttl+l.opts.StaleTTL, tags...)
+}
+
+// needsRefresh функция, которая решает, нужно ли обновить значение
+// Кроме устаревших значений обновляются и свежие с вероятностью, растущей к концу срока свежести (XFetch)
+// @param env envelope - значение
+// @return bool - true, если значение нужно обновить в фоне
+func (l *Loader) needsRefresh(env envelope) bool {
+	now := l.now()
+	if !now.Before(env.FreshUntil) {
+		return true
+	}
+	if l.opts.Beta <= 0 || env.Delta <= 0 {
+		return false
+	}
+	// -ln(random) имеет экспоненциальное распределение, поэтому обновление почти всегда происходит незадолго до срока
+	early := time.Duration(float64(env.Delta) * l.opts.Beta * -math.Log(1-l.random()))
+	return !now.Add(early).Before(env.FreshUntil)
+}
+
+// refresh функция, которая обновляет значение в фоне
+// Если значение уже обновляет этот процесс или другая реплика, новое обновление не запускается.
+// Обновления объединяются отдельно от загрузок при промахе, чтобы ожидающие промаха не получили errLocked
+func (l *Loader) refresh(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) (interface{}, error), tags []string) {
+	go l.group.Do("refresh:"+key, func() (interface{}, error) {
+		refreshCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), l.opts.RefreshTimeout)
+		defer cancel()
+
+		raw, err := l.loadLocked(refreshCtx, key, ttl, load, tags, false)
+		if err != nil && !errors.Is(err, errLocked) && !errors.Is(err, ErrAbsent) {
+			l.warn("failed to refresh cache", key, err)
+		}
+		return raw, err
+	})
+}
+
+// loadLocked функция, которая загружает значение под блокировкой ключа и записывает его в кеш
+// @param ctx context.Context - контекст загрузки
+// @param key string - ключ
+// @param ttl time.Duration - время свежести значения
+// @param load func(ctx context.Context) (interface{}, error) - загрузка значения
+// @param tags []string - теги значения
+// @param wait bool - true, если при занятой блокировке нужно дождаться значения другой реплики
+// @return json.RawMessage - значение
+// @return error - ошибка загрузки, ErrAbsent если значения нет в источнике или errLocked,
+// если блокировка занята и wait = false
+func (l *Loader) loadLocked(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) (interface{}, error), tags []string, wait bool) (json.RawMessage, error) {
+	if l.locker != nil {
+		unlock, acquired, err := l.locker.Lock(ctx, lockKeyPrefix+key, l.opts.LockTTL)
+		switch {
+		case err != nil:
+			// Без блокировки загрузка возможна, но без защиты от одновременной загрузки другими репликами
+			l.warn("failed to lock cache key", key, err)
+		case acquired:
+			defer unlock()
+			// Пока блокировку держала другая реплика, значение могло появиться в кеше
+			if wait {
+				if raw, ok, err := l.cached(ctx, key); ok {
+					return raw, err
+				}
+			}
+		case !wait:
+			return nil, errLocked
+		default:
+			if raw, ok, err := l.waitForValue(ctx, key); ok {
+				return raw, err
+			}
+			// Реплика с блокировкой не успела загрузить значение, загружаем его сами
+		}
+	}
+
+	start := l.now()
+	value, err := load(ctx)
+	if errors.Is(err, ErrAbsent) {
+		if err := l.SetAbsent(ctx, key, tags...); err != nil {
+			l.warn("failed to cache absent value", key, err)
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	raw, err := l.encoding.marshalNested(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	if err := l.set(ctx, key, raw, ttl, l.now().Sub(start), tags); err != nil {
+		l.warn("failed to cache value", key, err)
+	}
+	return raw, nil
+}
+
+// waitForValue функция, которая ждет свежее значение, загружаемое другой репликой
+// @param ctx context.Context - контекст выполнения
+// @param key string - ключ
+// @return json.RawMessage - значение
+// @return bool - false, если значение не появилось за LockWait
+// @return error - ErrAbsent, если другая реплика отметила отсутствие значения
+func (l *Loader) waitForValue(ctx context.Context, key string) (json.RawMessage, bool, error) {
+	timer := time.NewTimer(l.opts.LockWait)
+	defer timer.Stop()
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if raw, ok, err := l.cached(ctx, key); ok {
+				return raw, true, err
+			}
+		case <-timer.C:
+			return nil, false, nil
+		case <-ctx.Done():
+			return nil, false, nil
+		}
+	}
+}
+
+// cached функция, которая возвращает свежее значение или отметку об отсутствии значения
+// @param ctx context.Context - контекст выполнения
+// @param key string - ключ
+// @return json.RawMessage - значение
+// @return bool - true, если в кеше есть свежее значение или отметка
+// @return error - ErrAbsent для отметки
+func (l *Loader) cached(ctx context.Context, key string) (json.RawMessage, bool, error) {
+	env, err := l.get(ctx, key)
+	switch {
+	case err == nil && l.now().Before(env.FreshUntil):
+		return env.Value, true, nil
+	case errors.Is(err, ErrAbsent):
+		return nil, true, err
+	default:
+		return nil, false, nil
+	}
+}
+
+// warn функция, которая логирует ошибку кеша
+// Пока кеш отключен после ошибок хранилища, ErrUnavailable возвращается на каждое обращение, поэтому
+// такие ошибки логируются только на уровне debug
+func (l *Loader) warn(msg, key string, err error) {
+	if errors.Is(err, ErrUnavailable) {
+		l.logger.Debug(msg, zap.String("key", key), zap.Error(err))
+		return
+	}
+	l.logger.Warn(msg, zap.String("key", key), zap.Error(err))
+}
+
+// decodeValue функция, которая декодирует значение
+func (l *Loader) decodeValue(data json.RawMessage, dest interface{}) error {
+	if err := l.encoding.unmarshal(data, dest); err != nil {
+		return fmt.Errorf("failed to unmarshal value: %w", err)
+	}
+	return nil
+}
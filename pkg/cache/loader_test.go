@@ -0,0 +1,296 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// TestLoader тестирует загрузку значений без лавины запросов
+func TestLoader(t *testing.T) {
+	ctx := context.Background()
+	newMemoryLoader := func(t *testing.T, opts LoaderOptions) *Loader {
+		t.Helper()
+		c, err := NewMemoryCache(MemoryOptions{})
+		require.NoError(t, err)
+		return NewLoader(c, opts, zap.NewNop())
+	}
+
+	t.Run("Одновременные промахи по ключу объединяются в одну загрузку", func(t *testing.T) {
+		loader := newMemoryLoader(t, LoaderOptions{})
+		var loads atomic.Int32
+		release := make(chan struct{})
+		load := func(ctx context.Context) (interface{}, error) {
+			loads.Add(1)
+			<-release
+			return []string{"Купить молоко"}, nil
+		}
+
+		var wg sync.WaitGroup
+		results := make([][]string, 20)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				assert.NoError(t, loader.Fetch(ctx, "tasks:all", &results[i], time.Minute, load))
+			}(i)
+		}
+		// Даем всем вызовам дойти до ожидания загрузки
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), loads.Load())
+		for _, result := range results {
+			assert.Equal(t, []string{"Купить молоко"}, result)
+		}
+	})
+
+	t.Run("Реплика без блокировки ждет значение, загруженное другой репликой", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		newRedisLoader := func() *Loader {
+			c, err := NewRedisCache(RedisOptions{DSN: "redis://" + server.Addr()}, zap.NewNop())
+			require.NoError(t, err)
+			return NewLoader(c, LoaderOptions{}, zap.NewNop())
+		}
+		first, second := newRedisLoader(), newRedisLoader()
+
+		started, release := make(chan struct{}), make(chan struct{})
+		done := make(chan error)
+		go func() {
+			var v string
+			done <- first.Fetch(ctx, "tasks:all", &v, time.Minute, func(ctx context.Context) (interface{}, error) {
+				close(started)
+				<-release
+				return "first", nil
+			})
+		}()
+		<-started
+		assert.True(t, server.Exists(lockKeyPrefix+"tasks:all"))
+
+		var secondLoads atomic.Int32
+		var v string
+		go func() {
+			time.Sleep(30 * time.Millisecond)
+			close(release)
+		}()
+		err := second.Fetch(ctx, "tasks:all", &v, time.Minute, func(ctx context.Context) (interface{}, error) {
+			secondLoads.Add(1)
+			return "second", nil
+		})
+
+		require.NoError(t, err)
+		require.NoError(t, <-done)
+		assert.Equal(t, "first", v)
+		assert.Zero(t, secondLoads.Load())
+		// Блокировка снимается после загрузки
+		assert.False(t, server.Exists(lockKeyPrefix+"tasks:all"))
+	})
+
+	t.Run("Если реплика с блокировкой не успела загрузить значение, реплика загружает его сама", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		c, err := NewRedisCache(RedisOptions{DSN: "redis://" + server.Addr()}, zap.NewNop())
+		require.NoError(t, err)
+		loader := NewLoader(c, LoaderOptions{LockWait: 50 * time.Millisecond}, zap.NewNop())
+		// Блокировку держит зависшая реплика
+		require.NoError(t, server.Set(lockKeyPrefix+"tasks:all", "other"))
+
+		var v string
+		err = loader.Fetch(ctx, "tasks:all", &v, time.Minute, func(ctx context.Context) (interface{}, error) {
+			return "own", nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "own", v)
+	})
+
+	t.Run("Устаревшее значение отдается, пока обновляется в фоне", func(t *testing.T) {
+		loader := newMemoryLoader(t, LoaderOptions{StaleTTL: time.Hour})
+		now := time.Now()
+		loader.now = func() time.Time { return now }
+
+		var v string
+		require.NoError(t, loader.Fetch(ctx, "task:1", &v, time.Minute, func(ctx context.Context) (interface{}, error) {
+			return "old", nil
+		}))
+
+		now = now.Add(2 * time.Minute)
+		refreshed := make(chan struct{})
+		require.NoError(t, loader.Fetch(ctx, "task:1", &v, time.Minute, func(ctx context.Context) (interface{}, error) {
+			defer close(refreshed)
+			return "new", nil
+		}))
+		assert.Equal(t, "old", v)
+
+		<-refreshed
+		assert.Eventually(t, func() bool {
+			return loader.Get(ctx, "task:1", &v) == nil && v == "new"
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("Свежее значение обновляется заранее с вероятностью, растущей к концу срока", func(t *testing.T) {
+		loader := newMemoryLoader(t, LoaderOptions{Beta: 1})
+		now := time.Now()
+		loader.now = func() time.Time { return now }
+		// Значение загружалось 1 секунду, до конца свежести осталось 10 секунд
+		require.NoError(t, loader.set(ctx, "tasks:all", json.RawMessage(`"v"`), 10*time.Second, time.Second, nil))
+		env, err := loader.get(ctx, "tasks:all")
+		require.NoError(t, err)
+
+		// -ln(1-0.5) * 1s < 10s - обновление не нужно
+		loader.random = func() float64 { return 0.5 }
+		assert.False(t, loader.needsRefresh(env))
+
+		// -ln(1-0.99999) * 1s > 10s - значение обновляется заранее
+		loader.random = func() float64 { return 0.99999 }
+		assert.True(t, loader.needsRefresh(env))
+
+		// Без коэффициента значение обновляется только после истечения свежести
+		loader.opts.Beta = 0
+		assert.False(t, loader.needsRefresh(env))
+		now = now.Add(10 * time.Second)
+		assert.True(t, loader.needsRefresh(env))
+	})
+
+	t.Run("Ошибка загрузки не кешируется", func(t *testing.T) {
+		loader := newMemoryLoader(t, LoaderOptions{})
+		failure := errors.New("database is down")
+
+		var v string
+		err := loader.Fetch(ctx, "task:1", &v, time.Minute, func(ctx context.Context) (interface{}, error) {
+			return nil, failure
+		})
+		assert.ErrorIs(t, err, failure)
+
+		err = loader.Fetch(ctx, "task:1", &v, time.Minute, func(ctx context.Context) (interface{}, error) {
+			return "ok", nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", v)
+	})
+
+	t.Run("Отмена запроса не прерывает загрузку для других вызовов", func(t *testing.T) {
+		loader := newMemoryLoader(t, LoaderOptions{})
+		release := make(chan struct{})
+		load := func(ctx context.Context) (interface{}, error) {
+			<-release
+			return "v", ctx.Err()
+		}
+
+		canceled, cancel := context.WithCancel(ctx)
+		first := make(chan error)
+		go func() {
+			var v string
+			first <- loader.Fetch(canceled, "task:1", &v, time.Minute, load)
+		}()
+		time.Sleep(20 * time.Millisecond)
+		second := make(chan error)
+		go func() {
+			var v string
+			second <- loader.Fetch(ctx, "task:1", &v, time.Minute, load)
+		}()
+		time.Sleep(20 * time.Millisecond)
+
+		cancel()
+		assert.ErrorIs(t, <-first, context.Canceled)
+		close(release)
+		assert.NoError(t, <-second)
+	})
+
+	t.Run("Значения, записанные без Loader, считаются промахом", func(t *testing.T) {
+		c, err := NewMemoryCache(MemoryOptions{})
+		require.NoError(t, err)
+		loader := NewLoader(c, LoaderOptions{}, zap.NewNop())
+		require.NoError(t, c.Set(ctx, "task:1", map[string]int{"id": 1}, time.Minute))
+
+		var v map[string]int
+		assert.ErrorIs(t, loader.Get(ctx, "task:1", &v), ErrMiss)
+	})
+
+	t.Run("Значение, которое не удалось декодировать, удаляется и загружается заново", func(t *testing.T) {
+		loader := newMemoryLoader(t, LoaderOptions{})
+		require.NoError(t, loader.Set(ctx, "task:1", "не задача", time.Minute))
+
+		var v map[string]int
+		err := loader.Fetch(ctx, "task:1", &v, time.Minute, func(ctx context.Context) (interface{}, error) {
+			return map[string]int{"id": 1}, nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"id": 1}, v)
+	})
+
+	t.Run("Если Redis недоступен, значение загружается из источника", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		c, err := NewRedisCache(RedisOptions{DSN: "redis://" + server.Addr(), BreakerThreshold: 1}, zap.NewNop())
+		require.NoError(t, err)
+		loader := NewLoader(c, LoaderOptions{}, zap.NewNop())
+		server.SetError("LOADING Redis is loading the dataset in memory")
+
+		for i := 0; i < 3; i++ {
+			var v string
+			err := loader.Fetch(ctx, "tasks:all", &v, time.Minute, func(ctx context.Context) (interface{}, error) {
+				return "from db", nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, "from db", v)
+		}
+	})
+	t.Run("Загруженное значение удаляется по тегу", func(t *testing.T) {
+		loader := newMemoryLoader(t, LoaderOptions{})
+		load := func(ctx context.Context) (interface{}, error) {
+			return "v", nil
+		}
+		var v string
+		require.NoError(t, loader.Fetch(ctx, "tasks:all", &v, time.Minute, load, "tasks"))
+
+		require.NoError(t, loader.cache.InvalidateTags(ctx, "tasks"))
+
+		assert.ErrorIs(t, loader.Get(ctx, "tasks:all", &v), ErrMiss)
+	})
+	t.Run("Отсутствие значения кешируется на AbsentTTL", func(t *testing.T) {
+		loader := newMemoryLoader(t, LoaderOptions{AbsentTTL: time.Minute})
+		var loads atomic.Int32
+		load := func(ctx context.Context) (interface{}, error) {
+			loads.Add(1)
+			return nil, ErrAbsent
+		}
+
+		var v string
+		for i := 0; i < 3; i++ {
+			assert.ErrorIs(t, loader.Fetch(ctx, "task:404", &v, time.Minute, load, "task:404"), ErrAbsent)
+		}
+		assert.Equal(t, int32(1), loads.Load())
+
+		// Отметка удаляется по тегу, когда значение появляется в источнике
+		require.NoError(t, loader.cache.InvalidateTags(ctx, "task:404"))
+		require.NoError(t, loader.Fetch(ctx, "task:404", &v, time.Minute, func(ctx context.Context) (interface{}, error) {
+			return "created", nil
+		}))
+		assert.Equal(t, "created", v)
+	})
+
+	t.Run("Без AbsentTTL отсутствие значения не кешируется", func(t *testing.T) {
+		loader := newMemoryLoader(t, LoaderOptions{})
+		var loads atomic.Int32
+		load := func(ctx context.Context) (interface{}, error) {
+			loads.Add(1)
+			return nil, ErrAbsent
+		}
+
+		var v string
+		for i := 0; i < 3; i++ {
+			assert.ErrorIs(t, loader.Fetch(ctx, "task:404", &v, time.Minute, load), ErrAbsent)
+		}
+		assert.Equal(t, int32(3), loads.Load())
+	})
+}
@@ -0,0 +1,418 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EvictionPolicy политика вытеснения записей из MemoryCache
+type EvictionPolicy string
+
+const (
+	// PolicyLRU - вытесняется запись, которую дольше всех не читали и не записывали
+	PolicyLRU EvictionPolicy = "lru"
+	// PolicyTinyLFU - вытеснение LRU с фильтром TinyLFU: новая запись попадает в заполненный кеш,
+	// только если к ее ключу обращались чаще, чем к ключу вытесняемой записи
+	PolicyTinyLFU EvictionPolicy = "tinylfu"
+)
+
+// entryOverhead - примерный размер служебных данных записи, который учитывается в MaxBytes
+const entryOverhead = 64
+
+// MemoryOptions параметры MemoryCache
+type MemoryOptions struct {
+	// MaxEntries - максимальное количество записей, 0 - без ограничения
+	MaxEntries int
+	// MaxBytes - максимальный размер ключей и значений в байтах, 0 - без ограничения
+	MaxBytes int64
+	// Policy - политика вытеснения, по умолчанию PolicyLRU
+	Policy EvictionPolicy
+}
+
+// Stats статистика MemoryCache
+type Stats struct {
+	Hits   uint64
+	Misses uint64
+	// Evictions - количество записей, вытесненных из-за ограничений размера
+	Evictions uint64
+	// Rejections - количество записей, не принятых фильтром TinyLFU
+	Rejections uint64
+	Entries    int
+	Bytes      int64
+}
+
+// memoryEntry запись MemoryCache
+type memoryEntry struct {
+	key  string
+	data []byte
+	// expiresAt - время истечения срока записи, нулевое время для записей без срока
+	expiresAt time.Time
+	// tags - теги, по которым запись удаляется через InvalidateTags
+	tags []string
+}
+
+// size функция, которая возвращает размер записи для ограничения MaxBytes
+func (e *memoryEntry) size() int64 {
+	return int64(len(e.key) + len(e.data) + entryOverhead)
+}
+
+// MemoryCache кеш в памяти процесса с ограничением размера и сроком жизни записей
+// Значения хранятся в JSON, поэтому Get всегда возвращает копию значения
+type MemoryCache struct {
+	opts MemoryOptions
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	// tags - ключи записей для каждого тега
+	tags map[string]map[string]struct{}
+	// order - записи от последней использованной к давно не использованной
+	order  *list.List
+	bytes  int64
+	sketch *frequencySketch
+	stats  Stats
+
+	// now - источник времени, заменяется в тестах
+	now func() time.Time
+}
+
+// NewMemoryCache функция, которая создает новый экземпляр MemoryCache
+// @param opts MemoryOptions - ограничения размера и политика вытеснения
+// @return *MemoryCache - новый экземпляр MemoryCache
+// @return error - ошибка, если политика вытеснения неизвестна
+func NewMemoryCache(opts MemoryOptions) (*MemoryCache, error) {
+	if opts.Policy == "" {
+		opts.Policy = PolicyLRU
+	}
+
+	c := &MemoryCache{
+		opts:  opts,
+		items: make(map[string]*list.Element),
+		tags:  make(map[string]map[string]struct{}),
+		order: list.New(),
+		now:   time.Now,
+	}
+	switch opts.Policy {
+	case PolicyLRU:
+	case PolicyTinyLFU:
+		c.sketch = newFrequencySketch(opts.MaxEntries)
+	default:
+		return nil, fmt.Errorf("unknown eviction policy %q", opts.Policy)
+	}
+
+	return c, nil
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	reportPayloadSize(ctx, data)
+	c.setRaw(key, data, expiration)
+	return nil
+}
+
+func (c *MemoryCache) SetWithTags(ctx context.Context, key string, value interface{}, expiration time.Duration, tags ...string) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	reportPayloadSize(ctx, data)
+	c.setRaw(key, data, expiration, tags...)
+	return nil
+}
+
+func (c *MemoryCache) SetAbsent(ctx context.Context, key string, expiration time.Duration, tags ...string) error {
+	reportPayloadSize(ctx, absentValue)
+	c.setRaw(key, absentValue, expiration, tags...)
+	return nil
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string, dest interface{}) error {
+	data, ok := c.getRaw(key)
+	if !ok {
+		return fmt.Errorf("%w for key %s", ErrMiss, key)
+	}
+	reportPayloadSize(ctx, data)
+	if isAbsent(data) {
+		return fmt.Errorf("%w for key %s", ErrAbsent, key)
+	}
+
+	if err := json.Unmarshal(data, dest); err != nil {
+		c.Delete(ctx, key)
+		return fmt.Errorf("failed to unmarshal value: %w", err)
+	}
+
+	return nil
+}
+
+func (c *MemoryCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.remove(key)
+	return nil
+}
+
+func (c *MemoryCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			c.remove(key)
+		}
+		delete(c.tags, tag)
+	}
+	return nil
+}
+
+// Clear функция, которая удаляет все записи
+func (c *MemoryCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]*list.Element)
+	c.tags = make(map[string]map[string]struct{})
+	c.order.Init()
+	c.bytes = 0
+}
+
+// Inspect функция, которая возвращает запись со значением, не меняя порядок вытеснения
+// @param ctx context.Context - контекст выполнения
+// @param key string - ключ
+// @return Entry - запись
+// @return error - ErrMiss, если ключа нет или срок записи истек
+func (c *MemoryCache) Inspect(ctx context.Context, key string) (Entry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok || c.expired(elem.Value.(*memoryEntry)) {
+		return Entry{}, fmt.Errorf("%w for key %s", ErrMiss, key)
+	}
+	entry := elem.Value.(*memoryEntry)
+	return newEntry(key, entry.data, c.ttl(entry), Encoding{Codec: JSON{}}), nil
+}
+
+// Keys функция, которая возвращает записи с префиксом ключа в порядке ключей
+// @param ctx context.Context - контекст выполнения
+// @param prefix string - префикс ключей
+// @param limit int - максимальное количество записей
+// @return []EntryInfo - записи
+// @return error - всегда nil
+func (c *MemoryCache) Keys(ctx context.Context, prefix string, limit int) ([]EntryInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var infos []EntryInfo
+	for key, elem := range c.items {
+		entry := elem.Value.(*memoryEntry)
+		if !strings.HasPrefix(key, prefix) || c.expired(entry) {
+			continue
+		}
+		infos = append(infos, EntryInfo{Key: key, TTL: c.ttl(entry), Size: len(entry.data), Absent: isAbsent(entry.data)})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	if len(infos) > limit {
+		infos = infos[:limit]
+	}
+	return infos, nil
+}
+
+// FlushPrefix функция, которая удаляет записи с префиксом ключа
+// @param ctx context.Context - контекст выполнения
+// @param prefix string - префикс ключей
+// @return int - количество удаленных записей
+// @return error - всегда nil
+func (c *MemoryCache) FlushPrefix(ctx context.Context, prefix string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	flushed := 0
+	for key, elem := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.removeElement(elem)
+			flushed++
+		}
+	}
+	return flushed, nil
+}
+
+// Stats функция, которая возвращает статистику кеша
+// @return Stats - статистика
+func (c *MemoryCache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = len(c.items)
+	stats.Bytes = c.bytes
+	return stats
+}
+
+// setRaw функция, которая сохраняет закодированное значение
+// @param key string - ключ
+// @param data []byte - значение
+// @param expiration time.Duration - срок жизни, 0 - без срока
+// @param tags ...string - теги записи
+func (c *MemoryCache) setRaw(key string, data []byte, expiration time.Duration, tags ...string) {
+	entry := &memoryEntry{key: key, data: data, tags: tags}
+	if expiration > 0 {
+		entry.expiresAt = c.now().Add(expiration)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.sketch != nil {
+		c.sketch.increment(key)
+	}
+
+	// Значение, которое больше всего кеша, не сохраняется, а старое значение удаляется, чтобы не отдавать его
+	if c.opts.MaxBytes > 0 && entry.size() > c.opts.MaxBytes {
+		c.remove(key)
+		return
+	}
+
+	if elem, ok := c.items[key]; ok {
+		old := elem.Value.(*memoryEntry)
+		c.untag(old)
+		c.tag(entry)
+		c.bytes += entry.size() - old.size()
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		c.evict(elem)
+		return
+	}
+
+	if !c.admit(entry) {
+		c.stats.Rejections++
+		return
+	}
+	c.items[key] = c.order.PushFront(entry)
+	c.tag(entry)
+	c.bytes += entry.size()
+	c.evict(c.items[key])
+}
+
+// getRaw функция, которая возвращает закодированное значение
+// @param key string - ключ
+// @return []byte - значение, его нельзя изменять
+// @return bool - false, если ключа нет или срок записи истек
+func (c *MemoryCache) getRaw(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.sketch != nil {
+		c.sketch.increment(key)
+	}
+	elem, ok := c.items[key]
+	if ok && c.expired(elem.Value.(*memoryEntry)) {
+		c.removeElement(elem)
+		ok = false
+	}
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*memoryEntry).data, true
+}
+
+// admit функция, которая решает, принять ли новую запись в кеш, вызывается под блокировкой
+// Без TinyLFU и при свободном месте запись принимается всегда
+// @param entry *memoryEntry - новая запись
+// @return bool - true, если запись нужно сохранить
+func (c *MemoryCache) admit(entry *memoryEntry) bool {
+	if c.sketch == nil || !c.full(entry.size()) {
+		return true
+	}
+	victim := c.order.Back()
+	if victim == nil || c.expired(victim.Value.(*memoryEntry)) {
+		return true
+	}
+	return c.sketch.estimate(entry.key) > c.sketch.estimate(victim.Value.(*memoryEntry).key)
+}
+
+// full функция, которая проверяет, превысит ли кеш ограничения после добавления записи
+// @param size int64 - размер новой записи
+// @return bool - true, если для записи нужно вытеснить другие
+func (c *MemoryCache) full(size int64) bool {
+	return (c.opts.MaxEntries > 0 && len(c.items) >= c.opts.MaxEntries) ||
+		(c.opts.MaxBytes > 0 && c.bytes+size > c.opts.MaxBytes)
+}
+
+// evict функция, которая вытесняет давно не использованные записи, пока кеш не уложится в ограничения
+// @param keep *list.Element - только что записанная запись, которая не вытесняется
+func (c *MemoryCache) evict(keep *list.Element) {
+	for (c.opts.MaxEntries > 0 && len(c.items) > c.opts.MaxEntries) || (c.opts.MaxBytes > 0 && c.bytes > c.opts.MaxBytes) {
+		victim := c.order.Back()
+		if victim == nil || victim == keep {
+			return
+		}
+		if !c.expired(victim.Value.(*memoryEntry)) {
+			c.stats.Evictions++
+		}
+		c.removeElement(victim)
+	}
+}
+
+// ttl функция, которая возвращает оставшийся срок записи, 0 для записей без срока
+func (c *MemoryCache) ttl(entry *memoryEntry) time.Duration {
+	if entry.expiresAt.IsZero() {
+		return 0
+	}
+	return entry.expiresAt.Sub(c.now())
+}
+
+// expired функция, которая проверяет, истек ли срок записи
+func (c *MemoryCache) expired(entry *memoryEntry) bool {
+	return !entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt)
+}
+
+// remove функция, которая удаляет запись по ключу, вызывается под блокировкой
+func (c *MemoryCache) remove(key string) {
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// removeElement функция, которая удаляет запись, вызывается под блокировкой
+func (c *MemoryCache) removeElement(elem *list.Element) {
+	entry := c.order.Remove(elem).(*memoryEntry)
+	delete(c.items, entry.key)
+	c.untag(entry)
+	c.bytes -= entry.size()
+}
+
+// tag функция, которая добавляет ключ записи в ее теги, вызывается под блокировкой
+func (c *MemoryCache) tag(entry *memoryEntry) {
+	for _, tag := range entry.tags {
+		keys, ok := c.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.tags[tag] = keys
+		}
+		keys[entry.key] = struct{}{}
+	}
+}
+
+// untag функция, которая удаляет ключ записи из ее тегов, вызывается под блокировкой
+func (c *MemoryCache) untag(entry *memoryEntry) {
+	for _, tag := range entry.tags {
+		delete(c.tags[tag], entry.key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
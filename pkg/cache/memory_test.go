@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemoryCache тестирует ограничения размера и вытеснение MemoryCache
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	newCache := func(t *testing.T, opts MemoryOptions) *MemoryCache {
+		t.Helper()
+		c, err := NewMemoryCache(opts)
+		require.NoError(t, err)
+		return c
+	}
+
+	t.Run("LRU вытесняет запись, которую дольше всех не использовали", func(t *testing.T) {
+		c := newCache(t, MemoryOptions{MaxEntries: 2})
+		require.NoError(t, c.Set(ctx, "a", 1, 0))
+		require.NoError(t, c.Set(ctx, "b", 2, 0))
+
+		// Чтение делает a последней использованной записью
+		var v int
+		require.NoError(t, c.Get(ctx, "a", &v))
+		require.NoError(t, c.Set(ctx, "c", 3, 0))
+
+		assert.NoError(t, c.Get(ctx, "a", &v))
+		assert.Error(t, c.Get(ctx, "b", &v))
+		assert.NoError(t, c.Get(ctx, "c", &v))
+		assert.Equal(t, uint64(1), c.Stats().Evictions)
+	})
+
+	t.Run("Ограничение в байтах", func(t *testing.T) {
+		c := newCache(t, MemoryOptions{MaxBytes: 3 * (entryOverhead + 10)})
+		for i := 0; i < 5; i++ {
+			// Ключ из 2 байт и значение из 8 байт
+			require.NoError(t, c.Set(ctx, fmt.Sprintf("k%d", i), "123456", 0))
+		}
+
+		stats := c.Stats()
+		assert.Equal(t, 3, stats.Entries)
+		assert.Equal(t, int64(3*(entryOverhead+10)), stats.Bytes)
+		assert.Equal(t, uint64(2), stats.Evictions)
+	})
+
+	t.Run("Значение больше кеша не сохраняется и удаляет старое значение", func(t *testing.T) {
+		c := newCache(t, MemoryOptions{MaxBytes: entryOverhead + 16})
+		require.NoError(t, c.Set(ctx, "k", "small", 0))
+
+		require.NoError(t, c.Set(ctx, "k", "a value that does not fit into the cache", 0))
+
+		var v string
+		assert.Error(t, c.Get(ctx, "k", &v))
+		assert.Zero(t, c.Stats().Bytes)
+	})
+
+	t.Run("Запись с истекшим сроком вытесняется первой и не считается вытеснением", func(t *testing.T) {
+		c := newCache(t, MemoryOptions{MaxEntries: 2})
+		now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+		c.now = func() time.Time { return now }
+		require.NoError(t, c.Set(ctx, "a", 1, time.Second))
+		require.NoError(t, c.Set(ctx, "b", 2, 0))
+
+		now = now.Add(time.Minute)
+		require.NoError(t, c.Set(ctx, "c", 3, 0))
+
+		assert.Zero(t, c.Stats().Evictions)
+		assert.Equal(t, 2, c.Stats().Entries)
+	})
+
+	t.Run("TinyLFU не вытесняет часто используемые записи редкими", func(t *testing.T) {
+		c := newCache(t, MemoryOptions{MaxEntries: 2, Policy: PolicyTinyLFU})
+		var v int
+		for _, key := range []string{"hot1", "hot2"} {
+			require.NoError(t, c.Set(ctx, key, 1, 0))
+			for i := 0; i < 5; i++ {
+				require.NoError(t, c.Get(ctx, key, &v))
+			}
+		}
+
+		// Ключ, к которому обратились один раз, не попадает в заполненный кеш
+		require.NoError(t, c.Set(ctx, "scan", 1, 0))
+		assert.Error(t, c.Get(ctx, "scan", &v))
+		assert.NoError(t, c.Get(ctx, "hot1", &v))
+		assert.NoError(t, c.Get(ctx, "hot2", &v))
+		assert.Equal(t, uint64(1), c.Stats().Rejections)
+
+		// Ключ, который запрашивают чаще вытесняемой записи, принимается
+		for i := 0; i < 10; i++ {
+			_ = c.Get(ctx, "popular", &v)
+		}
+		require.NoError(t, c.Set(ctx, "popular", 1, 0))
+		assert.NoError(t, c.Get(ctx, "popular", &v))
+	})
+
+	t.Run("Статистика попаданий и промахов", func(t *testing.T) {
+		c := newCache(t, MemoryOptions{})
+		require.NoError(t, c.Set(ctx, "a", 1, 0))
+
+		var v int
+		_ = c.Get(ctx, "a", &v)
+		_ = c.Get(ctx, "a", &v)
+		_ = c.Get(ctx, "b", &v)
+
+		stats := c.Stats()
+		assert.Equal(t, uint64(2), stats.Hits)
+		assert.Equal(t, uint64(1), stats.Misses)
+		assert.Equal(t, 1, stats.Entries)
+	})
+
+	t.Run("Удаленные и вытесненные записи не остаются в тегах", func(t *testing.T) {
+		c := newCache(t, MemoryOptions{MaxEntries: 1})
+		require.NoError(t, c.SetWithTags(ctx, "task:1", 1, time.Minute, "task:1", "tasks"))
+		require.NoError(t, c.SetWithTags(ctx, "task:1", 1, time.Minute, "tasks"))
+		assert.NotContains(t, c.tags, "task:1")
+
+		require.NoError(t, c.SetWithTags(ctx, "task:2", 2, time.Minute, "tasks"))
+		assert.Equal(t, map[string]struct{}{"task:2": {}}, c.tags["tasks"])
+
+		require.NoError(t, c.Delete(ctx, "task:2"))
+		assert.Empty(t, c.tags)
+	})
+
+	t.Run("Неизвестная политика вытеснения", func(t *testing.T) {
+		_, err := NewMemoryCache(MemoryOptions{Policy: "fifo"})
+
+		assert.Error(t, err)
+	})
+}
+
+// TestFrequencySketch тестирует оценку частоты обращений
+func TestFrequencySketch(t *testing.T) {
+	s := newFrequencySketch(0)
+	for i := 0; i < 5; i++ {
+		s.increment("hot")
+	}
+	s.increment("cold")
+
+	assert.GreaterOrEqual(t, s.estimate("hot"), uint8(5))
+	assert.Less(t, s.estimate("cold"), s.estimate("hot"))
+
+	// После reset частоты уменьшаются вдвое
+	s.reset()
+	assert.Equal(t, uint8(2), s.estimate("hot"))
+}
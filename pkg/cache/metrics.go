@@ -0,0 +1,541 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// otherPrefix - группа статистики ключей без префикса и ключей сверх maxMetricsPrefixes
+	otherPrefix = "other"
+	// maxMetricsPrefixes - максимальное число групп статистики, ограничивает память при ключах без общего префикса
+	maxMetricsPrefixes = 256
+)
+
+var (
+	// latencyBounds - верхние границы корзин времени выполнения
+	latencyBounds = []int64{
+		int64(100 * time.Microsecond), int64(250 * time.Microsecond), int64(500 * time.Microsecond),
+		int64(time.Millisecond), int64(2500 * time.Microsecond), int64(5 * time.Millisecond),
+		int64(10 * time.Millisecond), int64(25 * time.Millisecond), int64(50 * time.Millisecond),
+		int64(100 * time.Millisecond), int64(250 * time.Millisecond), int64(500 * time.Millisecond),
+		int64(time.Second),
+	}
+	// sizeBounds - верхние границы корзин размера значений в байтах
+	sizeBounds = []int64{64, 256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20}
+)
+
+// MetricsOptions параметры MetricsCache
+type MetricsOptions struct {
+	// Prefixes - префиксы ключей, по которым группируется статистика. Ключ относится к самому длинному
+	// подходящему префиксу, а если ни один не подходит - к части до первого двоеточия включительно
+	Prefixes []string
+}
+
+// Metrics статистика MetricsCache
+type Metrics struct {
+	// Prefixes - статистика по префиксам ключей
+	Prefixes map[string]PrefixMetrics
+	// InvalidateTags - удаления по тегам, они не относятся к одному префиксу
+	InvalidateTags OpMetrics
+}
+
+// PrefixMetrics статистика обращений к ключам с одним префиксом
+type PrefixMetrics struct {
+	Hits   uint64
+	Misses uint64
+	// Absent - чтения отметок SetAbsent
+	Absent uint64
+	// Unavailable - обращения, которые вернули ErrUnavailable, пока хранилище отключено после ошибок
+	Unavailable uint64
+	// Get, Set и Delete - чтения, записи (в том числе SetWithTags и SetAbsent) и удаления. Промахи,
+	// отметки об отсутствии и ErrUnavailable не считаются ошибками
+	Get    OpMetrics
+	Set    OpMetrics
+	Delete OpMetrics
+	// ReadSize и WriteSize - размеры прочитанных и записанных закодированных значений в байтах
+	ReadSize  Histogram
+	WriteSize Histogram
+}
+
+// OpMetrics статистика одной операции
+type OpMetrics struct {
+	Calls  uint64
+	Errors uint64
+	// Latency - время выполнения в наносекундах
+	Latency Histogram
+}
+
+// Histogram распределение значений по корзинам
+type Histogram struct {
+	// Bounds - верхние границы корзин включительно, последняя корзина в Counts не ограничена сверху
+	Bounds []int64
+	Counts []uint64
+	Count  uint64
+	Sum    int64
+	Max    int64
+}
+
+// Mean функция, которая возвращает среднее значение
+// @return float64 - среднее, 0 если значений нет
+func (h Histogram) Mean() float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	return float64(h.Sum) / float64(h.Count)
+}
+
+// Quantile функция, которая оценивает квантиль по корзинам
+// @param q float64 - квантиль от 0 до 1
+// @return int64 - верхняя граница корзины с квантилем, но не больше Max
+func (h Histogram) Quantile(q float64) int64 {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := uint64(q*float64(h.Count) + 0.5)
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for i, count := range h.Counts {
+		seen += count
+		if seen >= rank && i < len(h.Bounds) {
+			return min(h.Bounds[i], h.Max)
+		}
+	}
+	return h.Max
+}
+
+// histogram распределение значений, которое обновляется без блокировок
+type histogram struct {
+	bounds []int64
+	counts []atomic.Uint64
+	count  atomic.Uint64
+	sum    atomic.Int64
+	max    atomic.Int64
+}
+
+// newHistogram функция, которая создает распределение с заданными корзинами
+// @param bounds []int64 - верхние границы корзин по возрастанию
+// @return *histogram - новый экземпляр histogram
+func newHistogram(bounds []int64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]atomic.Uint64, len(bounds)+1)}
+}
+
+// observe функция, которая учитывает значение
+// @param v int64 - значение
+func (h *histogram) observe(v int64) {
+	i := 0
+	for i < len(h.bounds) && v > h.bounds[i] {
+		i++
+	}
+	h.counts[i].Add(1)
+	h.count.Add(1)
+	h.sum.Add(v)
+	for {
+		current := h.max.Load()
+		if v <= current || h.max.CompareAndSwap(current, v) {
+			return
+		}
+	}
+}
+
+// snapshot функция, которая возвращает копию распределения
+// @return Histogram - копия
+func (h *histogram) snapshot() Histogram {
+	out := Histogram{
+		Bounds: h.bounds,
+		Counts: make([]uint64, len(h.counts)),
+		Count:  h.count.Load(),
+		Sum:    h.sum.Load(),
+		Max:    h.max.Load(),
+	}
+	for i := range h.counts {
+		out.Counts[i] = h.counts[i].Load()
+	}
+	return out
+}
+
+// opMetrics счетчики одной операции
+type opMetrics struct {
+	calls   atomic.Uint64
+	errors  atomic.Uint64
+	latency *histogram
+}
+
+// newOpMetrics функция, которая создает счетчики операции
+func newOpMetrics() *opMetrics {
+	return &opMetrics{latency: newHistogram(latencyBounds)}
+}
+
+// snapshot функция, которая возвращает копию счетчиков операции
+func (m *opMetrics) snapshot() OpMetrics {
+	return OpMetrics{Calls: m.calls.Load(), Errors: m.errors.Load(), Latency: m.latency.snapshot()}
+}
+
+// prefixMetrics счетчики одного префикса ключей
+type prefixMetrics struct {
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	absent      atomic.Uint64
+	unavailable atomic.Uint64
+	get         *opMetrics
+	set         *opMetrics
+	delete      *opMetrics
+	readSize    *histogram
+	writeSize   *histogram
+}
+
+// newPrefixMetrics функция, которая создает счетчики префикса
+func newPrefixMetrics() *prefixMetrics {
+	return &prefixMetrics{
+		get:       newOpMetrics(),
+		set:       newOpMetrics(),
+		delete:    newOpMetrics(),
+		readSize:  newHistogram(sizeBounds),
+		writeSize: newHistogram(sizeBounds),
+	}
+}
+
+// snapshot функция, которая возвращает копию счетчиков префикса
+func (m *prefixMetrics) snapshot() PrefixMetrics {
+	return PrefixMetrics{
+		Hits:        m.hits.Load(),
+		Misses:      m.misses.Load(),
+		Absent:      m.absent.Load(),
+		Unavailable: m.unavailable.Load(),
+		Get:         m.get.snapshot(),
+		Set:         m.set.snapshot(),
+		Delete:      m.delete.snapshot(),
+		ReadSize:    m.readSize.snapshot(),
+		WriteSize:   m.writeSize.snapshot(),
+	}
+}
+
+// payloadSizeKey ключ контекста, через который кеш сообщает MetricsCache размер закодированного значения
+type payloadSizeKey struct{}
+
+// withPayloadSize функция, которая добавляет в контекст место для размера значения
+// @param ctx context.Context - контекст обращения
+// @return context.Context - контекст для кеша
+// @return *int - размер значения, -1 если кеш его не сообщил
+func withPayloadSize(ctx context.Context) (context.Context, *int) {
+	size := -1
+	return context.WithValue(ctx, payloadSizeKey{}, &size), &size
+}
+
+// reportPayloadSize функция, которая сообщает MetricsCache размер прочитанного или записанного значения
+// @param ctx context.Context - контекст обращения
+// @param data []byte - закодированное значение
+func reportPayloadSize(ctx context.Context, data []byte) {
+	if size, ok := ctx.Value(payloadSizeKey{}).(*int); ok {
+		*size = len(data)
+	}
+}
+
+// MetricsCache декоратор Cache, который собирает статистику попаданий, промахов, ошибок, времени выполнения
+// и размеров значений по префиксам ключей
+// Блокировки, формат значений и просмотр записей передаются декорируемому кешу, если он их поддерживает
+type MetricsCache struct {
+	next     Cache
+	prefixes []string
+
+	mu      sync.RWMutex
+	metrics map[string]*prefixMetrics
+	tags    *opMetrics
+
+	// now - источник времени, заменяется в тестах
+	now func() time.Time
+}
+
+// NewMetricsCache функция, которая создает новый экземпляр MetricsCache
+// @param next Cache - декорируемый кеш
+// @param opts MetricsOptions - параметры
+// @return *MetricsCache - новый экземпляр MetricsCache
+func NewMetricsCache(next Cache, opts MetricsOptions) *MetricsCache {
+	return &MetricsCache{
+		next:     next,
+		prefixes: opts.Prefixes,
+		metrics:  make(map[string]*prefixMetrics),
+		tags:     newOpMetrics(),
+		now:      time.Now,
+	}
+}
+
+func (c *MetricsCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return c.observeSet(ctx, key, func(ctx context.Context) error {
+		return c.next.Set(ctx, key, value, expiration)
+	})
+}
+
+func (c *MetricsCache) SetWithTags(ctx context.Context, key string, value interface{}, expiration time.Duration, tags ...string) error {
+	return c.observeSet(ctx, key, func(ctx context.Context) error {
+		return c.next.SetWithTags(ctx, key, value, expiration, tags...)
+	})
+}
+
+func (c *MetricsCache) SetAbsent(ctx context.Context, key string, expiration time.Duration, tags ...string) error {
+	return c.observeSet(ctx, key, func(ctx context.Context) error {
+		return c.next.SetAbsent(ctx, key, expiration, tags...)
+	})
+}
+
+func (c *MetricsCache) Get(ctx context.Context, key string, dest interface{}) error {
+	m := c.prefixMetrics(key)
+	ctx, size := withPayloadSize(ctx)
+	start := c.now()
+	err := c.next.Get(ctx, key, dest)
+	m.get.latency.observe(int64(c.now().Sub(start)))
+	m.get.calls.Add(1)
+
+	switch {
+	case err == nil:
+		m.hits.Add(1)
+	case errors.Is(err, ErrMiss):
+		m.misses.Add(1)
+	case errors.Is(err, ErrAbsent):
+		m.absent.Add(1)
+	case errors.Is(err, ErrUnavailable):
+		m.unavailable.Add(1)
+	default:
+		m.get.errors.Add(1)
+	}
+	if *size >= 0 {
+		m.readSize.observe(int64(*size))
+	}
+	return err
+}
+
+func (c *MetricsCache) Delete(ctx context.Context, key string) error {
+	m := c.prefixMetrics(key)
+	start := c.now()
+	err := c.next.Delete(ctx, key)
+	c.observeOp(m, m.delete, start, err)
+	return err
+}
+
+func (c *MetricsCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	start := c.now()
+	err := c.next.InvalidateTags(ctx, tags...)
+	c.tags.latency.observe(int64(c.now().Sub(start)))
+	c.tags.calls.Add(1)
+	if err != nil {
+		c.tags.errors.Add(1)
+	}
+	return err
+}
+
+// Metrics функция, которая возвращает собранную статистику
+// @return Metrics - статистика
+func (c *MetricsCache) Metrics() Metrics {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	out := Metrics{Prefixes: make(map[string]PrefixMetrics, len(c.metrics)), InvalidateTags: c.tags.snapshot()}
+	for prefix, m := range c.metrics {
+		out.Prefixes[prefix] = m.snapshot()
+	}
+	return out
+}
+
+// Lock функция, которая берет блокировку декорируемого кеша
+// Если кеш не поддерживает блокировки, блокировка берется всегда: загрузка и так объединяется внутри процесса
+// @param ctx context.Context - контекст выполнения
+// @param key string - ключ блокировки
+// @param ttl time.Duration - время жизни блокировки
+// @return func() - снятие блокировки
+// @return bool - true, если блокировка взята
+// @return error - ошибка
+func (c *MetricsCache) Lock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	locker, ok := c.next.(Locker)
+	if !ok {
+		return func() {}, true, nil
+	}
+	return locker.Lock(ctx, key, ttl)
+}
+
+// Encoding функция, которая возвращает формат значений декорируемого кеша
+// @return Encoding - формат, JSON если кеш его не настраивает
+func (c *MetricsCache) Encoding() Encoding {
+	if encoded, ok := c.next.(encodedCache); ok {
+		return encoded.Encoding()
+	}
+	return Encoding{Codec: JSON{}}
+}
+
+// Inspect функция, которая возвращает запись декорируемого кеша
+// @param ctx context.Context - контекст выполнения
+// @param key string - ключ
+// @return Entry - запись
+// @return error - ErrMiss, если ключа нет, или errors.ErrUnsupported, если кеш не реализует Inspector
+func (c *MetricsCache) Inspect(ctx context.Context, key string) (Entry, error) {
+	inspector, ok := c.next.(Inspector)
+	if !ok {
+		return Entry{}, errors.ErrUnsupported
+	}
+	return inspector.Inspect(ctx, key)
+}
+
+// Keys функция, которая возвращает записи декорируемого кеша с префиксом
+// @param ctx context.Context - контекст выполнения
+// @param prefix string - префикс ключей
+// @param limit int - максимальное количество записей
+// @return []EntryInfo - записи
+// @return error - ошибка или errors.ErrUnsupported, если кеш не реализует Inspector
+func (c *MetricsCache) Keys(ctx context.Context, prefix string, limit int) ([]EntryInfo, error) {
+	inspector, ok := c.next.(Inspector)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+	return inspector.Keys(ctx, prefix, limit)
+}
+
+// FlushPrefix функция, которая удаляет записи декорируемого кеша с префиксом
+// @param ctx context.Context - контекст выполнения
+// @param prefix string - префикс ключей
+// @return int - количество удаленных записей
+// @return error - ошибка или errors.ErrUnsupported, если кеш не реализует Inspector
+func (c *MetricsCache) FlushPrefix(ctx context.Context, prefix string) (int, error) {
+	inspector, ok := c.next.(Inspector)
+	if !ok {
+		return 0, errors.ErrUnsupported
+	}
+	return inspector.FlushPrefix(ctx, prefix)
+}
+
+// TrackAccess функция, которая отмечает обращение к ключу в журнале декорируемого кеша, если он его ведет
+// @param key string - ключ
+func (c *MetricsCache) TrackAccess(key string) {
+	if tracker, ok := c.next.(AccessTracker); ok {
+		tracker.TrackAccess(key)
+	}
+}
+
+// RecentKeys функция, которая возвращает недавно прочитанные ключи декорируемого кеша
+// @param ctx context.Context - контекст выполнения
+// @param limit int - максимальное количество ключей
+// @return []string - ключи
+// @return error - ошибка или errors.ErrUnsupported, если кеш не реализует AccessTracker
+func (c *MetricsCache) RecentKeys(ctx context.Context, limit int) ([]string, error) {
+	tracker, ok := c.next.(AccessTracker)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+	return tracker.RecentKeys(ctx, limit)
+}
+
+// Publish функция, которая отправляет сообщение через декорируемый кеш
+// @param ctx context.Context - контекст выполнения
+// @param channel string - канал
+// @param message []byte - сообщение
+// @return error - ошибка или errors.ErrUnsupported, если кеш не реализует PubSub
+func (c *MetricsCache) Publish(ctx context.Context, channel string, message []byte) error {
+	pubsub, ok := c.next.(PubSub)
+	if !ok {
+		return errors.ErrUnsupported
+	}
+	return pubsub.Publish(ctx, channel, message)
+}
+
+// Subscribe функция, которая подписывается на канал через декорируемый кеш
+// @param ctx context.Context - контекст подписки на канал
+// @param channel string - канал
+// @param handle func(message []byte) - обработчик сообщений
+// @param resubscribed func() - вызывается после переподключения к Redis
+// @return io.Closer - подписка
+// @return error - ошибка или errors.ErrUnsupported, если кеш не реализует PubSub
+func (c *MetricsCache) Subscribe(ctx context.Context, channel string, handle func(message []byte), resubscribed func()) (io.Closer, error) {
+	pubsub, ok := c.next.(PubSub)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+	return pubsub.Subscribe(ctx, channel, handle, resubscribed)
+}
+
+// observeSet функция, которая выполняет запись и учитывает ее в статистике
+// @param ctx context.Context - контекст выполнения
+// @param key string - ключ
+// @param set func(ctx context.Context) error - запись в декорируемый кеш
+// @return error - ошибка записи
+func (c *MetricsCache) observeSet(ctx context.Context, key string, set func(ctx context.Context) error) error {
+	m := c.prefixMetrics(key)
+	ctx, size := withPayloadSize(ctx)
+	start := c.now()
+	err := set(ctx)
+	c.observeOp(m, m.set, start, err)
+	if *size >= 0 {
+		m.writeSize.observe(int64(*size))
+	}
+	return err
+}
+
+// observeOp функция, которая учитывает выполненную операцию
+// @param m *prefixMetrics - счетчики префикса ключа
+// @param op *opMetrics - счетчики операции
+// @param start time.Time - время начала операции
+// @param err error - результат операции
+func (c *MetricsCache) observeOp(m *prefixMetrics, op *opMetrics, start time.Time, err error) {
+	op.latency.observe(int64(c.now().Sub(start)))
+	op.calls.Add(1)
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrUnavailable):
+		m.unavailable.Add(1)
+	default:
+		op.errors.Add(1)
+	}
+}
+
+// prefixMetrics функция, которая возвращает счетчики префикса ключа и создает их при первом обращении
+// @param key string - ключ
+// @return *prefixMetrics - счетчики
+func (c *MetricsCache) prefixMetrics(key string) *prefixMetrics {
+	prefix := c.prefix(key)
+
+	c.mu.RLock()
+	m, ok := c.metrics[prefix]
+	c.mu.RUnlock()
+	if ok {
+		return m
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if m, ok := c.metrics[prefix]; ok {
+		return m
+	}
+	if len(c.metrics) >= maxMetricsPrefixes {
+		prefix = otherPrefix
+		if m, ok := c.metrics[prefix]; ok {
+			return m
+		}
+	}
+	m = newPrefixMetrics()
+	c.metrics[prefix] = m
+	return m
+}
+
+// prefix функция, которая возвращает префикс, по которому группируется статистика ключа
+// @param key string - ключ
+// @return string - самый длинный подходящий префикс из MetricsOptions, часть ключа до первого двоеточия
+// включительно или otherPrefix
+func (c *MetricsCache) prefix(key string) string {
+	var best string
+	for _, prefix := range c.prefixes {
+		if len(prefix) > len(best) && strings.HasPrefix(key, prefix) {
+			best = prefix
+		}
+	}
+	if best != "" {
+		return best
+	}
+	if i := strings.IndexByte(key, ':'); i >= 0 {
+		return key[:i+1]
+	}
+	return otherPrefix
+}
@@ -0,0 +1,193 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// failingCache кеш, все обращения к которому возвращают ошибку
+type failingCache struct {
+	Cache
+	err error
+}
+
+func (c failingCache) Get(ctx context.Context, key string, dest interface{}) error {
+	return c.err
+}
+
+func (c failingCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return c.err
+}
+
+// TestMetricsCache тестирует сбор статистики обращений к кешу
+func TestMetricsCache(t *testing.T) {
+	ctx := context.Background()
+	type task struct {
+		ID    int    `json:"id"`
+		Title string `json:"title"`
+	}
+	newMetricsCache := func(t *testing.T, opts MetricsOptions) *MetricsCache {
+		t.Helper()
+		memory, err := NewMemoryCache(MemoryOptions{})
+		require.NoError(t, err)
+		return NewMetricsCache(memory, opts)
+	}
+
+	t.Run("Попадания, промахи и отметки об отсутствии считаются по префиксам", func(t *testing.T) {
+		c := newMetricsCache(t, MetricsOptions{})
+		require.NoError(t, c.Set(ctx, "task:1", task{ID: 1}, time.Minute))
+		require.NoError(t, c.SetAbsent(ctx, "task:404", time.Minute))
+		require.NoError(t, c.Set(ctx, "tasks:all", []task{{ID: 1}}, time.Minute))
+
+		var got task
+		require.NoError(t, c.Get(ctx, "task:1", &got))
+		assert.ErrorIs(t, c.Get(ctx, "task:2", &got), ErrMiss)
+		assert.ErrorIs(t, c.Get(ctx, "task:404", &got), ErrAbsent)
+		require.NoError(t, c.Delete(ctx, "task:1"))
+
+		metrics := c.Metrics()
+		require.Contains(t, metrics.Prefixes, "task:")
+		m := metrics.Prefixes["task:"]
+		assert.Equal(t, uint64(1), m.Hits)
+		assert.Equal(t, uint64(1), m.Misses)
+		assert.Equal(t, uint64(1), m.Absent)
+		assert.Equal(t, uint64(3), m.Get.Calls)
+		assert.Zero(t, m.Get.Errors)
+		assert.Equal(t, uint64(2), m.Set.Calls)
+		assert.Equal(t, uint64(1), m.Delete.Calls)
+		assert.Equal(t, uint64(3), m.Get.Latency.Count)
+		assert.Contains(t, metrics.Prefixes, "tasks:")
+	})
+
+	t.Run("Размеры значений берутся из закодированных значений", func(t *testing.T) {
+		c := newMetricsCache(t, MetricsOptions{})
+		require.NoError(t, c.Set(ctx, "task:1", task{ID: 1, Title: "Купить молоко"}, time.Minute))
+		var got task
+		require.NoError(t, c.Get(ctx, "task:1", &got))
+		assert.ErrorIs(t, c.Get(ctx, "task:2", &got), ErrMiss)
+
+		size := int64(len(`{"id":1,"title":"Купить молоко"}`))
+		m := c.Metrics().Prefixes["task:"]
+		assert.Equal(t, uint64(1), m.WriteSize.Count)
+		assert.Equal(t, size, m.WriteSize.Max)
+		// Промах не учитывается в размерах прочитанных значений
+		assert.Equal(t, uint64(1), m.ReadSize.Count)
+		assert.Equal(t, size, m.ReadSize.Sum)
+	})
+
+	t.Run("Размеры значений Redis и tiered учитываются", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		remote, err := NewRedisCache(RedisOptions{DSN: "redis://" + server.Addr()}, zap.NewNop())
+		require.NoError(t, err)
+		local, err := NewMemoryCache(MemoryOptions{})
+		require.NoError(t, err)
+		tiered, err := NewTieredCache(local, remote, TieredOptions{}, zap.NewNop())
+		require.NoError(t, err)
+		defer tiered.Close()
+
+		for _, next := range []Cache{remote, tiered} {
+			c := NewMetricsCache(next, MetricsOptions{})
+			require.NoError(t, c.Set(ctx, "task:1", task{ID: 1}, time.Minute))
+			var got task
+			// Второе чтение tiered попадает в L1
+			require.NoError(t, c.Get(ctx, "task:1", &got))
+			require.NoError(t, c.Get(ctx, "task:1", &got))
+
+			m := c.Metrics().Prefixes["task:"]
+			assert.Equal(t, uint64(1), m.WriteSize.Count)
+			assert.Equal(t, uint64(2), m.ReadSize.Count)
+			assert.Equal(t, m.WriteSize.Sum*2, m.ReadSize.Sum)
+		}
+	})
+
+	t.Run("Ошибки и ErrUnavailable считаются отдельно", func(t *testing.T) {
+		c := NewMetricsCache(failingCache{err: errors.New("connection refused")}, MetricsOptions{})
+		var got task
+		assert.Error(t, c.Get(ctx, "task:1", &got))
+		assert.Error(t, c.Set(ctx, "task:1", task{}, time.Minute))
+
+		unavailable := NewMetricsCache(failingCache{err: ErrUnavailable}, MetricsOptions{})
+		assert.ErrorIs(t, unavailable.Get(ctx, "task:1", &got), ErrUnavailable)
+
+		m := c.Metrics().Prefixes["task:"]
+		assert.Equal(t, uint64(1), m.Get.Errors)
+		assert.Equal(t, uint64(1), m.Set.Errors)
+		assert.Zero(t, m.ReadSize.Count)
+		m = unavailable.Metrics().Prefixes["task:"]
+		assert.Zero(t, m.Get.Errors)
+		assert.Equal(t, uint64(1), m.Unavailable)
+	})
+
+	t.Run("Ключ относится к самому длинному подходящему префиксу", func(t *testing.T) {
+		c := newMetricsCache(t, MetricsOptions{Prefixes: []string{"tasks:", "tasks:suggest:"}})
+		var got task
+		_ = c.Get(ctx, "tasks:suggest:10:купить", &got)
+		_ = c.Get(ctx, "tasks:all", &got)
+		_ = c.Get(ctx, "idempotency:abc", &got)
+		_ = c.Get(ctx, "healthcheck", &got)
+
+		prefixes := c.Metrics().Prefixes
+		assert.Len(t, prefixes, 4)
+		for _, prefix := range []string{"tasks:suggest:", "tasks:", "idempotency:", otherPrefix} {
+			assert.Equal(t, uint64(1), prefixes[prefix].Misses, prefix)
+		}
+	})
+
+	t.Run("Блокировки и формат передаются декорируемому кешу", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		remote, err := NewRedisCache(RedisOptions{DSN: "redis://" + server.Addr(), Encoding: Encoding{Codec: MessagePack{}}}, zap.NewNop())
+		require.NoError(t, err)
+		c := NewMetricsCache(remote, MetricsOptions{})
+
+		assert.Equal(t, msgpackCodecID, c.Encoding().Codec.ID())
+		unlock, acquired, err := c.Lock(ctx, "lock:task:1", time.Second)
+		require.NoError(t, err)
+		require.True(t, acquired)
+		assert.True(t, server.Exists("lock:task:1"))
+		unlock()
+
+		memory := newMetricsCache(t, MetricsOptions{})
+		assert.Equal(t, jsonCodecID, memory.Encoding().Codec.ID())
+		_, acquired, err = memory.Lock(ctx, "lock:task:1", time.Second)
+		require.NoError(t, err)
+		assert.True(t, acquired)
+	})
+
+	t.Run("Просмотр недоступен, если кеш не реализует Inspector", func(t *testing.T) {
+		c := NewMetricsCache(failingCache{}, MetricsOptions{})
+
+		_, err := c.Inspect(ctx, "task:1")
+		assert.ErrorIs(t, err, errors.ErrUnsupported)
+		_, err = c.FlushPrefix(ctx, "task:")
+		assert.ErrorIs(t, err, errors.ErrUnsupported)
+	})
+}
+
+// TestHistogram тестирует распределение значений по корзинам
+func TestHistogram(t *testing.T) {
+	h := newHistogram([]int64{10, 100, 1000})
+	for i := 0; i < 90; i++ {
+		h.observe(5)
+	}
+	for i := 0; i < 9; i++ {
+		h.observe(50)
+	}
+	h.observe(5000)
+
+	snapshot := h.snapshot()
+	assert.Equal(t, []uint64{90, 9, 0, 1}, snapshot.Counts)
+	assert.Equal(t, uint64(100), snapshot.Count)
+	assert.Equal(t, int64(5000), snapshot.Max)
+	assert.InDelta(t, 59.0, snapshot.Mean(), 0.001)
+	assert.Equal(t, int64(10), snapshot.Quantile(0.5))
+	assert.Equal(t, int64(100), snapshot.Quantile(0.95))
+	assert.Equal(t, int64(5000), snapshot.Quantile(1))
+	assert.Zero(t, Histogram{}.Quantile(0.5))
+}
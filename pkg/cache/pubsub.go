@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PubSub интерфейс кешей, которые передают сообщения всем репликам через каналы Redis
+type PubSub interface {
+	// Publish отправляет сообщение всем подписчикам канала, включая подписки текущей реплики
+	Publish(ctx context.Context, channel string, message []byte) error
+	// Subscribe подписывается на канал и вызывает handle для каждого сообщения до закрытия подписки.
+	// resubscribed вызывается после переподключения к Redis: сообщения, отправленные во время разрыва, потеряны
+	Subscribe(ctx context.Context, channel string, handle func(message []byte), resubscribed func()) (io.Closer, error)
+}
+
+// Publish функция, которая отправляет сообщение в канал Redis
+// @param ctx context.Context - контекст выполнения
+// @param channel string - канал
+// @param message []byte - сообщение
+// @return error - ошибка отправки
+func (c *RedisCache) Publish(ctx context.Context, channel string, message []byte) error {
+	if err := c.client.Publish(ctx, channel, message).Err(); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", channel, err)
+	}
+	return nil
+}
+
+// Subscribe функция, которая подписывается на канал Redis
+// Функция возвращается после подтверждения подписки, поэтому сообщения, отправленные после нее, не теряются
+// @param ctx context.Context - контекст подписки на канал
+// @param channel string - канал
+// @param handle func(message []byte) - обработчик сообщений, вызывается из одной горутины
+// @param resubscribed func() - вызывается после переподключения к Redis
+// @return io.Closer - подписка, после использования ее нужно закрыть
+// @return error - ошибка подписки
+func (c *RedisCache) Subscribe(ctx context.Context, channel string, handle func(message []byte), resubscribed func()) (io.Closer, error) {
+	pubsub := c.client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", channel, err)
+	}
+
+	s := &subscription{pubsub: pubsub, done: make(chan struct{})}
+	go s.listen(handle, resubscribed)
+
+	return s, nil
+}
+
+// subscription подписка на канал Redis
+type subscription struct {
+	pubsub *redis.PubSub
+	done   chan struct{}
+	once   sync.Once
+}
+
+// listen функция, которая передает сообщения канала обработчику до закрытия подписки
+// @param handle func(message []byte) - обработчик сообщений
+// @param resubscribed func() - вызывается после переподключения к Redis
+func (s *subscription) listen(handle func(message []byte), resubscribed func()) {
+	defer close(s.done)
+
+	// Первое подтверждение подписки уже прочитано в Subscribe, следующие приходят после переподключения
+	for msg := range s.pubsub.ChannelWithSubscriptions() {
+		switch msg := msg.(type) {
+		case *redis.Message:
+			handle([]byte(msg.Payload))
+		case *redis.Subscription:
+			if msg.Kind == "subscribe" {
+				resubscribed()
+			}
+		}
+	}
+}
+
+// Close функция, которая отписывается от канала и ждет завершения обработки сообщений
+// @return error - ошибка
+func (s *subscription) Close() error {
+	var err error
+	s.once.Do(func() {
+		err = s.pubsub.Close()
+		<-s.done
+	})
+	return err
+}
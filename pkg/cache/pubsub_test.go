@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// TestPubSub тестирует передачу сообщений между репликами через каналы Redis
+func TestPubSub(t *testing.T) {
+	ctx := context.Background()
+
+	newCache := func(t *testing.T, server *miniredis.Miniredis) *RedisCache {
+		t.Helper()
+		c, err := NewRedisCache(RedisOptions{DSN: "redis://" + server.Addr()}, zap.NewNop())
+		require.NoError(t, err)
+		t.Cleanup(func() { c.Close() })
+		return c
+	}
+
+	t.Run("Сообщение одной реплики получает подписка другой", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		publisher, subscriber := newCache(t, server), newCache(t, server)
+
+		received := make(chan string, 1)
+		sub, err := subscriber.Subscribe(ctx, "events", func(message []byte) { received <- string(message) }, func() {})
+		require.NoError(t, err)
+
+		// Подписка подтверждена до возврата из Subscribe, поэтому сообщение не теряется
+		require.NoError(t, publisher.Publish(ctx, "events", []byte("hello")))
+
+		select {
+		case message := <-received:
+			assert.Equal(t, "hello", message)
+		case <-time.After(5 * time.Second):
+			t.Fatal("message was not received")
+		}
+
+		require.NoError(t, sub.Close())
+		assert.NoError(t, sub.Close())
+	})
+
+	t.Run("MetricsCache передает каналы кешу, который их поддерживает", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		metrics := NewMetricsCache(newCache(t, server), MetricsOptions{})
+
+		received := make(chan string, 1)
+		sub, err := metrics.Subscribe(ctx, "events", func(message []byte) { received <- string(message) }, func() {})
+		require.NoError(t, err)
+		defer sub.Close()
+
+		require.NoError(t, metrics.Publish(ctx, "events", []byte("hello")))
+		select {
+		case message := <-received:
+			assert.Equal(t, "hello", message)
+		case <-time.After(5 * time.Second):
+			t.Fatal("message was not received")
+		}
+	})
+
+	t.Run("MetricsCache поверх кеша в памяти не поддерживает каналы", func(t *testing.T) {
+		memory, err := NewMemoryCache(MemoryOptions{})
+		require.NoError(t, err)
+		metrics := NewMetricsCache(memory, MetricsOptions{})
+
+		_, err = metrics.Subscribe(ctx, "events", func([]byte) {}, func() {})
+		assert.True(t, errors.Is(err, errors.ErrUnsupported))
+		assert.True(t, errors.Is(metrics.Publish(ctx, "events", []byte("hello")), errors.ErrUnsupported))
+	})
+}
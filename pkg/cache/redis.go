@@ -2,69 +2,695 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
+const (
+	// tagKeyPrefix - префикс множеств Redis с ключами, помеченными тегом
+	tagKeyPrefix = "tag:"
+	// tagPopBatch - количество ключей, которые InvalidateTags извлекает из множества тега за одну команду
+	tagPopBatch = 500
+	// scanBatch - количество ключей, которые Keys и FlushPrefix запрашивают за одну команду SCAN
+	scanBatch = 500
+)
+
+// tagScript - добавляет ключ в множество тега и продлевает срок множества до срока ключа. Множество не должно
+// истечь раньше ключей в нем, иначе их нельзя будет удалить по тегу. Скрипт обращается к одному ключу,
+// поэтому теги одной записи могут храниться на разных узлах
+var tagScript = redis.NewScript(`
+local created = redis.call("EXISTS", KEYS[1]) == 0
+redis.call("SADD", KEYS[1], ARGV[1])
+local ttl = tonumber(ARGV[2])
+if ttl <= 0 then
+	redis.call("PERSIST", KEYS[1])
+	return 1
+end
+local current = redis.call("PTTL", KEYS[1])
+if created or (current >= 0 and current < ttl) then
+	redis.call("PEXPIRE", KEYS[1], ttl)
+end
+return 1`)
+
+// RedisOptions параметры RedisCache
+// Режим подключения выбирается по заполненным полям: SentinelMasterName - через Redis Sentinel,
+// ClusterAddrs - к Redis Cluster, иначе к одному узлу по DSN
+type RedisOptions struct {
+	// DSN - строка подключения к одному узлу в формате redis.ParseURL
+	DSN string
+	// SentinelMasterName - имя master в Redis Sentinel
+	SentinelMasterName string
+	// SentinelAddrs - адреса узлов Sentinel
+	SentinelAddrs []string
+	// SentinelPassword - пароль узлов Sentinel, если он отличается от пароля Redis
+	SentinelPassword string
+	// ClusterAddrs - начальные узлы Redis Cluster, остальные узлы определяются по ним
+	ClusterAddrs []string
+	// Username и Password - учетные данные Redis, заменяют указанные в DSN
+	Username string
+	Password string
+	// DB - номер базы данных в режимах single и sentinel, Redis Cluster поддерживает только базу 0
+	DB int
+	// TLSConfig - параметры TLS, nil - без TLS или по схеме rediss:// в DSN
+	TLSConfig *tls.Config
+	// PoolSize - размер пула соединений на узел, по умолчанию 10 на каждый CPU
+	PoolSize int
+	// MinIdleConns - минимальное число простаивающих соединений на узел
+	MinIdleConns int
+	// DialTimeout, ReadTimeout, WriteTimeout и PoolTimeout - таймауты подключения, чтения, записи
+	// и ожидания свободного соединения, 0 - значения go-redis по умолчанию
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	PoolTimeout  time.Duration
+	// BreakerThreshold - число ошибок Redis подряд, после которого кеш временно не используется,
+	// по умолчанию DefaultBreakerThreshold
+	BreakerThreshold int
+	// BreakerCooldown - время, в течение которого чтение и запись возвращают ErrUnavailable без обращения к Redis,
+	// по умолчанию DefaultBreakerCooldown
+	BreakerCooldown time.Duration
+	// Encoding - формат значений, по умолчанию JSON без сжатия
+	Encoding Encoding
+	// AccessLog - журнал недавно прочитанных ключей для прогрева кеша, по умолчанию выключен
+	AccessLog AccessLogOptions
+}
+
 type RedisCache struct {
-	client *redis.Client
+	client   redis.UniversalClient
+	breaker  *breaker
+	encoding Encoding
+	// access - журнал обращений, nil если он выключен
+	access *accessLog
 	logger *zap.Logger
 }
 
-func NewRedisCache(redisDSN string, logger *zap.Logger) (*RedisCache, error) {
-	opts, err := redis.ParseURL(redisDSN)
+func NewRedisCache(redisOpts RedisOptions, logger *zap.Logger) (*RedisCache, error) {
+	encoding, err := redisOpts.Encoding.withDefaults()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse redis DSN: %w", err)
+		return nil, err
 	}
 
-	client := redis.NewClient(opts)
+	client, err := newRedisClient(redisOpts)
+	if err != nil {
+		return nil, err
+	}
 
 	if err := client.Ping(context.Background()).Err(); err != nil {
+		_ = client.Close()
 		return nil, fmt.Errorf("failed to connect to redis: %w", err)
 	}
 
-	return &RedisCache{
-		client: client,
-		logger: logger,
-	}, nil
+	c := &RedisCache{
+		client:   client,
+		breaker:  newBreaker(redisOpts.BreakerThreshold, redisOpts.BreakerCooldown),
+		encoding: encoding,
+		logger:   logger,
+	}
+	if redisOpts.AccessLog.Key != "" {
+		c.access = newAccessLog(client, redisOpts.AccessLog, logger)
+	}
+
+	return c, nil
+}
+
+// newRedisClient функция, которая создает клиент Redis в режиме, выбранном по параметрам
+// Клиент Redis Cluster создается явно, так как redis.NewUniversalClient с одним начальным узлом
+// подключился бы к нему как к отдельному узлу
+// @param o RedisOptions - параметры подключения
+// @return redis.UniversalClient - клиент
+// @return error - ошибка, если параметры противоречат друг другу или DSN некорректен
+func newRedisClient(o RedisOptions) (redis.UniversalClient, error) {
+	universal := &redis.UniversalOptions{
+		Username:         o.Username,
+		Password:         o.Password,
+		DB:               o.DB,
+		SentinelPassword: o.SentinelPassword,
+		TLSConfig:        o.TLSConfig,
+		PoolSize:         o.PoolSize,
+		MinIdleConns:     o.MinIdleConns,
+		DialTimeout:      o.DialTimeout,
+		ReadTimeout:      o.ReadTimeout,
+		WriteTimeout:     o.WriteTimeout,
+		PoolTimeout:      o.PoolTimeout,
+	}
+
+	switch {
+	case o.SentinelMasterName != "" && len(o.ClusterAddrs) > 0:
+		return nil, errors.New("redis sentinel and cluster options are mutually exclusive")
+	case o.SentinelMasterName != "":
+		if len(o.SentinelAddrs) == 0 {
+			return nil, errors.New("redis sentinel addresses are required")
+		}
+		universal.MasterName = o.SentinelMasterName
+		universal.Addrs = o.SentinelAddrs
+		return redis.NewFailoverClient(universal.Failover()), nil
+	case len(o.ClusterAddrs) > 0:
+		if o.DB != 0 {
+			return nil, errors.New("redis cluster supports only database 0")
+		}
+		universal.Addrs = o.ClusterAddrs
+		return redis.NewClusterClient(universal.Cluster()), nil
+	}
+
+	opts, err := redis.ParseURL(o.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis DSN: %w", err)
+	}
+	applyRedisOverrides(opts, o)
+	return redis.NewClient(opts), nil
+}
+
+// applyRedisOverrides функция, которая заменяет параметры из DSN заполненными параметрами RedisOptions
+// @param opts *redis.Options - параметры из DSN
+// @param o RedisOptions - параметры кеша
+func applyRedisOverrides(opts *redis.Options, o RedisOptions) {
+	if o.Username != "" {
+		opts.Username = o.Username
+	}
+	if o.Password != "" {
+		opts.Password = o.Password
+	}
+	if o.DB != 0 {
+		opts.DB = o.DB
+	}
+	if o.TLSConfig != nil {
+		opts.TLSConfig = o.TLSConfig
+	}
+	if o.PoolSize > 0 {
+		opts.PoolSize = o.PoolSize
+	}
+	if o.MinIdleConns > 0 {
+		opts.MinIdleConns = o.MinIdleConns
+	}
+	if o.DialTimeout > 0 {
+		opts.DialTimeout = o.DialTimeout
+	}
+	if o.ReadTimeout > 0 {
+		opts.ReadTimeout = o.ReadTimeout
+	}
+	if o.WriteTimeout > 0 {
+		opts.WriteTimeout = o.WriteTimeout
+	}
+	if o.PoolTimeout > 0 {
+		opts.PoolTimeout = o.PoolTimeout
+	}
+}
+
+// Close функция, которая записывает последние обращения в журнал и закрывает соединения с Redis
+// Команды, выполняемые в момент закрытия, завершаются, новые возвращают ошибку
+// @return error - ошибка закрытия
+func (c *RedisCache) Close() error {
+	if c.access != nil {
+		if err := c.access.close(); err != nil {
+			c.logger.Warn("failed to write cache access log on close", zap.Error(err))
+		}
+	}
+	return c.client.Close()
 }
 
 func (c *RedisCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
-	data, err := json.Marshal(value)
+	data, err := c.encoding.marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	return c.setRaw(ctx, key, data, expiration)
+}
+
+func (c *RedisCache) SetWithTags(ctx context.Context, key string, value interface{}, expiration time.Duration, tags ...string) error {
+	data, err := c.encoding.marshal(value)
 	if err != nil {
 		return fmt.Errorf("failed to marshal value: %w", err)
 	}
 
-	if err := c.client.Set(ctx, key, data, expiration).Err(); err != nil {
+	return c.setRaw(ctx, key, data, expiration, tags...)
+}
+
+// setRaw функция, которая сохраняет закодированное значение
+// Ключ добавляется в множества тегов в том же запросе к Redis, что и значение
+// @param ctx context.Context - контекст выполнения
+// @param key string - ключ
+// @param data []byte - значение
+// @param expiration time.Duration - срок жизни, 0 - без срока
+// @param tags ...string - теги значения
+// @return error - ошибка
+func (c *RedisCache) setRaw(ctx context.Context, key string, data []byte, expiration time.Duration, tags ...string) error {
+	reportPayloadSize(ctx, data)
+	err := c.do(ctx, func() error {
+		if len(tags) == 0 {
+			return c.client.Set(ctx, key, data, expiration).Err()
+		}
+		_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, tag := range tags {
+				// Eval вместо Run: в пайплайне EvalSha не повторяется через Eval при отсутствии скрипта
+				tagScript.Eval(ctx, pipe, []string{tagKeyPrefix + tag}, key, expiration.Milliseconds())
+			}
+			pipe.Set(ctx, key, data, expiration)
+			return nil
+		})
+		return err
+	})
+	if errors.Is(err, ErrUnavailable) {
+		return err
+	}
+	if err != nil {
 		return fmt.Errorf("failed to set cache: %w", err)
 	}
 
 	return nil
 }
 
+func (c *RedisCache) SetAbsent(ctx context.Context, key string, expiration time.Duration, tags ...string) error {
+	return c.setRaw(ctx, key, absentValue, expiration, tags...)
+}
+
 func (c *RedisCache) Get(ctx context.Context, key string, dest interface{}) error {
-	data, err := c.client.Get(ctx, key).Bytes()
+	data, err := c.getRaw(ctx, key)
 	if err != nil {
-		if err == redis.Nil {
-			return fmt.Errorf("cache miss for key %s", key)
-		}
-		return fmt.Errorf("failed to get from cache: %w", err)
+		return err
+	}
+	if isAbsent(data) {
+		return fmt.Errorf("%w for key %s", ErrAbsent, key)
 	}
 
-	if err := json.Unmarshal(data, dest); err != nil {
+	if err := c.encoding.unmarshal(data, dest); err != nil {
+		c.deleteCorrupt(ctx, key, err)
 		return fmt.Errorf("failed to unmarshal value: %w", err)
 	}
 
 	return nil
 }
 
+// getRaw функция, которая возвращает закодированное значение
+// @param ctx context.Context - контекст выполнения
+// @param key string - ключ
+// @return []byte - значение
+// @return error - ошибка, ErrMiss при отсутствии ключа
+func (c *RedisCache) getRaw(ctx context.Context, key string) ([]byte, error) {
+	var data []byte
+	err := c.do(ctx, func() error {
+		var err error
+		data, err = c.client.Get(ctx, key).Bytes()
+		return err
+	})
+	if err != nil {
+		switch {
+		case err == redis.Nil:
+			return nil, fmt.Errorf("%w for key %s", ErrMiss, key)
+		case errors.Is(err, ErrUnavailable):
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get from cache: %w", err)
+	}
+	reportPayloadSize(ctx, data)
+	return data, nil
+}
+
 func (c *RedisCache) Delete(ctx context.Context, key string) error {
-	if err := c.client.Del(ctx, key).Err(); err != nil {
+	// Удаление выполняется, даже если кеш временно не используется: пропущенное удаление оставило бы
+	// устаревшее значение, которое будет прочитано после восстановления Redis
+	err := c.client.Del(ctx, key).Err()
+	c.record(ctx, err)
+	if err != nil {
 		return fmt.Errorf("failed to delete from cache: %w", err)
 	}
 	return nil
 }
+
+func (c *RedisCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	_, err := c.invalidateTags(ctx, tags...)
+	return err
+}
+
+// invalidateTags функция, которая удаляет ключи, помеченные тегами
+// Как и Delete, выполняется, даже если кеш временно не используется. Ключи извлекаются из множества тега
+// через SPOP, поэтому ключи, помеченные во время удаления, тоже удаляются
+// @param ctx context.Context - контекст выполнения
+// @param tags ...string - теги
+// @return []string - удаленные ключи, в том числе при ошибке
+// @return error - ошибка
+func (c *RedisCache) invalidateTags(ctx context.Context, tags ...string) ([]string, error) {
+	var keys []string
+	err := func() error {
+		for _, tag := range tags {
+			for {
+				batch, err := c.client.SPopN(ctx, tagKeyPrefix+tag, tagPopBatch).Result()
+				if err != nil && err != redis.Nil {
+					return err
+				}
+				if len(batch) == 0 {
+					break
+				}
+
+				// Ключи удаляются по одному, так как в Redis Cluster они могут храниться на разных узлах
+				_, err = c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+					for _, key := range batch {
+						pipe.Del(ctx, key)
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+				keys = append(keys, batch...)
+			}
+		}
+		return nil
+	}()
+	c.record(ctx, err)
+	if err != nil {
+		return keys, fmt.Errorf("failed to invalidate cache tags: %w", err)
+	}
+	return keys, nil
+}
+
+// Inspect функция, которая возвращает запись со значением и оставшимся сроком
+// Просмотр и удаление по префиксу обращаются к Redis, даже если кеш временно не используется
+// @param ctx context.Context - контекст выполнения
+// @param key string - ключ
+// @return Entry - запись
+// @return error - ошибка, ErrMiss если ключа нет
+func (c *RedisCache) Inspect(ctx context.Context, key string) (Entry, error) {
+	var get *redis.StringCmd
+	var pttl *redis.DurationCmd
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pttl = pipe.PTTL(ctx, key)
+		return nil
+	})
+	if err == redis.Nil {
+		return Entry{}, fmt.Errorf("%w for key %s", ErrMiss, key)
+	}
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to inspect cache key: %w", err)
+	}
+
+	data, _ := get.Bytes()
+	return newEntry(key, data, redisTTL(pttl.Val()), c.encoding), nil
+}
+
+// Keys функция, которая возвращает записи с префиксом ключа в порядке ключей
+// Ключи перебираются через SCAN на всех master узлах, поэтому Redis не блокируется на время перебора
+// @param ctx context.Context - контекст выполнения
+// @param prefix string - префикс ключей
+// @param limit int - максимальное количество записей
+// @return []EntryInfo - записи
+// @return error - ошибка
+func (c *RedisCache) Keys(ctx context.Context, prefix string, limit int) ([]EntryInfo, error) {
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	err := c.scan(ctx, prefix, func(batch []string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, batch...)
+		return len(keys) < limit
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan cache keys: %w", err)
+	}
+	sort.Strings(keys)
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	type keyCmds struct {
+		pttl   *redis.DurationCmd
+		size   *redis.IntCmd
+		header *redis.StringCmd
+	}
+	cmds := make([]keyCmds, len(keys))
+	// Множества тегов не строки, STRLEN для них возвращает ошибку, поэтому ошибки проверяются по командам
+	_, _ = c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = keyCmds{
+				pttl:   pipe.PTTL(ctx, key),
+				size:   pipe.StrLen(ctx, key),
+				header: pipe.GetRange(ctx, key, 0, headerSize-1),
+			}
+		}
+		return nil
+	})
+
+	infos := make([]EntryInfo, 0, len(keys))
+	for i, key := range keys {
+		ttl, err := cmds[i].pttl.Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect cache keys: %w", err)
+		}
+		// Ключ удален или истек после SCAN
+		if ttl == -2 {
+			continue
+		}
+		infos = append(infos, EntryInfo{
+			Key:    key,
+			TTL:    redisTTL(ttl),
+			Size:   int(cmds[i].size.Val()),
+			Absent: isAbsent([]byte(cmds[i].header.Val())),
+		})
+	}
+	return infos, nil
+}
+
+// FlushPrefix функция, которая удаляет записи с префиксом ключа
+// @param ctx context.Context - контекст выполнения
+// @param prefix string - префикс ключей
+// @return int - количество удаленных записей
+// @return error - ошибка
+func (c *RedisCache) FlushPrefix(ctx context.Context, prefix string) (int, error) {
+	keys, err := c.flushPrefix(ctx, prefix)
+	return len(keys), err
+}
+
+// flushPrefix функция, которая удаляет записи с префиксом ключа
+// Ключи удаляются пачками по мере перебора, поэтому ключи, записанные во время удаления, могут остаться
+// @param ctx context.Context - контекст выполнения
+// @param prefix string - префикс ключей
+// @return []string - удаленные ключи, в том числе при ошибке
+// @return error - ошибка
+func (c *RedisCache) flushPrefix(ctx context.Context, prefix string) ([]string, error) {
+	var (
+		mu      sync.Mutex
+		flushed []string
+		delErr  error
+	)
+	err := c.scan(ctx, prefix, func(batch []string) bool {
+		// Ключи удаляются по одному, так как в Redis Cluster они могут храниться на разных узлах
+		_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range batch {
+				pipe.Del(ctx, key)
+			}
+			return nil
+		})
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			delErr = err
+			return false
+		}
+		flushed = append(flushed, batch...)
+		return true
+	})
+	if err == nil {
+		err = delErr
+	}
+	if err != nil {
+		return flushed, fmt.Errorf("failed to flush cache prefix: %w", err)
+	}
+	return flushed, nil
+}
+
+// scan функция, которая перебирает ключи с префиксом на всех master узлах
+// В Redis Cluster узлы перебираются параллельно, поэтому fn должна быть безопасной для одновременного вызова
+// @param ctx context.Context - контекст выполнения
+// @param prefix string - префикс ключей
+// @param fn func(batch []string) bool - обработка найденных ключей, false останавливает перебор
+// @return error - ошибка
+func (c *RedisCache) scan(ctx context.Context, prefix string, fn func(batch []string) bool) error {
+	match := escapeGlob(prefix) + "*"
+	var stopped atomic.Bool
+	scanNode := func(ctx context.Context, client *redis.Client) error {
+		iter := client.Scan(ctx, 0, match, scanBatch).Iterator()
+		batch := make([]string, 0, scanBatch)
+		for !stopped.Load() && iter.Next(ctx) {
+			batch = append(batch, iter.Val())
+			if len(batch) == scanBatch {
+				if !fn(batch) {
+					stopped.Store(true)
+				}
+				batch = make([]string, 0, scanBatch)
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+		if len(batch) > 0 && !stopped.Load() && !fn(batch) {
+			stopped.Store(true)
+		}
+		return nil
+	}
+
+	switch client := c.client.(type) {
+	case *redis.ClusterClient:
+		return client.ForEachMaster(ctx, scanNode)
+	case *redis.Client:
+		return scanNode(ctx, client)
+	default:
+		return fmt.Errorf("unsupported redis client %T", c.client)
+	}
+}
+
+// escapeGlob функция, которая экранирует спецсимволы шаблона SCAN MATCH
+// @param s string - строка
+// @return string - строка, которая совпадает только сама с собой
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// redisTTL функция, которая преобразует ответ PTTL в оставшийся срок
+// @param pttl time.Duration - ответ PTTL, отрицательный для ключей без срока
+// @return time.Duration - оставшийся срок, 0 - без срока
+func redisTTL(pttl time.Duration) time.Duration {
+	if pttl < 0 {
+		return 0
+	}
+	return pttl
+}
+
+// TrackAccess функция, которая отмечает обращение к ключу в журнале, если он включен
+// @param key string - ключ
+func (c *RedisCache) TrackAccess(key string) {
+	if c.access != nil {
+		c.access.track(key)
+	}
+}
+
+// RecentKeys функция, которая возвращает ключи из журнала обращений, начиная с прочитанных последними
+// @param ctx context.Context - контекст выполнения
+// @param limit int - максимальное количество ключей
+// @return []string - ключи
+// @return error - ошибка или errors.ErrUnsupported, если журнал выключен
+func (c *RedisCache) RecentKeys(ctx context.Context, limit int) ([]string, error) {
+	if c.access == nil {
+		return nil, errors.ErrUnsupported
+	}
+	return c.access.recent(ctx, limit)
+}
+
+// Encoding функция, которая возвращает формат значений кеша
+// @return Encoding - формат
+func (c *RedisCache) Encoding() Encoding {
+	return c.encoding
+}
+
+// deleteCorrupt функция, которая удаляет значение, которое не удалось декодировать
+// @param ctx context.Context - контекст выполнения
+// @param key string - ключ
+// @param cause error - ошибка декодирования
+func (c *RedisCache) deleteCorrupt(ctx context.Context, key string, cause error) {
+	c.logger.Warn("deleting undecodable cache entry", zap.String("key", key), zap.Error(cause))
+	if err := c.Delete(ctx, key); err != nil {
+		c.logger.Warn("failed to delete undecodable cache entry", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// do функция, которая обращается к Redis, если кеш не отключен после ошибок
+// @param ctx context.Context - контекст выполнения
+// @param op func() error - обращение к Redis
+// @return error - ошибка обращения или ErrUnavailable, если кеш отключен
+func (c *RedisCache) do(ctx context.Context, op func() error) error {
+	if !c.breaker.allow() {
+		return ErrUnavailable
+	}
+	err := op()
+	c.record(ctx, err)
+	return err
+}
+
+// record функция, которая учитывает результат обращения к Redis
+// Отсутствие ключа считается успешным обращением, а ошибка из-за отмененного контекста не учитывается
+// @param ctx context.Context - контекст обращения
+// @param err error - результат обращения
+func (c *RedisCache) record(ctx context.Context, err error) {
+	switch {
+	case err == nil || err == redis.Nil:
+		if c.breaker.success() {
+			c.logger.Info("redis cache is available again")
+		}
+	case ctx.Err() != nil:
+		c.breaker.release()
+	default:
+		if c.breaker.failure() {
+			c.logger.Warn("redis cache is unavailable, bypassing cache",
+				zap.Duration("cooldown", c.breaker.cooldown),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// unlockScript - снимает блокировку, только если она принадлежит вызывающему
+var unlockScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`)
+
+// Lock функция, которая пытается взять блокировку ключа для всех реплик
+// Блокировка снимается автоматически через ttl, если реплика не сняла ее сама
+// @param ctx context.Context - контекст выполнения
+// @param key string - ключ блокировки
+// @param ttl time.Duration - время жизни блокировки
+// @return func() - снятие блокировки
+// @return bool - true, если блокировка взята
+// @return error - ошибка, ErrUnavailable если кеш отключен после ошибок Redis
+func (c *RedisCache) Lock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, false, fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+
+	var acquired bool
+	err := c.do(ctx, func() error {
+		var err error
+		acquired, err = c.client.SetNX(ctx, key, token, ttl).Result()
+		return err
+	})
+	if errors.Is(err, ErrUnavailable) {
+		return nil, false, err
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to lock cache key: %w", err)
+	}
+	if !acquired {
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		// Контекст загрузки к этому моменту может быть отменен, а блокировку нужно снять в любом случае
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := unlockScript.Run(ctx, c.client, []string{key}, token).Err(); err != nil {
+			c.logger.Warn("failed to unlock cache key", zap.String("key", key), zap.Error(err))
+		}
+	}
+	return unlock, true, nil
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"hash/maphash"
+	"math/bits"
+)
+
+const (
+	// sketchDepth - количество строк счетчиков, частота ключа оценивается минимумом по строкам
+	sketchDepth = 4
+	// sketchMaxCount - предел счетчика, частоты больше не различаются
+	sketchMaxCount = 15
+	// sketchMinWidth - минимальное количество счетчиков в строке
+	sketchMinWidth = 1024
+	// sketchResetFactor - после width*sketchResetFactor обращений счетчики уменьшаются вдвое,
+	// чтобы старая популярность ключей со временем забывалась
+	sketchResetFactor = 10
+)
+
+// frequencySketch приблизительный счетчик частоты обращений к ключам (count-min sketch) для TinyLFU
+// Память не зависит от количества ключей, а оценка частоты может быть только завышена коллизиями
+type frequencySketch struct {
+	seed     maphash.Seed
+	counters [sketchDepth][]uint8
+	// mask - маска индекса в строке, ширина строки - степень двойки
+	mask      uint64
+	additions int
+	resetAt   int
+}
+
+// newFrequencySketch функция, которая создает счетчик частоты для кеша заданного размера
+// @param capacity int - ожидаемое количество записей в кеше, 0 если не ограничено
+// @return *frequencySketch - счетчик
+func newFrequencySketch(capacity int) *frequencySketch {
+	width := sketchMinWidth
+	if capacity > width {
+		width = 1 << bits.Len(uint(capacity-1))
+	}
+
+	s := &frequencySketch{
+		seed:    maphash.MakeSeed(),
+		mask:    uint64(width - 1),
+		resetAt: width * sketchResetFactor,
+	}
+	for i := range s.counters {
+		s.counters[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// increment функция, которая учитывает обращение к ключу
+// @param key string - ключ
+func (s *frequencySketch) increment(key string) {
+	h1, h2 := s.hash(key)
+	for i := range s.counters {
+		idx := (h1 + uint64(i)*h2) & s.mask
+		if s.counters[i][idx] < sketchMaxCount {
+			s.counters[i][idx]++
+		}
+	}
+
+	s.additions++
+	if s.additions >= s.resetAt {
+		s.reset()
+	}
+}
+
+// estimate функция, которая оценивает частоту обращений к ключу
+// @param key string - ключ
+// @return uint8 - оценка частоты
+func (s *frequencySketch) estimate(key string) uint8 {
+	h1, h2 := s.hash(key)
+	min := uint8(sketchMaxCount)
+	for i := range s.counters {
+		if c := s.counters[i][(h1+uint64(i)*h2)&s.mask]; c < min {
+			min = c
+		}
+	}
+	return min
+}
+
+// reset функция, которая уменьшает все счетчики вдвое
+func (s *frequencySketch) reset() {
+	for i := range s.counters {
+		for j := range s.counters[i] {
+			s.counters[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+// hash функция, которая возвращает две хеш-функции ключа для индексов в строках
+func (s *frequencySketch) hash(key string) (uint64, uint64) {
+	h := maphash.String(s.seed, key)
+	return h, bits.RotateLeft64(h, 32) | 1
+}
@@ -0,0 +1,315 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultInvalidationChannel - канал Redis, в который TieredCache публикует удаленные ключи
+	DefaultInvalidationChannel = "cache:invalidate"
+	// DefaultLocalTTL - срок жизни копии в памяти процесса по умолчанию
+	DefaultLocalTTL = 5 * time.Second
+)
+
+// TieredOptions параметры TieredCache
+type TieredOptions struct {
+	// LocalTTL - максимальный срок жизни копии в памяти процесса. Он ограничивает устаревание копии,
+	// если сообщение об удалении ключа было потеряно, например при переподключении к Redis. По умолчанию DefaultLocalTTL
+	LocalTTL time.Duration
+	// Channel - канал Redis для сообщений об удалении ключей, по умолчанию DefaultInvalidationChannel
+	Channel string
+}
+
+// TieredCache двухуровневый кеш: копия в памяти процесса (L1) перед общим кешем в Redis (L2)
+// Чтение сначала ищет значение в L1 и только при промахе обращается к Redis. Удаление ключа публикуется
+// в канал Redis, и все реплики удаляют свою копию из L1
+type TieredCache struct {
+	local  *MemoryCache
+	remote *RedisCache
+	opts   TieredOptions
+	logger *zap.Logger
+
+	pubsub *redis.PubSub
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewTieredCache функция, которая создает двухуровневый кеш и подписывается на удаление ключей
+// @param local *MemoryCache - кеш в памяти процесса
+// @param remote *RedisCache - кеш в Redis
+// @param opts TieredOptions - параметры
+// @param logger *zap.Logger - логгер
+// @return *TieredCache - новый экземпляр TieredCache, после использования его нужно закрыть через Close
+// @return error - ошибка подписки на канал
+func NewTieredCache(local *MemoryCache, remote *RedisCache, opts TieredOptions, logger *zap.Logger) (*TieredCache, error) {
+	if opts.LocalTTL <= 0 {
+		opts.LocalTTL = DefaultLocalTTL
+	}
+	if opts.Channel == "" {
+		opts.Channel = DefaultInvalidationChannel
+	}
+
+	pubsub := remote.client.Subscribe(context.Background(), opts.Channel)
+	// Ожидаем подтверждения подписки, чтобы не пропустить удаления, опубликованные сразу после запуска
+	if _, err := pubsub.Receive(context.Background()); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to cache invalidation: %w", err)
+	}
+
+	c := &TieredCache{
+		local:  local,
+		remote: remote,
+		opts:   opts,
+		logger: logger,
+		pubsub: pubsub,
+		done:   make(chan struct{}),
+	}
+	go c.listen()
+
+	return c, nil
+}
+
+func (c *TieredCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return c.SetWithTags(ctx, key, value, expiration)
+}
+
+func (c *TieredCache) SetWithTags(ctx context.Context, key string, value interface{}, expiration time.Duration, tags ...string) error {
+	data, err := c.remote.encoding.marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	return c.setRaw(ctx, key, data, expiration, tags...)
+}
+
+func (c *TieredCache) SetAbsent(ctx context.Context, key string, expiration time.Duration, tags ...string) error {
+	return c.setRaw(ctx, key, absentValue, expiration, tags...)
+}
+
+func (c *TieredCache) Get(ctx context.Context, key string, dest interface{}) error {
+	data, ok := c.local.getRaw(key)
+	if !ok {
+		var err error
+		if data, err = c.remote.getRaw(ctx, key); err != nil {
+			return err
+		}
+		// Оставшийся срок записи в Redis не запрашивается, копия живет не дольше LocalTTL
+		c.local.setRaw(key, data, c.opts.LocalTTL)
+	} else {
+		reportPayloadSize(ctx, data)
+	}
+	if isAbsent(data) {
+		return fmt.Errorf("%w for key %s", ErrAbsent, key)
+	}
+
+	if err := c.remote.encoding.unmarshal(data, dest); err != nil {
+		c.logger.Warn("deleting undecodable cache entry", zap.String("key", key), zap.Error(err))
+		if err := c.Delete(ctx, key); err != nil {
+			c.logger.Warn("failed to delete undecodable cache entry", zap.String("key", key), zap.Error(err))
+		}
+		return fmt.Errorf("failed to unmarshal value: %w", err)
+	}
+
+	return nil
+}
+
+func (c *TieredCache) Delete(ctx context.Context, key string) error {
+	c.local.Delete(ctx, key)
+
+	if err := c.remote.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	if err := c.remote.client.Publish(ctx, c.opts.Channel, key).Err(); err != nil {
+		return fmt.Errorf("failed to publish cache invalidation: %w", err)
+	}
+
+	return nil
+}
+
+func (c *TieredCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	keys, err := c.remote.invalidateTags(ctx, tags...)
+
+	// Ключи, удаленные до ошибки, удаляются и из L1 всех реплик
+	for _, key := range keys {
+		c.local.Delete(ctx, key)
+	}
+	if pubErr := c.publish(ctx, keys); pubErr != nil && err == nil {
+		err = pubErr
+	}
+
+	return err
+}
+
+// Inspect функция, которая возвращает запись из Redis, копии в L1 могут быть старше нее не больше чем на LocalTTL
+// @param ctx context.Context - контекст выполнения
+// @param key string - ключ
+// @return Entry - запись
+// @return error - ошибка, ErrMiss если ключа нет
+func (c *TieredCache) Inspect(ctx context.Context, key string) (Entry, error) {
+	return c.remote.Inspect(ctx, key)
+}
+
+// Keys функция, которая возвращает записи с префиксом ключа из Redis
+// @param ctx context.Context - контекст выполнения
+// @param prefix string - префикс ключей
+// @param limit int - максимальное количество записей
+// @return []EntryInfo - записи
+// @return error - ошибка
+func (c *TieredCache) Keys(ctx context.Context, prefix string, limit int) ([]EntryInfo, error) {
+	return c.remote.Keys(ctx, prefix, limit)
+}
+
+// FlushPrefix функция, которая удаляет записи с префиксом ключа из Redis и копии из L1 всех реплик
+// @param ctx context.Context - контекст выполнения
+// @param prefix string - префикс ключей
+// @return int - количество удаленных записей в Redis
+// @return error - ошибка
+func (c *TieredCache) FlushPrefix(ctx context.Context, prefix string) (int, error) {
+	keys, err := c.remote.flushPrefix(ctx, prefix)
+
+	c.local.FlushPrefix(ctx, prefix)
+	if pubErr := c.publish(ctx, keys); pubErr != nil && err == nil {
+		err = pubErr
+	}
+
+	return len(keys), err
+}
+
+// publish функция, которая публикует удаленные ключи, чтобы реплики удалили их копии из L1
+// @param ctx context.Context - контекст выполнения
+// @param keys []string - удаленные ключи
+// @return error - ошибка публикации
+func (c *TieredCache) publish(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := c.remote.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Publish(ctx, c.opts.Channel, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish cache invalidation: %w", err)
+	}
+	return nil
+}
+
+// TrackAccess функция, которая отмечает обращение к ключу в журнале Redis
+// @param key string - ключ
+func (c *TieredCache) TrackAccess(key string) {
+	c.remote.TrackAccess(key)
+}
+
+// RecentKeys функция, которая возвращает ключи из журнала обращений в Redis, он общий для всех реплик
+// @param ctx context.Context - контекст выполнения
+// @param limit int - максимальное количество ключей
+// @return []string - ключи
+// @return error - ошибка или errors.ErrUnsupported, если журнал выключен
+func (c *TieredCache) RecentKeys(ctx context.Context, limit int) ([]string, error) {
+	return c.remote.RecentKeys(ctx, limit)
+}
+
+// Encoding функция, которая возвращает формат значений кеша, он совпадает с форматом Redis
+// @return Encoding - формат
+func (c *TieredCache) Encoding() Encoding {
+	return c.remote.encoding
+}
+
+// setRaw функция, которая сохраняет закодированное значение в Redis и копию в L1
+// Теги хранятся только в Redis: при удалении по тегу реплики получают удаленные ключи через канал
+// @param ctx context.Context - контекст выполнения
+// @param key string - ключ
+// @param data []byte - значение
+// @param expiration time.Duration - срок жизни, 0 - без срока
+// @param tags ...string - теги значения
+// @return error - ошибка
+func (c *TieredCache) setRaw(ctx context.Context, key string, data []byte, expiration time.Duration, tags ...string) error {
+	if err := c.remote.setRaw(ctx, key, data, expiration, tags...); err != nil {
+		// Копия в L1 могла устареть, а новое значение не записано
+		c.local.Delete(ctx, key)
+		return err
+	}
+	c.local.setRaw(key, data, c.localTTL(expiration))
+
+	return nil
+}
+
+// Close функция, которая отписывается от удаления ключей
+// @return error - ошибка
+func (c *TieredCache) Close() error {
+	var err error
+	c.once.Do(func() {
+		err = c.pubsub.Close()
+		<-c.done
+	})
+	return err
+}
+
+// listen функция, которая удаляет из L1 ключи, удаленные другими репликами
+// После переподключения к Redis L1 очищается целиком, так как сообщения за время разрыва потеряны
+func (c *TieredCache) listen() {
+	defer close(c.done)
+
+	// Первое подтверждение подписки уже прочитано в NewTieredCache, следующие приходят после переподключения
+	for msg := range c.pubsub.ChannelWithSubscriptions() {
+		switch msg := msg.(type) {
+		case *redis.Message:
+			c.local.Delete(context.Background(), msg.Payload)
+		case *redis.Subscription:
+			if msg.Kind == "subscribe" {
+				c.local.Clear()
+				c.logger.Warn("cache invalidation resubscribed, local cache cleared")
+			}
+		}
+	}
+}
+
+// localTTL функция, которая возвращает срок копии в L1
+// @param expiration time.Duration - срок записи в Redis
+// @return time.Duration - меньший из сроков
+func (c *TieredCache) localTTL(expiration time.Duration) time.Duration {
+	if expiration > 0 && expiration < c.opts.LocalTTL {
+		return expiration
+	}
+	return c.opts.LocalTTL
+}
+
+// Lock функция, которая берет блокировку ключа в Redis
+// @param ctx context.Context - контекст выполнения
+// @param key string - ключ блокировки
+// @param ttl time.Duration - время жизни блокировки
+// @return func() - снятие блокировки
+// @return bool - true, если блокировка взята
+// @return error - ошибка
+func (c *TieredCache) Lock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	return c.remote.Lock(ctx, key, ttl)
+}
+
+// Publish функция, которая отправляет сообщение в канал Redis
+// @param ctx context.Context - контекст выполнения
+// @param channel string - канал
+// @param message []byte - сообщение
+// @return error - ошибка отправки
+func (c *TieredCache) Publish(ctx context.Context, channel string, message []byte) error {
+	return c.remote.Publish(ctx, channel, message)
+}
+
+// Subscribe функция, которая подписывается на канал Redis
+// @param ctx context.Context - контекст подписки на канал
+// @param channel string - канал
+// @param handle func(message []byte) - обработчик сообщений
+// @param resubscribed func() - вызывается после переподключения к Redis
+// @return io.Closer - подписка
+// @return error - ошибка подписки
+func (c *TieredCache) Subscribe(ctx context.Context, channel string, handle func(message []byte), resubscribed func()) (io.Closer, error) {
+	return c.remote.Subscribe(ctx, channel, handle, resubscribed)
+}